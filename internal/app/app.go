@@ -12,9 +12,11 @@ import (
 	"serotonyl.ru/telegram-bot/internal/config"
 	"serotonyl.ru/telegram-bot/internal/features/admin"
 	"serotonyl.ru/telegram-bot/internal/features/casino"
+	"serotonyl.ru/telegram-bot/internal/features/debts"
 	"serotonyl.ru/telegram-bot/internal/features/economy"
 	"serotonyl.ru/telegram-bot/internal/features/karma"
 	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/features/shop"
 	"serotonyl.ru/telegram-bot/internal/features/streak"
 	"serotonyl.ru/telegram-bot/internal/jobs"
 )
@@ -44,6 +46,11 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		Ops:            tg.Ops,
 		Service:        infra.AdminService,
 		RiddleService:  infra.RiddleService,
+		DebtService:    infra.DebtService,
+		CasinoService:  infra.CasinoService,
+		ShopService:    infra.ShopService,
+		PayoutService:  infra.PayoutService,
+		StreakService:  infra.StreakService,
 		MemberService:  infra.MemberService,
 		EconomyService: infra.EconomyService,
 		PurgeMetrics: func() jobs.PurgeMetrics {
@@ -77,7 +84,17 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		return nil, err
 	}
 
-	casinoModule, err := casino.NewModule(casino.Deps{Cfg: cfg, Ops: tg.Ops, Service: infra.CasinoService})
+	casinoModule, err := casino.NewModule(casino.Deps{Cfg: cfg, Ops: tg.Ops, Service: infra.CasinoService, Members: infra.MemberService})
+	if err != nil {
+		return nil, err
+	}
+
+	debtsModule, err := debts.NewModule(debts.Deps{Cfg: cfg, Ops: tg.Ops, Service: infra.DebtService, MemberService: infra.MemberService})
+	if err != nil {
+		return nil, err
+	}
+
+	shopModule, err := shop.NewModule(shop.Deps{Cfg: cfg, Ops: tg.Ops, Service: infra.ShopService, Economy: infra.EconomyService})
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +104,8 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 	karma.RegisterCommands(cmdRouter, karmaModule.Handler, cfg)
 	streak.RegisterCommands(cmdRouter, streakModule.Handler, cfg)
 	casino.RegisterCommands(cmdRouter, casinoModule.Handler, cfg)
+	debts.RegisterCommands(cmdRouter, debtsModule.Handler, cfg)
+	shop.RegisterCommands(cmdRouter, shopModule.Handler, cfg)
 	membersModule.Feature.RegisterCommands(cmdRouter)
 
 	chatFilter := modules.BuildChatFilter(cfg, infra, tg)
@@ -94,6 +113,8 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		Admin:   adminModule.Handler,
 		Members: membersModule.Handler,
 		Economy: economyModule.Handler,
+		Casino:  casinoModule.Handler,
+		Shop:    shopModule.Handler,
 		Karma:   karmaModule.Handler,
 	}, modules.KarmaClassifier{Match: karma.IsThankYou})
 
@@ -29,8 +29,14 @@ type BotHandlers struct {
 		HandleEconomyCallback(ctx context.Context, q *models.CallbackQuery) bool
 		HandleEconomyMessage(ctx context.Context, message *models.Message) bool
 	}
+	Casino interface {
+		HandleCasinoCallback(ctx context.Context, q *models.CallbackQuery) bool
+	}
+	Shop interface {
+		HandleShopCallback(ctx context.Context, q *models.CallbackQuery) bool
+	}
 	Karma interface {
-		HandleThankYou(ctx context.Context, chatID int64, fromUserID int64, toUserID int64)
+		HandleThankYou(ctx context.Context, chatID int64, messageID int, fromUserID int64, toUserID int64)
 	}
 }
 
@@ -79,11 +85,22 @@ func BuildBot(cfg *config.Config, infra *Infra, tg *Telegram, cmdRouter *command
 		AdminHandler:   handlers.Admin,
 		MembersHandler: handlers.Members,
 		EconomyHandler: handlers.Economy,
+		CasinoHandler:  handlers.Casino,
+		ShopHandler:    handlers.Shop,
 		ChatFilter:     chatFilter,
 		ThankYou:       classifier,
 	})
 }
 
 func BuildScheduler(cfg *config.Config, infra *Infra, tg *Telegram, b *bot.Bot) *jobs.Scheduler {
-	return jobs.NewScheduler(cfg, infra.StreakService, infra.MemberService, infra.AdminService, b.SendMessageToUser, tg.Ops)
+	scheduler := jobs.NewScheduler(cfg, infra.StreakService, infra.MemberService, infra.AdminService, b.SendMessageToUser, tg.Ops)
+	scheduler.SetDebtService(infra.DebtService)
+	scheduler.SetMoneyRequestService(infra.EconomyService)
+	scheduler.SetLedgerReconciler(infra.EconomyService)
+	scheduler.SetPayoutService(infra.PayoutService)
+	if cfg.FeatureCasinoEnabled {
+		scheduler.SetDuelService(infra.CasinoService)
+		scheduler.SetCasinoDigest(infra.CasinoService)
+	}
+	return scheduler
 }
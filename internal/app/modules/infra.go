@@ -11,9 +11,12 @@ import (
 	"serotonyl.ru/telegram-bot/internal/db/postgres"
 	"serotonyl.ru/telegram-bot/internal/features/admin"
 	"serotonyl.ru/telegram-bot/internal/features/casino"
+	"serotonyl.ru/telegram-bot/internal/features/debts"
 	"serotonyl.ru/telegram-bot/internal/features/economy"
 	"serotonyl.ru/telegram-bot/internal/features/karma"
 	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/features/payouts"
+	"serotonyl.ru/telegram-bot/internal/features/shop"
 	"serotonyl.ru/telegram-bot/internal/features/streak"
 )
 
@@ -27,6 +30,9 @@ type Infra struct {
 	CasinoRepo  *casino.Repository
 	AdminRepo   *admin.Repository
 	RiddleRepo  *admin.RiddleRepository
+	DebtRepo    *debts.Repository
+	ShopRepo    *shop.Repository
+	PayoutRepo  *payouts.Repository
 
 	MemberService  *members.Service
 	EconomyService *economy.Service
@@ -35,9 +41,23 @@ type Infra struct {
 	CasinoService  *casino.Service
 	AdminService   *admin.Service
 	RiddleService  *admin.RiddleService
+	DebtService    *debts.Service
+	ShopService    *shop.Service
+	PayoutService  *payouts.Service
 }
 
 func BuildInfra(ctx context.Context, cfg *config.Config) (*Infra, error) {
+	// Машины проверяем до подключения к БД: битый файл не должен поднимать бота.
+	slotMachines, err := casino.LoadMachines(cfg.CasinoMachinesFile, cfg.CasinoDefaultMachine)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки слот-машин: %w", err)
+	}
+
+	streakPolicy, err := streak.NewPolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка настроек огонька: %w", err)
+	}
+
 	pool, err := postgres.NewPool(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка подключения к БД: %w", err)
@@ -54,14 +74,23 @@ func BuildInfra(ctx context.Context, cfg *config.Config) (*Infra, error) {
 	casinoRepo := casino.NewRepository(pool)
 	adminRepo := admin.NewRepository(pool)
 	riddleRepo := admin.NewRiddleRepository(pool)
+	debtRepo := debts.NewRepository(pool)
+	shopRepo := shop.NewRepository(pool)
+	payoutRepo := payouts.NewRepository(pool)
 
 	memberService := members.NewService(memberRepo)
 	economyService := economy.NewService(economyRepo)
-	streakService := streak.NewService(streakRepo, economyService, cfg)
+	streakService := streak.NewService(streakRepo, economyService, streakPolicy, cfg)
+	if cfg.StreakSpamStore == "postgres" {
+		streakService.SetAntiSpamStore(streakRepo)
+	}
 	karmaService := karma.NewService(karmaRepo, economyService, memberService, cfg)
-	casinoService := casino.NewService(casinoRepo, economyService, cfg)
+	casinoService := casino.NewService(casinoRepo, economyService, slotMachines, cfg)
 	adminService := admin.NewService(adminRepo, memberRepo, cfg)
 	riddleService := admin.NewRiddleService(riddleRepo, economyService)
+	debtService := debts.NewService(debtRepo, economyService)
+	shopService := shop.NewService(shopRepo, economyService, memberRepo, streakRepo)
+	payoutService := payouts.NewService(payoutRepo, economyService, memberRepo, cfg)
 
 	return &Infra{
 		DB:             pool,
@@ -72,6 +101,9 @@ func BuildInfra(ctx context.Context, cfg *config.Config) (*Infra, error) {
 		CasinoRepo:     casinoRepo,
 		AdminRepo:      adminRepo,
 		RiddleRepo:     riddleRepo,
+		DebtRepo:       debtRepo,
+		ShopRepo:       shopRepo,
+		PayoutRepo:     payoutRepo,
 		MemberService:  memberService,
 		EconomyService: economyService,
 		StreakService:  streakService,
@@ -79,5 +111,8 @@ func BuildInfra(ctx context.Context, cfg *config.Config) (*Infra, error) {
 		CasinoService:  casinoService,
 		AdminService:   adminService,
 		RiddleService:  riddleService,
+		DebtService:    debtService,
+		ShopService:    shopService,
+		PayoutService:  payoutService,
 	}, nil
 }
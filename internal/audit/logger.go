@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	models "github.com/mymmrac/telego"
 	log "github.com/sirupsen/logrus"
@@ -23,6 +24,16 @@ type BalanceChange struct {
 	NewBalance  int64
 }
 
+// LedgerDrift — строка сверки журнала: баланс пользователя и баланс по проводкам.
+type LedgerDrift struct {
+	TargetLabel string
+	Balance     int64
+	Ledger      int64
+}
+
+// ledgerDriftLimit — сколько строк расхождений показывать в одном сообщении.
+const ledgerDriftLimit = 30
+
 type Logger struct {
 	ops    *telegram.Ops
 	chatID int64
@@ -95,8 +106,13 @@ func (l *Logger) LogBalanceAdjust(ctx context.Context, actor string, delta int64
 	l.send(ctx, strings.Join(lines, "\n"))
 }
 
-func (l *Logger) LogTransfer(ctx context.Context, from, to string, amount int64) {
-	l.send(ctx, fmt.Sprintf("💸 transfer: %s -> %s (%d)", from, to, amount))
+// LogTransfer пишет исполненный перевод; fee — удержанная сверх суммы комиссия.
+func (l *Logger) LogTransfer(ctx context.Context, from, to string, amount, fee int64) {
+	text := fmt.Sprintf("💸 transfer: %s -> %s (%d)", from, to, amount)
+	if fee > 0 {
+		text = fmt.Sprintf("💸 transfer: %s -> %s (%d, fee %d)", from, to, amount, fee)
+	}
+	l.send(ctx, text)
 }
 
 func (l *Logger) LogRoleAssign(ctx context.Context, actor, target, role string) {
@@ -121,6 +137,52 @@ func (l *Logger) LogRiddleEnded(ctx context.Context, winners int, reward int64,
 	l.send(ctx, fmt.Sprintf("%s %s winners=%d reward=%d", prefix, state, winners, reward))
 }
 
+func (l *Logger) LogCreditIssue(ctx context.Context, actor, target string, principal, amountDue int64, dueAt time.Time) {
+	l.send(ctx, fmt.Sprintf("💳 credit_issue: %s -> %s (%d, due=%d до %s)", actor, target, principal, amountDue, common.FormatDateTime(dueAt)))
+}
+
+func (l *Logger) LogCreditRepay(ctx context.Context, target string, amount, remaining int64) {
+	l.send(ctx, fmt.Sprintf("💳 credit_repay: %s (%d, остаток=%d)", target, amount, remaining))
+}
+
+func (l *Logger) LogCreditWriteOff(ctx context.Context, actor, target string, remaining int64) {
+	l.send(ctx, fmt.Sprintf("🚫 credit_write_off: %s -> %s (списано %d)", actor, target, remaining))
+}
+
+func (l *Logger) LogCasinoExclusionLift(ctx context.Context, actor, target string, endsAt time.Time) {
+	l.send(ctx, fmt.Sprintf("🎰 casino_exclusion_lift: %s -> %s (было до %s)", actor, target, common.FormatDateTime(endsAt)))
+}
+
+func (l *Logger) LogPayoutRun(ctx context.Context, title, target string, periodAt time.Time, recipients int, amount, total int64) {
+	l.send(ctx, fmt.Sprintf("💼 payout: «%s» -> %s за %s (%d × %d = %d)", title, target, common.FormatDateTime(periodAt), recipients, amount, total))
+}
+
+func (l *Logger) LogLedgerMismatches(ctx context.Context, drifts []LedgerDrift) {
+	if len(drifts) == 0 {
+		return
+	}
+	l.send(ctx, formatLedgerDrifts(fmt.Sprintf("📒 ledger_mismatch: %d", len(drifts)), drifts))
+}
+
+func (l *Logger) LogLedgerCorrection(ctx context.Context, actor string, drifts []LedgerDrift) {
+	if len(drifts) == 0 {
+		return
+	}
+	l.send(ctx, formatLedgerDrifts(fmt.Sprintf("✍️ ledger_correction: %d by %s", len(drifts), actor), drifts))
+}
+
+func formatLedgerDrifts(header string, drifts []LedgerDrift) string {
+	lines := []string{header}
+	for i, drift := range drifts {
+		if i == ledgerDriftLimit {
+			lines = append(lines, fmt.Sprintf("… и ещё %d", len(drifts)-ledgerDriftLimit))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s: баланс %d, журнал %d (%+d)", drift.TargetLabel, drift.Balance, drift.Ledger, drift.Balance-drift.Ledger))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (l *Logger) send(ctx context.Context, text string) {
 	if l == nil || l.ops == nil || l.chatID == 0 || strings.TrimSpace(text) == "" {
 		return
@@ -3,6 +3,7 @@ package audit
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	models "github.com/mymmrac/telego"
@@ -76,3 +77,51 @@ func TestLoggerSwallowsSendFailure(t *testing.T) {
 		t.Fatalf("expected attempted send, got %#v", tg.sent)
 	}
 }
+
+func TestLogTransferIncludesFee(t *testing.T) {
+	tg := &fakeTG{}
+	logger := NewLogger(telegram.NewOps(tg), 555)
+
+	logger.LogTransfer(context.Background(), "@payer", "@payee", 100, 5)
+	logger.LogTransfer(context.Background(), "@payer", "@payee", 100, 0)
+
+	if len(tg.texts) != 2 || !strings.Contains(tg.texts[0], "(100, fee 5)") || strings.Contains(tg.texts[1], "fee") {
+		t.Fatalf("unexpected transfer audit lines: %#v", tg.texts)
+	}
+}
+
+func TestLogLedgerMismatchesCapsLines(t *testing.T) {
+	tg := &fakeTG{}
+	logger := NewLogger(telegram.NewOps(tg), 555)
+
+	drifts := make([]LedgerDrift, ledgerDriftLimit+2)
+	for i := range drifts {
+		drifts[i] = LedgerDrift{TargetLabel: "@user", Balance: 120, Ledger: 100}
+	}
+	logger.LogLedgerMismatches(context.Background(), drifts)
+
+	if len(tg.texts) != 1 {
+		t.Fatalf("expected one audit message, got %d", len(tg.texts))
+	}
+	lines := strings.Split(tg.texts[0], "\n")
+	if lines[0] != "📒 ledger_mismatch: 32" {
+		t.Fatalf("unexpected header %q", lines[0])
+	}
+	if lines[1] != "@user: баланс 120, журнал 100 (+20)" {
+		t.Fatalf("unexpected drift line %q", lines[1])
+	}
+	if last := lines[len(lines)-1]; last != "… и ещё 2" {
+		t.Fatalf("expected overflow line, got %q", last)
+	}
+}
+
+func TestLogLedgerMismatchesSkipsEmpty(t *testing.T) {
+	tg := &fakeTG{}
+	logger := NewLogger(telegram.NewOps(tg), 555)
+
+	logger.LogLedgerMismatches(context.Background(), nil)
+
+	if len(tg.sent) != 0 {
+		t.Fatalf("expected no sends for clean ledger, got %#v", tg.sent)
+	}
+}
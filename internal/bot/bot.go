@@ -35,6 +35,8 @@ type Deps struct {
 	AdminHandler   AdminHandler
 	MembersHandler MembersHandler
 	EconomyHandler EconomyHandler
+	CasinoHandler  CasinoHandler
+	ShopHandler    ShopHandler
 	ChatFilter     ChatAccessFilter
 	ThankYou       KarmaThankYouClassifier
 }
@@ -76,6 +78,8 @@ type Bot struct {
 	adminHandler   AdminHandler
 	membersHandler MembersHandler
 	economyHandler EconomyHandler
+	casinoHandler  CasinoHandler
+	shopHandler    ShopHandler
 	karmaHandler   KarmaHandler
 
 	memberService  MemberService
@@ -105,6 +109,8 @@ func New(d Deps) *Bot {
 		adminHandler:   d.AdminHandler,
 		membersHandler: d.MembersHandler,
 		economyHandler: d.EconomyHandler,
+		casinoHandler:  d.CasinoHandler,
+		shopHandler:    d.ShopHandler,
 		karmaHandler:   d.KarmaHandler,
 		memberService:  d.MemberService,
 		economyService: d.EconomyService,
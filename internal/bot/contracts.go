@@ -22,6 +22,14 @@ type EconomyHandler interface {
 	HandleEconomyMessage(ctx context.Context, message *models.Message) bool
 }
 
+type CasinoHandler interface {
+	HandleCasinoCallback(ctx context.Context, q *models.CallbackQuery) bool
+}
+
+type ShopHandler interface {
+	HandleShopCallback(ctx context.Context, q *models.CallbackQuery) bool
+}
+
 type ChatAccessFilter interface {
 	CheckAccess(ctx context.Context, message *models.Message) bool
 }
@@ -50,7 +58,7 @@ type KarmaService interface {
 }
 
 type KarmaHandler interface {
-	HandleThankYou(ctx context.Context, chatID int64, fromUserID int64, toUserID int64)
+	HandleThankYou(ctx context.Context, chatID int64, messageID int, fromUserID int64, toUserID int64)
 }
 
 type KarmaThankYouClassifier interface {
@@ -141,12 +141,14 @@ func (f fakePurgeMetricsProvider) GetPurgeMetrics() jobs.PurgeMetrics { return f
 
 type fakeStreakServiceStatus struct {
 	calls     int
+	lastText  string
 	callOrder *[]string
 	err       error
 }
 
 func (s *fakeStreakServiceStatus) CountMessage(ctx context.Context, userID int64, messageID int64, text string) error {
 	s.calls++
+	s.lastText = text
 	if s.callOrder != nil {
 		*s.callOrder = append(*s.callOrder, "count_message")
 	}
@@ -569,6 +571,39 @@ func TestHandleUpdate_MemberSourceChatNonTextMessage_DoesNotCountStreakMessage(t
 	}
 }
 
+func TestHandleUpdate_MemberSourceChatSticker_PassesEmojiToAntiSpam(t *testing.T) {
+	tg := &fakeTGStatus{}
+	repo := &fakeMembersRepoStatus{}
+	streakSvc := &fakeStreakServiceStatus{}
+	b := &Bot{
+		cfg: &config.Config{
+			MemberSourceChatID:    -1001,
+			AdminChatID:           -2002,
+			RateLimitRequests:     100,
+			RateLimitWindow:       time.Minute,
+			FeatureStreaksEnabled: true,
+		},
+		ops:           telegram.NewOps(tg),
+		memberService: members.NewService(repo),
+		streakService: streakSvc,
+		chatFilter:    policyChatFilterStatus{memberSourceChatID: -1001, adminChatID: -2002},
+		rateLimiter:   middleware.NewRateLimiter(100, time.Minute),
+		parser:        NewCommandParser(),
+		cmdRouter:     commands.NewRouter(),
+	}
+
+	upd := models.Update{Message: &models.Message{
+		Chat:    models.Chat{ID: -1001, Type: models.ChatTypeSupergroup},
+		From:    &models.User{ID: 55, Username: "u", FirstName: "User"},
+		Sticker: &models.Sticker{Emoji: "😂"},
+	}}
+	b.handleUpdate(context.Background(), upd)
+
+	if streakSvc.calls != 1 || streakSvc.lastText != "😂" {
+		t.Fatalf("expected sticker emoji passed to CountMessage, got calls=%d text=%q", streakSvc.calls, streakSvc.lastText)
+	}
+}
+
 func TestHandleUpdate_CallbackWithoutMessage_AcksAndReturns(t *testing.T) {
 	tg := &fakeTGStatus{}
 	b := &Bot{ops: telegram.NewOps(tg)}
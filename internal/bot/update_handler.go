@@ -97,6 +97,12 @@ func (b *Bot) handleCallbackUpdate(ctx context.Context, uc UpdateContext) bool {
 	if b.economyHandler != nil && b.economyHandler.HandleEconomyCallback(ctx, uc.Callback) {
 		return true
 	}
+	if b.casinoHandler != nil && b.casinoHandler.HandleCasinoCallback(ctx, uc.Callback) {
+		return true
+	}
+	if b.shopHandler != nil && b.shopHandler.HandleShopCallback(ctx, uc.Callback) {
+		return true
+	}
 	if b.adminHandler.HandleAdminCallback(ctx, uc.Callback) {
 		return true
 	}
@@ -136,6 +142,13 @@ func (b *Bot) handleMessageUpdate(ctx context.Context, uc UpdateContext) {
 	}
 
 	if message.Text == "" {
+		if message.Sticker != nil && message.Sticker.Emoji != "" && b.isMessageIngestChat(chatID) && b.cfg.FeatureStreaksEnabled {
+			// Стикер огонёк не засчитывает, но антиспам видит его как эмодзи
+			// и ставит паузу при флуде стикерами.
+			if err := b.streakService.CountMessage(ctx, userID, int64(message.MessageID), message.Sticker.Emoji); err != nil {
+				log.WithError(err).WithField("user_id", userID).Error("streak sticker count failed")
+			}
+		}
 		return
 	}
 
@@ -150,7 +163,7 @@ func (b *Bot) handleMessageUpdate(ctx context.Context, uc UpdateContext) {
 
 	if b.cfg.FeatureKarmaEnabled && message.ReplyToMessage != nil && message.ReplyToMessage.From != nil {
 		if b.thankYou != nil && b.thankYou.IsThankYou(messageText) {
-			b.karmaHandler.HandleThankYou(ctx, chatID, userID, message.ReplyToMessage.From.ID)
+			b.karmaHandler.HandleThankYou(ctx, chatID, message.MessageID, userID, message.ReplyToMessage.From.ID)
 			return
 		}
 	}
@@ -33,7 +33,7 @@ func TestRegisterAllFeaturesSmoke(t *testing.T) {
 		streak.NewFeature(nil, cfg),
 		casino.NewFeature(nil, cfg),
 		members.NewFeature(nil),
-		debts.NewFeature(),
+		debts.NewFeature(nil, cfg),
 	}
 
 	var current string
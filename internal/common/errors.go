@@ -18,6 +18,12 @@ var (
 	ErrUserNotFound = errors.New("пользователь не найден")
 	// ErrTransferTargetIsBot — нельзя использовать бота как получателя перевода
 	ErrTransferTargetIsBot = errors.New("нельзя переводить 🎞️ ботам")
+	// ErrTransferDailyCap — перевод превысит дневной лимит исходящих переводов
+	ErrTransferDailyCap = errors.New("дневной лимит переводов исчерпан")
+	// ErrTransferAccountTooNew — отправитель слишком недавно в чате
+	ErrTransferAccountTooNew = errors.New("переводы доступны не сразу после вступления")
+	// ErrTransferRecipientLeft — получатель вышел из чата
+	ErrTransferRecipientLeft = errors.New("получатель вышел из чата")
 )
 
 // Ошибки кармы
@@ -57,4 +63,14 @@ var (
 var (
 	// ErrCasinoDisabled — казино отключено в настройках
 	ErrCasinoDisabled = errors.New("казино временно отключено")
+	// ErrCasinoBetOutOfRange — ставка вне допустимых границ
+	ErrCasinoBetOutOfRange = errors.New("ставка вне допустимого диапазона")
+	// ErrCasinoDailyWagerCap — ставка превысит дневной лимит ставок
+	ErrCasinoDailyWagerCap = errors.New("дневной лимит ставок исчерпан")
+	// ErrCasinoDailyLossCap — ставка превысит дневной лимит проигрыша
+	ErrCasinoDailyLossCap = errors.New("дневной лимит проигрыша исчерпан")
+	// ErrCasinoWeeklyLossCap — ставка превысит недельный лимит проигрыша
+	ErrCasinoWeeklyLossCap = errors.New("недельный лимит проигрыша исчерпан")
+	// ErrCasinoSelfExcluded — игрок в самоисключении
+	ErrCasinoSelfExcluded = errors.New("игрок в самоисключении из казино")
 )
@@ -57,9 +57,32 @@ type Config struct {
 	AdminPasswordHash string `envconfig:"ADMIN_PASSWORD_HASH" required:"true"`
 
 	// Streak
-	StreakMessagesNeed      int `envconfig:"STREAK_MESSAGES_NEED" default:"50"`
+	StreakMessagesNeed      int `envconfig:"STREAK_MESSAGES_NEED" default:"4"`
 	StreakReminderThreshold int `envconfig:"STREAK_REMINDER_THRESHOLD" default:"7"`
 	StreakInactiveHours     int `envconfig:"STREAK_INACTIVE_HOURS" default:"10"`
+	// Награда за каждый день серии через запятую; последний элемент — потолок.
+	// Вехи — разовые бонусы "день:бонус" через запятую.
+	StreakRewards    string `envconfig:"STREAK_REWARDS" default:"10,20,30,40,50,60,70"`
+	StreakMilestones string `envconfig:"STREAK_MILESTONES" default:""`
+	// Дни серии через запятую, с которыми бот публично поздравляет в чате
+	// участников; пусто — без поздравлений.
+	StreakCelebrations string `envconfig:"STREAK_CELEBRATIONS" default:"7,30,100"`
+	// Заморозки стрика: одна за каждые STREAK_FREEZE_EARN_EVERY закрытых дней
+	// (0 — только из магазина); пока на руках STREAK_FREEZE_MAX заморозок и
+	// больше, новые не начисляются (0 — без ограничения).
+	StreakFreezeEarnEvery int `envconfig:"STREAK_FREEZE_EARN_EVERY" default:"10"`
+	StreakFreezeMax       int `envconfig:"STREAK_FREEZE_MAX" default:"3"`
+	// Антиспам огонька: где хранить историю (memory — в процессе, postgres —
+	// общая для инстансов и переживает рестарт), лимит засчитанных сообщений
+	// в минуту, окно и порог похожести (0..1] для поиска повторов, пауза после
+	// STREAK_SPAM_FLOOD_LIMIT стикеров или эмодзи за STREAK_SPAM_FLOOD_WINDOW.
+	// Нулевой лимит отключает правило.
+	StreakSpamStore           string        `envconfig:"STREAK_SPAM_STORE" default:"memory"`
+	StreakSpamMaxPerMinute    int           `envconfig:"STREAK_SPAM_MAX_PER_MINUTE" default:"2"`
+	StreakSpamDuplicateWindow time.Duration `envconfig:"STREAK_SPAM_DUPLICATE_WINDOW" default:"3s"`
+	StreakSpamSimilarity      float64       `envconfig:"STREAK_SPAM_SIMILARITY" default:"1"`
+	StreakSpamFloodLimit      int           `envconfig:"STREAK_SPAM_FLOOD_LIMIT" default:"0"`
+	StreakSpamFloodWindow     time.Duration `envconfig:"STREAK_SPAM_FLOOD_WINDOW" default:"2m"`
 
 	// Karma / Thanks
 	KarmaDailyLimit            int `envconfig:"KARMA_DAILY_LIMIT" default:"2"`
@@ -67,14 +90,57 @@ type Config struct {
 	ThanksDailyLimit           int `envconfig:"THANKS_DAILY_LIMIT" default:"3"`
 
 	// Casino
-	CasinoSlotsBet int64   `envconfig:"CASINO_SLOTS_BET" default:"50"`
-	CasinoInitRTP  float64 `envconfig:"CASINO_INITIAL_RTP" default:"96.00"`
-	CasinoMinRTP   float64 `envconfig:"CASINO_MIN_RTP" default:"94.00"`
-	CasinoMaxRTP   float64 `envconfig:"CASINO_MAX_RTP" default:"98.00"`
+	CasinoSlotsBet int64 `envconfig:"CASINO_SLOTS_BET" default:"50"`
+	// Границы ставки в слотах; CASINO_SLOTS_BET — ставка без аргумента во
+	// всех играх (прижимается к границам игры).
+	CasinoSlotsMinBet int64 `envconfig:"CASINO_SLOTS_MIN_BET" default:"10"`
+	CasinoSlotsMaxBet int64 `envconfig:"CASINO_SLOTS_MAX_BET" default:"1000"`
+	// Дневные лимиты игрока (день по APP_TIMEZONE); 0 — без лимита.
+	CasinoDailyWagerCap int64 `envconfig:"CASINO_DAILY_WAGER_CAP" default:"10000"`
+	CasinoDailyLossCap  int64 `envconfig:"CASINO_DAILY_LOSS_CAP" default:"5000"`
+	// Недельный лимит чистого проигрыша (неделя с понедельника по APP_TIMEZONE); 0 — без лимита.
+	CasinoWeeklyLossCap int64   `envconfig:"CASINO_WEEKLY_LOSS_CAP" default:"20000"`
+	CasinoInitRTP       float64 `envconfig:"CASINO_INITIAL_RTP" default:"96.00"`
+	CasinoMinRTP        float64 `envconfig:"CASINO_MIN_RTP" default:"94.00"`
+	CasinoMaxRTP        float64 `envconfig:"CASINO_MAX_RTP" default:"98.00"`
+	// Процент каждой ставки, уходящий в прогрессивный джекпот; 0 — пул не пополняется.
+	CasinoJackpotPercent float64 `envconfig:"CASINO_JACKPOT_PERCENT" default:"1"`
+	// Доказуемо честные спины: сетка выводится из сидов игрока, а не из crypto/rand.
+	CasinoProvablyFair bool `envconfig:"CASINO_PROVABLY_FAIR" default:"true"`
+	// Преимущество казино в процентах для настольных игр.
+	CasinoDiceHouseEdge     float64 `envconfig:"CASINO_DICE_HOUSE_EDGE" default:"3"`
+	CasinoRouletteHouseEdge float64 `envconfig:"CASINO_ROULETTE_HOUSE_EDGE" default:"2.7"`
+	CasinoCoinHouseEdge     float64 `envconfig:"CASINO_COIN_HOUSE_EDGE" default:"3"`
+	// Границы ставки настольных игр.
+	CasinoDiceMinBet     int64 `envconfig:"CASINO_DICE_MIN_BET" default:"10"`
+	CasinoDiceMaxBet     int64 `envconfig:"CASINO_DICE_MAX_BET" default:"1000"`
+	CasinoRouletteMinBet int64 `envconfig:"CASINO_ROULETTE_MIN_BET" default:"10"`
+	CasinoRouletteMaxBet int64 `envconfig:"CASINO_ROULETTE_MAX_BET" default:"1000"`
+	CasinoCoinMinBet     int64 `envconfig:"CASINO_COIN_MIN_BET" default:"10"`
+	CasinoCoinMaxBet     int64 `envconfig:"CASINO_COIN_MAX_BET" default:"1000"`
+	// Дуэли !дуэль: комиссия казино с банка в процентах (0 — без комиссии)
+	// и время на принятие вызова, после которого ставка возвращается.
+	CasinoDuelFeePercent float64       `envconfig:"CASINO_DUEL_FEE_PERCENT" default:"0"`
+	CasinoDuelTTL        time.Duration `envconfig:"CASINO_DUEL_TTL" default:"10m"`
+	// Границы ставки одного участника дуэли.
+	CasinoDuelMinStake int64 `envconfig:"CASINO_DUEL_MIN_STAKE" default:"10"`
+	CasinoDuelMaxStake int64 `envconfig:"CASINO_DUEL_MAX_STAKE" default:"1000"`
+	// Файл с описанием слот-машин (JSON); пусто — только встроенная classic.
+	CasinoMachinesFile   string `envconfig:"CASINO_MACHINES_FILE" default:""`
+	CasinoDefaultMachine string `envconfig:"CASINO_DEFAULT_MACHINE" default:""`
 
 	// Economy
 	EconomyStartingBalance int64  `envconfig:"ECONOMY_STARTING_BALANCE" default:"0"`
 	EconomyCurrencyName    string `envconfig:"ECONOMY_CURRENCY_NAME" default:"плюшки"`
+	// Сколько запрос плёнок (!запросить) ждёт оплаты.
+	EconomyRequestTTL time.Duration `envconfig:"ECONOMY_REQUEST_TTL" default:"24h"`
+	// Политика переводов: комиссия в процентах (уходит в казну или
+	// сжигается при ECONOMY_TREASURY_USER_ID=0), дневной лимит исходящих
+	// переводов и минимальный стаж отправителя в чате (0 — без ограничений).
+	EconomyTransferFeePercent    float64       `envconfig:"ECONOMY_TRANSFER_FEE_PERCENT" default:"0"`
+	EconomyTreasuryUserID        int64         `envconfig:"ECONOMY_TREASURY_USER_ID" default:"0"`
+	EconomyTransferDailyCap      int64         `envconfig:"ECONOMY_TRANSFER_DAILY_CAP" default:"0"`
+	EconomyTransferMinAccountAge time.Duration `envconfig:"ECONOMY_TRANSFER_MIN_ACCOUNT_AGE" default:"0"`
 
 	// Rate limiting
 	RateLimitRequests int           `envconfig:"RATE_LIMIT_REQUESTS" default:"10"`
@@ -84,6 +150,7 @@ type Config struct {
 	FeatureCasinoEnabled  bool `envconfig:"FEATURE_CASINO_ENABLED" default:"true"`
 	FeatureKarmaEnabled   bool `envconfig:"FEATURE_KARMA_ENABLED" default:"true"`
 	FeatureStreaksEnabled bool `envconfig:"FEATURE_STREAKS_ENABLED" default:"true"`
+	FeatureShopEnabled    bool `envconfig:"FEATURE_SHOP_ENABLED" default:"true"`
 }
 
 func (c *Config) DatabaseDSN() string {
@@ -121,6 +188,107 @@ func (c *Config) Validate() error {
 	if c.DBMaxConns <= 0 || c.DBMinConns < 0 || c.DBMinConns > c.DBMaxConns {
 		return fmt.Errorf("invalid DB_MIN_CONNS/DB_MAX_CONNS values")
 	}
+	if err := c.validateEconomy(); err != nil {
+		return err
+	}
+	if err := c.validateStreak(); err != nil {
+		return err
+	}
+	if c.FeatureCasinoEnabled {
+		if err := c.validateCasino(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEconomy проверяет правила переводов и запросов плёнок; они
+// действуют и без казино.
+func (c *Config) validateEconomy() error {
+	if c.EconomyRequestTTL <= 0 {
+		return fmt.Errorf("ECONOMY_REQUEST_TTL must be > 0")
+	}
+	if c.EconomyTransferFeePercent < 0 || c.EconomyTransferFeePercent >= 100 {
+		return fmt.Errorf("ECONOMY_TRANSFER_FEE_PERCENT must be in range [0..100)")
+	}
+	if c.EconomyTreasuryUserID < 0 {
+		return fmt.Errorf("ECONOMY_TREASURY_USER_ID must be >= 0")
+	}
+	if c.EconomyTransferDailyCap < 0 {
+		return fmt.Errorf("ECONOMY_TRANSFER_DAILY_CAP must be >= 0")
+	}
+	if c.EconomyTransferMinAccountAge < 0 {
+		return fmt.Errorf("ECONOMY_TRANSFER_MIN_ACCOUNT_AGE must be >= 0")
+	}
+	return nil
+}
+
+// validateStreak проверяет настройки огонька; они нужны и без казино.
+func (c *Config) validateStreak() error {
+	if c.StreakFreezeEarnEvery < 0 || c.StreakFreezeMax < 0 {
+		return fmt.Errorf("STREAK_FREEZE_EARN_EVERY and STREAK_FREEZE_MAX must be >= 0")
+	}
+	if c.StreakSpamStore != "memory" && c.StreakSpamStore != "postgres" {
+		return fmt.Errorf("STREAK_SPAM_STORE must be memory or postgres")
+	}
+	if c.StreakSpamMaxPerMinute < 0 || c.StreakSpamFloodLimit < 0 {
+		return fmt.Errorf("STREAK_SPAM_MAX_PER_MINUTE and STREAK_SPAM_FLOOD_LIMIT must be >= 0")
+	}
+	if c.StreakSpamDuplicateWindow < 0 {
+		return fmt.Errorf("STREAK_SPAM_DUPLICATE_WINDOW must be >= 0")
+	}
+	if c.StreakSpamSimilarity <= 0 || c.StreakSpamSimilarity > 1 {
+		return fmt.Errorf("STREAK_SPAM_SIMILARITY must be in range (0..1]")
+	}
+	if c.StreakSpamFloodLimit > 0 && c.StreakSpamFloodWindow <= 0 {
+		return fmt.Errorf("STREAK_SPAM_FLOOD_WINDOW must be > 0 when STREAK_SPAM_FLOOD_LIMIT is set")
+	}
+	return nil
+}
+
+func (c *Config) validateCasino() error {
+	if c.CasinoSlotsMinBet <= 0 || c.CasinoSlotsMaxBet < c.CasinoSlotsMinBet {
+		return fmt.Errorf("invalid CASINO_SLOTS_MIN_BET/CASINO_SLOTS_MAX_BET values")
+	}
+	if c.CasinoSlotsBet < c.CasinoSlotsMinBet || c.CasinoSlotsBet > c.CasinoSlotsMaxBet {
+		return fmt.Errorf("CASINO_SLOTS_BET must be in range [%d..%d]", c.CasinoSlotsMinBet, c.CasinoSlotsMaxBet)
+	}
+	if c.CasinoDailyWagerCap < 0 || c.CasinoDailyLossCap < 0 {
+		return fmt.Errorf("CASINO_DAILY_WAGER_CAP and CASINO_DAILY_LOSS_CAP must be >= 0")
+	}
+	if c.CasinoWeeklyLossCap < 0 {
+		return fmt.Errorf("CASINO_WEEKLY_LOSS_CAP must be >= 0")
+	}
+	if c.CasinoJackpotPercent < 0 || c.CasinoJackpotPercent >= 100 {
+		return fmt.Errorf("CASINO_JACKPOT_PERCENT must be in range [0..100)")
+	}
+	for name, bets := range map[string][2]int64{
+		"CASINO_DICE":     {c.CasinoDiceMinBet, c.CasinoDiceMaxBet},
+		"CASINO_ROULETTE": {c.CasinoRouletteMinBet, c.CasinoRouletteMaxBet},
+		"CASINO_COIN":     {c.CasinoCoinMinBet, c.CasinoCoinMaxBet},
+	} {
+		if bets[0] <= 0 || bets[1] < bets[0] {
+			return fmt.Errorf("invalid %s_MIN_BET/%s_MAX_BET values", name, name)
+		}
+	}
+	for name, edge := range map[string]float64{
+		"CASINO_DICE_HOUSE_EDGE":     c.CasinoDiceHouseEdge,
+		"CASINO_ROULETTE_HOUSE_EDGE": c.CasinoRouletteHouseEdge,
+		"CASINO_COIN_HOUSE_EDGE":     c.CasinoCoinHouseEdge,
+	} {
+		if edge < 0 || edge >= 100 {
+			return fmt.Errorf("%s must be in range [0..100)", name)
+		}
+	}
+	if c.CasinoDuelFeePercent < 0 || c.CasinoDuelFeePercent >= 100 {
+		return fmt.Errorf("CASINO_DUEL_FEE_PERCENT must be in range [0..100)")
+	}
+	if c.CasinoDuelMinStake <= 0 || c.CasinoDuelMaxStake < c.CasinoDuelMinStake {
+		return fmt.Errorf("invalid CASINO_DUEL_MIN_STAKE/CASINO_DUEL_MAX_STAKE values")
+	}
+	if c.CasinoDuelTTL <= 0 {
+		return fmt.Errorf("CASINO_DUEL_TTL must be > 0")
+	}
 	return nil
 }
 
@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestParseIDList(t *testing.T) {
 	ids := parseIDList(" 123 , bad, , 456 , 123, 7x, 789 ")
@@ -65,6 +68,9 @@ func TestConfigValidate_LeaveDebug(t *testing.T) {
 				BotUpdateQueue:          minBotUpdateQueue,
 				DBMaxConns:              1,
 				DBMinConns:              0,
+				EconomyRequestTTL:       24 * time.Hour,
+				StreakSpamStore:         "memory",
+				StreakSpamSimilarity:    1,
 			}
 
 			err := cfg.Validate()
@@ -77,3 +83,137 @@ func TestConfigValidate_LeaveDebug(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigValidate_CasinoBets(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			MemberSourceChatID:      -1001,
+			AdminChatID:             -2002,
+			BotMaxInflight:          1,
+			BotUpdateTimeoutSeconds: 1,
+			BotWorkers:              minBotWorkers,
+			BotUpdateQueue:          minBotUpdateQueue,
+			DBMaxConns:              1,
+			FeatureCasinoEnabled:    true,
+			CasinoSlotsBet:          50,
+			CasinoSlotsMinBet:       10,
+			CasinoSlotsMaxBet:       1000,
+			CasinoDiceMinBet:        10,
+			CasinoDiceMaxBet:        1000,
+			CasinoRouletteMinBet:    10,
+			CasinoRouletteMaxBet:    1000,
+			CasinoCoinMinBet:        10,
+			CasinoCoinMaxBet:        1000,
+			CasinoDailyWagerCap:     10000,
+			CasinoDailyLossCap:      5000,
+			CasinoDuelTTL:           10 * time.Minute,
+			CasinoDuelMinStake:      10,
+			CasinoDuelMaxStake:      1000,
+			EconomyRequestTTL:       24 * time.Hour,
+			StreakSpamStore:         "postgres",
+			StreakSpamSimilarity:    0.8,
+			StreakSpamFloodLimit:    5,
+			StreakSpamFloodWindow:   2 * time.Minute,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(c *Config)
+		wantErr bool
+	}{
+		{name: "defaults", mutate: func(c *Config) {}},
+		{name: "caps disabled", mutate: func(c *Config) { c.CasinoDailyWagerCap, c.CasinoDailyLossCap = 0, 0 }},
+		{name: "zero min", mutate: func(c *Config) { c.CasinoSlotsMinBet = 0 }, wantErr: true},
+		{name: "max below min", mutate: func(c *Config) { c.CasinoSlotsMaxBet = 5 }, wantErr: true},
+		{name: "default bet above max", mutate: func(c *Config) { c.CasinoSlotsBet = 5000 }, wantErr: true},
+		{name: "negative loss cap", mutate: func(c *Config) { c.CasinoDailyLossCap = -1 }, wantErr: true},
+		{name: "negative weekly loss cap", mutate: func(c *Config) { c.CasinoWeeklyLossCap = -1 }, wantErr: true},
+		{name: "jackpot takes whole bet", mutate: func(c *Config) { c.CasinoJackpotPercent = 100 }, wantErr: true},
+		{name: "roulette max below min", mutate: func(c *Config) { c.CasinoRouletteMaxBet = 5 }, wantErr: true},
+		{name: "zero coin min", mutate: func(c *Config) { c.CasinoCoinMinBet = 0 }, wantErr: true},
+		{name: "negative roulette edge", mutate: func(c *Config) { c.CasinoRouletteHouseEdge = -1 }, wantErr: true},
+		{name: "duel fee takes whole pot", mutate: func(c *Config) { c.CasinoDuelFeePercent = 100 }, wantErr: true},
+		{name: "duel max stake below min", mutate: func(c *Config) { c.CasinoDuelMaxStake = 5 }, wantErr: true},
+		{name: "zero duel ttl", mutate: func(c *Config) { c.CasinoDuelTTL = 0 }, wantErr: true},
+		{name: "zero request ttl", mutate: func(c *Config) { c.EconomyRequestTTL = 0 }, wantErr: true},
+		{name: "transfer fee takes whole amount", mutate: func(c *Config) { c.EconomyTransferFeePercent = 100 }, wantErr: true},
+		{name: "negative transfer cap", mutate: func(c *Config) { c.EconomyTransferDailyCap = -1 }, wantErr: true},
+		{name: "negative freeze cap", mutate: func(c *Config) { c.StreakFreezeMax = -1 }, wantErr: true},
+		{name: "unknown spam store", mutate: func(c *Config) { c.StreakSpamStore = "redis" }, wantErr: true},
+		{name: "spam similarity above one", mutate: func(c *Config) { c.StreakSpamSimilarity = 1.5 }, wantErr: true},
+		{name: "spam flood without window", mutate: func(c *Config) { c.StreakSpamFloodWindow = 0 }, wantErr: true},
+		{name: "spam flood disabled", mutate: func(c *Config) { c.StreakSpamFloodLimit, c.StreakSpamFloodWindow = 0, 0 }},
+		{name: "negative account age", mutate: func(c *Config) { c.EconomyTransferMinAccountAge = -time.Hour }, wantErr: true},
+		{name: "casino disabled skips checks", mutate: func(c *Config) {
+			c.FeatureCasinoEnabled = false
+			c.CasinoSlotsMinBet = 0
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.wantErr && err == nil {
+				t.Fatal("expected validation error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_WithoutCasino(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			MemberSourceChatID:      -1001,
+			AdminChatID:             -2002,
+			BotMaxInflight:          1,
+			BotUpdateTimeoutSeconds: 1,
+			BotWorkers:              minBotWorkers,
+			BotUpdateQueue:          minBotUpdateQueue,
+			DBMaxConns:              1,
+			FeatureCasinoEnabled:    false,
+			EconomyRequestTTL:       24 * time.Hour,
+			StreakSpamStore:         "memory",
+			StreakSpamSimilarity:    1,
+			StreakSpamFloodWindow:   2 * time.Minute,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(c *Config)
+		wantErr bool
+	}{
+		{name: "defaults", mutate: func(c *Config) {}},
+		{name: "negative freeze cap", mutate: func(c *Config) { c.StreakFreezeMax = -1 }, wantErr: true},
+		{name: "negative freeze interval", mutate: func(c *Config) { c.StreakFreezeEarnEvery = -1 }, wantErr: true},
+		{name: "misspelled spam store", mutate: func(c *Config) { c.StreakSpamStore = "postgress" }, wantErr: true},
+		{name: "spam similarity above one", mutate: func(c *Config) { c.StreakSpamSimilarity = 1.5 }, wantErr: true},
+		{name: "negative spam limit", mutate: func(c *Config) { c.StreakSpamMaxPerMinute = -1 }, wantErr: true},
+		{name: "spam flood without window", mutate: func(c *Config) { c.StreakSpamFloodLimit, c.StreakSpamFloodWindow = 5, 0 }, wantErr: true},
+		{name: "transfer fee takes whole amount", mutate: func(c *Config) { c.EconomyTransferFeePercent = 100 }, wantErr: true},
+		{name: "zero request ttl", mutate: func(c *Config) { c.EconomyRequestTTL = 0 }, wantErr: true},
+		{name: "negative treasury", mutate: func(c *Config) { c.EconomyTreasuryUserID = -1 }, wantErr: true},
+		{name: "negative transfer cap", mutate: func(c *Config) { c.EconomyTransferDailyCap = -1 }, wantErr: true},
+		{name: "negative account age", mutate: func(c *Config) { c.EconomyTransferMinAccountAge = -time.Hour }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.wantErr && err == nil {
+				t.Fatal("expected validation error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/casino"
+)
+
+const (
+	cbAdminCasinoMenu       = "admin:casino"
+	cbCasinoRTP             = "admin:casino:rtp"
+	cbCasinoExclusions      = "admin:casino:excl"
+	cbCasinoExclusionPick   = "admin:casino:excl:"
+	cbCasinoExclusionLiftOK = "admin:casino:excl_ok:"
+	cbCasinoGame            = "admin:casino:game"
+
+	casinoExclusionListLimit = 20
+)
+
+type casinoService interface {
+	ListRTPTiers(ctx context.Context) ([]*casino.Stats, error)
+	ListActiveExclusions(ctx context.Context) ([]*casino.Exclusion, error)
+	LiftExclusion(ctx context.Context, exclusionID, actorID int64) (*casino.Exclusion, error)
+	ReplayGame(ctx context.Context, gameID int64) (*casino.GameReplay, error)
+}
+
+func (h *Handler) SetCasinoService(casinoSvc casinoService) {
+	h.casinoService = casinoSvc
+}
+
+func (h *Handler) handleCasinoCallback(ctx context.Context, chatID, userID int64, panelMsgID int, data string) {
+	if !h.service.CanManageCasino(ctx, userID) {
+		h.denyInsufficientPermissions(ctx, chatID)
+		return
+	}
+	h.service.ClearState(userID)
+	switch {
+	case data == cbAdminCasinoMenu:
+		h.showCasinoMenu(ctx, chatID, userID, panelMsgID)
+	case data == cbCasinoRTP:
+		h.showCasinoRTPTiers(ctx, chatID, userID, panelMsgID)
+	case data == cbCasinoGame:
+		h.startCasinoGameInspect(ctx, chatID, userID, panelMsgID)
+	case data == cbCasinoExclusions:
+		h.showCasinoExclusions(ctx, chatID, userID, panelMsgID)
+	case strings.HasPrefix(data, cbCasinoExclusionLiftOK):
+		exclusionID, err := strconv.ParseInt(strings.TrimPrefix(data, cbCasinoExclusionLiftOK), 10, 64)
+		if err != nil {
+			h.showCasinoExclusions(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		h.handleCasinoExclusionLift(ctx, chatID, userID, panelMsgID, exclusionID)
+	case strings.HasPrefix(data, cbCasinoExclusionPick):
+		exclusionID, err := strconv.ParseInt(strings.TrimPrefix(data, cbCasinoExclusionPick), 10, 64)
+		if err != nil {
+			h.showCasinoExclusions(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		h.renderCasinoExclusionLiftConfirm(ctx, chatID, userID, panelMsgID, exclusionID)
+	}
+}
+
+func (h *Handler) handleCasinoMessageInput(ctx context.Context, chatID, userID int64, messageID int, text string) bool {
+	state := h.service.GetState(userID)
+	if state == nil || state.State != StateCasinoGameID {
+		return false
+	}
+	h.handleCasinoGameIDStep(ctx, chatID, userID, text)
+	h.deleteAdminInputMessage(ctx, chatID, messageID)
+	return true
+}
+
+func (h *Handler) showCasinoMenu(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "casino_menu", "Казино", newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonData("📊 RTP игроков", cbCasinoRTP)),
+		newInlineKeyboardRow(newInlineKeyboardButtonData("🔍 Игра по номеру", cbCasinoGame)),
+		newInlineKeyboardRow(newInlineKeyboardButtonData("🚫 Самоисключения", cbCasinoExclusions)),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminReturnPanel, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+// showCasinoRTPTiers показывает текущую ступень коррекции RTP каждого игрока.
+func (h *Handler) showCasinoRTPTiers(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if h.casinoService == nil {
+		h.sendMessage(ctx, chatID, "Казино сейчас недоступно.")
+		return
+	}
+	stats, err := h.casinoService.ListRTPTiers(ctx)
+	if err != nil {
+		log.WithError(err).Warn("list casino rtp tiers failed")
+		h.sendMessage(ctx, chatID, "Не удалось загрузить RTP игроков.")
+		return
+	}
+
+	text := "Игроки ещё не крутили слоты."
+	if len(stats) > 0 {
+		lines := []string{"RTP игроков (сначала с активной коррекцией):", ""}
+		for _, s := range stats {
+			lines = append(lines, fmt.Sprintf("%s — %.2f%%, спинов: %d, %s", h.creditMemberLabel(ctx, s.UserID), s.CurrentRTP, s.TotalSpins, s.RTPTier.Label()))
+		}
+		text = strings.Join(lines, "\n")
+	}
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "casino_rtp", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonData("🔄 Обновить", cbCasinoRTP)),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminCasinoMenu, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+// showCasinoExclusions показывает действующие самоисключения игроков.
+func (h *Handler) showCasinoExclusions(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if h.casinoService == nil {
+		h.sendMessage(ctx, chatID, "Казино сейчас недоступно.")
+		return
+	}
+	exclusions, err := h.casinoService.ListActiveExclusions(ctx)
+	if err != nil {
+		log.WithError(err).Warn("list casino exclusions failed")
+		h.sendMessage(ctx, chatID, "Не удалось загрузить самоисключения.")
+		return
+	}
+
+	text := "Выберите самоисключение, чтобы снять его досрочно."
+	if len(exclusions) == 0 {
+		text = "Действующих самоисключений нет."
+	}
+	rows := make([][]models.InlineKeyboardButton, 0, len(exclusions)+1)
+	for i, e := range exclusions {
+		if i >= casinoExclusionListLimit {
+			break
+		}
+		label := fmt.Sprintf("%s — до %s", h.creditMemberLabel(ctx, e.UserID), common.FormatDateTime(e.EndsAt))
+		rows = append(rows, newInlineKeyboardRow(newInlineKeyboardButtonData(shortenForButton(label, 48), fmt.Sprintf("%s%d", cbCasinoExclusionPick, e.ID))))
+	}
+	rows = append(rows, newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminCasinoMenu, "danger")))
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "casino_exclusions", text, newInlineKeyboardMarkup(rows...)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) renderCasinoExclusionLiftConfirm(ctx context.Context, chatID, userID int64, panelMsgID int, exclusionID int64) {
+	text := fmt.Sprintf("Снять самоисключение #%d досрочно? Игрок снова сможет делать ставки, действие попадёт в аудит.", exclusionID)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "casino_exclusion_confirm", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Снять", fmt.Sprintf("%s%d", cbCasinoExclusionLiftOK, exclusionID), "danger")),
+		newInlineKeyboardRow(newInlineKeyboardButtonData("Назад", cbCasinoExclusions)),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) handleCasinoExclusionLift(ctx context.Context, chatID, userID int64, panelMsgID int, exclusionID int64) {
+	if h.casinoService == nil {
+		h.sendMessage(ctx, chatID, "Казино сейчас недоступно.")
+		return
+	}
+	lifted, err := h.casinoService.LiftExclusion(ctx, exclusionID, userID)
+	if err != nil {
+		if errors.Is(err, casino.ErrExclusionNotFound) {
+			h.sendMessage(ctx, chatID, "Самоисключение уже закончилось или снято.")
+			h.showCasinoExclusions(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		log.WithError(err).WithField("exclusion_id", exclusionID).Warn("casino exclusion lift failed")
+		h.sendMessage(ctx, chatID, "Не удалось снять самоисключение.")
+		return
+	}
+	text := fmt.Sprintf("Самоисключение %s снято.", h.creditMemberLabel(ctx, lifted.UserID))
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "casino_exclusion_lifted", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbCasinoExclusions, "success")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) startCasinoGameInspect(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if h.casinoService == nil {
+		h.sendMessage(ctx, chatID, "Казино сейчас недоступно.")
+		return
+	}
+	h.service.SetState(userID, StateCasinoGameID, nil)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "casino_game_prompt", "Отправьте номер игры слотов.", newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminCasinoMenu, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+// handleCasinoGameIDStep показывает восстановленный спин для разбора спора о выплате.
+func (h *Handler) handleCasinoGameIDStep(ctx context.Context, chatID, userID int64, text string) {
+	gameID, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(text), "#"), 10, 64)
+	if err != nil || gameID <= 0 {
+		h.sendMessage(ctx, chatID, "Номер игры должен быть положительным числом.")
+		return
+	}
+	replay, err := h.casinoService.ReplayGame(ctx, gameID)
+	if err != nil {
+		switch {
+		case errors.Is(err, casino.ErrGameNotFound):
+			h.sendMessage(ctx, chatID, fmt.Sprintf("Игра #%d не найдена.", gameID))
+		case errors.Is(err, casino.ErrNotSlotGame):
+			h.sendMessage(ctx, chatID, fmt.Sprintf("Игра #%d сыграна не в слотах.", gameID))
+		default:
+			log.WithError(err).WithField("game_id", gameID).Warn("casino game replay failed")
+			h.sendMessage(ctx, chatID, "Не удалось загрузить игру.")
+		}
+		return
+	}
+	panelMsgID := h.panelMessageIDFromState(userID)
+	h.service.ClearState(userID)
+	text = fmt.Sprintf("Игрок: %s\n\n%s", h.creditMemberLabel(ctx, replay.Game.UserID), casino.FormatGameReplay(replay))
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "casino_game", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonData("🔍 Другая игра", cbCasinoGame)),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminCasinoMenu, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/features/casino"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+)
+
+type fakeCasinoService struct {
+	tiers      []*casino.Stats
+	exclusions []*casino.Exclusion
+	liftedBy   map[int64]int64
+	replays    map[int64]*casino.GameReplay
+}
+
+func (f *fakeCasinoService) ReplayGame(ctx context.Context, gameID int64) (*casino.GameReplay, error) {
+	if r, ok := f.replays[gameID]; ok {
+		return r, nil
+	}
+	return nil, casino.ErrGameNotFound
+}
+
+func (f *fakeCasinoService) ListRTPTiers(ctx context.Context) ([]*casino.Stats, error) {
+	return f.tiers, nil
+}
+
+func (f *fakeCasinoService) ListActiveExclusions(ctx context.Context) ([]*casino.Exclusion, error) {
+	return f.exclusions, nil
+}
+
+func (f *fakeCasinoService) LiftExclusion(ctx context.Context, exclusionID, actorID int64) (*casino.Exclusion, error) {
+	for i, e := range f.exclusions {
+		if e.ID == exclusionID {
+			if f.liftedBy == nil {
+				f.liftedBy = make(map[int64]int64)
+			}
+			f.liftedBy[exclusionID] = actorID
+			f.exclusions = append(f.exclusions[:i], f.exclusions[i+1:]...)
+			return e, nil
+		}
+	}
+	return nil, casino.ErrExclusionNotFound
+}
+
+func TestAdminCasinoRTPView_ShowsPlayerTiers(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{
+		77:  {UserID: 77, IsAdmin: true},
+		501: {UserID: 501, Username: "lucky"},
+	}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	h.SetCasinoService(&fakeCasinoService{tiers: []*casino.Stats{
+		{UserID: 501, TotalSpins: 40, CurrentRTP: 130.5, RTPTier: casino.RTPTierTighten},
+	}})
+
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 0, "Панель")
+	if e := tg.last("send"); e == nil || !hasButton(e.markup, "🎰 Казино", cbAdminCasinoMenu) {
+		t.Fatalf("expected casino entry in admin panel")
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCasinoRTP))
+	edit := tg.last("edit")
+	if edit == nil || !strings.Contains(edit.text, "@lucky") || !strings.Contains(edit.text, "130.50%") || !strings.Contains(edit.text, casino.RTPTierTighten.Label()) {
+		t.Fatalf("expected rtp tier list, got %#v", edit)
+	}
+}
+
+func TestModeratorCannotOpenCasinoRTP(t *testing.T) {
+	tg := &fakeTG{}
+	h := newModeratorHandlerForFlow(t, &fakeMemberRepoHandlers{members: map[int64]*members.Member{}}, tg)
+	h.SetCasinoService(&fakeCasinoService{})
+
+	if !h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCasinoRTP)) {
+		t.Fatalf("expected callback handled")
+	}
+	if !hasCallText(tg.calls, "send", "Недостаточно прав") {
+		t.Fatalf("expected permission denial message")
+	}
+}
+
+func TestAdminCasinoExclusions_ListAndLift(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{
+		77:  {UserID: 77, IsAdmin: true},
+		501: {UserID: 501, Username: "tilted"},
+	}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	svc := &fakeCasinoService{exclusions: []*casino.Exclusion{
+		{ID: 9, UserID: 501, EndsAt: time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC)},
+	}}
+	h.SetCasinoService(svc)
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbAdminCasinoMenu))
+	if e := tg.last("edit"); e == nil || !hasButton(e.markup, "🚫 Самоисключения", cbCasinoExclusions) {
+		t.Fatalf("expected exclusions entry in casino menu")
+	}
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCasinoExclusions))
+	if e := tg.last("edit"); e == nil || !hasButton(e.markup, "", cbCasinoExclusionPick+"9") {
+		t.Fatalf("expected exclusion #9 in list, got %#v", e)
+	}
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCasinoExclusionPick+"9"))
+	if e := tg.last("edit"); e == nil || !hasButton(e.markup, "", cbCasinoExclusionLiftOK+"9") {
+		t.Fatalf("expected lift confirmation, got %#v", e)
+	}
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCasinoExclusionLiftOK+"9"))
+	if svc.liftedBy[9] != 77 {
+		t.Fatalf("exclusion lifted by %d, want 77", svc.liftedBy[9])
+	}
+	if e := tg.last("edit"); e == nil || !strings.Contains(e.text, "@tilted") {
+		t.Fatalf("expected lift result, got %#v", e)
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCasinoExclusionLiftOK+"9"))
+	if !hasCallText(tg.calls, "send", "уже закончилось или снято") {
+		t.Fatalf("expected stale lift message")
+	}
+}
+
+func TestAdminCasinoGameInspector(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{
+		77:  {UserID: 77, IsAdmin: true},
+		501: {UserID: 501, Username: "disputer"},
+	}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	grid := casino.Grid{{"🍒", "🍋"}, {"🍒", "🍋"}, {"🍒", "🍋"}}
+	h.SetCasinoService(&fakeCasinoService{replays: map[int64]*casino.GameReplay{
+		12: {
+			Game: &casino.Game{ID: 12, UserID: 501, BetAmount: 50, ResultAmount: 100},
+			Result: &casino.SlotResult{Grid: grid, Bet: 50, TotalPayout: 100, IsWin: true,
+				WinLines: []casino.WinLine{{LineIndex: 0, Symbol: "🍒", Count: 3, Payout: 100}}},
+			Paylines: map[int][]int{0: {0, 0, 0}},
+		},
+	}})
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCasinoGame))
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 601, "13")
+	if !hasCallText(tg.calls, "send", "Игра #13 не найдена") {
+		t.Fatalf("expected missing game message")
+	}
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 602, "#12")
+	edit := tg.last("edit")
+	if edit == nil || !strings.Contains(edit.text, "@disputer") || !strings.Contains(edit.text, "ИГРА #12") || !strings.Contains(edit.text, "Линия 1: 3x 🍒") {
+		t.Fatalf("expected game replay screen, got %#v", edit)
+	}
+}
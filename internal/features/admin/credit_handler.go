@@ -0,0 +1,340 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/debts"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+)
+
+const (
+	cbAdminCreditsMenu      = "admin:credits"
+	cbCreditIssue           = "admin:credits:issue"
+	cbCreditIssueConfirm    = "admin:credits:issue_ok"
+	cbCreditWriteOff        = "admin:credits:writeoff"
+	cbCreditWriteOffPick    = "admin:credits:writeoff:"
+	cbCreditWriteOffConfirm = "admin:credits:writeoff_ok:"
+	cbCreditCancel          = "admin:credits:cancel"
+
+	creditWriteOffListLimit = 20
+)
+
+type creditService interface {
+	Issue(ctx context.Context, req debts.IssueRequest) (*debts.Debt, error)
+	WriteOff(ctx context.Context, debtID int64, actorID int64) (*debts.Debt, error)
+	ListActive(ctx context.Context) ([]*debts.Debt, error)
+}
+
+// CreditDraftData хранит черновик выдачи кредита между шагами диалога.
+type CreditDraftData struct {
+	TargetUserID    int64
+	TargetLabel     string
+	Principal       int64
+	InterestPercent int
+	TermDays        int
+}
+
+func (h *Handler) SetCreditService(credits creditService) {
+	h.creditService = credits
+}
+
+func (h *Handler) handleCreditMessageInput(ctx context.Context, chatID, userID int64, messageID int, text string) bool {
+	state := h.service.GetState(userID)
+	if state == nil {
+		return false
+	}
+	switch state.State {
+	case StateCreditTarget:
+		h.handleCreditTargetStep(ctx, chatID, userID, text)
+	case StateCreditAmount:
+		h.handleCreditAmountStep(ctx, chatID, userID, text)
+	case StateCreditInterest:
+		h.handleCreditInterestStep(ctx, chatID, userID, text)
+	case StateCreditTerm:
+		h.handleCreditTermStep(ctx, chatID, userID, text)
+	default:
+		return false
+	}
+	h.deleteAdminInputMessage(ctx, chatID, messageID)
+	return true
+}
+
+func (h *Handler) handleCreditCallback(ctx context.Context, chatID, userID int64, panelMsgID int, data string) {
+	if !h.service.CanManageCredits(ctx, userID) {
+		h.denyInsufficientPermissions(ctx, chatID)
+		return
+	}
+	switch {
+	case data == cbAdminCreditsMenu, data == cbCreditCancel:
+		h.service.ClearState(userID)
+		h.showCreditsMenu(ctx, chatID, userID, panelMsgID)
+	case data == cbCreditIssue:
+		h.startCreditIssue(ctx, chatID, userID, panelMsgID)
+	case data == cbCreditIssueConfirm:
+		h.handleCreditIssueConfirm(ctx, chatID, userID, panelMsgID)
+	case data == cbCreditWriteOff:
+		h.showCreditWriteOffList(ctx, chatID, userID, panelMsgID)
+	case strings.HasPrefix(data, cbCreditWriteOffConfirm):
+		debtID, err := strconv.ParseInt(strings.TrimPrefix(data, cbCreditWriteOffConfirm), 10, 64)
+		if err != nil {
+			h.showCreditWriteOffList(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		h.handleCreditWriteOffConfirm(ctx, chatID, userID, panelMsgID, debtID)
+	case strings.HasPrefix(data, cbCreditWriteOffPick):
+		debtID, err := strconv.ParseInt(strings.TrimPrefix(data, cbCreditWriteOffPick), 10, 64)
+		if err != nil {
+			h.showCreditWriteOffList(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		h.renderCreditWriteOffConfirm(ctx, chatID, userID, panelMsgID, debtID)
+	}
+}
+
+func (h *Handler) showCreditsMenu(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "credits_menu", "Кредиты", newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonData("💳 Выдать кредит", cbCreditIssue)),
+		newInlineKeyboardRow(newInlineKeyboardButtonData("🚫 Аннулировать кредит", cbCreditWriteOff)),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminReturnPanel, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) startCreditIssue(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if h.creditService == nil {
+		h.sendMessage(ctx, chatID, "Функция кредитов сейчас недоступна.")
+		return
+	}
+	h.service.SetState(userID, StateCreditTarget, &CreditDraftData{})
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "credit_target", "Кому выдать кредит? Отправьте @username или ID участника.", creditCancelMarkup()); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) handleCreditTargetStep(ctx context.Context, chatID, userID int64, text string) {
+	member, err := h.resolveCreditTarget(ctx, text)
+	if err != nil || member == nil {
+		h.sendMessage(ctx, chatID, "Участник не найден. Отправьте @username или ID.")
+		return
+	}
+	if member.IsBot {
+		h.sendMessage(ctx, chatID, "Нельзя выдать кредит боту.")
+		return
+	}
+	draft := h.creditDraftFromState(userID)
+	draft.TargetUserID = member.UserID
+	draft.TargetLabel = formatMemberIdentityCompact(member)
+	h.service.SetState(userID, StateCreditAmount, draft)
+	h.renderCreditPrompt(ctx, chatID, userID, fmt.Sprintf("Получатель: %s\n\nУкажите сумму кредита в плёнках: положительное целое число.", draft.TargetLabel))
+}
+
+func (h *Handler) handleCreditAmountStep(ctx context.Context, chatID, userID int64, text string) {
+	value, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil || value <= 0 {
+		h.sendMessage(ctx, chatID, "Сумма должна быть положительным целым числом.")
+		return
+	}
+	draft := h.creditDraftFromState(userID)
+	draft.Principal = value
+	h.service.SetState(userID, StateCreditInterest, draft)
+	h.renderCreditPrompt(ctx, chatID, userID, fmt.Sprintf("Укажите процент за весь срок: от 0 до %d.", debts.MaxInterestPercent))
+}
+
+func (h *Handler) handleCreditInterestStep(ctx context.Context, chatID, userID int64, text string) {
+	value, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(text), "%"))
+	if err != nil || value < 0 || value > debts.MaxInterestPercent {
+		h.sendMessage(ctx, chatID, fmt.Sprintf("Процент должен быть целым числом от 0 до %d.", debts.MaxInterestPercent))
+		return
+	}
+	draft := h.creditDraftFromState(userID)
+	draft.InterestPercent = value
+	h.service.SetState(userID, StateCreditTerm, draft)
+	h.renderCreditPrompt(ctx, chatID, userID, fmt.Sprintf("Укажите срок кредита в днях: от 1 до %d.", debts.MaxTermDays))
+}
+
+func (h *Handler) handleCreditTermStep(ctx context.Context, chatID, userID int64, text string) {
+	value, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || value <= 0 || value > debts.MaxTermDays {
+		h.sendMessage(ctx, chatID, fmt.Sprintf("Срок должен быть целым числом дней от 1 до %d.", debts.MaxTermDays))
+		return
+	}
+	draft := h.creditDraftFromState(userID)
+	draft.TermDays = value
+	h.service.SetState(userID, StateCreditConfirm, draft)
+
+	text = fmt.Sprintf(
+		"Подтверждение кредита\n\nПолучатель: %s\nСумма: %s\nПроцент: %d%%\nК возврату: %s\nСрок: %d %s",
+		draft.TargetLabel,
+		common.FormatBalance(draft.Principal),
+		draft.InterestPercent,
+		common.FormatBalance(debts.CalculateAmountDue(draft.Principal, draft.InterestPercent)),
+		draft.TermDays,
+		common.PluralizeDays(draft.TermDays),
+	)
+	if err := h.renderAdminScreen(ctx, chatID, userID, h.panelMessageIDFromState(userID), "credit_confirm", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Выдать", cbCreditIssueConfirm, "success")),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Отмена", cbCreditCancel, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) handleCreditIssueConfirm(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if h.creditService == nil {
+		h.sendMessage(ctx, chatID, "Функция кредитов сейчас недоступна.")
+		return
+	}
+	state := h.service.GetState(userID)
+	if state == nil || state.State != StateCreditConfirm {
+		h.showCreditsMenu(ctx, chatID, userID, panelMsgID)
+		return
+	}
+	draft, _ := state.Data.(*CreditDraftData)
+	if draft == nil || draft.TargetUserID == 0 || draft.Principal <= 0 || draft.TermDays <= 0 {
+		h.sendMessage(ctx, chatID, "Черновик кредита поврежден. Начните заново.")
+		h.service.ClearState(userID)
+		h.showCreditsMenu(ctx, chatID, userID, panelMsgID)
+		return
+	}
+
+	debt, err := h.creditService.Issue(ctx, debts.IssueRequest{
+		UserID:          draft.TargetUserID,
+		Principal:       draft.Principal,
+		InterestPercent: draft.InterestPercent,
+		TermDays:        draft.TermDays,
+		IssuedBy:        userID,
+	})
+	if err != nil {
+		if errors.Is(err, debts.ErrDebtAlreadyActive) {
+			h.sendMessage(ctx, chatID, "У участника уже есть активный кредит.")
+			return
+		}
+		log.WithError(err).WithField("target_user_id", draft.TargetUserID).Warn("credit issue failed")
+		h.sendMessage(ctx, chatID, "Не удалось выдать кредит.")
+		return
+	}
+
+	h.service.ClearState(userID)
+	text := fmt.Sprintf("Кредит #%d выдан: %s → %s. Вернуть до %s.", debt.ID, common.FormatBalance(debt.Principal), draft.TargetLabel, common.FormatDateTime(debt.DueAt))
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "credit_issued", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminCreditsMenu, "success")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) showCreditWriteOffList(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	if h.creditService == nil {
+		h.sendMessage(ctx, chatID, "Функция кредитов сейчас недоступна.")
+		return
+	}
+	h.service.ClearState(userID)
+	active, err := h.creditService.ListActive(ctx)
+	if err != nil {
+		log.WithError(err).Warn("list active credits failed")
+		h.sendMessage(ctx, chatID, "Не удалось загрузить кредиты.")
+		return
+	}
+
+	text := "Выберите кредит для аннулирования."
+	if len(active) == 0 {
+		text = "Активных кредитов нет."
+	}
+	rows := make([][]models.InlineKeyboardButton, 0, len(active)+1)
+	for i, debt := range active {
+		if i >= creditWriteOffListLimit {
+			break
+		}
+		label := fmt.Sprintf("#%d %s — %s", debt.ID, h.creditMemberLabel(ctx, debt.UserID), common.FormatBalance(debt.Remaining()))
+		rows = append(rows, newInlineKeyboardRow(newInlineKeyboardButtonData(shortenForButton(label, 48), fmt.Sprintf("%s%d", cbCreditWriteOffPick, debt.ID))))
+	}
+	rows = append(rows, newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminCreditsMenu, "danger")))
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "credit_writeoff_list", text, newInlineKeyboardMarkup(rows...)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) renderCreditWriteOffConfirm(ctx context.Context, chatID, userID int64, panelMsgID int, debtID int64) {
+	text := fmt.Sprintf("Аннулировать кредит #%d? Остаток долга будет списан без взыскания.", debtID)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "credit_writeoff_confirm", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Аннулировать", fmt.Sprintf("%s%d", cbCreditWriteOffConfirm, debtID), "danger")),
+		newInlineKeyboardRow(newInlineKeyboardButtonData("Назад", cbCreditWriteOff)),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) handleCreditWriteOffConfirm(ctx context.Context, chatID, userID int64, panelMsgID int, debtID int64) {
+	if h.creditService == nil {
+		h.sendMessage(ctx, chatID, "Функция кредитов сейчас недоступна.")
+		return
+	}
+	debt, err := h.creditService.WriteOff(ctx, debtID, userID)
+	if err != nil {
+		if errors.Is(err, debts.ErrDebtNotFound) {
+			h.sendMessage(ctx, chatID, "Кредит уже закрыт или не найден.")
+			h.showCreditWriteOffList(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		log.WithError(err).WithField("debt_id", debtID).Warn("credit write-off failed")
+		h.sendMessage(ctx, chatID, "Не удалось аннулировать кредит.")
+		return
+	}
+	text := fmt.Sprintf("Кредит #%d (%s) аннулирован.", debt.ID, h.creditMemberLabel(ctx, debt.UserID))
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "credit_written_off", text, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminCreditsMenu, "success")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) renderCreditPrompt(ctx context.Context, chatID, userID int64, text string) {
+	if err := h.renderAdminScreen(ctx, chatID, userID, h.panelMessageIDFromState(userID), "credit_prompt", text, creditCancelMarkup()); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) resolveCreditTarget(ctx context.Context, raw string) (*members.Member, error) {
+	raw = strings.TrimSpace(raw)
+	if id, err := strconv.ParseInt(strings.TrimPrefix(raw, "id:"), 10, 64); err == nil && id > 0 {
+		return h.service.memberRepo.GetByUserID(ctx, id)
+	}
+	username := strings.TrimPrefix(raw, "@")
+	if username == "" || h.memberService == nil {
+		return nil, fmt.Errorf("credit target %q not resolved", raw)
+	}
+	return h.memberService.GetByUsername(ctx, username)
+}
+
+func (h *Handler) creditMemberLabel(ctx context.Context, userID int64) string {
+	member, err := h.service.memberRepo.GetByUserID(ctx, userID)
+	if err != nil || member == nil {
+		return fmt.Sprintf("id:%d", userID)
+	}
+	return formatMemberIdentityCompact(member)
+}
+
+func (h *Handler) creditDraftFromState(userID int64) *CreditDraftData {
+	state := h.service.GetState(userID)
+	if state != nil {
+		if data, ok := state.Data.(*CreditDraftData); ok && data != nil {
+			return data
+		}
+	}
+	return &CreditDraftData{}
+}
+
+func creditCancelMarkup() models.InlineKeyboardMarkup {
+	return newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Отмена", cbCreditCancel, "danger")),
+	)
+}
@@ -0,0 +1,153 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/features/debts"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+)
+
+type fakeCreditService struct {
+	issued    []debts.IssueRequest
+	writeOffs []int64
+	active    []*debts.Debt
+	issueErr  error
+}
+
+func (f *fakeCreditService) Issue(ctx context.Context, req debts.IssueRequest) (*debts.Debt, error) {
+	if f.issueErr != nil {
+		return nil, f.issueErr
+	}
+	f.issued = append(f.issued, req)
+	return &debts.Debt{ID: int64(len(f.issued)), UserID: req.UserID, Principal: req.Principal, Status: debts.StatusActive, DueAt: time.Now().Add(24 * time.Hour)}, nil
+}
+
+func (f *fakeCreditService) WriteOff(ctx context.Context, debtID int64, actorID int64) (*debts.Debt, error) {
+	for _, d := range f.active {
+		if d.ID == debtID {
+			f.writeOffs = append(f.writeOffs, debtID)
+			cp := *d
+			cp.Status = debts.StatusWrittenOff
+			return &cp, nil
+		}
+	}
+	return nil, debts.ErrDebtNotFound
+}
+
+func (f *fakeCreditService) ListActive(ctx context.Context) ([]*debts.Debt, error) {
+	return f.active, nil
+}
+
+func TestAdminPanel_ExposesCreditMenu(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{77: {UserID: 77, IsAdmin: true}}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 0, "Панель")
+	e := tg.last("send")
+	if e == nil {
+		t.Fatalf("expected panel")
+	}
+	if !hasButton(e.markup, "💳 Кредиты", cbAdminCreditsMenu) {
+		t.Fatalf("expected credits entry in admin panel")
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbAdminCreditsMenu))
+	edit := tg.last("edit")
+	if edit == nil || !hasButton(edit.markup, "💳 Выдать кредит", cbCreditIssue) || !hasButton(edit.markup, "🚫 Аннулировать кредит", cbCreditWriteOff) {
+		t.Fatalf("expected credit actions, got %#v", edit)
+	}
+}
+
+func TestModeratorCannotOpenCredits(t *testing.T) {
+	tg := &fakeTG{}
+	h := newModeratorHandlerForFlow(t, &fakeMemberRepoHandlers{members: map[int64]*members.Member{}}, tg)
+	h.SetCreditService(&fakeCreditService{})
+
+	for _, data := range []string{cbAdminCreditsMenu, cbCreditIssue, cbCreditWriteOff, cbCreditWriteOffConfirm + "1"} {
+		if !h.HandleAdminCallback(context.Background(), callback(77, 42, 77, data)) {
+			t.Fatalf("expected callback %q handled", data)
+		}
+	}
+	if !hasCallText(tg.calls, "send", "Недостаточно прав") {
+		t.Fatalf("expected permission denial message")
+	}
+	if st := h.service.GetState(77); st != nil {
+		t.Fatalf("forbidden callbacks must not enter credit flow, got %+v", st)
+	}
+}
+
+func TestCreditIssueWizard(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{
+		77:  {UserID: 77, IsAdmin: true},
+		501: {UserID: 501, Username: "debtor"},
+	}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	credits := &fakeCreditService{}
+	h.SetCreditService(credits)
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCreditIssue))
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 601, "999")
+	if last := tg.last("send"); last == nil || !strings.Contains(last.text, "Участник не найден") {
+		t.Fatalf("expected unknown target error, got %#v", last)
+	}
+
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 602, "501")
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 603, "-5")
+	if last := tg.last("send"); last == nil || !strings.Contains(last.text, "Сумма должна быть положительным") {
+		t.Fatalf("expected amount validation, got %#v", last)
+	}
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 604, "300")
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 605, "10%")
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 606, "7")
+
+	edit := tg.last("edit")
+	if edit == nil || !strings.Contains(edit.text, "@debtor") || !strings.Contains(edit.text, "К возврату: 330") || !hasButton(edit.markup, "Выдать", cbCreditIssueConfirm) {
+		t.Fatalf("expected credit confirm screen, got %#v", edit)
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCreditIssueConfirm))
+	if len(credits.issued) != 1 {
+		t.Fatalf("expected single issue call, got %d", len(credits.issued))
+	}
+	req := credits.issued[0]
+	if req.UserID != 501 || req.Principal != 300 || req.InterestPercent != 10 || req.TermDays != 7 || req.IssuedBy != 77 {
+		t.Fatalf("unexpected issue request %+v", req)
+	}
+	if st := h.service.GetState(77); st != nil {
+		t.Fatalf("expected cleared state after issue, got %+v", st)
+	}
+}
+
+func TestCreditWriteOffFromList(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{
+		77:  {UserID: 77, IsAdmin: true},
+		501: {UserID: 501, Username: "debtor"},
+	}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	credits := &fakeCreditService{active: []*debts.Debt{{ID: 9, UserID: 501, AmountDue: 120, Status: debts.StatusActive}}}
+	h.SetCreditService(credits)
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCreditWriteOff))
+	edit := tg.last("edit")
+	if edit == nil || !hasButton(edit.markup, "", cbCreditWriteOffPick+"9") {
+		t.Fatalf("expected active credit in list, got %#v", edit)
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCreditWriteOffPick+"9"))
+	if len(credits.writeOffs) != 0 {
+		t.Fatalf("write-off must wait for confirmation")
+	}
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbCreditWriteOffConfirm+"9"))
+	if len(credits.writeOffs) != 1 || credits.writeOffs[0] != 9 {
+		t.Fatalf("expected write-off of #9, got %v", credits.writeOffs)
+	}
+	if edit := tg.last("edit"); edit == nil || !strings.Contains(edit.text, "аннулирован") {
+		t.Fatalf("expected write-off result screen, got %#v", edit)
+	}
+}
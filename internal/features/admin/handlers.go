@@ -67,6 +67,12 @@ type Handler struct {
 	memberService      *members.Service
 	economyService     economyService
 	riddleService      *RiddleService
+	creditService      creditService
+	casinoService      casinoService
+	shopService        shopService
+	ledgerService      ledgerService
+	payoutService      payoutService
+	streakReport       streakReportService
 	ops                *telegram.Ops
 	audit              *audit.Logger
 	memberSourceChatID int64
@@ -205,6 +211,18 @@ func (h *Handler) HandleAdminMessage(ctx context.Context, chatID int64, userID i
 		if h.service.CanManageRiddles(ctx, userID) && h.handleRiddleMessageInput(ctx, chatID, userID, messageID, text) {
 			return true
 		}
+		if h.service.CanManageCredits(ctx, userID) && h.handleCreditMessageInput(ctx, chatID, userID, messageID, text) {
+			return true
+		}
+		if h.service.CanManageCasino(ctx, userID) && h.handleCasinoMessageInput(ctx, chatID, userID, messageID, text) {
+			return true
+		}
+		if h.service.CanManageBalance(ctx, userID) && h.handlePayoutMessageInput(ctx, chatID, userID, messageID, text) {
+			return true
+		}
+		if h.service.CanManageShop(ctx, userID) && h.handleShopMessageInput(ctx, chatID, userID, messageID, text) {
+			return true
+		}
 	}
 
 	// Обрабатываем кнопки клавиатуры
@@ -410,6 +428,30 @@ func (h *Handler) HandleAdminCallback(ctx context.Context, q *models.CallbackQue
 		h.handleBalanceAdjustCallback(ctx, chatID, userID, panelMsgID, data)
 		return true
 	}
+	if data == cbAdminCreditsMenu || strings.HasPrefix(data, cbAdminCreditsMenu+":") {
+		h.handleCreditCallback(ctx, chatID, userID, panelMsgID, data)
+		return true
+	}
+	if data == cbAdminCasinoMenu || strings.HasPrefix(data, cbAdminCasinoMenu+":") {
+		h.handleCasinoCallback(ctx, chatID, userID, panelMsgID, data)
+		return true
+	}
+	if data == cbAdminShopMenu || strings.HasPrefix(data, cbAdminShopMenu+":") {
+		h.handleShopCallback(ctx, chatID, userID, panelMsgID, data)
+		return true
+	}
+	if data == cbAdminPayoutsMenu || strings.HasPrefix(data, cbAdminPayoutsMenu+":") {
+		h.handlePayoutCallback(ctx, chatID, userID, panelMsgID, data)
+		return true
+	}
+	if data == cbAdminLedgerMenu || strings.HasPrefix(data, cbAdminLedgerMenu+":") {
+		h.handleLedgerCallback(ctx, chatID, userID, panelMsgID, data)
+		return true
+	}
+	if data == cbAdminStreakReport || strings.HasPrefix(data, cbAdminStreakReport+":") {
+		h.handleStreakReportCallback(ctx, chatID, userID, panelMsgID, data)
+		return true
+	}
 	if strings.HasPrefix(data, cbAdminParticipantsPage) {
 		if !h.service.CanManageBalance(ctx, userID) {
 			h.denyInsufficientPermissions(ctx, chatID)
@@ -479,6 +521,24 @@ func (h *Handler) showKeyboard(ctx context.Context, chatID int64, userID int64,
 		newInlineKeyboardRow(
 			newInlineKeyboardButtonData("➕ Дельты", cbAdminDeltasMenu),
 		),
+		newInlineKeyboardRow(
+			newInlineKeyboardButtonData("💳 Кредиты", cbAdminCreditsMenu),
+		),
+		newInlineKeyboardRow(
+			newInlineKeyboardButtonData("🎰 Казино", cbAdminCasinoMenu),
+		),
+		newInlineKeyboardRow(
+			newInlineKeyboardButtonData("🛒 Магазин", cbAdminShopMenu),
+		),
+		newInlineKeyboardRow(
+			newInlineKeyboardButtonData("💼 Выплаты", cbAdminPayoutsMenu),
+		),
+		newInlineKeyboardRow(
+			newInlineKeyboardButtonData("📒 Сверка", cbAdminLedgerMenu),
+		),
+		newInlineKeyboardRow(
+			newInlineKeyboardButtonData("📈 Огонёк", cbAdminStreakReport),
+		),
 	)
 
 	return h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "panel", "✅ Админ-панель открыта", keyboard)
@@ -1881,21 +1881,6 @@ func TestAdminDeltasMenu_OpenAddDeleteAndBack(t *testing.T) {
 	}
 }
 
-func TestAdminPanel_DoesNotExposeCreditMenu(t *testing.T) {
-	tg := &fakeTG{}
-	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{77: {UserID: 77, IsAdmin: true}}}
-	h := newAdminHandlerForFlow(t, repo, tg)
-
-	_ = h.HandleAdminMessage(context.Background(), 77, 77, 0, "Панель")
-	e := tg.last("send")
-	if e == nil {
-		t.Fatalf("expected panel")
-	}
-	if hasButton(e.markup, "💳 Управление кредитами", "admin:credit_menu") || hasButton(e.markup, "💳 Выдать кредит", "admin:credit_issue") || hasButton(e.markup, "🚫 Отменить кредит", "admin:credit_cancel") {
-		t.Fatalf("credit controls must be absent from admin panel")
-	}
-}
-
 func TestBalanceAdjust_NoLongerHasDeltaManagementButtons(t *testing.T) {
 	tg := &fakeTG{}
 	role := "role"
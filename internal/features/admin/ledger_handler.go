@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+)
+
+const (
+	cbAdminLedgerMenu = "admin:ledger"
+	cbLedgerCorrect   = "admin:ledger:correct"
+
+	ledgerMismatchListLimit = 20
+)
+
+type ledgerService interface {
+	FindLedgerMismatches(ctx context.Context) ([]economy.LedgerMismatch, error)
+	CorrectLedger(ctx context.Context) ([]economy.LedgerMismatch, error)
+}
+
+func (h *Handler) SetLedgerService(ledger ledgerService) {
+	h.ledgerService = ledger
+}
+
+func (h *Handler) handleLedgerCallback(ctx context.Context, chatID, userID int64, panelMsgID int, data string) {
+	if !h.service.CanManageBalance(ctx, userID) {
+		h.denyInsufficientPermissions(ctx, chatID)
+		return
+	}
+	h.service.ClearState(userID)
+	if h.ledgerService == nil {
+		h.sendMessage(ctx, chatID, "Сверка журнала сейчас недоступна.")
+		return
+	}
+	if data == cbLedgerCorrect {
+		h.correctLedger(ctx, chatID, userID, panelMsgID)
+		return
+	}
+	h.showLedgerMismatches(ctx, chatID, userID, panelMsgID, "")
+}
+
+// showLedgerMismatches показывает расхождения балансов с журналом транзакций.
+func (h *Handler) showLedgerMismatches(ctx context.Context, chatID, userID int64, panelMsgID int, notice string) {
+	mismatches, err := h.ledgerService.FindLedgerMismatches(ctx)
+	if err != nil {
+		log.WithError(err).Warn("find ledger mismatches failed")
+		h.sendMessage(ctx, chatID, "Не удалось сверить журнал.")
+		return
+	}
+
+	lines := make([]string, 0, len(mismatches)+4)
+	if notice != "" {
+		lines = append(lines, notice, "")
+	}
+	rows := make([][]models.InlineKeyboardButton, 0, 3)
+	if len(mismatches) == 0 {
+		lines = append(lines, "📒 Балансы сходятся с журналом транзакций.")
+	} else {
+		lines = append(lines, fmt.Sprintf("📒 Расхождений с журналом: %d", len(mismatches)))
+		for i, m := range mismatches {
+			if i >= ledgerMismatchListLimit {
+				lines = append(lines, fmt.Sprintf("… и ещё %d", len(mismatches)-ledgerMismatchListLimit))
+				break
+			}
+			lines = append(lines, formatLedgerMismatch(h.ledgerMemberLabel(ctx, m.UserID), m))
+		}
+		lines = append(lines, "", "Корректировка допишет в журнал проводку на разницу; балансы не изменятся.")
+		rows = append(rows, newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("✍️ Записать корректировки", cbLedgerCorrect, "success")))
+	}
+	rows = append(rows,
+		newInlineKeyboardRow(newInlineKeyboardButtonData("🔄 Проверить снова", cbAdminLedgerMenu)),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminReturnPanel, "danger")),
+	)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "ledger", strings.Join(lines, "\n"), newInlineKeyboardMarkup(rows...)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) correctLedger(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	corrected, err := h.ledgerService.CorrectLedger(ctx)
+	if err != nil {
+		log.WithError(err).Warn("correct ledger failed")
+		h.sendMessage(ctx, chatID, "Не удалось записать корректировки.")
+		return
+	}
+	if h.audit != nil && len(corrected) > 0 {
+		h.audit.LogLedgerCorrection(ctx, h.auditActorLabel(ctx, userID), economy.LedgerDrifts(ctx, h.audit, h.service.memberRepo, corrected))
+	}
+	h.showLedgerMismatches(ctx, chatID, userID, panelMsgID, fmt.Sprintf("✅ Записано корректировок: %d", len(corrected)))
+}
+
+func (h *Handler) ledgerMemberLabel(ctx context.Context, userID int64) string {
+	if h.audit != nil {
+		return h.audit.ResolveMemberLabel(ctx, h.service.memberRepo, userID)
+	}
+	return fmt.Sprintf("id:%d", userID)
+}
+
+func formatLedgerMismatch(label string, m economy.LedgerMismatch) string {
+	line := fmt.Sprintf("• %s: баланс %d, журнал %d (%+d)", label, m.Balance, m.LedgerBalance(), m.Drift())
+	if m.Drift() == 0 {
+		line = fmt.Sprintf("• %s: итоги %d/%d, журнал %d/%d", label, m.TotalEarned, m.TotalSpent, m.LedgerEarned, m.LedgerSpent)
+	}
+	return line
+}
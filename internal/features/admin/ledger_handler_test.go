@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+)
+
+type fakeLedgerService struct {
+	mismatches  []economy.LedgerMismatch
+	corrections int
+}
+
+func (f *fakeLedgerService) FindLedgerMismatches(ctx context.Context) ([]economy.LedgerMismatch, error) {
+	return f.mismatches, nil
+}
+
+func (f *fakeLedgerService) CorrectLedger(ctx context.Context) ([]economy.LedgerMismatch, error) {
+	f.corrections++
+	corrected := f.mismatches
+	f.mismatches = nil
+	return corrected, nil
+}
+
+func TestLedgerScreen_ListsMismatchesAndCorrects(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{77: {UserID: 77, IsAdmin: true}}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	ledger := &fakeLedgerService{mismatches: []economy.LedgerMismatch{
+		{UserID: 501, Balance: 120, TotalEarned: 120, LedgerEarned: 100},
+	}}
+	h.SetLedgerService(ledger)
+
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 0, "Панель")
+	if e := tg.last("send"); e == nil || !hasButton(e.markup, "📒 Сверка", cbAdminLedgerMenu) {
+		t.Fatalf("expected ledger entry in admin panel")
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbAdminLedgerMenu))
+	edit := tg.last("edit")
+	if edit == nil || !strings.Contains(edit.text, "баланс 120, журнал 100 (+20)") || !hasButton(edit.markup, "✍️ Записать корректировки", cbLedgerCorrect) {
+		t.Fatalf("expected mismatch screen, got %#v", edit)
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbLedgerCorrect))
+	if ledger.corrections != 1 {
+		t.Fatalf("expected single correction run, got %d", ledger.corrections)
+	}
+	edit = tg.last("edit")
+	if edit == nil || !strings.Contains(edit.text, "Записано корректировок: 1") || !strings.Contains(edit.text, "сходятся") {
+		t.Fatalf("expected clean ledger after correction, got %#v", edit)
+	}
+}
+
+func TestModeratorCannotCorrectLedger(t *testing.T) {
+	tg := &fakeTG{}
+	h := newModeratorHandlerForFlow(t, &fakeMemberRepoHandlers{members: map[int64]*members.Member{}}, tg)
+	ledger := &fakeLedgerService{mismatches: []economy.LedgerMismatch{{UserID: 501, Balance: 10}}}
+	h.SetLedgerService(ledger)
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbLedgerCorrect))
+	if ledger.corrections != 0 {
+		t.Fatalf("moderator must not write ledger corrections")
+	}
+	if !hasCallText(tg.calls, "send", "Недостаточно прав") {
+		t.Fatalf("expected permission denial message")
+	}
+}
@@ -126,4 +126,14 @@ const (
 	StateRiddleAnswers        = "admin:riddle_answers"
 	StateRiddleReward         = "admin:riddle_reward"
 	StateRiddleConfirm        = "admin:riddle_confirm"
+	StateCreditTarget         = "admin:credit_target"
+	StateCreditAmount         = "admin:credit_amount"
+	StateCreditInterest       = "admin:credit_interest"
+	StateCreditTerm           = "admin:credit_term"
+	StateCreditConfirm        = "admin:credit_confirm"
+	StateCasinoGameID         = "admin:casino_game_id"
+	StateShopItemNew          = "admin:shop_item_new"
+	StateShopItemPrice        = "admin:shop_item_price"
+	StateShopItemStock        = "admin:shop_item_stock"
+	StatePayoutRuleNew        = "admin:payout_rule_new"
 )
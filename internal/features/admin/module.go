@@ -4,8 +4,13 @@ import (
 	"serotonyl.ru/telegram-bot/internal/audit"
 	"serotonyl.ru/telegram-bot/internal/config"
 	"serotonyl.ru/telegram-bot/internal/feature"
+	"serotonyl.ru/telegram-bot/internal/features/casino"
+	"serotonyl.ru/telegram-bot/internal/features/debts"
 	"serotonyl.ru/telegram-bot/internal/features/economy"
 	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/features/payouts"
+	"serotonyl.ru/telegram-bot/internal/features/shop"
+	"serotonyl.ru/telegram-bot/internal/features/streak"
 	"serotonyl.ru/telegram-bot/internal/jobs"
 	"serotonyl.ru/telegram-bot/internal/telegram"
 )
@@ -16,6 +21,11 @@ type Deps struct {
 	Ops            *telegram.Ops
 	Service        *Service
 	RiddleService  *RiddleService
+	DebtService    *debts.Service
+	CasinoService  *casino.Service
+	ShopService    *shop.Service
+	PayoutService  *payouts.Service
+	StreakService  *streak.Service
 	MemberService  *members.Service
 	EconomyService *economy.Service
 	PurgeMetrics   func() jobs.PurgeMetrics
@@ -43,6 +53,27 @@ func NewModule(deps Deps) (*Module, error) {
 		}
 	}
 	h := NewHandler(deps.Service, deps.MemberService, deps.EconomyService, deps.Ops, memberSourceChatID)
+	if deps.DebtService != nil {
+		h.SetCreditService(deps.DebtService)
+	}
+	if deps.CasinoService != nil {
+		h.SetCasinoService(deps.CasinoService)
+	}
+	if deps.ShopService != nil {
+		h.SetShopService(deps.ShopService)
+	}
+	if deps.EconomyService != nil {
+		h.SetLedgerService(deps.EconomyService)
+	}
+	if deps.PayoutService != nil {
+		h.SetPayoutService(deps.PayoutService)
+		if deps.Cfg != nil {
+			deps.PayoutService.SetAuditLogger(audit.NewLogger(deps.Ops, deps.Cfg.AdminChatID))
+		}
+	}
+	if deps.StreakService != nil {
+		h.SetStreakReportService(deps.StreakService)
+	}
 	if deps.Cfg != nil {
 		h.SetAuditLogger(audit.NewLogger(deps.Ops, deps.Cfg.AdminChatID))
 	}
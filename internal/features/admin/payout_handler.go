@@ -0,0 +1,211 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/payouts"
+)
+
+const (
+	cbAdminPayoutsMenu = "admin:payouts"
+	cbPayoutNew        = "admin:payouts:new"
+	cbPayoutPick       = "admin:payouts:rule:"
+	cbPayoutToggle     = "admin:payouts:toggle:"
+
+	payoutRuleListLimit = 30
+	payoutRuleFormat    = "получатели | сумма | расписание | название"
+)
+
+type payoutService interface {
+	ListRules(ctx context.Context) ([]*payouts.Rule, error)
+	GetRule(ctx context.Context, ruleID int64) (*payouts.Rule, error)
+	CreateRule(ctx context.Context, rule *payouts.Rule, actorID int64) (*payouts.Rule, error)
+	SetActive(ctx context.Context, ruleID int64, active bool) (*payouts.Rule, error)
+	NextRun(rule *payouts.Rule, now time.Time) (time.Time, bool)
+}
+
+func (h *Handler) SetPayoutService(payoutSvc payoutService) {
+	h.payoutService = payoutSvc
+}
+
+func (h *Handler) handlePayoutCallback(ctx context.Context, chatID, userID int64, panelMsgID int, data string) {
+	if !h.service.CanManageBalance(ctx, userID) {
+		h.denyInsufficientPermissions(ctx, chatID)
+		return
+	}
+	h.service.ClearState(userID)
+	if h.payoutService == nil {
+		h.sendMessage(ctx, chatID, "Выплаты сейчас недоступны.")
+		return
+	}
+	if data == cbAdminPayoutsMenu {
+		h.showPayoutRules(ctx, chatID, userID, panelMsgID)
+		return
+	}
+	if data == cbPayoutNew {
+		h.startPayoutRuleInput(ctx, chatID, userID, panelMsgID)
+		return
+	}
+	for _, prefix := range []string{cbPayoutPick, cbPayoutToggle} {
+		if !strings.HasPrefix(data, prefix) {
+			continue
+		}
+		ruleID, err := strconv.ParseInt(strings.TrimPrefix(data, prefix), 10, 64)
+		if err != nil {
+			h.showPayoutRules(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		if prefix == cbPayoutPick {
+			h.showPayoutRule(ctx, chatID, userID, panelMsgID, ruleID)
+		} else {
+			h.togglePayoutRule(ctx, chatID, userID, panelMsgID, ruleID)
+		}
+		return
+	}
+}
+
+func (h *Handler) handlePayoutMessageInput(ctx context.Context, chatID, userID int64, messageID int, text string) bool {
+	state := h.service.GetState(userID)
+	if state == nil || state.State != StatePayoutRuleNew {
+		return false
+	}
+	h.handlePayoutRuleNewStep(ctx, chatID, userID, text)
+	h.deleteAdminInputMessage(ctx, chatID, messageID)
+	return true
+}
+
+// showPayoutRules показывает правила выплат вместе с выключенными.
+func (h *Handler) showPayoutRules(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	rules, err := h.payoutService.ListRules(ctx)
+	if err != nil {
+		log.WithError(err).Warn("list payout rules failed")
+		h.sendMessage(ctx, chatID, "Не удалось загрузить выплаты.")
+		return
+	}
+
+	text := "Регулярные выплаты. Выберите правило, чтобы посмотреть его или выключить."
+	if len(rules) == 0 {
+		text = "Регулярных выплат пока нет."
+	}
+	rows := make([][]models.InlineKeyboardButton, 0, len(rules)+2)
+	for i, rule := range rules {
+		if i >= payoutRuleListLimit {
+			break
+		}
+		rows = append(rows, newInlineKeyboardRow(newInlineKeyboardButtonData(
+			shortenForButton(payoutRuleLabel(rule), 48), fmt.Sprintf("%s%d", cbPayoutPick, rule.ID))))
+	}
+	rows = append(rows,
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("➕ Новая выплата", cbPayoutNew, "success")),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminReturnPanel, "danger")),
+	)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "payout_rules", text, newInlineKeyboardMarkup(rows...)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) showPayoutRule(ctx context.Context, chatID, userID int64, panelMsgID int, ruleID int64) {
+	rule, err := h.payoutService.GetRule(ctx, ruleID)
+	if err != nil {
+		if errors.Is(err, payouts.ErrRuleNotFound) {
+			h.showPayoutRules(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		log.WithError(err).WithField("rule_id", ruleID).Warn("get payout rule failed")
+		h.sendMessage(ctx, chatID, "Не удалось загрузить выплату.")
+		return
+	}
+	h.renderPayoutRule(ctx, chatID, userID, panelMsgID, rule)
+}
+
+func (h *Handler) renderPayoutRule(ctx context.Context, chatID, userID int64, panelMsgID int, rule *payouts.Rule) {
+	status := "включена"
+	toggle := newInlineKeyboardButtonDataStyled("⏸ Выключить", fmt.Sprintf("%s%d", cbPayoutToggle, rule.ID), "danger")
+	if !rule.Active {
+		status = "выключена"
+		toggle = newInlineKeyboardButtonDataStyled("▶️ Включить", fmt.Sprintf("%s%d", cbPayoutToggle, rule.ID), "success")
+	}
+	lines := []string{
+		fmt.Sprintf("Выплата #%d: %s", rule.ID, rule.Title),
+		fmt.Sprintf("Получатели: %s", payouts.TargetLabel(rule)),
+		fmt.Sprintf("Сумма: %s каждому", common.FormatBalance(rule.Amount)),
+		fmt.Sprintf("Расписание: %s", rule.Schedule),
+		fmt.Sprintf("Статус: %s", status),
+	}
+	if rule.LastPeriodAt != nil {
+		lines = append(lines, fmt.Sprintf("Последняя выплата: %s", common.FormatDateTime(*rule.LastPeriodAt)))
+	}
+	if next, ok := h.payoutService.NextRun(rule, time.Now()); ok && rule.Active {
+		lines = append(lines, fmt.Sprintf("Следующая: %s", common.FormatDateTime(next)))
+	}
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "payout_rule", strings.Join(lines, "\n"), newInlineKeyboardMarkup(
+		newInlineKeyboardRow(toggle),
+		newInlineKeyboardRow(newInlineKeyboardButtonData("Назад", cbAdminPayoutsMenu)),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) startPayoutRuleInput(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	h.service.SetState(userID, StatePayoutRuleNew, nil)
+	prompt := fmt.Sprintf("Отправьте выплату одной строкой:\n%s\n\nПолучатели — «все», «роль <название>» или @username / user_id через пробел. Расписание — cron из 5 полей в часовом поясе бота, не чаще раза в час.\n\nПример: роль Модератор | 100 | 0 12 * * 5 | Зарплата модераторам",
+		payoutRuleFormat)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "payout_input", prompt, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminPayoutsMenu, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) handlePayoutRuleNewStep(ctx context.Context, chatID, userID int64, text string) {
+	draft, err := payouts.ParseRule(text)
+	if err == nil {
+		draft, err = h.payoutService.CreateRule(ctx, draft, userID)
+	}
+	if err != nil {
+		if errors.Is(err, payouts.ErrInvalidRule) {
+			h.sendMessage(ctx, chatID, fmt.Sprintf("Выплата не добавлена: %s\nФормат: %s", inputErrorDetail(err), payoutRuleFormat))
+			return
+		}
+		log.WithError(err).Warn("create payout rule failed")
+		h.sendMessage(ctx, chatID, "Не удалось добавить выплату.")
+		return
+	}
+	panelMsgID := h.panelMessageIDFromState(userID)
+	h.service.ClearState(userID)
+	h.renderPayoutRule(ctx, chatID, userID, panelMsgID, draft)
+}
+
+func (h *Handler) togglePayoutRule(ctx context.Context, chatID, userID int64, panelMsgID int, ruleID int64) {
+	rule, err := h.payoutService.GetRule(ctx, ruleID)
+	if err == nil {
+		rule, err = h.payoutService.SetActive(ctx, ruleID, !rule.Active)
+	}
+	if err != nil {
+		if errors.Is(err, payouts.ErrRuleNotFound) {
+			h.showPayoutRules(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		log.WithError(err).WithField("rule_id", ruleID).Warn("toggle payout rule failed")
+		h.sendMessage(ctx, chatID, "Не удалось изменить выплату.")
+		return
+	}
+	h.renderPayoutRule(ctx, chatID, userID, panelMsgID, rule)
+}
+
+func payoutRuleLabel(rule *payouts.Rule) string {
+	label := fmt.Sprintf("💼 %s — %s, %s", rule.Title, common.FormatBalance(rule.Amount), payouts.TargetLabel(rule))
+	if !rule.Active {
+		label = "⏸ " + label
+	}
+	return label
+}
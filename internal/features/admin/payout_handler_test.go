@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/features/payouts"
+)
+
+type fakePayoutService struct {
+	rules []*payouts.Rule
+}
+
+func (f *fakePayoutService) ListRules(ctx context.Context) ([]*payouts.Rule, error) {
+	return f.rules, nil
+}
+
+func (f *fakePayoutService) GetRule(ctx context.Context, ruleID int64) (*payouts.Rule, error) {
+	for _, rule := range f.rules {
+		if rule.ID == ruleID {
+			return rule, nil
+		}
+	}
+	return nil, payouts.ErrRuleNotFound
+}
+
+func (f *fakePayoutService) CreateRule(ctx context.Context, rule *payouts.Rule, actorID int64) (*payouts.Rule, error) {
+	rule.ID = int64(len(f.rules) + 1)
+	rule.CreatedBy = actorID
+	f.rules = append(f.rules, rule)
+	return rule, nil
+}
+
+func (f *fakePayoutService) SetActive(ctx context.Context, ruleID int64, active bool) (*payouts.Rule, error) {
+	rule, err := f.GetRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	rule.Active = active
+	return rule, nil
+}
+
+func (f *fakePayoutService) NextRun(rule *payouts.Rule, now time.Time) (time.Time, bool) {
+	return now.Add(time.Hour), true
+}
+
+func TestPayoutRuleWizard(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{77: {UserID: 77, IsAdmin: true}}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	svc := &fakePayoutService{}
+	h.SetPayoutService(svc)
+
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 0, "Панель")
+	if e := tg.last("send"); e == nil || !hasButton(e.markup, "💼 Выплаты", cbAdminPayoutsMenu) {
+		t.Fatalf("expected payouts entry in admin panel")
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbPayoutNew))
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 601, "все | 0 | 0 12 * * 5 | Пособие")
+	if last := tg.last("send"); last == nil || !strings.Contains(last.text, "сумма должна быть больше нуля") {
+		t.Fatalf("expected validation hint, got %#v", last)
+	}
+
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 602, "роль Модератор | 100 | 0 12 * * 5 | Зарплата")
+	if len(svc.rules) != 1 || svc.rules[0].CreatedBy != 77 || svc.rules[0].TargetRole != "Модератор" {
+		t.Fatalf("expected created rule, got %+v", svc.rules)
+	}
+	edit := tg.last("edit")
+	if edit == nil || !strings.Contains(edit.text, "роль «Модератор»") || !hasButton(edit.markup, "⏸ Выключить", cbPayoutToggle+"1") {
+		t.Fatalf("expected rule screen, got %#v", edit)
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbPayoutToggle+"1"))
+	if svc.rules[0].Active {
+		t.Fatalf("expected rule to be switched off")
+	}
+}
+
+func TestModeratorCannotManagePayouts(t *testing.T) {
+	tg := &fakeTG{}
+	h := newModeratorHandlerForFlow(t, &fakeMemberRepoHandlers{members: map[int64]*members.Member{}}, tg)
+	h.SetPayoutService(&fakePayoutService{})
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbPayoutNew))
+	if st := h.service.GetState(77); st != nil {
+		t.Fatalf("forbidden callbacks must not enter payout flow, got %+v", st)
+	}
+	if !hasCallText(tg.calls, "send", "Недостаточно прав") {
+		t.Fatalf("expected permission denial message")
+	}
+}
@@ -46,6 +46,14 @@ func (p *permissionSet) CanManageCredits(userID int64, member *members.Member) b
 	return p.IsAdmin(userID, member)
 }
 
+func (p *permissionSet) CanManageCasino(userID int64, member *members.Member) bool {
+	return p.IsAdmin(userID, member)
+}
+
+func (p *permissionSet) CanManageShop(userID int64, member *members.Member) bool {
+	return p.IsAdmin(userID, member)
+}
+
 func (p *permissionSet) isEnvAdmin(userID int64) bool {
 	if p == nil || p.cfg == nil {
 		return false
@@ -123,3 +131,11 @@ func (s *Service) CanManageBalance(ctx context.Context, userID int64) bool {
 func (s *Service) CanManageCredits(ctx context.Context, userID int64) bool {
 	return s.permissions.CanManageCredits(userID, s.permissionMember(ctx, userID))
 }
+
+func (s *Service) CanManageCasino(ctx context.Context, userID int64) bool {
+	return s.permissions.CanManageCasino(userID, s.permissionMember(ctx, userID))
+}
+
+func (s *Service) CanManageShop(ctx context.Context, userID int64) bool {
+	return s.permissions.CanManageShop(userID, s.permissionMember(ctx, userID))
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	models "github.com/mymmrac/telego"
 	"serotonyl.ru/telegram-bot/internal/audit"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
 	"serotonyl.ru/telegram-bot/internal/telegram"
 )
 
@@ -23,7 +24,7 @@ var (
 
 type riddleEconomy interface {
 	WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error
-	AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error
+	AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error)
 }
 
 type riddleRepo interface {
@@ -173,7 +174,8 @@ func (s *RiddleService) ProcessGuess(ctx context.Context, message *models.Messag
 			if ans.WinnerUserID == nil {
 				continue
 			}
-			if err := s.economy.AddBalanceTx(ctx, tx, *ans.WinnerUserID, rdl.RewardAmount, "riddle_reward", fmt.Sprintf("Riddle %d reward", rdl.ID)); err != nil {
+			key := economy.OperationKey("riddle", rdl.ID, "answer", ans.ID)
+			if _, err := s.economy.AddBalanceOnceTx(ctx, tx, key, *ans.WinnerUserID, rdl.RewardAmount, "riddle_reward", fmt.Sprintf("Riddle %d reward", rdl.ID)); err != nil {
 				return err
 			}
 		}
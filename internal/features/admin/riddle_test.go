@@ -3,6 +3,7 @@ package admin
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -143,16 +144,23 @@ func (f *fakeRiddleRepo) CleanupExpired(ctx context.Context, now time.Time) (int
 type fakeRiddleEconomy struct {
 	rewards []int64
 	awardTo []int64
+	keys    []string
 }
 
 func (f *fakeRiddleEconomy) WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
 	return fn(ctx, nil)
 }
 
-func (f *fakeRiddleEconomy) AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+func (f *fakeRiddleEconomy) AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	for _, key := range f.keys {
+		if key == operationKey {
+			return false, nil
+		}
+	}
+	f.keys = append(f.keys, operationKey)
 	f.awardTo = append(f.awardTo, userID)
 	f.rewards = append(f.rewards, amount)
-	return nil
+	return true, nil
 }
 
 func TestRiddleWizardValidationAndConfirm(t *testing.T) {
@@ -213,6 +221,9 @@ func TestRiddleProcessGuessNormalizesOnceAndClaimsDirectly(t *testing.T) {
 	if len(econ.awardTo) != 1 || econ.awardTo[0] != 1 || econ.rewards[0] != 10 {
 		t.Fatalf("unexpected rewards: %+v %+v", econ.awardTo, econ.rewards)
 	}
+	if want := fmt.Sprintf("riddle:%d:answer:%d", res.Riddle.ID, res.Answers[0].ID); len(econ.keys) != 1 || econ.keys[0] != want {
+		t.Fatalf("expected reward keyed by riddle answer %q, got %v", want, econ.keys)
+	}
 }
 
 func TestRiddleMultiAnswerAndRepeatedSameAnswer(t *testing.T) {
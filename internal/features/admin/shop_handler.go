@@ -0,0 +1,301 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/shop"
+)
+
+const (
+	cbAdminShopMenu = "admin:shop"
+	cbShopItemNew   = "admin:shop:new"
+	cbShopItemPick  = "admin:shop:item:"
+	cbShopPrice     = "admin:shop:price:"
+	cbShopStock     = "admin:shop:stock:"
+	cbShopToggle    = "admin:shop:toggle:"
+
+	shopItemListLimit = 30
+	shopItemFormat    = "вид | название | цена | остаток | выдача | описание"
+)
+
+type shopService interface {
+	ListAllItems(ctx context.Context) ([]*shop.Item, error)
+	GetItem(ctx context.Context, itemID int64) (*shop.Item, error)
+	CreateItem(ctx context.Context, item *shop.Item, actorID int64) (*shop.Item, error)
+	SetPrice(ctx context.Context, itemID, price int64) (*shop.Item, error)
+	SetStock(ctx context.Context, itemID int64, stock *int) (*shop.Item, error)
+	SetActive(ctx context.Context, itemID int64, active bool) (*shop.Item, error)
+}
+
+// ShopItemInputData — товар, у которого админ меняет цену или остаток.
+type ShopItemInputData struct {
+	ItemID int64
+}
+
+func (h *Handler) SetShopService(shopSvc shopService) {
+	h.shopService = shopSvc
+}
+
+func (h *Handler) handleShopCallback(ctx context.Context, chatID, userID int64, panelMsgID int, data string) {
+	if !h.service.CanManageShop(ctx, userID) {
+		h.denyInsufficientPermissions(ctx, chatID)
+		return
+	}
+	h.service.ClearState(userID)
+	if h.shopService == nil {
+		h.sendMessage(ctx, chatID, "Магазин сейчас недоступен.")
+		return
+	}
+	if data == cbAdminShopMenu {
+		h.showShopItems(ctx, chatID, userID, panelMsgID)
+		return
+	}
+	if data == cbShopItemNew {
+		h.startShopItemInput(ctx, chatID, userID, panelMsgID, StateShopItemNew, 0)
+		return
+	}
+	for _, prefix := range []string{cbShopItemPick, cbShopPrice, cbShopStock, cbShopToggle} {
+		if !strings.HasPrefix(data, prefix) {
+			continue
+		}
+		itemID, err := strconv.ParseInt(strings.TrimPrefix(data, prefix), 10, 64)
+		if err != nil {
+			h.showShopItems(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		switch prefix {
+		case cbShopItemPick:
+			h.showShopItem(ctx, chatID, userID, panelMsgID, itemID)
+		case cbShopPrice:
+			h.startShopItemInput(ctx, chatID, userID, panelMsgID, StateShopItemPrice, itemID)
+		case cbShopStock:
+			h.startShopItemInput(ctx, chatID, userID, panelMsgID, StateShopItemStock, itemID)
+		case cbShopToggle:
+			h.toggleShopItem(ctx, chatID, userID, panelMsgID, itemID)
+		}
+		return
+	}
+}
+
+func (h *Handler) handleShopMessageInput(ctx context.Context, chatID, userID int64, messageID int, text string) bool {
+	state := h.service.GetState(userID)
+	if state == nil {
+		return false
+	}
+	switch state.State {
+	case StateShopItemNew:
+		h.handleShopItemNewStep(ctx, chatID, userID, text)
+	case StateShopItemPrice, StateShopItemStock:
+		input, ok := state.Data.(*ShopItemInputData)
+		if !ok || input == nil {
+			h.service.ClearState(userID)
+			return false
+		}
+		h.handleShopItemEditStep(ctx, chatID, userID, state.State, input.ItemID, text)
+	default:
+		return false
+	}
+	h.deleteAdminInputMessage(ctx, chatID, messageID)
+	return true
+}
+
+// showShopItems показывает каталог магазина вместе со скрытыми товарами.
+func (h *Handler) showShopItems(ctx context.Context, chatID, userID int64, panelMsgID int) {
+	items, err := h.shopService.ListAllItems(ctx)
+	if err != nil {
+		log.WithError(err).Warn("list shop items failed")
+		h.sendMessage(ctx, chatID, "Не удалось загрузить каталог.")
+		return
+	}
+
+	text := "Магазин. Выберите товар, чтобы изменить цену, остаток или скрыть его."
+	if len(items) == 0 {
+		text = "В магазине пока нет товаров."
+	}
+	rows := make([][]models.InlineKeyboardButton, 0, len(items)+2)
+	for i, item := range items {
+		if i >= shopItemListLimit {
+			break
+		}
+		rows = append(rows, newInlineKeyboardRow(newInlineKeyboardButtonData(
+			shortenForButton(shopItemLabel(item), 48), fmt.Sprintf("%s%d", cbShopItemPick, item.ID))))
+	}
+	rows = append(rows,
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("➕ Добавить товар", cbShopItemNew, "success")),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminReturnPanel, "danger")),
+	)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "shop_items", text, newInlineKeyboardMarkup(rows...)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) showShopItem(ctx context.Context, chatID, userID int64, panelMsgID int, itemID int64) {
+	item, err := h.shopService.GetItem(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, shop.ErrItemNotFound) {
+			h.showShopItems(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		log.WithError(err).WithField("item_id", itemID).Warn("get shop item failed")
+		h.sendMessage(ctx, chatID, "Не удалось загрузить товар.")
+		return
+	}
+	h.renderShopItem(ctx, chatID, userID, panelMsgID, item)
+}
+
+func (h *Handler) renderShopItem(ctx context.Context, chatID, userID int64, panelMsgID int, item *shop.Item) {
+	status := "в продаже"
+	toggle := newInlineKeyboardButtonDataStyled("🙈 Скрыть", fmt.Sprintf("%s%d", cbShopToggle, item.ID), "danger")
+	if !item.Active {
+		status = "скрыт"
+		toggle = newInlineKeyboardButtonDataStyled("👁 Вернуть в продажу", fmt.Sprintf("%s%d", cbShopToggle, item.ID), "success")
+	}
+	lines := []string{
+		fmt.Sprintf("Товар #%d: %s", item.ID, item.Title),
+		fmt.Sprintf("Вид: %s, выдача: %s", shop.KindLabel(item.Kind), item.Payload),
+		fmt.Sprintf("Цена: %s", common.FormatBalance(item.Price)),
+		fmt.Sprintf("Остаток: %s", shopStockLabel(item.Stock)),
+		fmt.Sprintf("Статус: %s", status),
+	}
+	if item.Description != "" {
+		lines = append(lines, "", item.Description)
+	}
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "shop_item", strings.Join(lines, "\n"), newInlineKeyboardMarkup(
+		newInlineKeyboardRow(
+			newInlineKeyboardButtonData("💰 Цена", fmt.Sprintf("%s%d", cbShopPrice, item.ID)),
+			newInlineKeyboardButtonData("📦 Остаток", fmt.Sprintf("%s%d", cbShopStock, item.ID)),
+		),
+		newInlineKeyboardRow(toggle),
+		newInlineKeyboardRow(newInlineKeyboardButtonData("Назад", cbAdminShopMenu)),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) startShopItemInput(ctx context.Context, chatID, userID int64, panelMsgID int, state string, itemID int64) {
+	back := cbAdminShopMenu
+	var (
+		data   interface{}
+		prompt string
+	)
+	switch state {
+	case StateShopItemNew:
+		prompt = fmt.Sprintf("Отправьте товар одной строкой:\n%s\n\nВид — роль, тег или заморозка. Выдача — текст роли, тег до %d символов без эмодзи или число заморозок. Остаток «-» — без ограничения, описание можно опустить.\n\nПример: роль | Меценат | 500 | 10 | Меценат | Роль для щедрых",
+			shopItemFormat, shop.MaxTagLength)
+	case StateShopItemPrice:
+		data, prompt = &ShopItemInputData{ItemID: itemID}, fmt.Sprintf("Отправьте новую цену товара #%d.", itemID)
+		back = fmt.Sprintf("%s%d", cbShopItemPick, itemID)
+	case StateShopItemStock:
+		data, prompt = &ShopItemInputData{ItemID: itemID}, fmt.Sprintf("Отправьте новый остаток товара #%d или «-», чтобы снять ограничение.", itemID)
+		back = fmt.Sprintf("%s%d", cbShopItemPick, itemID)
+	}
+	h.service.SetState(userID, state, data)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "shop_input", prompt, newInlineKeyboardMarkup(
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", back, "danger")),
+	)); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+func (h *Handler) handleShopItemNewStep(ctx context.Context, chatID, userID int64, text string) {
+	draft, err := shop.ParseItem(text)
+	if err == nil {
+		draft, err = h.shopService.CreateItem(ctx, draft, userID)
+	}
+	if err != nil {
+		if errors.Is(err, shop.ErrInvalidItem) {
+			h.sendMessage(ctx, chatID, fmt.Sprintf("Товар не добавлен: %s\nФормат: %s", inputErrorDetail(err), shopItemFormat))
+			return
+		}
+		log.WithError(err).Warn("create shop item failed")
+		h.sendMessage(ctx, chatID, "Не удалось добавить товар.")
+		return
+	}
+	panelMsgID := h.panelMessageIDFromState(userID)
+	h.service.ClearState(userID)
+	h.renderShopItem(ctx, chatID, userID, panelMsgID, draft)
+}
+
+func (h *Handler) handleShopItemEditStep(ctx context.Context, chatID, userID int64, state string, itemID int64, text string) {
+	var (
+		item *shop.Item
+		err  error
+	)
+	if state == StateShopItemPrice {
+		price, parseErr := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if parseErr != nil || price <= 0 {
+			h.sendMessage(ctx, chatID, "Цена должна быть положительным числом.")
+			return
+		}
+		item, err = h.shopService.SetPrice(ctx, itemID, price)
+	} else {
+		stock, parseErr := shop.ParseStock(text)
+		if parseErr != nil {
+			h.sendMessage(ctx, chatID, "Остаток — неотрицательное число или «-».")
+			return
+		}
+		item, err = h.shopService.SetStock(ctx, itemID, stock)
+	}
+	panelMsgID := h.panelMessageIDFromState(userID)
+	if err != nil {
+		if errors.Is(err, shop.ErrItemNotFound) {
+			h.service.ClearState(userID)
+			h.sendMessage(ctx, chatID, fmt.Sprintf("Товар #%d не найден.", itemID))
+			return
+		}
+		log.WithError(err).WithField("item_id", itemID).Warn("update shop item failed")
+		h.sendMessage(ctx, chatID, "Не удалось изменить товар.")
+		return
+	}
+	h.service.ClearState(userID)
+	h.renderShopItem(ctx, chatID, userID, panelMsgID, item)
+}
+
+func (h *Handler) toggleShopItem(ctx context.Context, chatID, userID int64, panelMsgID int, itemID int64) {
+	item, err := h.shopService.GetItem(ctx, itemID)
+	if err == nil {
+		item, err = h.shopService.SetActive(ctx, itemID, !item.Active)
+	}
+	if err != nil {
+		if errors.Is(err, shop.ErrItemNotFound) {
+			h.showShopItems(ctx, chatID, userID, panelMsgID)
+			return
+		}
+		log.WithError(err).WithField("item_id", itemID).Warn("toggle shop item failed")
+		h.sendMessage(ctx, chatID, "Не удалось изменить товар.")
+		return
+	}
+	h.renderShopItem(ctx, chatID, userID, panelMsgID, item)
+}
+
+func shopItemLabel(item *shop.Item) string {
+	label := fmt.Sprintf("%s %s — %s, %s", shop.KindIcon(item.Kind), item.Title, common.FormatBalance(item.Price), shopStockLabel(item.Stock))
+	if !item.Active {
+		label = "🙈 " + label
+	}
+	return label
+}
+
+func shopStockLabel(stock *int) string {
+	if stock == nil {
+		return "∞"
+	}
+	return strconv.Itoa(*stock)
+}
+
+// inputErrorDetail возвращает пояснение из ошибки проверки ввода (товара, выплаты).
+func inputErrorDetail(err error) string {
+	msg := err.Error()
+	if i := strings.Index(msg, ": "); i >= 0 {
+		return msg[i+2:]
+	}
+	return msg
+}
@@ -37,6 +37,18 @@ func marshalAdminStateData(stateName string, data interface{}) ([]byte, error) {
 			return nil, fmt.Errorf("unexpected admin state payload for %s", stateName)
 		}
 		return json.Marshal(v)
+	case StateCreditTarget, StateCreditAmount, StateCreditInterest, StateCreditTerm, StateCreditConfirm:
+		v, ok := data.(*CreditDraftData)
+		if !ok {
+			return nil, fmt.Errorf("unexpected admin state payload for %s", stateName)
+		}
+		return json.Marshal(v)
+	case StateShopItemPrice, StateShopItemStock:
+		v, ok := data.(*ShopItemInputData)
+		if !ok {
+			return nil, fmt.Errorf("unexpected admin state payload for %s", stateName)
+		}
+		return json.Marshal(v)
 	default:
 		return nil, fmt.Errorf("unsupported admin state %s", stateName)
 	}
@@ -72,6 +84,18 @@ func unmarshalAdminStateData(stateName string, raw []byte) (interface{}, error)
 			return nil, err
 		}
 		return &v, nil
+	case StateCreditTarget, StateCreditAmount, StateCreditInterest, StateCreditTerm, StateCreditConfirm:
+		var v CreditDraftData
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	case StateShopItemPrice, StateShopItemStock:
+		var v ShopItemInputData
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
 	case StateAwaitingPassword:
 		return nil, nil
 	default:
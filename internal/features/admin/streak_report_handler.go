@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/features/streak"
+)
+
+const (
+	cbAdminStreakReport = "admin:streaks"
+
+	streakReportDefaultDays = 14
+	streakReportBarWidth    = 10
+)
+
+// streakReportPeriods — периоды отчёта, между которыми переключают кнопки.
+var streakReportPeriods = []int{7, 14, 30}
+
+type streakReportService interface {
+	CompletionStats(ctx context.Context, days int) ([]streak.DayStats, error)
+}
+
+func (h *Handler) SetStreakReportService(report streakReportService) {
+	h.streakReport = report
+}
+
+func (h *Handler) handleStreakReportCallback(ctx context.Context, chatID, userID int64, panelMsgID int, data string) {
+	if !h.service.CanAccessAdminPanel(ctx, userID) {
+		h.denyInsufficientPermissions(ctx, chatID)
+		return
+	}
+	h.service.ClearState(userID)
+	if h.streakReport == nil {
+		h.sendMessage(ctx, chatID, "Отчёт по огоньку сейчас недоступен.")
+		return
+	}
+
+	days := streakReportDefaultDays
+	if raw := strings.TrimPrefix(data, cbAdminStreakReport+":"); raw != data {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 && n <= 31 {
+			days = n
+		}
+	}
+	stats, err := h.streakReport.CompletionStats(ctx, days)
+	if err != nil {
+		log.WithError(err).Warn("streak completion stats failed")
+		h.sendMessage(ctx, chatID, "Не удалось собрать отчёт по огоньку.")
+		return
+	}
+
+	periodRow := make([]models.InlineKeyboardButton, 0, len(streakReportPeriods))
+	for _, n := range streakReportPeriods {
+		label := fmt.Sprintf("%d дн.", n)
+		if n == days {
+			label = "• " + label
+		}
+		periodRow = append(periodRow, newInlineKeyboardButtonData(label, fmt.Sprintf("%s:%d", cbAdminStreakReport, n)))
+	}
+	keyboard := newInlineKeyboardMarkup(
+		newInlineKeyboardRow(periodRow...),
+		newInlineKeyboardRow(newInlineKeyboardButtonDataStyled("Назад", cbAdminReturnPanel, "danger")),
+	)
+	if err := h.renderAdminScreen(ctx, chatID, userID, panelMsgID, "streaks", formatStreakReport(stats, days), keyboard); err != nil {
+		h.sendUIErrorHint(ctx, chatID, err)
+	}
+}
+
+// formatStreakReport выводит по строке на день: долю закрывших квоту среди
+// писавших, абсолютные числа и спасённые заморозками дни.
+func formatStreakReport(stats []streak.DayStats, days int) string {
+	lines := []string{fmt.Sprintf("📈 Огонёк за %d дн.: закрытие квоты", days)}
+	if len(stats) == 0 {
+		return strings.Join(append(lines, "", "За период нет активности."), "\n")
+	}
+
+	lines = append(lines, "")
+	var active, completed int
+	for _, d := range stats {
+		active += d.Active
+		completed += d.Completed
+		line := fmt.Sprintf("%s %s %.0f%% (%d/%d)", d.Day.Format("02.01"), completionBar(d.CompletionRate()), d.CompletionRate(), d.Completed, d.Active)
+		if d.Frozen > 0 {
+			line += fmt.Sprintf(" ❄️%d", d.Frozen)
+		}
+		lines = append(lines, line)
+	}
+	total := streak.DayStats{Active: active, Completed: completed}
+	lines = append(lines, "", fmt.Sprintf("Всего: %.0f%% (%d из %d участников-дней)", total.CompletionRate(), completed, active))
+	return strings.Join(lines, "\n")
+}
+
+func completionBar(rate float64) string {
+	filled := int(rate*streakReportBarWidth/100 + 0.5)
+	return strings.Repeat("▓", filled) + strings.Repeat("░", streakReportBarWidth-filled)
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/features/streak"
+)
+
+type fakeStreakReport struct {
+	stats    []streak.DayStats
+	lastDays int
+}
+
+func (f *fakeStreakReport) CompletionStats(ctx context.Context, days int) ([]streak.DayStats, error) {
+	f.lastDays = days
+	return f.stats, nil
+}
+
+func TestStreakReport_ShowsCompletionRatesAndSwitchesPeriod(t *testing.T) {
+	tg := &fakeTG{}
+	repo := &fakeMemberRepoHandlers{members: map[int64]*members.Member{77: {UserID: 77, IsAdmin: true}}}
+	h := newAdminHandlerForFlow(t, repo, tg)
+	report := &fakeStreakReport{stats: []streak.DayStats{
+		{Day: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC), Active: 10, Completed: 4, Frozen: 2},
+		{Day: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), Active: 5, Completed: 5},
+	}}
+	h.SetStreakReportService(report)
+
+	_ = h.HandleAdminMessage(context.Background(), 77, 77, 0, "Панель")
+	if e := tg.last("send"); e == nil || !hasButton(e.markup, "📈 Огонёк", cbAdminStreakReport) {
+		t.Fatalf("expected streak report entry in admin panel")
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbAdminStreakReport))
+	edit := tg.last("edit")
+	if edit == nil || report.lastDays != streakReportDefaultDays {
+		t.Fatalf("expected default period report, got %#v (days %d)", edit, report.lastDays)
+	}
+	for _, want := range []string{"07.03 ▓▓▓▓░░░░░░ 40% (4/10) ❄️2", "08.03 ▓▓▓▓▓▓▓▓▓▓ 100% (5/5)", "Всего: 60% (9 из 15"} {
+		if !strings.Contains(edit.text, want) {
+			t.Fatalf("expected %q in report, got %q", want, edit.text)
+		}
+	}
+
+	h.HandleAdminCallback(context.Background(), callback(77, 42, 77, cbAdminStreakReport+":30"))
+	if report.lastDays != 30 {
+		t.Fatalf("expected 30-day report, got %d", report.lastDays)
+	}
+}
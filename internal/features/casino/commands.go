@@ -14,9 +14,42 @@ func RegisterCommands(r *commands.Router, h *Handler, cfg *config.Config) {
 	}
 
 	r.Register("слоты", func(ctx context.Context, c commands.Context, args []string) {
-		h.HandleSlots(ctx, c.ChatID, c.UserID)
+		h.HandleSlots(ctx, c.ChatID, c.UserID, args)
 	})
 	r.Register("статслоты", func(ctx context.Context, c commands.Context, args []string) {
 		h.HandleSlotStats(ctx, c.ChatID, c.UserID)
 	})
+	r.Register("игра", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleGame(ctx, c.ChatID, c.UserID, args)
+	})
+	r.Register("топслоты", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleTopSlots(ctx, c.ChatID, args)
+	})
+	for name, gameType := range map[string]string{
+		"кости":   GameTypeDice,
+		"рулетка": GameTypeRoulette,
+		"монетка": GameTypeCoinFlip,
+	} {
+		r.Register(name, func(ctx context.Context, c commands.Context, args []string) {
+			h.HandleTableGame(ctx, c.ChatID, c.UserID, gameType, args)
+		})
+	}
+	r.Register("самоисключение", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleSelfExclusion(ctx, c.ChatID, c.UserID, args)
+	})
+	r.Register("лимиты", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleLossLimits(ctx, c.ChatID, c.UserID, args)
+	})
+	r.Register("дуэль", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleDuel(ctx, c, args)
+	})
+	if !cfg.CasinoProvablyFair {
+		return
+	}
+	r.Register("сид", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleSeed(ctx, c.ChatID, c.UserID, args)
+	})
+	r.Register("проверить", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleVerify(ctx, c.ChatID, args)
+	})
 }
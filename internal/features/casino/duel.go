@@ -0,0 +1,364 @@
+// Package casino — duel.go описывает дуэли игроков: вызывающий ставит
+// плёнки на депонирование, соперник принимает или отказывается кнопкой,
+// победитель забирает банк за вычетом комиссии казино. Непринятые вызовы
+// возвращаются планировщиком по истечении CASINO_DUEL_TTL.
+package casino
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+)
+
+const (
+	DuelStatePending   = "pending"
+	DuelStateCompleted = "completed"
+	DuelStateDeclined  = "declined"
+	DuelStateCanceled  = "canceled"
+	DuelStateExpired   = "expired"
+
+	// duelExpireBatch ограничивает число возвратов за один запуск планировщика.
+	duelExpireBatch = 100
+	// duelMaxRolls ограничивает перебросы при ничьей.
+	duelMaxRolls = 32
+)
+
+var (
+	// ErrDuelNotFound — дуэли с таким токеном нет.
+	ErrDuelNotFound = errors.New("casino duel not found")
+	// ErrDuelSelf — вызов самого себя.
+	ErrDuelSelf = errors.New("casino duel against self")
+	// ErrDuelNotParticipant — кнопку нажал не тот участник.
+	ErrDuelNotParticipant = errors.New("casino duel actor is not a participant")
+	// ErrDuelClosed — дуэль уже сыграна, отклонена или возвращена.
+	ErrDuelClosed = errors.New("casino duel is closed")
+	// ErrDuelExpired — вызов истёк, ставка возвращена.
+	ErrDuelExpired = errors.New("casino duel expired")
+	// ErrDuelChallengerExcluded — вызывающий успел уйти в самоисключение,
+	// вызов отменён, ставка возвращена.
+	ErrDuelChallengerExcluded = errors.New("casino duel challenger is self-excluded")
+)
+
+// Duel — вызов на дуэль и её итог (таблица casino_duels).
+type Duel struct {
+	ID             int64
+	Token          string
+	ChatID         int64
+	MessageID      int
+	ChallengerID   int64
+	OpponentID     int64
+	Stake          int64
+	State          string
+	ChallengerRoll int
+	OpponentRoll   int
+	WinnerID       *int64
+	Fee            int64
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	ResolvedAt     *time.Time
+}
+
+// Pot возвращает банк дуэли — обе ставки.
+func (d *Duel) Pot() int64 { return 2 * d.Stake }
+
+// Payout возвращает выплату победителю за вычетом комиссии.
+func (d *Duel) Payout() int64 { return d.Pot() - d.Fee }
+
+// LoserID возвращает проигравшего сыгранной дуэли.
+func (d *Duel) LoserID() int64 {
+	if d.WinnerID != nil && *d.WinnerID == d.ChallengerID {
+		return d.OpponentID
+	}
+	return d.ChallengerID
+}
+
+// DuelFeePercent возвращает комиссию казино с банка дуэли в процентах.
+func (s *Service) DuelFeePercent() float64 {
+	return s.cfg.CasinoDuelFeePercent
+}
+
+// CreateDuel списывает ставку вызывающего на депонирование и создаёт вызов.
+// Ставка проверяется по самоисключению и лимитам вызывающего, как ставка
+// в игре. Сообщение с кнопками отправляет обработчик и привязывает его
+// через AttachDuelMessage.
+func (s *Service) CreateDuel(ctx context.Context, chatID, challengerID, opponentID, stake int64) (*Duel, error) {
+	if challengerID == opponentID {
+		return nil, ErrDuelSelf
+	}
+	if !s.BetRange(GameTypeDuel).Contains(stake) {
+		return nil, common.ErrCasinoBetOutOfRange
+	}
+	token, err := newDuelToken()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации токена дуэли: %w", err)
+	}
+	now := s.now().UTC()
+	duel := &Duel{
+		Token:        token,
+		ChatID:       chatID,
+		ChallengerID: challengerID,
+		OpponentID:   opponentID,
+		Stake:        stake,
+		State:        DuelStatePending,
+		ExpiresAt:    now.Add(s.cfg.CasinoDuelTTL),
+	}
+	err = s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := s.checkBetAllowedTx(ctx, tx, challengerID, s.today(), stake); err != nil {
+			return err
+		}
+		if err := s.economyService.DeductBalanceTx(ctx, tx, challengerID, stake,
+			economy.TxTypeDuelStake, "Duel stake"); err != nil {
+			return err
+		}
+		return s.repo.CreateDuelTx(ctx, tx, duel)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return duel, nil
+}
+
+// AttachDuelMessage запоминает сообщение с кнопками вызова.
+func (s *Service) AttachDuelMessage(ctx context.Context, duel *Duel, messageID int) error {
+	if err := s.repo.SetDuelMessage(ctx, duel.ID, messageID); err != nil {
+		return err
+	}
+	duel.MessageID = messageID
+	return nil
+}
+
+// GetDuel возвращает дуэль по токену кнопки.
+func (s *Service) GetDuel(ctx context.Context, token string) (*Duel, error) {
+	return s.repo.GetDuel(ctx, token)
+}
+
+// AcceptDuel списывает ставку соперника и разыгрывает дуэль: каждый
+// бросает кубик, ничья перебрасывается. Победитель получает банк
+// (duel_win), комиссия списывается с него отдельной проводкой (duel_fee).
+// Ставка соперника проверяется по его лимитам; сыгранная дуэль попадает
+// в дневной учёт обоих игроков. Истёкший вызов возвращается вызывающему,
+// AcceptDuel отдаёт ErrDuelExpired. Если вызывающий с момента вызова ушёл
+// в самоисключение, вызов отменяется с возвратом ставки и AcceptDuel
+// отдаёт ErrDuelChallengerExcluded.
+func (s *Service) AcceptDuel(ctx context.Context, token string, actorID int64) (*Duel, error) {
+	var (
+		duel     *Duel
+		expired  bool
+		excluded bool
+	)
+	err := s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		duel, err = s.repo.LockDuelTx(ctx, tx, token)
+		if err != nil {
+			return err
+		}
+		if actorID != duel.OpponentID {
+			return ErrDuelNotParticipant
+		}
+		if duel.State != DuelStatePending {
+			return ErrDuelClosed
+		}
+		now := s.now().UTC()
+		if now.After(duel.ExpiresAt) {
+			expired = true
+			return s.refundDuelTx(ctx, tx, duel, DuelStateExpired, now)
+		}
+		if err := s.checkNotExcluded(ctx, duel.ChallengerID); err != nil {
+			if !errors.Is(err, common.ErrCasinoSelfExcluded) {
+				return err
+			}
+			excluded = true
+			return s.refundDuelTx(ctx, tx, duel, DuelStateCanceled, now)
+		}
+		day := s.today()
+		// Строки дневного учёта блокируются по возрастанию user_id, чтобы
+		// встречные дуэли одной пары не ждали друг друга.
+		if duel.ChallengerID < duel.OpponentID {
+			if _, err := s.repo.LockDailyUsageTx(ctx, tx, duel.ChallengerID, day); err != nil {
+				return err
+			}
+		}
+		if _, err := s.checkBetAllowedTx(ctx, tx, duel.OpponentID, day, duel.Stake); err != nil {
+			return err
+		}
+
+		if err := s.economyService.DeductBalanceTx(ctx, tx, duel.OpponentID, duel.Stake,
+			economy.TxTypeDuelStake, "Duel stake"); err != nil {
+			return err
+		}
+		if duel.ChallengerRoll, duel.OpponentRoll, err = rollDuel(s.rng); err != nil {
+			return err
+		}
+		winnerID := duel.OpponentID
+		if duel.ChallengerRoll > duel.OpponentRoll {
+			winnerID = duel.ChallengerID
+		}
+		duel.WinnerID = &winnerID
+		duel.Fee = int64(float64(duel.Pot()) * s.cfg.CasinoDuelFeePercent / 100)
+		if err := s.economyService.AddBalanceTx(ctx, tx, winnerID, duel.Pot(),
+			economy.TxTypeDuelWin, "Duel pot"); err != nil {
+			return err
+		}
+		if duel.Fee > 0 {
+			if err := s.economyService.DeductBalanceTx(ctx, tx, winnerID, duel.Fee,
+				economy.TxTypeDuelFee, "Duel house fee"); err != nil {
+				return err
+			}
+		}
+		for _, userID := range []int64{duel.ChallengerID, duel.OpponentID} {
+			var won int64
+			if userID == winnerID {
+				won = duel.Payout()
+			}
+			if err := s.repo.AddDailyUsageTx(ctx, tx, userID, day, duel.Stake, won); err != nil {
+				return err
+			}
+		}
+		duel.State = DuelStateCompleted
+		duel.ResolvedAt = &now
+		return s.repo.ResolveDuelTx(ctx, tx, duel)
+	})
+	if err != nil {
+		if errors.Is(err, ErrDuelClosed) || errors.Is(err, ErrDuelNotParticipant) {
+			return duel, err
+		}
+		return nil, err
+	}
+	if expired {
+		return duel, ErrDuelExpired
+	}
+	if excluded {
+		return duel, ErrDuelChallengerExcluded
+	}
+	return duel, nil
+}
+
+// DeclineDuel закрывает вызов без игры и возвращает ставку вызывающему:
+// соперник отказывается (declined), вызывающий отзывает вызов (canceled).
+func (s *Service) DeclineDuel(ctx context.Context, token string, actorID int64) (*Duel, error) {
+	var (
+		duel    *Duel
+		expired bool
+	)
+	err := s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		duel, err = s.repo.LockDuelTx(ctx, tx, token)
+		if err != nil {
+			return err
+		}
+		state := DuelStateDeclined
+		switch actorID {
+		case duel.OpponentID:
+		case duel.ChallengerID:
+			state = DuelStateCanceled
+		default:
+			return ErrDuelNotParticipant
+		}
+		if duel.State != DuelStatePending {
+			return ErrDuelClosed
+		}
+		now := s.now().UTC()
+		if now.After(duel.ExpiresAt) {
+			expired = true
+			state = DuelStateExpired
+		}
+		return s.refundDuelTx(ctx, tx, duel, state, now)
+	})
+	if err != nil {
+		if errors.Is(err, ErrDuelClosed) || errors.Is(err, ErrDuelNotParticipant) {
+			return duel, err
+		}
+		return nil, err
+	}
+	if expired {
+		return duel, ErrDuelExpired
+	}
+	return duel, nil
+}
+
+// RefundExpiredDuels возвращает ставки непринятых вызовов с истёкшим сроком
+// и через editFunc убирает кнопки из сообщений вызова. Возвращает число
+// возвращённых дуэлей.
+func (s *Service) RefundExpiredDuels(ctx context.Context, editFunc func(ctx context.Context, chatID int64, messageID int, text string) error) (int, error) {
+	now := s.now().UTC()
+	tokens, err := s.repo.ListExpiredDuels(ctx, now, duelExpireBatch)
+	if err != nil {
+		return 0, err
+	}
+	refunded := 0
+	for _, token := range tokens {
+		var duel *Duel
+		err := s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			var err error
+			duel, err = s.repo.LockDuelTx(ctx, tx, token)
+			if err != nil {
+				return err
+			}
+			if duel.State != DuelStatePending || !now.After(duel.ExpiresAt) {
+				duel = nil
+				return nil
+			}
+			return s.refundDuelTx(ctx, tx, duel, DuelStateExpired, now)
+		})
+		if err != nil {
+			return refunded, fmt.Errorf("ошибка возврата дуэли %s: %w", token, err)
+		}
+		if duel == nil {
+			continue
+		}
+		refunded++
+		if editFunc != nil && duel.MessageID != 0 {
+			text := fmt.Sprintf("⌛ Вызов на дуэль не принят вовремя. Ставка %s возвращена.",
+				common.FormatBalance(duel.Stake))
+			// Деньги уже возвращены; если правка не прошла, устаревшие
+			// кнопки ответят, что вызов закрыт.
+			_ = editFunc(ctx, duel.ChatID, duel.MessageID, text)
+		}
+	}
+	return refunded, nil
+}
+
+// refundDuelTx возвращает ставку вызывающего и закрывает дуэль в состоянии state.
+func (s *Service) refundDuelTx(ctx context.Context, tx pgx.Tx, duel *Duel, state string, now time.Time) error {
+	if err := s.economyService.AddBalanceTx(ctx, tx, duel.ChallengerID, duel.Stake,
+		economy.TxTypeDuelRefund, "Duel stake refund"); err != nil {
+		return err
+	}
+	duel.State = state
+	duel.ResolvedAt = &now
+	return s.repo.ResolveDuelTx(ctx, tx, duel)
+}
+
+// rollDuel бросает кубик за каждого участника, пока броски не различаются.
+func rollDuel(src RandomSource) (challenger, opponent int, err error) {
+	for i := 0; i < duelMaxRolls; i++ {
+		a, err := src.Int63n(6)
+		if err != nil {
+			return 0, 0, fmt.Errorf("ошибка броска дуэли: %w", err)
+		}
+		b, err := src.Int63n(6)
+		if err != nil {
+			return 0, 0, fmt.Errorf("ошибка броска дуэли: %w", err)
+		}
+		if a != b {
+			return int(a) + 1, int(b) + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("ошибка броска дуэли: %d ничьих подряд", duelMaxRolls)
+}
+
+func newDuelToken() (string, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
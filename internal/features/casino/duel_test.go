@@ -0,0 +1,308 @@
+package casino
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+)
+
+// rollsSource отдаёт заранее заданные броски по очереди.
+func rollsSource(rolls ...int64) RandomSource {
+	return sourceFunc(func(n int64) (int64, error) {
+		if len(rolls) == 0 {
+			return 0, errors.New("rolls exhausted")
+		}
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r, nil
+	})
+}
+
+func TestDuel_AcceptPaysWinnerMinusFee(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1], store.balances[2] = 500, 500
+	svc := newTestService(store)
+	svc.cfg.CasinoDuelFeePercent = 5
+	// Ничья 3:3 перебрасывается, затем 2 против 6 — побеждает соперник.
+	svc.rng = rollsSource(2, 2, 1, 5)
+
+	duel, err := svc.CreateDuel(ctx, -100, 1, 2, 100)
+	if err != nil {
+		t.Fatalf("create duel: %v", err)
+	}
+	if store.balances[1] != 400 {
+		t.Fatalf("challenger stake not escrowed: balance=%d", store.balances[1])
+	}
+
+	duel, err = svc.AcceptDuel(ctx, duel.Token, 2)
+	if err != nil {
+		t.Fatalf("accept duel: %v", err)
+	}
+	if duel.State != DuelStateCompleted || duel.WinnerID == nil || *duel.WinnerID != 2 {
+		t.Fatalf("unexpected duel: %+v", duel)
+	}
+	if duel.ChallengerRoll != 2 || duel.OpponentRoll != 6 || duel.Fee != 10 {
+		t.Fatalf("rolls/fee = %d:%d fee %d, want 2:6 fee 10", duel.ChallengerRoll, duel.OpponentRoll, duel.Fee)
+	}
+	if store.balances[1] != 400 || store.balances[2] != 590 {
+		t.Fatalf("balances = %d/%d, want 400/590", store.balances[1], store.balances[2])
+	}
+	want := map[string]int64{
+		economy.TxTypeDuelStake: -200,
+		economy.TxTypeDuelWin:   200,
+		economy.TxTypeDuelFee:   -10,
+	}
+	for txType, amount := range want {
+		if got := sumByType(store.entries, txType); got != amount {
+			t.Fatalf("%s sum = %d, want %d", txType, got, amount)
+		}
+	}
+}
+
+func TestDuel_AcceptErrors(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1], store.balances[2] = 500, 50
+	svc := newTestService(store)
+	svc.rng = rollsSource(5, 0)
+
+	duel, err := svc.CreateDuel(ctx, -100, 1, 2, 100)
+	if err != nil {
+		t.Fatalf("create duel: %v", err)
+	}
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 3); !errors.Is(err, ErrDuelNotParticipant) {
+		t.Fatalf("stranger accept: expected ErrDuelNotParticipant, got %v", err)
+	}
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 2); !errors.Is(err, common.ErrInsufficientBalance) {
+		t.Fatalf("poor opponent: expected ErrInsufficientBalance, got %v", err)
+	}
+	if got := store.duels[duel.Token].State; got != DuelStatePending || store.balances[2] != 50 {
+		t.Fatalf("failed accept changed duel: state=%s balance=%d", got, store.balances[2])
+	}
+
+	store.balances[2] = 100
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 2); err != nil {
+		t.Fatalf("accept duel: %v", err)
+	}
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 2); !errors.Is(err, ErrDuelClosed) {
+		t.Fatalf("second accept: expected ErrDuelClosed, got %v", err)
+	}
+	if _, err := svc.DeclineDuel(ctx, duel.Token, 1); !errors.Is(err, ErrDuelClosed) {
+		t.Fatalf("cancel after accept: expected ErrDuelClosed, got %v", err)
+	}
+}
+
+func TestDuel_DeclineAndCancelRefund(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1] = 500
+	svc := newTestService(store)
+
+	for actor, state := range map[int64]string{2: DuelStateDeclined, 1: DuelStateCanceled} {
+		duel, err := svc.CreateDuel(ctx, -100, 1, 2, 100)
+		if err != nil {
+			t.Fatalf("create duel: %v", err)
+		}
+		duel, err = svc.DeclineDuel(ctx, duel.Token, actor)
+		if err != nil {
+			t.Fatalf("decline by %d: %v", actor, err)
+		}
+		if duel.State != state || store.balances[1] != 500 {
+			t.Fatalf("decline by %d: state=%s balance=%d", actor, duel.State, store.balances[1])
+		}
+	}
+	if got := sumByType(store.entries, economy.TxTypeDuelRefund); got != 200 {
+		t.Fatalf("refund sum = %d, want 200", got)
+	}
+}
+
+func TestDuel_CreateValidation(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1] = 50
+	svc := newTestService(store)
+
+	if _, err := svc.CreateDuel(ctx, -100, 1, 1, 20); !errors.Is(err, ErrDuelSelf) {
+		t.Fatalf("self duel: expected ErrDuelSelf, got %v", err)
+	}
+	if _, err := svc.CreateDuel(ctx, -100, 1, 2, 5000); !errors.Is(err, common.ErrCasinoBetOutOfRange) {
+		t.Fatalf("huge stake: expected ErrCasinoBetOutOfRange, got %v", err)
+	}
+	// Границы дуэли не зависят от границ слотов
+	svc.cfg.CasinoSlotsMaxBet = 10
+	if _, err := svc.CreateDuel(ctx, -100, 1, 2, 100); !errors.Is(err, common.ErrInsufficientBalance) {
+		t.Fatalf("poor challenger: expected ErrInsufficientBalance, got %v", err)
+	}
+	if len(store.duels) != 0 || len(store.entries) != 0 {
+		t.Fatalf("failed challenges left side effects: duels=%v entries=%v", store.duels, store.entries)
+	}
+}
+
+func TestRefundExpiredDuels(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1] = 500
+	svc := newTestService(store)
+	start := svc.now()
+
+	duel, err := svc.CreateDuel(ctx, -100, 1, 2, 100)
+	if err != nil {
+		t.Fatalf("create duel: %v", err)
+	}
+	if err := svc.AttachDuelMessage(ctx, duel, 42); err != nil {
+		t.Fatalf("attach message: %v", err)
+	}
+
+	var edited []int
+	edit := func(ctx context.Context, chatID int64, messageID int, text string) error {
+		edited = append(edited, messageID)
+		return nil
+	}
+	if n, err := svc.RefundExpiredDuels(ctx, edit); err != nil || n != 0 {
+		t.Fatalf("fresh duel refunded: n=%d err=%v", n, err)
+	}
+
+	svc.now = func() time.Time { return start.Add(11 * time.Minute) }
+	if n, err := svc.RefundExpiredDuels(ctx, edit); err != nil || n != 1 {
+		t.Fatalf("expired duel: n=%d err=%v", n, err)
+	}
+	if store.balances[1] != 500 || store.duels[duel.Token].State != DuelStateExpired {
+		t.Fatalf("expired duel not refunded: balance=%d duel=%+v", store.balances[1], store.duels[duel.Token])
+	}
+	if len(edited) != 1 || edited[0] != 42 {
+		t.Fatalf("challenge message not edited: %v", edited)
+	}
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 2); !errors.Is(err, ErrDuelClosed) {
+		t.Fatalf("accept after refund: expected ErrDuelClosed, got %v", err)
+	}
+}
+
+func TestDuel_LateAcceptRefunds(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1], store.balances[2] = 500, 500
+	svc := newTestService(store)
+	start := svc.now()
+
+	duel, err := svc.CreateDuel(ctx, -100, 1, 2, 100)
+	if err != nil {
+		t.Fatalf("create duel: %v", err)
+	}
+	svc.now = func() time.Time { return start.Add(time.Hour) }
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 2); !errors.Is(err, ErrDuelExpired) {
+		t.Fatalf("late accept: expected ErrDuelExpired, got %v", err)
+	}
+	if store.balances[1] != 500 || store.balances[2] != 500 || store.duels[duel.Token].State != DuelStateExpired {
+		t.Fatalf("late accept: balances=%d/%d duel=%+v", store.balances[1], store.balances[2], store.duels[duel.Token])
+	}
+}
+
+func TestDuel_CappedPlayersRefused(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1], store.balances[2], store.balances[3] = 500, 500, 500
+	svc := newTestService(store)
+	svc.cfg.CasinoDailyLossCap = 500
+	svc.cfg.CasinoDailyWagerCap = 1000
+	day := svc.today()
+
+	store.daily[1] = DailyUsage{UserID: 1, Day: day, Wagered: 450}
+	if _, err := svc.CreateDuel(ctx, -100, 1, 2, 100); !errors.Is(err, common.ErrCasinoDailyLossCap) {
+		t.Fatalf("capped challenger: expected ErrCasinoDailyLossCap, got %v", err)
+	}
+	if len(store.duels) != 0 || store.balances[1] != 500 {
+		t.Fatalf("refused challenge left side effects: duels=%d balance=%d", len(store.duels), store.balances[1])
+	}
+
+	duel, err := svc.CreateDuel(ctx, -100, 3, 2, 100)
+	if err != nil {
+		t.Fatalf("create duel: %v", err)
+	}
+	store.daily[2] = DailyUsage{UserID: 2, Day: day, Wagered: 950, Won: 900}
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 2); !errors.Is(err, common.ErrCasinoDailyWagerCap) {
+		t.Fatalf("capped opponent: expected ErrCasinoDailyWagerCap, got %v", err)
+	}
+	if store.duels[duel.Token].State != DuelStatePending || store.balances[2] != 500 {
+		t.Fatalf("refused accept changed duel: state=%s balance=%d", store.duels[duel.Token].State, store.balances[2])
+	}
+}
+
+func TestDuel_AcceptRefundsExcludedChallenger(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1], store.balances[2] = 500, 500
+	svc := newTestService(store)
+
+	duel, err := svc.CreateDuel(ctx, -100, 1, 2, 100)
+	if err != nil {
+		t.Fatalf("create duel: %v", err)
+	}
+	now := svc.now().UTC()
+	if _, err := store.UpsertExclusion(ctx, 1, now, now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("exclude challenger: %v", err)
+	}
+
+	got, err := svc.AcceptDuel(ctx, duel.Token, 2)
+	if !errors.Is(err, ErrDuelChallengerExcluded) {
+		t.Fatalf("expected ErrDuelChallengerExcluded, got %v", err)
+	}
+	if got == nil || got.State != DuelStateCanceled || store.duels[duel.Token].State != DuelStateCanceled {
+		t.Fatalf("duel should be canceled, got %+v", got)
+	}
+	if store.balances[1] != 500 || store.balances[2] != 500 {
+		t.Fatalf("balances = %d/%d, want stake refunded and opponent untouched", store.balances[1], store.balances[2])
+	}
+	if refunds := sumByType(store.entries, economy.TxTypeDuelRefund); refunds != 100 {
+		t.Fatalf("refund entries = %d, want 100", refunds)
+	}
+}
+
+func TestDuel_RecordedInDailyUsage(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.balances[1], store.balances[2] = 500, 500
+	svc := newTestService(store)
+	svc.rng = rollsSource(5, 0)
+
+	duel, err := svc.CreateDuel(ctx, -100, 1, 2, 100)
+	if err != nil {
+		t.Fatalf("create duel: %v", err)
+	}
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 2); err != nil {
+		t.Fatalf("accept duel: %v", err)
+	}
+	if u := store.daily[1]; u.Wagered != 100 || u.Won != 200 {
+		t.Fatalf("winner usage = %+v, want wagered 100 won 200", u)
+	}
+	if u := store.daily[2]; u.Wagered != 100 || u.Won != 0 || u.NetLoss() != 100 {
+		t.Fatalf("loser usage = %+v, want net loss 100", u)
+	}
+}
+
+func TestParseDuelArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		username string
+		stake    int64
+		ok       bool
+	}{
+		{[]string{"@bob", "100"}, "bob", 100, true},
+		{[]string{"100", "@bob"}, "bob", 100, true},
+		{[]string{"100"}, "", 100, true},
+		{[]string{"@bob"}, "", 0, false},
+		{[]string{"@bob", "-5"}, "", 0, false},
+		{[]string{"@bob", "@alice", "5"}, "", 0, false},
+		{[]string{"100", "200"}, "", 0, false},
+	}
+	for _, tt := range tests {
+		username, stake, ok := parseDuelArgs(tt.args)
+		if username != tt.username || stake != tt.stake || ok != tt.ok {
+			t.Fatalf("parseDuelArgs(%v) = %q, %d, %v", tt.args, username, stake, ok)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package casino — fair.go реализует доказуемо честные спины.
+//
+// Бот заранее публикует SHA-256 от серверного сида игрока. Каждая сетка
+// выводится из серверного сида, клиентского сида и номера спина (nonce),
+// а не из crypto/rand. После смены сида серверный сид раскрывается,
+// и любой может пересчитать сетку по данным из casino_games.game_data.
+//
+// Алгоритм выбора числа в [0, n):
+//
+//	блок_i = HMAC-SHA256(ключ = server_seed, сообщение = "<client_seed>:<nonce>:<i>"), i = 0, 1, …
+//	каждый блок режется на 4 числа uint64 (big-endian), у числа берутся старшие 63 бита;
+//	число v принимается, если v < 2^63 − (2^63 mod n), иначе берётся следующее;
+//	результат — v mod n.
+//
+// Символ на позиции выбирается по сумме весов, как в selectWeightedSymbol:
+// рилы слева направо, строки сверху вниз; затем по очереди фриспины.
+package casino
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Размеры сидов в байтах до hex-кодирования.
+const (
+	serverSeedBytes = 32
+	clientSeedBytes = 8
+	// maxClientSeedLen ограничивает клиентский сид, заданный игроком.
+	maxClientSeedLen = 64
+)
+
+// ErrInvalidClientSeed — клиентский сид пуст, слишком длинный или с пробелами.
+var ErrInvalidClientSeed = errors.New("invalid client seed")
+
+// Seed — сид игрока для доказуемо честных спинов.
+type Seed struct {
+	ID             int64      `db:"id"`
+	UserID         int64      `db:"user_id"`
+	ServerSeed     string     `db:"server_seed"`      // Раскрывается только после смены
+	ServerSeedHash string     `db:"server_seed_hash"` // SHA-256 серверного сида, публикуется сразу
+	ClientSeed     string     `db:"client_seed"`
+	Nonce          int64      `db:"nonce"` // Номер последнего сыгранного спина
+	CreatedAt      time.Time  `db:"created_at"`
+	RevealedAt     *time.Time `db:"revealed_at"` // nil — сид активен и скрыт
+}
+
+// Revealed сообщает, раскрыт ли серверный сид.
+func (s *Seed) Revealed() bool {
+	return s.RevealedAt != nil
+}
+
+// FairProof — входные данные вывода сетки, сохраняемые в game_data.
+type FairProof struct {
+	SeedID         int64        `json:"seed_id"`
+	ServerSeedHash string       `json:"server_seed_hash"`
+	ClientSeed     string       `json:"client_seed"`
+	Nonce          int64        `json:"nonce"`
+	RTPTier        RTPTier      `json:"rtp_tier"`
+	Weights        []FairWeight `json:"weights"` // Веса с учётом ступени RTP на момент спина
+}
+
+// FairWeight — вес символа, которым была выведена сетка.
+type FairWeight struct {
+	Emoji  string `json:"emoji"`
+	Weight int    `json:"weight"`
+}
+
+// fairSource — детерминированный поток чисел из HMAC-SHA256.
+type fairSource struct {
+	key    []byte
+	prefix string
+	block  int
+	buf    []byte
+}
+
+// NewFairSource создаёт источник, выводящий числа из серверного сида,
+// клиентского сида и nonce по алгоритму из описания файла.
+func NewFairSource(serverSeed, clientSeed string, nonce int64) RandomSource {
+	return &fairSource{
+		key:    []byte(serverSeed),
+		prefix: fmt.Sprintf("%s:%d:", clientSeed, nonce),
+	}
+}
+
+func (s *fairSource) Int63n(n int64) (int64, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("некорректная граница %d", n)
+	}
+	limit := uint64(math.MaxInt64) + 1
+	limit -= limit % uint64(n)
+	for {
+		if len(s.buf) < 8 {
+			mac := hmac.New(sha256.New, s.key)
+			fmt.Fprintf(mac, "%s%d", s.prefix, s.block)
+			s.buf = mac.Sum(nil)
+			s.block++
+		}
+		v := binary.BigEndian.Uint64(s.buf[:8]) >> 1
+		s.buf = s.buf[8:]
+		if v < limit {
+			return int64(v % uint64(n)), nil
+		}
+	}
+}
+
+// HashServerSeed возвращает публикуемый хэш серверного сида.
+func HashServerSeed(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// newServerSeed генерирует серверный сид и его хэш.
+func newServerSeed() (seed, hash string, err error) {
+	seed, err = randomHex(serverSeedBytes)
+	if err != nil {
+		return "", "", err
+	}
+	return seed, HashServerSeed(seed), nil
+}
+
+// newClientSeed генерирует клиентский сид, если игрок не задал свой.
+func newClientSeed() (string, error) {
+	return randomHex(clientSeedBytes)
+}
+
+// normalizeClientSeed проверяет клиентский сид, заданный игроком.
+func normalizeClientSeed(raw string) (string, error) {
+	seed := strings.TrimSpace(raw)
+	if seed == "" || len(seed) > maxClientSeedLen || strings.ContainsAny(seed, " \t\n:") {
+		return "", ErrInvalidClientSeed
+	}
+	return seed, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка генерации сида: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// newFairProof собирает доказательство спина.
+func newFairProof(seed *Seed, tier RTPTier, symbols []Symbol) *FairProof {
+	weights := make([]FairWeight, len(symbols))
+	for i, s := range symbols {
+		weights[i] = FairWeight{Emoji: s.Emoji, Weight: s.Weight}
+	}
+	return &FairProof{
+		SeedID:         seed.ID,
+		ServerSeedHash: seed.ServerSeedHash,
+		ClientSeed:     seed.ClientSeed,
+		Nonce:          seed.Nonce,
+		RTPTier:        tier,
+		Weights:        weights,
+	}
+}
+
+// ReplayGrid пересчитывает основную сетку спина по раскрытому серверному сиду.
+func ReplayGrid(serverSeed string, proof *FairProof, reels, rows int) (Grid, error) {
+	if len(proof.Weights) == 0 {
+		return nil, errors.New("в доказательстве нет весов символов")
+	}
+	symbols := make([]Symbol, len(proof.Weights))
+	for i, w := range proof.Weights {
+		symbols[i] = Symbol{Emoji: w.Emoji, Weight: w.Weight}
+	}
+	m := &Machine{Reels: reels, Rows: rows}
+	return m.GenerateGrid(NewFairSource(serverSeed, proof.ClientSeed, proof.Nonce), symbols)
+}
@@ -0,0 +1,74 @@
+package casino
+
+import "testing"
+
+func TestHashServerSeed(t *testing.T) {
+	// SHA-256("abc") из FIPS 180-2
+	const want = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := HashServerSeed("abc"); got != want {
+		t.Fatalf("HashServerSeed = %s, want %s", got, want)
+	}
+}
+
+func TestFairSource_Deterministic(t *testing.T) {
+	draw := func(server, client string, nonce int64) []int64 {
+		src := NewFairSource(server, client, nonce)
+		out := make([]int64, 40) // больше одного блока HMAC
+		for i := range out {
+			v, err := src.Int63n(100)
+			if err != nil {
+				t.Fatalf("Int63n: %v", err)
+			}
+			if v < 0 || v >= 100 {
+				t.Fatalf("value %d out of range", v)
+			}
+			out[i] = v
+		}
+		return out
+	}
+	equal := func(a, b []int64) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	base := draw("server", "client", 1)
+	if !equal(base, draw("server", "client", 1)) {
+		t.Fatal("same inputs must give the same sequence")
+	}
+	if equal(base, draw("server", "client", 2)) || equal(base, draw("server", "other", 1)) || equal(base, draw("other", "client", 1)) {
+		t.Fatal("any input change must change the sequence")
+	}
+}
+
+func TestReplayGrid_MatchesSpin(t *testing.T) {
+	m := ClassicMachine()
+	symbols := m.WeightsForTier(RTPTierBoost)
+	seed := &Seed{ID: 1, ServerSeed: "server", ServerSeedHash: HashServerSeed("server"), ClientSeed: "client", Nonce: 5}
+
+	result, err := m.Spin(NewFairSource(seed.ServerSeed, seed.ClientSeed, seed.Nonce), symbols, 50)
+	if err != nil {
+		t.Fatalf("spin: %v", err)
+	}
+	grid, err := ReplayGrid(seed.ServerSeed, newFairProof(seed, RTPTierBoost, symbols), m.Reels, m.Rows)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !gridsEqual(grid, result.Grid) {
+		t.Fatalf("replayed grid differs:\n%s\n%s", FormatGrid(grid), FormatGrid(result.Grid))
+	}
+}
+
+func TestNormalizeClientSeed(t *testing.T) {
+	for _, bad := range []string{"", "   ", "a b", "a:b", string(make([]byte, maxClientSeedLen+1))} {
+		if _, err := normalizeClientSeed(bad); err == nil {
+			t.Fatalf("client seed %q must be rejected", bad)
+		}
+	}
+	if got, err := normalizeClientSeed("  lucky7 "); err != nil || got != "lucky7" {
+		t.Fatalf("normalizeClientSeed = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,218 @@
+// Package casino — games.go описывает настольные игры с одной ставкой
+// и одним исходом: кости, европейскую рулетку и монетку. Игра только
+// разыгрывает исход; списание ставки, выплата, запись в casino_games
+// и статистика общие для всех игр и живут в Service.playRound.
+package casino
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownGame — запрошенная игра не зарегистрирована.
+var ErrUnknownGame = errors.New("unknown casino game")
+
+// ErrInvalidChoice — ставка игрока не подходит игре.
+var ErrInvalidChoice = errors.New("invalid casino bet choice")
+
+// TableGame — подключаемая игра казино.
+type TableGame interface {
+	// Type возвращает значение casino_games.game_type.
+	Type() string
+	// Title возвращает название игры для сообщений.
+	Title() string
+	// Usage подсказывает, на что можно ставить.
+	Usage() string
+	// ParseChoice нормализует ставку игрока или возвращает ErrInvalidChoice.
+	ParseChoice(raw string) (string, error)
+	// Play разыгрывает исход для нормализованной ставки choice.
+	Play(src RandomSource, choice string) (TableOutcome, error)
+}
+
+// TableOutcome — исход раунда настольной игры.
+type TableOutcome struct {
+	Roll  int    `json:"roll"`  // Выпавшее значение
+	Label string `json:"label"` // Исход для сообщения: «🔴 17», «орёл», «5»
+	Win   bool   `json:"win"`
+	// FairOdds — множитель выплаты при выигрыше без преимущества казино
+	// (монетка — 2, число в рулетке — 37).
+	FairOdds float64 `json:"fair_odds"`
+}
+
+// TableResult — итог раунда настольной игры.
+type TableResult struct {
+	GameID    int64
+	GameType  string
+	Title     string
+	Choice    string
+	Bet       int64
+	Payout    int64
+	HouseEdge float64
+	Outcome   TableOutcome
+}
+
+// tableGameData — содержимое casino_games.game_data настольной игры.
+type tableGameData struct {
+	Choice    string       `json:"choice"`
+	Outcome   TableOutcome `json:"outcome"`
+	HouseEdge float64      `json:"house_edge"`
+}
+
+// tablePayout возвращает выплату выигрыша с учётом преимущества казино,
+// округлённую вниз.
+func tablePayout(bet int64, outcome TableOutcome, houseEdge float64) int64 {
+	if !outcome.Win {
+		return 0
+	}
+	return int64(float64(bet) * outcome.FairOdds * (1 - houseEdge/100))
+}
+
+// normalizeChoice приводит ставку к нижнему регистру и убирает «ё».
+func normalizeChoice(raw string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(raw)), "ё", "е")
+}
+
+// DiceGame — кости: число 1–6, чёт/нечет или больше (4–6)/меньше (1–3).
+// В боте бросок делает Telegram: обработчик передаёт источник, который
+// отправляет анимацию 🎲 и возвращает выпавшее значение.
+type DiceGame struct{}
+
+func (DiceGame) Type() string  { return GameTypeDice }
+func (DiceGame) Title() string { return "🎲 Кости" }
+func (DiceGame) Usage() string {
+	return "число 1–6, чет, нечет, больше (4–6) или меньше (1–3)"
+}
+
+func (DiceGame) ParseChoice(raw string) (string, error) {
+	choice := normalizeChoice(raw)
+	switch choice {
+	case "чет", "нечет", "больше", "меньше":
+		return choice, nil
+	}
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= 6 {
+		return choice, nil
+	}
+	return "", ErrInvalidChoice
+}
+
+func (DiceGame) Play(src RandomSource, choice string) (TableOutcome, error) {
+	n, err := src.Int63n(6)
+	if err != nil {
+		return TableOutcome{}, fmt.Errorf("ошибка броска костей: %w", err)
+	}
+	roll := int(n) + 1
+	outcome := TableOutcome{Roll: roll, Label: strconv.Itoa(roll), FairOdds: 2}
+	switch choice {
+	case "чет":
+		outcome.Win = roll%2 == 0
+	case "нечет":
+		outcome.Win = roll%2 == 1
+	case "больше":
+		outcome.Win = roll >= 4
+	case "меньше":
+		outcome.Win = roll <= 3
+	default:
+		outcome.Win = choice == strconv.Itoa(roll)
+		outcome.FairOdds = 6
+	}
+	return outcome, nil
+}
+
+// rouletteRed — красные числа европейской рулетки.
+var rouletteRed = map[int]bool{
+	1: true, 3: true, 5: true, 7: true, 9: true, 12: true, 14: true, 16: true, 18: true,
+	19: true, 21: true, 23: true, 25: true, 27: true, 30: true, 32: true, 34: true, 36: true,
+}
+
+// rouletteCells — число ячеек европейского колеса (0–36).
+const rouletteCells = 37
+
+// RouletteGame — европейская рулетка: цвет, чётность или число 0–36.
+// Зеро проигрывает ставки на цвет и чётность.
+type RouletteGame struct{}
+
+func (RouletteGame) Type() string  { return GameTypeRoulette }
+func (RouletteGame) Title() string { return "🎡 Рулетка" }
+func (RouletteGame) Usage() string {
+	return "красное, черное, чет, нечет или число 0–36"
+}
+
+func (RouletteGame) ParseChoice(raw string) (string, error) {
+	choice := normalizeChoice(raw)
+	switch choice {
+	case "красное", "красный", "к":
+		return "красное", nil
+	case "черное", "черный", "ч":
+		return "черное", nil
+	case "чет", "нечет":
+		return choice, nil
+	}
+	if n, err := strconv.Atoi(choice); err == nil && n >= 0 && n < rouletteCells {
+		return strconv.Itoa(n), nil
+	}
+	return "", ErrInvalidChoice
+}
+
+func (RouletteGame) Play(src RandomSource, choice string) (TableOutcome, error) {
+	n, err := src.Int63n(rouletteCells)
+	if err != nil {
+		return TableOutcome{}, fmt.Errorf("ошибка запуска рулетки: %w", err)
+	}
+	pocket := int(n)
+	outcome := TableOutcome{Roll: pocket, Label: rouletteLabel(pocket), FairOdds: float64(rouletteCells) / 18}
+	switch choice {
+	case "красное":
+		outcome.Win = rouletteRed[pocket]
+	case "черное":
+		outcome.Win = pocket != 0 && !rouletteRed[pocket]
+	case "чет":
+		outcome.Win = pocket != 0 && pocket%2 == 0
+	case "нечет":
+		outcome.Win = pocket%2 == 1
+	default:
+		outcome.Win = choice == strconv.Itoa(pocket)
+		outcome.FairOdds = rouletteCells
+	}
+	return outcome, nil
+}
+
+func rouletteLabel(pocket int) string {
+	switch {
+	case pocket == 0:
+		return "🟢 0"
+	case rouletteRed[pocket]:
+		return fmt.Sprintf("🔴 %d", pocket)
+	default:
+		return fmt.Sprintf("⚫ %d", pocket)
+	}
+}
+
+// CoinFlipGame — монетка: орёл или решка.
+type CoinFlipGame struct{}
+
+func (CoinFlipGame) Type() string  { return GameTypeCoinFlip }
+func (CoinFlipGame) Title() string { return "🪙 Монетка" }
+func (CoinFlipGame) Usage() string { return "орел или решка" }
+
+func (CoinFlipGame) ParseChoice(raw string) (string, error) {
+	choice := normalizeChoice(raw)
+	switch choice {
+	case "орел", "решка":
+		return choice, nil
+	}
+	return "", ErrInvalidChoice
+}
+
+func (CoinFlipGame) Play(src RandomSource, choice string) (TableOutcome, error) {
+	n, err := src.Int63n(2)
+	if err != nil {
+		return TableOutcome{}, fmt.Errorf("ошибка броска монетки: %w", err)
+	}
+	side := "орел"
+	if n == 1 {
+		side = "решка"
+	}
+	return TableOutcome{Roll: int(n), Label: side, Win: side == choice, FairOdds: 2}, nil
+}
@@ -0,0 +1,211 @@
+package casino
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+)
+
+func TestTableGames_Outcomes(t *testing.T) {
+	tests := []struct {
+		name   string
+		game   TableGame
+		choice string
+		roll   int64 // значение источника
+		label  string
+		win    bool
+		odds   float64
+	}{
+		{name: "dice exact hit", game: DiceGame{}, choice: "5", roll: 4, label: "5", win: true, odds: 6},
+		{name: "dice exact miss", game: DiceGame{}, choice: "5", roll: 0, label: "1", odds: 6},
+		{name: "dice even", game: DiceGame{}, choice: "чёт", roll: 1, label: "2", win: true, odds: 2},
+		{name: "dice high", game: DiceGame{}, choice: "больше", roll: 2, label: "3", odds: 2},
+		{name: "roulette red", game: RouletteGame{}, choice: "Красное", roll: 1, label: "🔴 1", win: true, odds: 37.0 / 18},
+		{name: "roulette black on red", game: RouletteGame{}, choice: "ч", roll: 1, label: "🔴 1", odds: 37.0 / 18},
+		{name: "roulette zero loses parity", game: RouletteGame{}, choice: "чет", roll: 0, label: "🟢 0", odds: 37.0 / 18},
+		{name: "roulette zero number", game: RouletteGame{}, choice: "0", roll: 0, label: "🟢 0", win: true, odds: 37},
+		{name: "roulette black", game: RouletteGame{}, choice: "черное", roll: 2, label: "⚫ 2", win: true, odds: 37.0 / 18},
+		{name: "coin heads", game: CoinFlipGame{}, choice: "Орёл", roll: 0, label: "орел", win: true, odds: 2},
+		{name: "coin tails", game: CoinFlipGame{}, choice: "орел", roll: 1, label: "решка", odds: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choice, err := tt.game.ParseChoice(tt.choice)
+			if err != nil {
+				t.Fatalf("parse choice %q: %v", tt.choice, err)
+			}
+			outcome, err := tt.game.Play(fixedSource(tt.roll), choice)
+			if err != nil {
+				t.Fatalf("play: %v", err)
+			}
+			if outcome.Label != tt.label || outcome.Win != tt.win || outcome.FairOdds != tt.odds {
+				t.Fatalf("outcome = %+v, want label=%q win=%v odds=%v", outcome, tt.label, tt.win, tt.odds)
+			}
+		})
+	}
+}
+
+func TestTableGames_RejectInvalidChoice(t *testing.T) {
+	cases := map[TableGame][]string{
+		DiceGame{}:     {"0", "7", "зеро"},
+		RouletteGame{}: {"37", "-1", "зелёное"},
+		CoinFlipGame{}: {"ребро", ""},
+	}
+	for game, choices := range cases {
+		for _, choice := range choices {
+			if _, err := game.ParseChoice(choice); !errors.Is(err, ErrInvalidChoice) {
+				t.Fatalf("%s: choice %q must be rejected, got %v", game.Type(), choice, err)
+			}
+		}
+	}
+}
+
+func TestTablePayout_AppliesHouseEdge(t *testing.T) {
+	win := TableOutcome{Win: true, FairOdds: 2}
+	if got := tablePayout(100, win, 3); got != 194 {
+		t.Fatalf("payout = %d, want 194", got)
+	}
+	if got := tablePayout(100, TableOutcome{FairOdds: 2}, 3); got != 0 {
+		t.Fatalf("loss payout = %d, want 0", got)
+	}
+}
+
+func TestPlayTableGame_SharesSettlementWithSlots(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+	svc.cfg.CasinoCoinHouseEdge = 3
+	svc.cfg.CasinoJackpotPercent = 5
+
+	result, err := svc.PlayTableGame(context.Background(), 7, GameTypeCoinFlip, 100, "орёл", fixedSource(0))
+	if err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if !result.Outcome.Win || result.Payout != 194 || result.GameID != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if store.balances[7] != 1094 {
+		t.Fatalf("balance = %d, want 1094", store.balances[7])
+	}
+	// Настольные игры не пополняют джекпот слотов
+	if store.jackpot.Amount != 0 || sumByType(store.entries, economy.TxTypeCasinoBet) != -100 {
+		t.Fatalf("unexpected ledger: %+v pool=%d", store.entries, store.jackpot.Amount)
+	}
+	if sumByType(store.entries, economy.TxTypeCasinoWin) != 194 {
+		t.Fatalf("casino_win missing: %+v", store.entries)
+	}
+	game := store.games[0]
+	if game.GameType != GameTypeCoinFlip || game.BetAmount != 100 || game.ResultAmount != 194 {
+		t.Fatalf("unexpected game: %+v", game)
+	}
+	var data tableGameData
+	if err := json.Unmarshal(game.GameData, &data); err != nil || data.Choice != "орел" || data.HouseEdge != 3 {
+		t.Fatalf("unexpected game data: %s (%v)", game.GameData, err)
+	}
+	if stats := store.stats[7]; stats.TotalSpins != 1 || stats.TotalWagered != 100 || stats.TotalWon != 194 {
+		t.Fatalf("stats must aggregate table games: %+v", stats)
+	}
+	if u := store.daily[7]; u.Wagered != 100 || u.Won != 194 {
+		t.Fatalf("daily usage must count table games: %+v", u)
+	}
+}
+
+func TestPlayTableGame_Errors(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 30
+	svc := newTestService(store)
+	ctx := context.Background()
+
+	if _, err := svc.PlayTableGame(ctx, 7, "покер", 0, "x", nil); !errors.Is(err, ErrUnknownGame) {
+		t.Fatalf("expected ErrUnknownGame, got %v", err)
+	}
+	if _, err := svc.PlayTableGame(ctx, 7, GameTypeRoulette, 0, "зелёное", nil); !errors.Is(err, ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice, got %v", err)
+	}
+	if _, err := svc.PlayTableGame(ctx, 7, GameTypeDice, 5, "6", nil); err == nil {
+		t.Fatal("bet below minimum must be refused")
+	}
+
+	// Без денег на ставку кубик не бросается
+	rolled := false
+	src := sourceFunc(func(n int64) (int64, error) { rolled = true; return 0, nil })
+	if _, err := svc.PlayTableGame(ctx, 7, GameTypeDice, 50, "6", src); err == nil || rolled {
+		t.Fatalf("dice must not roll without funds: err=%v rolled=%v", err, rolled)
+	}
+	if len(store.games) != 0 || store.balances[7] != 30 {
+		t.Fatalf("failed rounds left side effects: games=%v balance=%d", store.games, store.balances[7])
+	}
+}
+
+func TestPlayTableGame_RollsOutsideTransaction(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+
+	rolledInTx := false
+	src := sourceFunc(func(n int64) (int64, error) {
+		rolledInTx = store.inTx
+		return 5, nil
+	})
+	result, err := svc.PlayTableGame(context.Background(), 7, GameTypeDice, 100, "6", src)
+	if err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if rolledInTx {
+		t.Fatal("dice must be rolled before the settlement transaction")
+	}
+	if result.Outcome.Label != "6" || !result.Outcome.Win || len(store.games) != 1 {
+		t.Fatalf("round must settle the rolled value: %+v games=%d", result, len(store.games))
+	}
+}
+
+func TestPlayTableGame_UsesPerGameBetLimits(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 10000
+	svc := newTestService(store)
+	svc.cfg.CasinoSlotsMaxBet = 100
+	svc.cfg.CasinoCoinMinBet, svc.cfg.CasinoCoinMaxBet = 100, 5000
+	ctx := context.Background()
+
+	if _, err := svc.PlayTableGame(ctx, 7, GameTypeCoinFlip, 2000, "орёл", fixedSource(0)); err != nil {
+		t.Fatalf("coin bet within its own limits must pass: %v", err)
+	}
+	if _, err := svc.PlayTableGame(ctx, 7, GameTypeCoinFlip, 50, "орёл", fixedSource(0)); !errors.Is(err, common.ErrCasinoBetOutOfRange) {
+		t.Fatalf("coin bet below its minimum must be refused, got %v", err)
+	}
+	// Ставка по умолчанию прижимается к границам игры
+	if got := svc.DefaultBet(GameTypeCoinFlip); got != 100 {
+		t.Fatalf("default coin bet = %d, want 100", got)
+	}
+}
+
+type sourceFunc func(n int64) (int64, error)
+
+func (f sourceFunc) Int63n(n int64) (int64, error) { return f(n) }
+
+func TestParseTableArgs(t *testing.T) {
+	tests := []struct {
+		args   []string
+		bet    int64
+		choice string
+		ok     bool
+	}{
+		{args: []string{"орёл"}, choice: "орёл", ok: true},
+		{args: []string{"100", "17"}, bet: 100, choice: "17", ok: true},
+		{args: []string{"17"}, choice: "17", ok: true},
+		{args: []string{"x", "орёл"}},
+		{args: []string{"0", "орёл"}},
+		{args: nil},
+	}
+	for _, tt := range tests {
+		bet, choice, ok := parseTableArgs(tt.args)
+		if bet != tt.bet || choice != tt.choice || ok != tt.ok {
+			t.Fatalf("parseTableArgs(%q) = %d, %q, %v", tt.args, bet, choice, ok)
+		}
+	}
+}
@@ -1,26 +1,42 @@
-// Package casino — handlers.go обрабатывает команды !слоты и !статслоты.
+// Package casino — handlers.go обрабатывает команды !слоты, !статслоты, !сид,
+// !проверить, !игра, !топслоты, настольные игры !кости, !рулетка, !монетка, дуэли
+// !дуэль и ответственную игру !самоисключение, !лимиты.
 package casino
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	models "github.com/mymmrac/telego"
 	log "github.com/sirupsen/logrus"
 
+	"serotonyl.ru/telegram-bot/internal/commands"
 	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/config"
+	"serotonyl.ru/telegram-bot/internal/features/members"
 	"serotonyl.ru/telegram-bot/internal/telegram"
 )
 
+type memberLookup interface {
+	GetByUserID(ctx context.Context, userID int64) (*members.Member, error)
+	GetByUsername(ctx context.Context, username string) (*members.Member, error)
+}
+
 // Handler обрабатывает команды казино.
 type Handler struct {
 	service *Service
+	members memberLookup
 	tgOps   *telegram.Ops
+	cfg     *config.Config
 }
 
 // NewHandler создаёт обработчик казино.
-func NewHandler(service *Service, tgOps *telegram.Ops) *Handler {
-	return &Handler{service: service, tgOps: tgOps}
+func NewHandler(service *Service, members memberLookup, tgOps *telegram.Ops, cfg *config.Config) *Handler {
+	return &Handler{service: service, members: members, tgOps: tgOps, cfg: cfg}
 }
 
 // HandleSlots обрабатывает команду !слоты — спин слот-машины.
@@ -36,18 +52,29 @@ func NewHandler(service *Service, tgOps *telegram.Ops) *Handler {
 //
 //	💰 Выплата: 100 пленок (2x)
 //	📊 Баланс: 150 пленок
-func (h *Handler) HandleSlots(ctx context.Context, chatID int64, userID int64) {
+//
+// `!слоты <машина>` играет на именованной машине из файла машин,
+// `!слоты 200` — со ставкой 200; аргументы можно сочетать в любом порядке.
+func (h *Handler) HandleSlots(ctx context.Context, chatID int64, userID int64, args []string) {
+	machineName, bet, ok := parseSlotsArgs(args)
+	if !ok {
+		h.sendMessage(ctx, chatID, "❌ Формат: !слоты [ставка] [машина]")
+		return
+	}
+	if bet == 0 {
+		bet, _, _ = h.service.BetLimits()
+	}
+
 	// Выполняем спин
-	result, err := h.service.PlaySlots(ctx, userID)
+	result, err := h.service.PlaySlots(ctx, userID, machineName, bet)
 	if err != nil {
 		// Проверяем тип ошибки для понятного сообщения
-		if strings.Contains(err.Error(), "недостаточно") {
-			h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Недостаточно плёнок! Ставка: %s",
-				common.FormatBalance(h.service.cfg.CasinoSlotsBet)))
-		} else {
-			log.WithError(err).Error("Ошибка спина слотов")
-			h.sendMessage(ctx, chatID, "❌ Ошибка при игре в слоты")
+		if errors.Is(err, ErrUnknownMachine) {
+			h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Нет такой машины. Доступны: %s",
+				strings.Join(h.service.MachineNames(), ", ")))
+			return
 		}
+		h.replyPlayError(ctx, chatID, userID, GameTypeSlots, err, bet, "Ошибка спина слотов", "❌ Ошибка при игре в слоты")
 		return
 	}
 
@@ -69,7 +96,7 @@ func (h *Handler) HandleSlots(ctx context.Context, chatID int64, userID int64) {
 	}
 
 	// Скаттер-бонус
-	if result.ScatterCount >= 3 {
+	if result.ScatterWin > 0 || result.FreeSpins > 0 {
 		fmt.Fprintf(&sb, "\n🎰 Скаттер бонус! %d скаттеров → +%s",
 			result.ScatterCount, common.FormatBalance(result.ScatterWin))
 		if result.FreeSpins > 0 {
@@ -78,8 +105,13 @@ func (h *Handler) HandleSlots(ctx context.Context, chatID int64, userID int64) {
 		sb.WriteString("\n")
 	}
 
+	// Джекпот
+	if result.JackpotWin > 0 {
+		fmt.Fprintf(&sb, "\n🏆 ДЖЕКПОТ! Пул %s твой!\n", common.FormatBalance(result.JackpotWin))
+	}
+
 	// Итог
-	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "\n🎟 Ставка: %s\n", common.FormatBalance(result.Bet))
 	if result.IsWin {
 		fmt.Fprintf(&sb, "💰 Выплата: %s\n", common.FormatBalance(result.TotalPayout))
 	} else {
@@ -89,8 +121,165 @@ func (h *Handler) HandleSlots(ctx context.Context, chatID int64, userID int64) {
 	// Текущий баланс
 	balance, _ := h.service.economyService.GetBalance(ctx, userID)
 	fmt.Fprintf(&sb, "📊 Баланс: %s", common.FormatBalance(balance))
+	if result.Fair != nil {
+		fmt.Fprintf(&sb, "\n🔐 Игра #%d · nonce %d", result.GameID, result.Fair.Nonce)
+	}
 
 	h.sendMessage(ctx, chatID, sb.String())
+
+	if result.JackpotWin > 0 {
+		h.announceJackpot(ctx, userID, result.JackpotWin)
+	}
+}
+
+// announceJackpot объявляет выигрыш джекпота в чате участников.
+func (h *Handler) announceJackpot(ctx context.Context, userID, amount int64) {
+	if h.cfg == nil || h.cfg.MemberSourceChatID == 0 {
+		return
+	}
+	text := fmt.Sprintf("🎰🏆 %s сорвал(а) джекпот в слотах: %s! Пул начинает копиться заново.",
+		h.displayName(ctx, userID), common.FormatBalance(amount))
+	h.sendMessage(ctx, h.cfg.MemberSourceChatID, text)
+}
+
+// HandleTableGame обрабатывает !кости, !рулетка и !монетка:
+// `!монетка орёл` — ставка по умолчанию, `!монетка 100 орёл` — ставка 100.
+// Кости бросает Telegram нативной анимацией 🎲.
+func (h *Handler) HandleTableGame(ctx context.Context, chatID int64, userID int64, gameType string, args []string) {
+	game, ok := h.service.TableGame(gameType)
+	if !ok {
+		return
+	}
+	bet, choice, ok := parseTableArgs(args)
+	if !ok {
+		h.sendMessage(ctx, chatID, fmt.Sprintf("%s\nФормат: [ставка] <исход>, исход — %s", game.Title(), game.Usage()))
+		return
+	}
+	if bet == 0 {
+		bet = h.service.DefaultBet(gameType)
+	}
+
+	var src RandomSource
+	if gameType == GameTypeDice {
+		src = &telegramDice{ctx: ctx, ops: h.tgOps, chatID: chatID}
+	}
+	result, err := h.service.PlayTableGame(ctx, userID, gameType, bet, choice, src)
+	if err != nil {
+		if errors.Is(err, ErrInvalidChoice) {
+			h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Не понял ставку. %s: %s", game.Title(), game.Usage()))
+			return
+		}
+		userMsg := "❌ Ошибка при игре"
+		if gameType == GameTypeDice {
+			// Кубик мог уже выпасть в чате: объясняем, что бросок не засчитан.
+			userMsg = "❌ Ошибка при игре: бросок не засчитан, ставка не списана"
+		}
+		h.replyPlayError(ctx, chatID, userID, gameType, err, bet, "Ошибка настольной игры казино", userMsg)
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n\nСтавка: %s на «%s»\nВыпало: %s\n\n",
+		result.Title, common.FormatBalance(result.Bet), result.Choice, result.Outcome.Label)
+	if result.Outcome.Win {
+		fmt.Fprintf(&sb, "💰 Выигрыш: %s\n", common.FormatBalance(result.Payout))
+	} else {
+		sb.WriteString("💸 Мимо\n")
+	}
+	balance, _ := h.service.economyService.GetBalance(ctx, userID)
+	fmt.Fprintf(&sb, "📊 Баланс: %s", common.FormatBalance(balance))
+	h.sendMessage(ctx, chatID, sb.String())
+}
+
+// replyPlayError отвечает на ошибку раунда игры gameType, общую для всех игр казино.
+func (h *Handler) replyPlayError(ctx context.Context, chatID, userID int64, gameType string, err error, bet int64, logMsg, userMsg string) {
+	switch {
+	case errors.Is(err, common.ErrCasinoSelfExcluded):
+		h.sendMessage(ctx, chatID, h.exclusionRefusal(ctx, userID))
+	case errors.Is(err, common.ErrCasinoBetOutOfRange):
+		limits := h.service.BetRange(gameType)
+		h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Ставка должна быть от %s до %s",
+			common.FormatBalance(limits.Min), common.FormatBalance(limits.Max)))
+	case errors.Is(err, common.ErrCasinoDailyWagerCap):
+		wagerCap, _ := h.service.DailyCaps()
+		h.sendMessage(ctx, chatID, fmt.Sprintf("⛔ Дневной лимит ставок %s исчерпан. Приходи завтра!",
+			common.FormatBalance(wagerCap)))
+	case errors.Is(err, common.ErrCasinoDailyLossCap), errors.Is(err, common.ErrCasinoWeeklyLossCap):
+		h.sendMessage(ctx, chatID, h.lossCapRefusal(ctx, userID, err))
+	case errors.Is(err, common.ErrInsufficientBalance):
+		h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Недостаточно плёнок! Ставка: %s",
+			common.FormatBalance(bet)))
+	default:
+		log.WithError(err).Error(logMsg)
+		h.sendMessage(ctx, chatID, userMsg)
+	}
+}
+
+// exclusionRefusal объясняет самоисключённому игроку, почему ставка не принята.
+func (h *Handler) exclusionRefusal(ctx context.Context, userID int64) string {
+	exclusion, err := h.service.ActiveExclusion(ctx, userID)
+	if err != nil || exclusion == nil {
+		return "🚫 Казино для тебя закрыто по самоисключению."
+	}
+	return fmt.Sprintf("🚫 Казино для тебя закрыто по самоисключению до %s. "+
+		"Досрочно снять его может только администратор.", common.FormatDateTime(exclusion.EndsAt))
+}
+
+// lossCapRefusal объясняет, какой лимит проигрыша превысит ставка.
+func (h *Handler) lossCapRefusal(ctx context.Context, userID int64, err error) string {
+	weekly := errors.Is(err, common.ErrCasinoWeeklyLossCap)
+	caps, capsErr := h.service.LossCaps(ctx, userID)
+	if capsErr != nil {
+		if weekly {
+			return "⛔ Эта ставка превысит недельный лимит проигрыша."
+		}
+		return "⛔ Эта ставка превысит дневной лимит проигрыша. Приходи завтра!"
+	}
+	if weekly {
+		return fmt.Sprintf("⛔ Эта ставка превысит недельный лимит проигрыша %s. Приходи в понедельник!",
+			common.FormatBalance(caps.Weekly))
+	}
+	return fmt.Sprintf("⛔ Эта ставка превысит дневной лимит проигрыша %s. Приходи завтра!",
+		common.FormatBalance(caps.Daily))
+}
+
+// telegramDice — источник исхода костей: бросок делает Telegram,
+// анимация 🎲 уходит в чат, а выпавшее значение становится исходом.
+type telegramDice struct {
+	ctx    context.Context
+	ops    *telegram.Ops
+	chatID int64
+}
+
+func (d *telegramDice) Int63n(n int64) (int64, error) {
+	if n != 6 {
+		return 0, fmt.Errorf("кубик Telegram даёт 6 значений, запрошено %d", n)
+	}
+	_, value, err := d.ops.SendDice(d.ctx, d.chatID, "🎲")
+	if err != nil {
+		return 0, err
+	}
+	if value < 1 || value > 6 {
+		return 0, fmt.Errorf("кубик Telegram вернул %d", value)
+	}
+	return int64(value - 1), nil
+}
+
+// parseTableArgs разбирает аргументы настольной игры: `<исход>` или `<ставка> <исход>`.
+// Ставка 0 означает ставку по умолчанию.
+func parseTableArgs(args []string) (bet int64, choice string, ok bool) {
+	switch len(args) {
+	case 1:
+		return 0, args[0], true
+	case 2:
+		n, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || n <= 0 {
+			return 0, "", false
+		}
+		return n, args[1], true
+	default:
+		return 0, "", false
+	}
 }
 
 // HandleSlotStats обрабатывает команду !статслоты — статистика.
@@ -103,7 +292,11 @@ func (h *Handler) HandleSlots(ctx context.Context, chatID int64, userID int64) {
 //	Выиграно: 2 120 пленок
 //	Чистая прибыль: -230 пленок
 //	💎 Лучший выигрыш: 1 500 пленок
+//	🎟 Средняя ставка: 50 пленок
 //	📈 Твой RTP: 90.21%
+//
+//	📅 Сегодня: поставлено 300 из 10 000, проигрыш 120 из 5 000
+//	🏆 Джекпот: 12 345 пленок
 func (h *Handler) HandleSlotStats(ctx context.Context, chatID int64, userID int64) {
 	stats, err := h.service.GetStats(ctx, userID)
 	if err != nil {
@@ -112,6 +305,11 @@ func (h *Handler) HandleSlotStats(ctx context.Context, chatID int64, userID int6
 	}
 
 	netProfit := stats.TotalWon - stats.TotalWagered
+	// Ставки бывают разными, поэтому средняя ставка считается по обороту
+	var avgBet int64
+	if stats.TotalSpins > 0 {
+		avgBet = stats.TotalWagered / int64(stats.TotalSpins)
+	}
 	profitSign := ""
 	if netProfit > 0 {
 		profitSign = "+"
@@ -124,18 +322,728 @@ func (h *Handler) HandleSlotStats(ctx context.Context, chatID int64, userID int6
 			"Выиграно: %s%s\n"+
 			"Чистая прибыль: %s%s%s\n\n"+
 			"💎 Лучший выигрыш: %s%s\n"+
+			"🎟 Средняя ставка: %s%s\n"+
 			"📈 Твой RTP: %.2f%%",
 		stats.TotalSpins,
 		common.FormatNumber(stats.TotalWagered), common.PluralizeFilms(stats.TotalWagered),
 		common.FormatNumber(stats.TotalWon), common.PluralizeFilms(stats.TotalWon),
 		profitSign, common.FormatNumber(netProfit), common.PluralizeFilms(netProfit),
 		common.FormatNumber(stats.BiggestWin), common.PluralizeFilms(stats.BiggestWin),
+		common.FormatNumber(avgBet), common.PluralizeFilms(avgBet),
 		stats.CurrentRTP,
 	)
 
+	if usage, err := h.service.GetDailyUsage(ctx, userID); err != nil {
+		log.WithError(err).Warn("Не удалось загрузить дневные лимиты казино")
+	} else if caps, err := h.service.LossCaps(ctx, userID); err != nil {
+		log.WithError(err).Warn("Не удалось загрузить лимиты проигрыша казино")
+	} else {
+		wagerCap, _ := h.service.DailyCaps()
+		text += "\n\n" + formatDailyUsage(usage, wagerCap, caps)
+	}
+	if jackpot, err := h.service.GetJackpot(ctx); err != nil {
+		log.WithError(err).Warn("Не удалось загрузить джекпот")
+	} else {
+		text += "\n🏆 Джекпот: " + common.FormatBalance(jackpot.Amount)
+	}
+
 	h.sendMessage(ctx, chatID, text)
 }
 
+const (
+	topSlotsOverviewLimit = 5
+	topSlotsMetricLimit   = 10
+)
+
+// HandleTopSlots обрабатывает !топслоты [выигрыш|профит|спины] [неделя].
+// Без показателя выводит все три рейтинга коротко, с показателем — один
+// подробнее. «неделя» ограничивает рейтинг текущей неделей.
+func (h *Handler) HandleTopSlots(ctx context.Context, chatID int64, args []string) {
+	metrics, weekly, ok := parseTopSlotsArgs(args)
+	if !ok {
+		h.sendMessage(ctx, chatID, "❌ Формат: !топслоты [выигрыш|профит|спины] [неделя]")
+		return
+	}
+	limit := topSlotsMetricLimit
+	if len(metrics) > 1 {
+		limit = topSlotsOverviewLimit
+	}
+
+	title := "🏆 ТОП КАЗИНО ЗА ВСЁ ВРЕМЯ"
+	if weekly {
+		title = "🏆 ТОП КАЗИНО ЗА НЕДЕЛЮ"
+	}
+	sections := []string{title}
+	for _, metric := range metrics {
+		entries, err := h.service.Leaderboard(ctx, metric, weekly, limit)
+		if err != nil {
+			log.WithError(err).WithField("metric", metric).Error("Ошибка загрузки рейтинга казино")
+			h.sendMessage(ctx, chatID, "❌ Не удалось загрузить рейтинг")
+			return
+		}
+		sections = append(sections, h.formatLeaderboard(ctx, metric, entries))
+	}
+	h.sendMessage(ctx, chatID, strings.Join(sections, "\n\n"))
+}
+
+// formatLeaderboard форматирует один рейтинг.
+func (h *Handler) formatLeaderboard(ctx context.Context, metric LeaderboardMetric, entries []LeaderboardEntry) string {
+	lines := []string{leaderboardTitle(metric)}
+	if len(entries) == 0 {
+		return lines[0] + "\nПока никого."
+	}
+	for i, e := range entries {
+		value := common.FormatBalance(e.Value)
+		switch metric {
+		case LeaderboardNetProfit:
+			value = formatSigned(e.Value)
+		case LeaderboardSpins:
+			value = common.FormatNumber(e.Value)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s — %s", i+1, h.displayName(ctx, e.UserID), value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func leaderboardTitle(metric LeaderboardMetric) string {
+	switch metric {
+	case LeaderboardNetProfit:
+		return "📈 Чистая прибыль"
+	case LeaderboardSpins:
+		return "🎲 Больше всех игр"
+	default:
+		return "💎 Крупнейший выигрыш"
+	}
+}
+
+// parseTopSlotsArgs разбирает аргументы !топслоты в любом порядке.
+func parseTopSlotsArgs(args []string) (metrics []LeaderboardMetric, weekly, ok bool) {
+	for _, arg := range args {
+		var metric LeaderboardMetric
+		switch normalizeChoice(arg) {
+		case "неделя", "нед":
+			if weekly {
+				return nil, false, false
+			}
+			weekly = true
+			continue
+		case "выигрыш", "выигрыши":
+			metric = LeaderboardBiggestWin
+		case "профит", "прибыль":
+			metric = LeaderboardNetProfit
+		case "спины", "игры":
+			metric = LeaderboardSpins
+		default:
+			return nil, false, false
+		}
+		if metrics != nil {
+			return nil, false, false
+		}
+		metrics = []LeaderboardMetric{metric}
+	}
+	if metrics == nil {
+		metrics = LeaderboardMetrics
+	}
+	return metrics, weekly, true
+}
+
+// HandleSeed обрабатывает команду !сид.
+//
+//	!сид                   — хэш текущего серверного сида, клиентский сид и nonce
+//	!сид сменить [сид]     — раскрыть серверный сид и завести новый
+func (h *Handler) HandleSeed(ctx context.Context, chatID int64, userID int64, args []string) {
+	if len(args) > 0 && strings.EqualFold(args[0], "сменить") {
+		clientSeed := ""
+		if len(args) > 1 {
+			clientSeed = strings.Join(args[1:], " ")
+		}
+		h.rotateSeed(ctx, chatID, userID, clientSeed)
+		return
+	}
+	if len(args) > 0 {
+		h.sendMessage(ctx, chatID, "❌ Формат: !сид или !сид сменить [клиентский сид]")
+		return
+	}
+
+	seed, err := h.service.CurrentSeed(ctx, userID)
+	if err != nil {
+		log.WithError(err).Error("Ошибка загрузки сида казино")
+		h.sendMessage(ctx, chatID, "❌ Не удалось загрузить сид")
+		return
+	}
+	h.sendMessage(ctx, chatID, fmt.Sprintf(
+		"🔐 ЧЕСТНЫЕ СПИНЫ\n\n"+
+			"Хэш серверного сида:\n%s\n"+
+			"Клиентский сид: %s\n"+
+			"Сыграно спинов: %d\n\n"+
+			"Серверный сид раскроется после !сид сменить — тогда любую игру можно проверить через !проверить <номер игры>.",
+		seed.ServerSeedHash, seed.ClientSeed, seed.Nonce,
+	))
+}
+
+func (h *Handler) rotateSeed(ctx context.Context, chatID, userID int64, clientSeed string) {
+	revealed, next, err := h.service.RotateSeed(ctx, userID, clientSeed)
+	if err != nil {
+		if errors.Is(err, ErrInvalidClientSeed) {
+			h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Клиентский сид — до %d символов, без пробелов и двоеточий", maxClientSeedLen))
+			return
+		}
+		log.WithError(err).Error("Ошибка смены сида казино")
+		h.sendMessage(ctx, chatID, "❌ Не удалось сменить сид")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🔄 СИД СМЕНЁН\n\n")
+	if revealed != nil {
+		fmt.Fprintf(&sb, "Раскрытый серверный сид:\n%s\nЕго хэш: %s\nКлиентский сид: %s, спинов: %d\n\n",
+			revealed.ServerSeed, revealed.ServerSeedHash, revealed.ClientSeed, revealed.Nonce)
+	}
+	fmt.Fprintf(&sb, "Новый хэш серверного сида:\n%s\nКлиентский сид: %s", next.ServerSeedHash, next.ClientSeed)
+	h.sendMessage(ctx, chatID, sb.String())
+}
+
+// HandleVerify обрабатывает команду !проверить <номер игры>.
+func (h *Handler) HandleVerify(ctx context.Context, chatID int64, args []string) {
+	if len(args) != 1 {
+		h.sendMessage(ctx, chatID, "❌ Формат: !проверить <номер игры>")
+		return
+	}
+	gameID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil || gameID <= 0 {
+		h.sendMessage(ctx, chatID, "❌ Номер игры — положительное число")
+		return
+	}
+
+	v, err := h.service.VerifyGame(ctx, gameID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrGameNotFound):
+			h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Игра #%d не найдена", gameID))
+		case errors.Is(err, ErrGameNotFair):
+			h.sendMessage(ctx, chatID, fmt.Sprintf("ℹ️ Игра #%d сыграна до включения честного режима", gameID))
+		default:
+			log.WithError(err).Error("Ошибка проверки игры казино")
+			h.sendMessage(ctx, chatID, "❌ Не удалось проверить игру")
+		}
+		return
+	}
+	h.sendMessage(ctx, chatID, formatVerification(v))
+}
+
+// HandleGame обрабатывает !игра <номер> — показывает владельцу сыгранный
+// спин слотов: сетку, выигрышные линии с формой, скаттеры и фриспины.
+func (h *Handler) HandleGame(ctx context.Context, chatID, userID int64, args []string) {
+	if len(args) != 1 {
+		h.sendMessage(ctx, chatID, "❌ Формат: !игра <номер игры>")
+		return
+	}
+	gameID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil || gameID <= 0 {
+		h.sendMessage(ctx, chatID, "❌ Номер игры — положительное число")
+		return
+	}
+
+	replay, err := h.service.ReplayOwnGame(ctx, gameID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrGameNotFound):
+			h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Среди твоих игр нет #%d", gameID))
+		case errors.Is(err, ErrNotSlotGame):
+			h.sendMessage(ctx, chatID, fmt.Sprintf("ℹ️ Игра #%d сыграна не в слотах", gameID))
+		default:
+			log.WithError(err).Error("Ошибка загрузки игры казино")
+			h.sendMessage(ctx, chatID, "❌ Не удалось загрузить игру")
+		}
+		return
+	}
+	h.sendMessage(ctx, chatID, FormatGameReplay(replay))
+}
+
+// formatVerification описывает проверку игры.
+func formatVerification(v *GameVerification) string {
+	proof := v.Data.Fair
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔍 ПРОВЕРКА ИГРЫ #%d\n\n", v.Game.ID)
+	fmt.Fprintf(&sb, "Хэш серверного сида: %s\nКлиентский сид: %s\nNonce: %d\nСтупень RTP: %s\n",
+		proof.ServerSeedHash, proof.ClientSeed, proof.Nonce, proof.RTPTier.Label())
+
+	if !v.Seed.Revealed() {
+		sb.WriteString("\n🔒 Серверный сид ещё активен. Владелец игры раскроет его командой !сид сменить.")
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "Серверный сид: %s\n\n", v.Seed.ServerSeed)
+	if v.HashMatches {
+		sb.WriteString("✅ Хэш сида совпадает с опубликованным\n")
+	} else {
+		sb.WriteString("❌ Хэш сида НЕ совпадает с опубликованным\n")
+	}
+	if v.GridMatches {
+		sb.WriteString("✅ Сетка пересчитана и совпадает с сыгранной\n\n")
+	} else {
+		sb.WriteString("❌ Пересчитанная сетка НЕ совпадает с сыгранной\n\n")
+	}
+	sb.WriteString(FormatGrid(v.Replayed))
+	return sb.String()
+}
+
+// formatDailyUsage описывает дневной учёт игрока относительно лимитов.
+func formatDailyUsage(usage *DailyUsage, wagerCap int64, caps *LossCaps) string {
+	wagered := common.FormatNumber(usage.Wagered)
+	if wagerCap > 0 {
+		wagered += " из " + common.FormatNumber(wagerCap)
+	}
+	return fmt.Sprintf("📅 Сегодня: поставлено %s, проигрыш %s\n🗓 Неделя: проигрыш %s",
+		wagered, formatLossOfCap(usage.NetLoss(), caps.Daily), formatLossOfCap(caps.WeekLoss, caps.Weekly))
+}
+
+// formatLossOfCap форматирует проигрыш «120 из 5 000» (без лимита — просто число).
+func formatLossOfCap(loss, limit int64) string {
+	if loss < 0 {
+		loss = 0
+	}
+	text := common.FormatNumber(loss)
+	if limit > 0 {
+		text += " из " + common.FormatNumber(limit)
+	}
+	return text
+}
+
+// HandleSelfExclusion обрабатывает !самоисключение <срок> — закрывает игроку
+// казино на срок: «7д», «2н», «1м» или число дней. Без аргументов показывает
+// действующее исключение. Сократить срок может только администратор.
+func (h *Handler) HandleSelfExclusion(ctx context.Context, chatID, userID int64, args []string) {
+	if len(args) == 0 {
+		exclusion, err := h.service.ActiveExclusion(ctx, userID)
+		if err != nil {
+			log.WithError(err).Error("Ошибка загрузки самоисключения")
+			h.sendMessage(ctx, chatID, "❌ Не удалось загрузить самоисключение")
+			return
+		}
+		if exclusion != nil {
+			h.sendMessage(ctx, chatID, fmt.Sprintf("🚫 Самоисключение действует до %s.\nПродлить: !самоисключение <срок>",
+				common.FormatDateTime(exclusion.EndsAt)))
+			return
+		}
+		h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Формат: !самоисключение <срок>, например 7д, 2н, 1м (до %d дней).\n"+
+			"Казино закроется сразу; досрочно снять исключение сможет только администратор.", MaxExclusionDays))
+		return
+	}
+	days, err := ParseExclusionPeriod(strings.Join(args, ""))
+	if err != nil {
+		h.sendMessage(ctx, chatID, fmt.Sprintf("❌ Срок — от 1 до %d дней: 7д, 2н, 1м или число дней.", MaxExclusionDays))
+		return
+	}
+	exclusion, err := h.service.SelfExclude(ctx, userID, days)
+	if err != nil {
+		log.WithError(err).Error("Ошибка самоисключения")
+		h.sendMessage(ctx, chatID, "❌ Не удалось оформить самоисключение")
+		return
+	}
+	h.sendMessage(ctx, chatID, fmt.Sprintf("🚫 Казино закрыто для тебя до %s. Береги себя!",
+		common.FormatDateTime(exclusion.EndsAt)))
+}
+
+// HandleLossLimits обрабатывает !лимиты — показывает лимиты проигрыша, а
+// `!лимиты день|неделя <сумма>` задаёт личный лимит (0 — снять). Ужесточение
+// действует сразу, ослабление — через сутки.
+func (h *Handler) HandleLossLimits(ctx context.Context, chatID, userID int64, args []string) {
+	if len(args) == 0 {
+		caps, err := h.service.LossCaps(ctx, userID)
+		if err != nil {
+			log.WithError(err).Error("Ошибка загрузки лимитов проигрыша")
+			h.sendMessage(ctx, chatID, "❌ Не удалось загрузить лимиты")
+			return
+		}
+		h.sendMessage(ctx, chatID, formatLossCaps(caps))
+		return
+	}
+	period, amount, ok := parseLossLimitArgs(args)
+	if !ok {
+		h.sendMessage(ctx, chatID, "❌ Формат: !лимиты день|неделя <сумма> (0 — снять личный лимит)")
+		return
+	}
+	limits, immediate, err := h.service.SetLossLimit(ctx, userID, period, amount)
+	if err != nil {
+		log.WithError(err).Error("Ошибка установки лимита проигрыша")
+		h.sendMessage(ctx, chatID, "❌ Не удалось сохранить лимит")
+		return
+	}
+	label := "дневной"
+	if period == LossLimitWeek {
+		label = "недельный"
+	}
+	switch {
+	case immediate:
+		h.sendMessage(ctx, chatID, fmt.Sprintf("✅ Личный %s лимит проигрыша: %s. Действует сразу.",
+			label, common.FormatBalance(amount)))
+	case limits.PendingFrom != nil:
+		h.sendMessage(ctx, chatID, fmt.Sprintf("⏳ Ослабление лимита вступит в силу %s.",
+			common.FormatDateTime(*limits.PendingFrom)))
+	default:
+		h.sendMessage(ctx, chatID, fmt.Sprintf("✅ Личный %s лимит проигрыша снят.", label))
+	}
+}
+
+// formatLossCaps описывает лимиты проигрыша игрока для !лимиты.
+func formatLossCaps(caps *LossCaps) string {
+	var sb strings.Builder
+	sb.WriteString("🛡 ЛИМИТЫ ПРОИГРЫША\n\n")
+	fmt.Fprintf(&sb, "📅 Сегодня: %s\n", formatLossOfCap(caps.DayLoss, caps.Daily))
+	fmt.Fprintf(&sb, "🗓 Неделя: %s\n", formatLossOfCap(caps.WeekLoss, caps.Weekly))
+	if caps.Personal != nil && (caps.Personal.Daily > 0 || caps.Personal.Weekly > 0) {
+		fmt.Fprintf(&sb, "\nЛичные: день %s, неделя %s\n",
+			formatLimit(caps.Personal.Daily), formatLimit(caps.Personal.Weekly))
+	}
+	if caps.RaisePending {
+		fmt.Fprintf(&sb, "⏳ Ослабление вступит в силу %s\n", common.FormatDateTime(*caps.Personal.PendingFrom))
+	}
+	sb.WriteString("\nИзменить: !лимиты день|неделя <сумма>")
+	return sb.String()
+}
+
+func formatLimit(limit int64) string {
+	if limit == 0 {
+		return "—"
+	}
+	return common.FormatNumber(limit)
+}
+
+// parseLossLimitArgs разбирает `день|неделя <сумма>`.
+func parseLossLimitArgs(args []string) (period string, amount int64, ok bool) {
+	if len(args) != 2 {
+		return "", 0, false
+	}
+	switch normalizeChoice(args[0]) {
+	case "день", "д":
+		period = LossLimitDay
+	case "неделя", "н":
+		period = LossLimitWeek
+	default:
+		return "", 0, false
+	}
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || n < 0 {
+		return "", 0, false
+	}
+	return period, n, true
+}
+
+// parseSlotsArgs разбирает аргументы !слоты: число — ставка, иначе имя машины.
+// Ставка 0 означает ставку по умолчанию.
+func parseSlotsArgs(args []string) (machineName string, bet int64, ok bool) {
+	if len(args) > 2 {
+		return "", 0, false
+	}
+	for _, arg := range args {
+		if n, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			if bet != 0 || n <= 0 {
+				return "", 0, false
+			}
+			bet = n
+			continue
+		}
+		if machineName != "" {
+			return "", 0, false
+		}
+		machineName = arg
+	}
+	return machineName, bet, true
+}
+
+func (h *Handler) displayName(ctx context.Context, userID int64) string {
+	if h.members == nil {
+		return fmt.Sprintf("id:%d", userID)
+	}
+	member, err := h.members.GetByUserID(ctx, userID)
+	if err != nil || member == nil {
+		return fmt.Sprintf("id:%d", userID)
+	}
+	if name := strings.TrimSpace(member.DisplayName()); name != "" && name != "@" {
+		return name
+	}
+	return fmt.Sprintf("id:%d", userID)
+}
+
 func (h *Handler) sendMessage(ctx context.Context, chatID int64, text string) {
 	_, _ = h.tgOps.Send(ctx, chatID, text, nil)
 }
+
+const (
+	duelCallbackPrefix = "casino:duel:"
+	duelActionAccept   = "accept"
+	duelActionDecline  = "decline"
+)
+
+// duelTarget — соперник, выбранный по @username или ответом на сообщение.
+type duelTarget struct {
+	UserID  int64
+	Display string
+}
+
+// HandleDuel обрабатывает !дуэль @user 100 (или ответом: !дуэль 100).
+// Ставка вызывающего списывается сразу; соперник принимает или отказывается
+// кнопкой, вызывающий может отозвать вызов той же кнопкой «Отказаться».
+func (h *Handler) HandleDuel(ctx context.Context, c commands.Context, args []string) {
+	username, stake, ok := parseDuelArgs(args)
+	if !ok {
+		h.sendMessage(ctx, c.ChatID, "❌ Формат: !дуэль @username <ставка> или ответом: !дуэль <ставка>")
+		return
+	}
+	target, err := h.resolveDuelTarget(ctx, c.Message, username)
+	if err != nil {
+		h.sendMessage(ctx, c.ChatID, userFacingDuelError(err))
+		return
+	}
+
+	duel, err := h.service.CreateDuel(ctx, c.ChatID, c.UserID, target.UserID, stake)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDuelSelf):
+			h.sendMessage(ctx, c.ChatID, userFacingDuelError(err))
+		default:
+			h.replyPlayError(ctx, c.ChatID, c.UserID, GameTypeDuel, err, stake, "Ошибка создания дуэли", "❌ Не удалось создать дуэль")
+		}
+		return
+	}
+
+	text := duelChallengeText(h.displayName(ctx, c.UserID), target.Display, duel, h.service.DuelFeePercent(), h.cfg.CasinoDuelTTL)
+	messageID, err := h.tgOps.Send(ctx, c.ChatID, text, duelMarkup(duel.Token))
+	if err != nil {
+		log.WithError(err).WithField("duel_id", duel.ID).Warn("duel challenge send failed")
+		if _, refundErr := h.service.DeclineDuel(ctx, duel.Token, c.UserID); refundErr != nil {
+			log.WithError(refundErr).WithField("duel_id", duel.ID).Error("duel refund after send failure failed")
+		}
+		return
+	}
+	if err := h.service.AttachDuelMessage(ctx, duel, messageID); err != nil {
+		log.WithError(err).WithField("duel_id", duel.ID).Warn("duel message attach failed")
+	}
+}
+
+// HandleCasinoCallback обрабатывает кнопки казино; возвращает true,
+// если callback принадлежит казино.
+func (h *Handler) HandleCasinoCallback(ctx context.Context, q *models.CallbackQuery) bool {
+	if q == nil || !strings.HasPrefix(q.Data, duelCallbackPrefix) {
+		return false
+	}
+	token, action, ok := parseDuelCallbackData(q.Data)
+	if !ok {
+		h.answerCallback(ctx, q.ID, "")
+		return true
+	}
+	duel, err := h.service.GetDuel(ctx, token)
+	if err != nil {
+		if !errors.Is(err, ErrDuelNotFound) {
+			log.WithError(err).Warn("duel lookup failed")
+		}
+		h.answerCallback(ctx, q.ID, "Вызов устарел.")
+		return true
+	}
+	if msg := callbackMessage(q); msg == nil || msg.Chat.ID != duel.ChatID || msg.MessageID != duel.MessageID {
+		h.answerCallback(ctx, q.ID, "Вызов устарел.")
+		return true
+	}
+
+	switch action {
+	case duelActionAccept:
+		duel, err = h.service.AcceptDuel(ctx, token, q.From.ID)
+	case duelActionDecline:
+		duel, err = h.service.DeclineDuel(ctx, token, q.From.ID)
+	default:
+		h.answerCallback(ctx, q.ID, "")
+		return true
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDuelNotParticipant):
+			h.answerCallback(ctx, q.ID, "Это не ваша дуэль.")
+		case errors.Is(err, ErrDuelClosed):
+			h.answerCallback(ctx, q.ID, "Дуэль уже завершена.")
+		case errors.Is(err, ErrDuelExpired):
+			h.answerCallback(ctx, q.ID, "Вызов истёк, ставка возвращена.")
+			h.finishDuelMessage(ctx, duel, fmt.Sprintf("⌛ Вызов на дуэль не принят вовремя. Ставка %s возвращена.",
+				common.FormatBalance(duel.Stake)))
+		case errors.Is(err, ErrDuelChallengerExcluded):
+			h.answerCallback(ctx, q.ID, "Вызов отменён.")
+			h.finishDuelMessage(ctx, duel, fmt.Sprintf("🚫 Вызов на дуэль отменён: казино для вызывающего закрыто. Ставка %s возвращена.",
+				common.FormatBalance(duel.Stake)))
+		case errors.Is(err, common.ErrInsufficientBalance):
+			h.answerCallback(ctx, q.ID, "Недостаточно плёнок для ставки.")
+		case errors.Is(err, common.ErrCasinoSelfExcluded):
+			h.answerCallback(ctx, q.ID, "Казино для вас закрыто по самоисключению.")
+		case errors.Is(err, common.ErrCasinoDailyWagerCap):
+			h.answerCallback(ctx, q.ID, "Дневной лимит ставок исчерпан.")
+		case errors.Is(err, common.ErrCasinoDailyLossCap), errors.Is(err, common.ErrCasinoWeeklyLossCap):
+			h.answerCallback(ctx, q.ID, "Ставка превысит ваш лимит проигрыша.")
+		default:
+			log.WithError(err).WithField("token", token).Error("duel callback failed")
+			h.answerCallback(ctx, q.ID, "Не удалось обработать дуэль.")
+		}
+		return true
+	}
+
+	h.answerCallback(ctx, q.ID, "")
+	h.finishDuelMessage(ctx, duel, h.duelResultText(ctx, duel))
+	return true
+}
+
+func (h *Handler) duelResultText(ctx context.Context, duel *Duel) string {
+	challenger := h.displayName(ctx, duel.ChallengerID)
+	opponent := h.displayName(ctx, duel.OpponentID)
+	switch duel.State {
+	case DuelStateCompleted:
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "⚔️ Дуэль %s vs %s\n\n🎲 %s: %d\n🎲 %s: %d\n\n",
+			challenger, opponent, challenger, duel.ChallengerRoll, opponent, duel.OpponentRoll)
+		winner := opponent
+		if duel.WinnerID != nil && *duel.WinnerID == duel.ChallengerID {
+			winner = challenger
+		}
+		fmt.Fprintf(&sb, "🏆 %s забирает %s", winner, common.FormatBalance(duel.Payout()))
+		if duel.Fee > 0 {
+			fmt.Fprintf(&sb, " (комиссия казино %s)", common.FormatBalance(duel.Fee))
+		}
+		return sb.String()
+	case DuelStateDeclined:
+		return fmt.Sprintf("🏳️ %s отказывается от дуэли. Ставка %s возвращена %s.",
+			opponent, common.FormatBalance(duel.Stake), challenger)
+	case DuelStateCanceled:
+		return fmt.Sprintf("↩️ %s отзывает вызов. Ставка %s возвращена.",
+			challenger, common.FormatBalance(duel.Stake))
+	default:
+		return fmt.Sprintf("⌛ Вызов на дуэль не принят вовремя. Ставка %s возвращена.",
+			common.FormatBalance(duel.Stake))
+	}
+}
+
+func (h *Handler) finishDuelMessage(ctx context.Context, duel *Duel, text string) {
+	if duel == nil || duel.MessageID == 0 {
+		return
+	}
+	if err := h.tgOps.Edit(ctx, duel.ChatID, duel.MessageID, text, nil); err != nil && !telegram.IsEditNotModified(err) {
+		log.WithError(err).WithField("duel_id", duel.ID).Warn("duel final edit failed")
+	}
+}
+
+func (h *Handler) resolveDuelTarget(ctx context.Context, message *models.Message, username string) (duelTarget, error) {
+	if username != "" {
+		if h.members == nil {
+			return duelTarget{}, common.ErrUserNotFound
+		}
+		member, err := h.members.GetByUsername(ctx, username)
+		if err != nil || member == nil {
+			return duelTarget{}, common.ErrUserNotFound
+		}
+		if member.IsBot {
+			return duelTarget{}, errDuelTargetIsBot
+		}
+		return duelTarget{UserID: member.UserID, Display: "@" + strings.TrimPrefix(member.Username, "@")}, nil
+	}
+	if message != nil && message.ReplyToMessage != nil && message.ReplyToMessage.From != nil {
+		user := message.ReplyToMessage.From
+		if user.IsBot {
+			return duelTarget{}, errDuelTargetIsBot
+		}
+		return duelTarget{UserID: user.ID, Display: h.displayName(ctx, user.ID)}, nil
+	}
+	return duelTarget{}, errDuelTargetMissing
+}
+
+var (
+	errDuelTargetMissing = errors.New("duel target missing")
+	errDuelTargetIsBot   = errors.New("duel target is bot")
+)
+
+func userFacingDuelError(err error) string {
+	switch {
+	case errors.Is(err, errDuelTargetMissing):
+		return "❌ Укажите соперника: @username или ответьте на его сообщение."
+	case errors.Is(err, errDuelTargetIsBot):
+		return "❌ Боты не дерутся на дуэлях."
+	case errors.Is(err, common.ErrUserNotFound):
+		return "❌ Не удалось найти пользователя по указанному username."
+	case errors.Is(err, ErrDuelSelf):
+		return "❌ Нельзя вызвать на дуэль самого себя."
+	case errors.Is(err, common.ErrCasinoBetOutOfRange):
+		return "❌ Ставка вне допустимого диапазона."
+	default:
+		return "❌ Не удалось создать дуэль."
+	}
+}
+
+// parseDuelArgs разбирает `@username <ставка>` в любом порядке;
+// без @username соперник берётся из ответа.
+func parseDuelArgs(args []string) (username string, stake int64, ok bool) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "@") {
+			if username != "" || len(arg) == 1 {
+				return "", 0, false
+			}
+			username = strings.TrimPrefix(arg, "@")
+			continue
+		}
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || n <= 0 || stake != 0 {
+			return "", 0, false
+		}
+		stake = n
+	}
+	if stake == 0 {
+		return "", 0, false
+	}
+	return username, stake, true
+}
+
+func duelChallengeText(challenger, opponent string, duel *Duel, feePercent float64, ttl time.Duration) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⚔️ %s вызывает %s на дуэль!\n\nСтавка: %s с каждого\nБанк: %s",
+		challenger, opponent, common.FormatBalance(duel.Stake), common.FormatBalance(duel.Pot()))
+	if feePercent > 0 {
+		fmt.Fprintf(&sb, " (комиссия казино %g%%)", feePercent)
+	}
+	fmt.Fprintf(&sb, "\n\n%s, у тебя %d мин., чтобы принять вызов.", opponent, int(ttl.Minutes()))
+	return sb.String()
+}
+
+func duelMarkup(token string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{{
+			{
+				Text:         "Принять",
+				Style:        models.ButtonStyleSuccess,
+				CallbackData: duelCallbackPrefix + token + ":" + duelActionAccept,
+			},
+			{
+				Text:         "Отказаться",
+				Style:        models.ButtonStyleDanger,
+				CallbackData: duelCallbackPrefix + token + ":" + duelActionDecline,
+			},
+		}},
+	}
+}
+
+func parseDuelCallbackData(data string) (token, action string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(data, duelCallbackPrefix), ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func callbackMessage(q *models.CallbackQuery) *models.Message {
+	if q == nil || q.Message == nil {
+		return nil
+	}
+	return q.Message.Message()
+}
+
+func (h *Handler) answerCallback(ctx context.Context, callbackID, text string) {
+	if h.tgOps == nil || callbackID == "" {
+		return
+	}
+	if err := h.tgOps.AnswerCallback(ctx, callbackID, text, false); err != nil {
+		log.WithError(err).Debug("ошибка ответа на callback дуэли")
+	}
+}
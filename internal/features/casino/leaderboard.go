@@ -0,0 +1,163 @@
+// Package casino — leaderboard.go строит рейтинги !топслоты и еженедельную
+// сводку казино. Рейтинг «за всё время» читает накопительные счётчики
+// casino_stats, недельный рейтинг и сводка считаются по casino_games за окно
+// времени, чтобы не зависеть от пожизненных счётчиков.
+package casino
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+)
+
+// LeaderboardMetric — показатель, по которому ранжируются игроки.
+type LeaderboardMetric string
+
+const (
+	// LeaderboardBiggestWin — самая крупная выплата за одну игру.
+	LeaderboardBiggestWin LeaderboardMetric = "biggest_win"
+	// LeaderboardNetProfit — выигрыши минус ставки.
+	LeaderboardNetProfit LeaderboardMetric = "net_profit"
+	// LeaderboardSpins — число сыгранных игр.
+	LeaderboardSpins LeaderboardMetric = "spins"
+)
+
+// LeaderboardMetrics перечисляет показатели в порядке вывода.
+var LeaderboardMetrics = []LeaderboardMetric{LeaderboardBiggestWin, LeaderboardNetProfit, LeaderboardSpins}
+
+// ErrUnknownLeaderboard — неизвестный показатель рейтинга.
+var ErrUnknownLeaderboard = errors.New("unknown casino leaderboard metric")
+
+// LeaderboardEntry — строка рейтинга.
+type LeaderboardEntry struct {
+	UserID int64
+	Value  int64
+}
+
+// PeriodTotals — итоги казино за окно времени по casino_games.
+type PeriodTotals struct {
+	Games   int64
+	Players int64
+	Wagered int64
+	PaidOut int64
+}
+
+// HouseResult возвращает результат казино за период: ставки минус выплаты.
+func (t PeriodTotals) HouseResult() int64 { return t.Wagered - t.PaidOut }
+
+// Digest — еженедельная сводка казино за окно [From, To).
+type Digest struct {
+	From, To   time.Time
+	Totals     PeriodTotals
+	BiggestWin *LeaderboardEntry
+	TopProfit  *LeaderboardEntry
+	MostSpins  *LeaderboardEntry
+}
+
+// Leaderboard возвращает рейтинг игроков по метрике: за всё время или, если
+// weekly, за текущую неделю в APP_TIMEZONE (с понедельника).
+func (s *Service) Leaderboard(ctx context.Context, metric LeaderboardMetric, weekly bool, limit int) ([]LeaderboardEntry, error) {
+	if !validLeaderboardMetric(metric) {
+		return nil, ErrUnknownLeaderboard
+	}
+	if !weekly {
+		return s.repo.ListStatsLeaders(ctx, metric, limit)
+	}
+	from, _ := s.weekBounds(0)
+	return s.repo.ListGameLeaders(ctx, metric, from, s.now().UTC(), limit)
+}
+
+// LastWeekDigest собирает сводку за прошлую полную неделю. Nil — игр не было.
+func (s *Service) LastWeekDigest(ctx context.Context) (*Digest, error) {
+	from, to := s.weekBounds(-1)
+	totals, err := s.repo.GetPeriodTotals(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if totals.Games == 0 {
+		return nil, nil
+	}
+	digest := &Digest{From: from, To: to, Totals: *totals}
+	for metric, dst := range map[LeaderboardMetric]**LeaderboardEntry{
+		LeaderboardBiggestWin: &digest.BiggestWin,
+		LeaderboardNetProfit:  &digest.TopProfit,
+		LeaderboardSpins:      &digest.MostSpins,
+	} {
+		leaders, err := s.repo.ListGameLeaders(ctx, metric, from, to, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(leaders) > 0 {
+			*dst = &leaders[0]
+		}
+	}
+	return digest, nil
+}
+
+// SendWeeklyDigest отправляет сводку прошлой недели через sendFunc.
+// Если игр не было, ничего не отправляет.
+func (s *Service) SendWeeklyDigest(ctx context.Context, sendFunc func(ctx context.Context, text string) error) error {
+	digest, err := s.LastWeekDigest(ctx)
+	if err != nil || digest == nil {
+		return err
+	}
+	return sendFunc(ctx, s.formatDigest(ctx, digest))
+}
+
+// weekBounds возвращает границы недели в APP_TIMEZONE со сдвигом offset
+// недель от текущей, переведённые в UTC для сравнения с created_at.
+func (s *Service) weekBounds(offset int) (from, to time.Time) {
+	loc := s.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	monday := weekStart(s.today()).AddDate(0, 0, 7*offset)
+	from = time.Date(monday.Year(), monday.Month(), monday.Day(), 0, 0, 0, 0, loc)
+	return from.UTC(), from.AddDate(0, 0, 7).UTC()
+}
+
+// formatDigest описывает сводку для чата участников.
+func (s *Service) formatDigest(ctx context.Context, d *Digest) string {
+	loc := s.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🎰 ИТОГИ НЕДЕЛИ В КАЗИНО\n%s — %s\n\n",
+		d.From.In(loc).Format("02.01"), d.To.In(loc).AddDate(0, 0, -1).Format("02.01"))
+	fmt.Fprintf(&sb, "Игр: %s, игроков: %s\n", common.FormatNumber(d.Totals.Games), common.FormatNumber(d.Totals.Players))
+	fmt.Fprintf(&sb, "Поставлено: %s\n", common.FormatBalance(d.Totals.Wagered))
+	fmt.Fprintf(&sb, "Выплачено: %s\n", common.FormatBalance(d.Totals.PaidOut))
+	fmt.Fprintf(&sb, "Казино: %s\n", formatSigned(d.Totals.HouseResult()))
+	if d.BiggestWin != nil && d.BiggestWin.Value > 0 {
+		fmt.Fprintf(&sb, "\n💎 Крупнейший выигрыш: %s — %s", s.memberLabel(ctx, d.BiggestWin.UserID), common.FormatBalance(d.BiggestWin.Value))
+	}
+	if d.TopProfit != nil && d.TopProfit.Value > 0 {
+		fmt.Fprintf(&sb, "\n📈 Лучший профит: %s — %s", s.memberLabel(ctx, d.TopProfit.UserID), formatSigned(d.TopProfit.Value))
+	}
+	if d.MostSpins != nil {
+		fmt.Fprintf(&sb, "\n🎲 Больше всех игр: %s — %s", s.memberLabel(ctx, d.MostSpins.UserID), common.FormatNumber(d.MostSpins.Value))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatSigned форматирует сумму плёнок со знаком: «+1 500 плёнок».
+func formatSigned(amount int64) string {
+	if amount > 0 {
+		return "+" + common.FormatBalance(amount)
+	}
+	return common.FormatBalance(amount)
+}
+
+func validLeaderboardMetric(metric LeaderboardMetric) bool {
+	for _, m := range LeaderboardMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
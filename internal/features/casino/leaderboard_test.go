@@ -0,0 +1,120 @@
+package casino
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeaderboard_WeeklyUsesGamesWindow(t *testing.T) {
+	store := newFakeStore()
+	svc := newTestService(store)
+	// 10.03.2026 — вторник, неделя началась в понедельник 09.03.
+	monday := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	store.games = []Game{
+		{UserID: 1, BetAmount: 100, ResultAmount: 5000, CreatedAt: monday.Add(-time.Hour)},
+		{UserID: 2, BetAmount: 100, ResultAmount: 300, CreatedAt: monday.Add(time.Hour)},
+		{UserID: 3, BetAmount: 100, ResultAmount: 0, CreatedAt: monday.Add(2 * time.Hour)},
+		{UserID: 3, BetAmount: 100, ResultAmount: 150, CreatedAt: monday.Add(3 * time.Hour)},
+	}
+	store.stats[1] = Stats{UserID: 1, TotalSpins: 1, TotalWagered: 100, TotalWon: 5000, BiggestWin: 5000}
+	ctx := context.Background()
+
+	week, err := svc.Leaderboard(ctx, LeaderboardBiggestWin, true, 10)
+	if err != nil {
+		t.Fatalf("Leaderboard: %v", err)
+	}
+	if len(week) != 2 || week[0].UserID != 2 || week[0].Value != 300 {
+		t.Fatalf("weekly biggest win = %+v, last week's game must not count", week)
+	}
+	spins, _ := svc.Leaderboard(ctx, LeaderboardSpins, true, 10)
+	if spins[0].UserID != 3 || spins[0].Value != 2 {
+		t.Fatalf("weekly spins = %+v", spins)
+	}
+	profit, _ := svc.Leaderboard(ctx, LeaderboardNetProfit, true, 10)
+	if profit[0].UserID != 2 || profit[0].Value != 200 || profit[1].Value != -50 {
+		t.Fatalf("weekly profit = %+v", profit)
+	}
+
+	allTime, _ := svc.Leaderboard(ctx, LeaderboardBiggestWin, false, 10)
+	if len(allTime) != 1 || allTime[0].UserID != 1 {
+		t.Fatalf("all-time leaderboard must read casino_stats, got %+v", allTime)
+	}
+}
+
+func TestLeaderboard_WeekStartsInAppTimezone(t *testing.T) {
+	store := newFakeStore()
+	svc := newTestService(store)
+	svc.location = time.FixedZone("MSK", 3*60*60)
+	// Понедельник 00:30 по Москве — ещё воскресенье по UTC.
+	svc.now = func() time.Time { return time.Date(2026, 3, 8, 21, 30, 0, 0, time.UTC) }
+	store.games = []Game{
+		{UserID: 1, BetAmount: 100, ResultAmount: 700, CreatedAt: time.Date(2026, 3, 8, 20, 59, 0, 0, time.UTC)},
+		{UserID: 2, BetAmount: 100, ResultAmount: 400, CreatedAt: time.Date(2026, 3, 8, 21, 1, 0, 0, time.UTC)},
+	}
+
+	week, err := svc.Leaderboard(context.Background(), LeaderboardBiggestWin, true, 10)
+	if err != nil {
+		t.Fatalf("Leaderboard: %v", err)
+	}
+	if len(week) != 1 || week[0].UserID != 2 {
+		t.Fatalf("week must start at Monday 00:00 MSK, got %+v", week)
+	}
+}
+
+func TestSendWeeklyDigest(t *testing.T) {
+	store := newFakeStore()
+	svc := newTestService(store)
+	ctx := context.Background()
+
+	var sent []string
+	send := func(ctx context.Context, text string) error {
+		sent = append(sent, text)
+		return nil
+	}
+	if err := svc.SendWeeklyDigest(ctx, send); err != nil || len(sent) != 0 {
+		t.Fatalf("empty week must not be posted: err=%v sent=%q", err, sent)
+	}
+
+	lastWeek := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	store.games = []Game{
+		{UserID: 1, BetAmount: 100, ResultAmount: 900, CreatedAt: lastWeek},
+		{UserID: 2, BetAmount: 500, ResultAmount: 0, CreatedAt: lastWeek},
+		{UserID: 2, BetAmount: 500, ResultAmount: 0, CreatedAt: lastWeek},
+		{UserID: 1, BetAmount: 100, ResultAmount: 99_999, CreatedAt: time.Date(2026, 3, 9, 1, 0, 0, 0, time.UTC)},
+	}
+	if err := svc.SendWeeklyDigest(ctx, send); err != nil {
+		t.Fatalf("SendWeeklyDigest: %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d digests, want 1", len(sent))
+	}
+	for _, want := range []string{"02.03 — 08.03", "Игр: 3, игроков: 2", "Казино: +200", "Крупнейший выигрыш: id:1", "Больше всех игр: id:2 — 2"} {
+		if !strings.Contains(sent[0], want) {
+			t.Fatalf("digest %q does not contain %q", sent[0], want)
+		}
+	}
+}
+
+func TestParseTopSlotsArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		metrics int
+		weekly  bool
+		ok      bool
+	}{
+		{args: nil, metrics: 3, ok: true},
+		{args: []string{"неделя"}, metrics: 3, weekly: true, ok: true},
+		{args: []string{"профит", "неделя"}, metrics: 1, weekly: true, ok: true},
+		{args: []string{"Неделя", "спины"}, metrics: 1, weekly: true, ok: true},
+		{args: []string{"спины", "профит"}},
+		{args: []string{"месяц"}},
+	}
+	for _, tt := range tests {
+		metrics, weekly, ok := parseTopSlotsArgs(tt.args)
+		if len(metrics) != tt.metrics || weekly != tt.weekly || ok != tt.ok {
+			t.Fatalf("parseTopSlotsArgs(%q) = %v, %v, %v", tt.args, metrics, weekly, ok)
+		}
+	}
+}
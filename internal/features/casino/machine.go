@@ -0,0 +1,305 @@
+// Package casino — machine.go описывает слот-машину как данные:
+// символы, веса, линии, выплаты и бонусы скаттеров. Машины загружаются
+// из JSON-файла при старте и проверяются до того, как бот примет первый спин.
+package casino
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultMachineName — имя встроенной машины, собранной из переменных models.go.
+const DefaultMachineName = "classic"
+
+// Границы размеров сетки: меньше трёх рилов не даёт линий,
+// а слишком большая сетка не помещается в сообщение Telegram.
+const (
+	minMachineReels = 3
+	maxMachineReels = 8
+	minMachineRows  = 1
+	maxMachineRows  = 8
+)
+
+// Machine — описание одной слот-машины.
+type Machine struct {
+	Name           string                   `json:"name"`
+	Title          string                   `json:"title"`
+	Reels          int                      `json:"reels"`
+	Rows           int                      `json:"rows"`
+	Wild           string                   `json:"wild,omitempty"`    // Эмодзи вайлда, пусто — без вайлдов
+	Scatter        string                   `json:"scatter,omitempty"` // Эмодзи скаттера, пусто — без скаттеров
+	Jackpot        string                   `json:"jackpot,omitempty"` // Эмодзи джекпота: полная линия забирает пул
+	Symbols        []Symbol                 `json:"symbols"`
+	Paylines       [][]int                  `json:"paylines"`
+	Payouts        map[int]int64            `json:"payouts"`
+	SpecialPayouts map[string]map[int]int64 `json:"special_payouts,omitempty"`
+	ScatterPayouts map[int]ScatterPayout    `json:"scatter_payouts,omitempty"`
+}
+
+// MachineSet — набор именованных машин с машиной по умолчанию.
+type MachineSet struct {
+	machines    map[string]*Machine
+	defaultName string
+}
+
+// machinesFile — формат файла CASINO_MACHINES_FILE.
+type machinesFile struct {
+	Default  string     `json:"default"`
+	Machines []*Machine `json:"machines"`
+}
+
+// ClassicMachine возвращает встроенную машину 5×6 из DefaultSymbols,
+// Paylines, PayoutTable, SpecialPayouts и ScatterPayouts.
+func ClassicMachine() *Machine {
+	paylines := make([][]int, len(Paylines))
+	for i, line := range Paylines {
+		paylines[i] = append([]int(nil), line...)
+	}
+	special := make(map[string]map[int]int64, len(SpecialPayouts))
+	for symbol, payouts := range SpecialPayouts {
+		special[symbol] = copyPayouts(payouts)
+	}
+	scatters := make(map[int]ScatterPayout, len(ScatterPayouts))
+	for count, payout := range ScatterPayouts {
+		scatters[count] = payout
+	}
+	return &Machine{
+		Name:           DefaultMachineName,
+		Title:          "Классика",
+		Reels:          5,
+		Rows:           6,
+		Wild:           WildEmoji,
+		Scatter:        ScatterEmoji,
+		Jackpot:        JackpotEmoji,
+		Symbols:        copySymbols(DefaultSymbols),
+		Paylines:       paylines,
+		Payouts:        copyPayouts(PayoutTable),
+		SpecialPayouts: special,
+		ScatterPayouts: scatters,
+	}
+}
+
+// NewMachineSet проверяет машины и собирает из них набор.
+// Пустое defaultName означает первую машину из списка.
+func NewMachineSet(defaultName string, machines ...*Machine) (*MachineSet, error) {
+	if len(machines) == 0 {
+		return nil, errors.New("не описано ни одной машины")
+	}
+	set := &MachineSet{machines: make(map[string]*Machine, len(machines))}
+	for i, m := range machines {
+		if m == nil {
+			return nil, fmt.Errorf("машина #%d: пустое описание", i+1)
+		}
+		m.Name = strings.ToLower(strings.TrimSpace(m.Name))
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := set.machines[m.Name]; exists {
+			return nil, fmt.Errorf("машина %q описана дважды", m.Name)
+		}
+		set.machines[m.Name] = m
+	}
+
+	defaultName = strings.ToLower(strings.TrimSpace(defaultName))
+	if defaultName == "" {
+		defaultName = machines[0].Name
+	}
+	if _, ok := set.machines[defaultName]; !ok {
+		return nil, fmt.Errorf("машина по умолчанию %q не описана", defaultName)
+	}
+	set.defaultName = defaultName
+	return set, nil
+}
+
+// LoadMachines читает машины из JSON-файла. Пустой путь — только встроенная classic.
+// defaultName из конфига перекрывает поле default из файла.
+func LoadMachines(path, defaultName string) (*MachineSet, error) {
+	if strings.TrimSpace(path) == "" {
+		return NewMachineSet(defaultName, ClassicMachine())
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("чтение файла машин %s: %w", path, err)
+	}
+	set, err := ParseMachines(raw, defaultName)
+	if err != nil {
+		return nil, fmt.Errorf("файл машин %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// ParseMachines разбирает и проверяет JSON с описанием машин.
+func ParseMachines(raw []byte, defaultName string) (*MachineSet, error) {
+	var file machinesFile
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("разбор JSON: %w", err)
+	}
+	if strings.TrimSpace(defaultName) == "" {
+		defaultName = file.Default
+	}
+	return NewMachineSet(defaultName, file.Machines...)
+}
+
+// Default возвращает машину по умолчанию.
+func (s *MachineSet) Default() *Machine {
+	return s.machines[s.defaultName]
+}
+
+// Get возвращает машину по имени без учёта регистра.
+func (s *MachineSet) Get(name string) (*Machine, bool) {
+	m, ok := s.machines[strings.ToLower(strings.TrimSpace(name))]
+	return m, ok
+}
+
+// Names возвращает имена машин: сначала машина по умолчанию, затем по алфавиту.
+func (s *MachineSet) Names() []string {
+	names := make([]string, 0, len(s.machines))
+	for name := range s.machines {
+		if name != s.defaultName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{s.defaultName}, names...)
+}
+
+// Validate проверяет размеры сетки, ссылки на символы, линии и выплаты.
+func (m *Machine) Validate() error {
+	if m.Name == "" {
+		return errors.New("у машины не указано имя")
+	}
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("машина %q: %s", m.Name, fmt.Sprintf(format, args...))
+	}
+
+	if m.Reels < minMachineReels || m.Reels > maxMachineReels {
+		return fail("рилов должно быть от %d до %d, указано %d", minMachineReels, maxMachineReels, m.Reels)
+	}
+	if m.Rows < minMachineRows || m.Rows > maxMachineRows {
+		return fail("строк должно быть от %d до %d, указано %d", minMachineRows, maxMachineRows, m.Rows)
+	}
+
+	if len(m.Symbols) == 0 {
+		return fail("не описаны символы")
+	}
+	known := make(map[string]struct{}, len(m.Symbols))
+	for i, sym := range m.Symbols {
+		if strings.TrimSpace(sym.Emoji) == "" {
+			return fail("символ #%d без эмодзи", i+1)
+		}
+		if _, dup := known[sym.Emoji]; dup {
+			return fail("символ %s описан дважды", sym.Emoji)
+		}
+		if sym.Weight <= 0 {
+			return fail("вес символа %s должен быть положительным, указано %d", sym.Emoji, sym.Weight)
+		}
+		known[sym.Emoji] = struct{}{}
+	}
+	if m.Wild != "" {
+		if _, ok := known[m.Wild]; !ok {
+			return fail("вайлд %s не входит в символы", m.Wild)
+		}
+	}
+	if m.Scatter != "" {
+		if _, ok := known[m.Scatter]; !ok {
+			return fail("скаттер %s не входит в символы", m.Scatter)
+		}
+		if m.Scatter == m.Wild {
+			return fail("один символ не может быть и вайлдом, и скаттером")
+		}
+	}
+	if m.Jackpot != "" {
+		if _, ok := known[m.Jackpot]; !ok {
+			return fail("символ джекпота %s не входит в символы", m.Jackpot)
+		}
+		if m.Jackpot == m.Scatter {
+			return fail("скаттер не может быть символом джекпота")
+		}
+	}
+
+	if len(m.Paylines) == 0 {
+		return fail("не описаны линии")
+	}
+	for i, line := range m.Paylines {
+		if len(line) != m.Reels {
+			return fail("линия %d: нужно %d позиций, указано %d", i+1, m.Reels, len(line))
+		}
+		for reel, row := range line {
+			if row < 0 || row >= m.Rows {
+				return fail("линия %d, рил %d: строка %d вне сетки 0..%d", i+1, reel+1, row, m.Rows-1)
+			}
+		}
+	}
+
+	if len(m.Payouts) == 0 {
+		return fail("не описаны выплаты за линии")
+	}
+	if err := validatePayouts(m.Payouts, m.Reels); err != nil {
+		return fail("выплаты: %v", err)
+	}
+	for symbol, payouts := range m.SpecialPayouts {
+		if _, ok := known[symbol]; !ok {
+			return fail("особая выплата для неизвестного символа %s", symbol)
+		}
+		if symbol == m.Scatter {
+			return fail("скаттер не может выигрывать на линиях")
+		}
+		if err := validatePayouts(payouts, m.Reels); err != nil {
+			return fail("особые выплаты %s: %v", symbol, err)
+		}
+	}
+
+	if len(m.ScatterPayouts) > 0 && m.Scatter == "" {
+		return fail("бонусы скаттеров описаны, но скаттер не задан")
+	}
+	for count, payout := range m.ScatterPayouts {
+		if count <= 0 || count > m.Reels*m.Rows {
+			return fail("бонус за %d скаттеров вне сетки %d×%d", count, m.Reels, m.Rows)
+		}
+		if payout.FreeSpins < 0 || payout.Bonus < 0 {
+			return fail("бонус за %d скаттеров не может быть отрицательным", count)
+		}
+	}
+	return nil
+}
+
+// JackpotLine возвращает индекс в wins первой линии, собравшей символ джекпота
+// на всех рилах, или -1. Линия из одних вайлдов джекпот не даёт.
+func (m *Machine) JackpotLine(wins []WinLine) int {
+	if m.Jackpot == "" {
+		return -1
+	}
+	for i, win := range wins {
+		if win.Symbol == m.Jackpot && win.Count == m.Reels {
+			return i
+		}
+	}
+	return -1
+}
+
+func validatePayouts(payouts map[int]int64, reels int) error {
+	for count, multiplier := range payouts {
+		if count <= 0 || count > reels {
+			return fmt.Errorf("количество совпадений %d вне диапазона 1..%d", count, reels)
+		}
+		if multiplier <= 0 {
+			return fmt.Errorf("множитель за %d совпадений должен быть положительным", count)
+		}
+	}
+	return nil
+}
+
+func copyPayouts(src map[int]int64) map[int]int64 {
+	dst := make(map[int]int64, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -0,0 +1,150 @@
+package casino
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassicMachine_MatchesPackageEngine(t *testing.T) {
+	m := ClassicMachine()
+	if err := m.Validate(); err != nil {
+		t.Fatalf("classic must be valid: %v", err)
+	}
+
+	grid, err := m.GenerateGrid(NewSeededSource(9), m.Symbols)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(grid) != 5 || len(grid[0]) != 6 {
+		t.Fatalf("unexpected grid size %dx%d", len(grid), len(grid[0]))
+	}
+	if got, want := len(m.CheckPaylines(grid, 50)), len(CheckPaylines(grid, 50)); got != want {
+		t.Fatalf("machine wins = %d, package wins = %d", got, want)
+	}
+}
+
+func TestCheckPaylines_UsesMachineDefinition(t *testing.T) {
+	m := &Machine{
+		Name:     "tiny",
+		Reels:    3,
+		Rows:     1,
+		Wild:     "⭐",
+		Symbols:  []Symbol{{Emoji: "🍒", Weight: 1}, {Emoji: "⭐", Weight: 1}},
+		Paylines: [][]int{{0, 0, 0}},
+		Payouts:  map[int]int64{3: 4},
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	wins := m.CheckPaylines(Grid{{"🍒"}, {"⭐"}, {"🍒"}}, 10)
+	if len(wins) != 1 || wins[0].Payout != 40 || wins[0].Count != 3 {
+		t.Fatalf("unexpected wins: %+v", wins)
+	}
+	if m.CountScatters(Grid{{"🍒"}, {"🍒"}, {"🍒"}}) != 0 {
+		t.Fatal("machine without scatter must not count scatters")
+	}
+}
+
+func TestMachineValidate_RejectsBrokenDefinitions(t *testing.T) {
+	cases := map[string]func(m *Machine){
+		"too few reels":        func(m *Machine) { m.Reels = 2 },
+		"too many rows":        func(m *Machine) { m.Rows = 20 },
+		"zero weight":          func(m *Machine) { m.Symbols[0].Weight = 0 },
+		"duplicate symbol":     func(m *Machine) { m.Symbols[1].Emoji = m.Symbols[0].Emoji },
+		"unknown wild":         func(m *Machine) { m.Wild = "🐸" },
+		"unknown scatter":      func(m *Machine) { m.Scatter = "🐸" },
+		"payline out of rows":  func(m *Machine) { m.Paylines[0][2] = 6 },
+		"payline wrong length": func(m *Machine) { m.Paylines[0] = []int{0, 0, 0} },
+		"no payouts":           func(m *Machine) { m.Payouts = nil },
+		"payout beyond reels":  func(m *Machine) { m.Payouts[6] = 100 },
+		"unknown special":      func(m *Machine) { m.SpecialPayouts["🐸"] = map[int]int64{5: 10} },
+		"unknown jackpot":      func(m *Machine) { m.Jackpot = "🐸" },
+		"scatter jackpot":      func(m *Machine) { m.Jackpot = m.Scatter },
+		"scatter without symbol": func(m *Machine) {
+			m.Scatter = ""
+		},
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := ClassicMachine()
+			mutate(m)
+			if err := m.Validate(); err == nil {
+				t.Fatalf("expected validation error")
+			}
+		})
+	}
+}
+
+func TestParseMachines_ExampleFile(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("..", "..", "..", "deploy", "casino_machines.example.json"))
+	if err != nil {
+		t.Fatalf("read example: %v", err)
+	}
+	set, err := ParseMachines(raw, "")
+	if err != nil {
+		t.Fatalf("parse example: %v", err)
+	}
+	if set.Default().Name != DefaultMachineName {
+		t.Fatalf("default = %q", set.Default().Name)
+	}
+	if names := set.Names(); len(names) != 2 || names[0] != DefaultMachineName {
+		t.Fatalf("unexpected names %v", names)
+	}
+	if _, ok := set.Get("ХЭЛЛОУИН"); !ok {
+		t.Fatal("machine lookup must ignore case")
+	}
+
+	// Классика из файла совпадает со встроенной.
+	fromFile, _ := set.Get(DefaultMachineName)
+	want, _ := json.Marshal(ClassicMachine())
+	got, _ := json.Marshal(fromFile)
+	if string(got) != string(want) {
+		t.Fatalf("example classic drifted from built-in:\n%s\n%s", got, want)
+	}
+
+	override, err := ParseMachines(raw, "хэллоуин")
+	if err != nil || override.Default().Name != "хэллоуин" {
+		t.Fatalf("config default must override file default: %v", err)
+	}
+}
+
+func TestParseMachines_Errors(t *testing.T) {
+	cases := map[string]string{
+		"unknown field":   `{"machines":[{"name":"x","reelz":5}]}`,
+		"no machines":     `{"machines":[]}`,
+		"missing default": `{"default":"nope","machines":[` + classicJSON(t) + `]}`,
+		"duplicate":       `{"machines":[` + classicJSON(t) + `,` + classicJSON(t) + `]}`,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseMachines([]byte(raw), ""); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+	if _, err := LoadMachines(filepath.Join(t.TempDir(), "missing.json"), ""); err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Fatalf("expected file error, got %v", err)
+	}
+}
+
+func TestLoadMachines_EmptyPathUsesClassic(t *testing.T) {
+	set, err := LoadMachines("", "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if set.Default().Name != DefaultMachineName {
+		t.Fatalf("default = %q", set.Default().Name)
+	}
+}
+
+func classicJSON(t *testing.T) string {
+	t.Helper()
+	raw, err := json.Marshal(ClassicMachine())
+	if err != nil {
+		t.Fatalf("marshal classic: %v", err)
+	}
+	return string(raw)
+}
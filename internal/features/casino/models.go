@@ -9,13 +9,13 @@ import (
 
 // Symbol представляет символ слот-машины.
 type Symbol struct {
-	Emoji  string // Эмодзи символа (🍒, 💎, 7️⃣ и т.д.)
-	Name   string // Название для логов
-	Weight int    // Вес (вероятность появления)
-	Value  int    // Множитель выплаты
+	Emoji  string `json:"emoji"`  // Эмодзи символа (🍒, 💎, 7️⃣ и т.д.)
+	Name   string `json:"name"`   // Название для логов
+	Weight int    `json:"weight"` // Вес (вероятность появления)
+	Value  int    `json:"value"`  // Множитель выплаты
 }
 
-// DefaultSymbols — символы с начальными весами.
+// DefaultSymbols — символы встроенной машины classic с начальными весами.
 // Веса определяют вероятность: чем больше вес, тем чаще выпадает.
 var DefaultSymbols = []Symbol{
 	{Emoji: "🍒", Name: "Cherry", Weight: 25, Value: 1},     // 25% — самый частый
@@ -29,15 +29,37 @@ var DefaultSymbols = []Symbol{
 	{Emoji: "🎰", Name: "Scatter", Weight: 1, Value: 0},     // 1% — бонус
 }
 
-// Константы символов
+// Константы символов встроенной машины classic
 const (
 	WildEmoji    = "⭐"
 	ScatterEmoji = "🎰"
+	JackpotEmoji = "7️⃣"
 )
 
-// Grid — сетка слотов 5 рилов × 6 строк.
+// Grid — сетка слотов: рилы × строки (у classic 5 × 6).
 // Grid[reel][row] — символ на конкретной позиции.
-type Grid [5][6]string
+type Grid [][]string
+
+// newGrid создаёт пустую сетку заданного размера.
+func newGrid(reels, rows int) Grid {
+	grid := make(Grid, reels)
+	for reel := range grid {
+		grid[reel] = make([]string, rows)
+	}
+	return grid
+}
+
+// Значения casino_games.game_type.
+const (
+	GameTypeSlots    = "slots"
+	GameTypeDice     = "dice"
+	GameTypeRoulette = "roulette"
+	GameTypeCoinFlip = "coinflip"
+)
+
+// GameTypeDuel — ключ границ ставки дуэли в BetRange; в casino_games дуэли
+// не записываются.
+const GameTypeDuel = "duel"
 
 // Game — запись одной игры в БД.
 type Game struct {
@@ -60,12 +82,38 @@ type Stats struct {
 	TotalWon     int64     `db:"total_won"`
 	BiggestWin   int64     `db:"biggest_win"`
 	CurrentRTP   float64   `db:"current_rtp"`
+	RTPTier      RTPTier   `db:"rtp_tier"`
 	CreatedAt    time.Time `db:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at"`
 }
 
+// Jackpot — общий прогрессивный джекпот слотов.
+type Jackpot struct {
+	Amount        int64      `db:"amount"`
+	LastWinnerID  *int64     `db:"last_winner_id"`
+	LastWinAmount int64      `db:"last_win_amount"`
+	LastWonAt     *time.Time `db:"last_won_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+}
+
+// DailyUsage — дневной учёт игрока для лимитов ставок и проигрыша.
+type DailyUsage struct {
+	UserID  int64     `db:"user_id"`
+	Day     time.Time `db:"day"`
+	Spins   int       `db:"spins"`
+	Wagered int64     `db:"wagered"`
+	Won     int64     `db:"won"`
+}
+
+// NetLoss возвращает чистый проигрыш за день (отрицательный — игрок в плюсе).
+func (u DailyUsage) NetLoss() int64 {
+	return u.Wagered - u.Won
+}
+
 // SlotResult — результат одного спина.
 type SlotResult struct {
+	Machine      string   // Имя машины, на которой сыгран спин
+	Bet          int64    // Ставка спина
 	Grid         Grid     // Сетка 5x6
 	WinLines     []WinLine // Выигрышные линии
 	ScatterCount int      // Количество скаттеров
@@ -73,17 +121,39 @@ type SlotResult struct {
 	TotalPayout  int64    // Общий выигрыш
 	IsWin        bool     // Есть ли выигрыш
 	FreeSpins    int      // Бесплатные спины от скаттеров
+	JackpotWin   int64    // Выплата из джекпот-пула (входит в TotalPayout)
+	GameID       int64      // ID записи в casino_games
+	Fair         *FairProof // Входные данные честного спина; nil — обычный режим
+
+	FreeSpinRounds []FreeSpinRound // Результаты сыгранных фриспинов
+}
+
+// FreeSpinRound — результат одного фриспина.
+type FreeSpinRound struct {
+	Grid         Grid
+	WinLines     []WinLine
+	ScatterCount int
+	ScatterWin   int64
+}
+
+// Payout возвращает выплату фриспина по линиям и скаттерам.
+func (r FreeSpinRound) Payout() int64 {
+	total := r.ScatterWin
+	for _, win := range r.WinLines {
+		total += win.Payout
+	}
+	return total
 }
 
 // WinLine — выигрышная линия.
 type WinLine struct {
-	LineIndex int    // Номер линии (0-19)
+	LineIndex int    // Номер линии (с 0)
 	Symbol    string // Выигрышный символ
 	Count     int    // Сколько совпало (3, 4 или 5)
 	Payout    int64  // Выплата по этой линии
 }
 
-// PayoutTable — таблица выплат (множители от ставки).
+// PayoutTable — таблица выплат classic (множители от ставки).
 var PayoutTable = map[int]int64{
 	3: 2,  // 3 символа: 2x ставки (100 пленок)
 	4: 5,  // 4 символа: 5x (250 пленок)
@@ -92,15 +162,18 @@ var PayoutTable = map[int]int64{
 
 // SpecialPayouts — специальные выплаты для редких символов.
 var SpecialPayouts = map[string]map[int]int64{
-	"7️⃣": {5: 50}, // 5x Seven: 50x (2500 пленок) — ДЖЕКПОТ!
+	"7️⃣": {5: 50}, // 5x Seven: 50x (2500 пленок) — джекпот, если пул меньше
 	"💎": {5: 30}, // 5x Diamond: 30x (1500 пленок)
 }
 
+// ScatterPayout — бонус за количество скаттеров.
+type ScatterPayout struct {
+	FreeSpins int   `json:"free_spins"`
+	Bonus     int64 `json:"bonus"`
+}
+
 // ScatterPayouts — бонусы за скаттеры (появляются в любом месте сетки).
-var ScatterPayouts = map[int]struct {
-	FreeSpins int
-	Bonus     int64
-}{
+var ScatterPayouts = map[int]ScatterPayout{
 	3: {FreeSpins: 1, Bonus: 100},  // 3 скаттера: 1 фриспин + 100
 	4: {FreeSpins: 2, Bonus: 200},  // 4 скаттера: 2 фриспина + 200
 	5: {FreeSpins: 3, Bonus: 500},  // 5 скаттеров: 3 фриспина + 500
@@ -1,8 +1,10 @@
 package casino
 
 import (
+	"serotonyl.ru/telegram-bot/internal/audit"
 	"serotonyl.ru/telegram-bot/internal/config"
 	"serotonyl.ru/telegram-bot/internal/feature"
+	"serotonyl.ru/telegram-bot/internal/features/members"
 	"serotonyl.ru/telegram-bot/internal/telegram"
 )
 
@@ -10,6 +12,7 @@ type Deps struct {
 	Cfg     *config.Config
 	Ops     *telegram.Ops
 	Service *Service
+	Members *members.Service
 }
 
 type Module struct {
@@ -18,7 +21,10 @@ type Module struct {
 }
 
 func NewModule(deps Deps) (*Module, error) {
-	h := NewHandler(deps.Service, deps.Ops)
+	if deps.Service != nil && deps.Cfg != nil {
+		deps.Service.SetAuditLogger(audit.NewLogger(deps.Ops, deps.Cfg.AdminChatID), deps.Members)
+	}
+	h := NewHandler(deps.Service, deps.Members, deps.Ops, deps.Cfg)
 	f := NewFeature(h, deps.Cfg)
 	return &Module{Handler: h, Feature: f}, nil
 }
@@ -0,0 +1,199 @@
+// Package casino — replay.go восстанавливает сыгранный спин слотов по
+// casino_games.game_data для !игра и админского инспектора: сетку, выигрышные
+// линии с их формой, скаттеры и фриспины. Поддержка разбирает по нему споры
+// «выиграл, но не получил»: выплата записывается в той же транзакции, что и игра.
+package casino
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+)
+
+// ErrNotSlotGame — игра сыграна не в слотах, сетки у неё нет.
+var ErrNotSlotGame = errors.New("casino game is not a slot game")
+
+const (
+	paylineCellOff  = "⬛"
+	paylineCellMiss = "⬜"
+)
+
+// GameReplay — восстановленный спин слотов.
+type GameReplay struct {
+	Game     *Game
+	Result   *SlotResult
+	Paylines map[int][]int // Формы выигравших линий; нет формы — машина изменилась
+	// FreeSpinsMissing — фриспины выпали, но их сетки не сохранены (игры
+	// до сохранения фриспинов).
+	FreeSpinsMissing bool
+}
+
+// ReplayGame восстанавливает спин слотов gameID для администратора.
+func (s *Service) ReplayGame(ctx context.Context, gameID int64) (*GameReplay, error) {
+	game, err := s.repo.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game.GameType != GameTypeSlots {
+		return nil, ErrNotSlotGame
+	}
+	data, err := ParseGameData(game.GameData)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildReplay(game, data), nil
+}
+
+// ReplayOwnGame восстанавливает спин, только если его сыграл userID;
+// чужая игра выглядит как несуществующая.
+func (s *Service) ReplayOwnGame(ctx context.Context, gameID, userID int64) (*GameReplay, error) {
+	replay, err := s.ReplayGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if replay.Game.UserID != userID {
+		return nil, ErrGameNotFound
+	}
+	return replay, nil
+}
+
+// buildReplay собирает SlotResult из сохранённых данных. Старые записи без
+// scatter_win и форм линий дополняются из текущего описания машины.
+func (s *Service) buildReplay(game *Game, data *GameData) *GameReplay {
+	result := &SlotResult{
+		Machine:      data.Machine,
+		Bet:          game.BetAmount,
+		Grid:         data.Grid,
+		WinLines:     data.Wins,
+		ScatterCount: data.Scatters,
+		ScatterWin:   data.ScatterWin,
+		TotalPayout:  game.ResultAmount,
+		IsWin:        game.ResultAmount > 0,
+		FreeSpins:    len(data.FreeSpins),
+		JackpotWin:   data.JackpotWin,
+		GameID:       game.ID,
+		Fair:         data.Fair,
+	}
+	for _, spin := range data.FreeSpins {
+		result.FreeSpinRounds = append(result.FreeSpinRounds, FreeSpinRound{
+			Grid:         spin.Grid,
+			WinLines:     spin.Wins,
+			ScatterCount: spin.Scatters,
+			ScatterWin:   spin.ScatterWin,
+		})
+	}
+
+	replay := &GameReplay{Game: game, Result: result, Paylines: data.Paylines}
+	machine, ok := s.machines.Get(data.Machine)
+	if data.Machine == "" {
+		machine, ok = s.machines.Default(), true
+	}
+	if !ok {
+		return replay
+	}
+	bonus, freeSpins := machine.CalculateScatterBonus(data.Scatters)
+	if result.ScatterWin == 0 {
+		result.ScatterWin = bonus
+	}
+	if freeSpins > len(data.FreeSpins) {
+		result.FreeSpins = freeSpins
+		replay.FreeSpinsMissing = true
+	}
+	if replay.Paylines == nil {
+		replay.Paylines = make(map[int][]int)
+		for _, wins := range append([][]WinLine{data.Wins}, freeSpinWins(data.FreeSpins)...) {
+			for _, win := range wins {
+				if win.LineIndex >= 0 && win.LineIndex < len(machine.Paylines) {
+					replay.Paylines[win.LineIndex] = machine.Paylines[win.LineIndex]
+				}
+			}
+		}
+	}
+	return replay
+}
+
+func freeSpinWins(spins []FreeSpinData) [][]WinLine {
+	out := make([][]WinLine, 0, len(spins))
+	for _, spin := range spins {
+		out = append(out, spin.Wins)
+	}
+	return out
+}
+
+// FormatGameReplay описывает восстановленный спин: сетку, каждую выигрышную
+// линию с её формой, скаттеры, фриспины и итоговую выплату.
+func FormatGameReplay(r *GameReplay) string {
+	res := r.Result
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🎰 ИГРА #%d\n", r.Game.ID)
+	machine := res.Machine
+	if machine == "" {
+		machine = DefaultMachineName
+	}
+	fmt.Fprintf(&sb, "Машина: %s · %s\n\n", machine, common.FormatDateTime(r.Game.CreatedAt))
+
+	sb.WriteString(FormatGrid(res.Grid))
+	writeReplayWins(&sb, res.Grid, res.WinLines, r.Paylines)
+	if res.ScatterCount > 0 {
+		fmt.Fprintf(&sb, "\n🎰 Скаттеров: %d → %s\n", res.ScatterCount, common.FormatBalance(res.ScatterWin))
+	}
+
+	for i, round := range res.FreeSpinRounds {
+		fmt.Fprintf(&sb, "\n🎁 Фриспин %d из %d\n", i+1, res.FreeSpins)
+		sb.WriteString(FormatGrid(round.Grid))
+		writeReplayWins(&sb, round.Grid, round.WinLines, r.Paylines)
+		if round.ScatterWin > 0 {
+			fmt.Fprintf(&sb, "🎰 Скаттеров: %d → %s\n", round.ScatterCount, common.FormatBalance(round.ScatterWin))
+		}
+	}
+	if r.FreeSpinsMissing {
+		fmt.Fprintf(&sb, "\nℹ️ Фриспинов: %d, их сетки не сохранялись в то время\n", res.FreeSpins)
+	}
+
+	if res.JackpotWin > 0 {
+		fmt.Fprintf(&sb, "\n🏆 Джекпот: %s\n", common.FormatBalance(res.JackpotWin))
+	}
+	fmt.Fprintf(&sb, "\n🎟 Ставка: %s\n💰 Выплата: %s", common.FormatBalance(res.Bet), common.FormatBalance(res.TotalPayout))
+	if res.TotalPayout > 0 {
+		sb.WriteString("\n✅ Выплата зачислена в одной транзакции с записью игры")
+	}
+	return sb.String()
+}
+
+func writeReplayWins(sb *strings.Builder, grid Grid, wins []WinLine, paylines map[int][]int) {
+	for _, win := range wins {
+		fmt.Fprintf(sb, "\n✅ Линия %d: %dx %s → %s\n", win.LineIndex+1, win.Count, win.Symbol, common.FormatBalance(win.Payout))
+		if line, ok := paylines[win.LineIndex]; ok {
+			sb.WriteString(FormatPayline(grid, line, win.Count))
+		}
+	}
+}
+
+// FormatPayline рисует форму линии на сетке: совпавшие клетки линии
+// показывают символ, остальные клетки линии — ⬜, клетки вне линии — ⬛.
+func FormatPayline(grid Grid, line []int, count int) string {
+	if len(grid) == 0 || len(line) != len(grid) {
+		return ""
+	}
+	var sb strings.Builder
+	for row := 0; row < len(grid[0]); row++ {
+		for reel := 0; reel < len(grid); reel++ {
+			if reel > 0 {
+				sb.WriteString(" ")
+			}
+			switch {
+			case line[reel] != row:
+				sb.WriteString(paylineCellOff)
+			case reel < count:
+				sb.WriteString(grid[reel][row])
+			default:
+				sb.WriteString(paylineCellMiss)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package casino
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReplayGame_RebuildsPlayedSpin(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 10_000
+	svc := newTestService(store)
+	// Сетка из семёрок: выигрывают все линии, формы каждой должны сохраниться.
+	svc.rng = sevensSource
+	ctx := context.Background()
+
+	result, err := svc.PlaySlots(ctx, 7, "", 50)
+	if err != nil {
+		t.Fatalf("PlaySlots: %v", err)
+	}
+	replay, err := svc.ReplayOwnGame(ctx, result.GameID, 7)
+	if err != nil {
+		t.Fatalf("ReplayOwnGame: %v", err)
+	}
+	if replay.Result.TotalPayout != result.TotalPayout || len(replay.Result.WinLines) != len(result.WinLines) {
+		t.Fatalf("replay = %+v, want %+v", replay.Result, result)
+	}
+	if !gridsEqual(replay.Result.Grid, result.Grid) {
+		t.Fatalf("replayed grid differs")
+	}
+	for _, win := range result.WinLines {
+		if _, ok := replay.Paylines[win.LineIndex]; !ok {
+			t.Fatalf("payline shape for line %d not persisted", win.LineIndex+1)
+		}
+	}
+	if _, err := svc.ReplayOwnGame(ctx, result.GameID, 8); !errors.Is(err, ErrGameNotFound) {
+		t.Fatalf("foreign game: err = %v, want ErrGameNotFound", err)
+	}
+}
+
+func TestSaveGameData_PersistsFreeSpins(t *testing.T) {
+	grid := Grid{{"🍒"}, {"🍒"}, {"🍒"}}
+	result := &SlotResult{
+		Machine: DefaultMachineName, Grid: grid, ScatterCount: 3, ScatterWin: 100, FreeSpins: 1,
+		FreeSpinRounds: []FreeSpinRound{{
+			Grid:     grid,
+			WinLines: []WinLine{{LineIndex: 1, Symbol: "🍒", Count: 3, Payout: 100}},
+		}},
+	}
+	data, err := ParseGameData(SaveGameData(result, [][]int{{0, 0, 0}, {0, 0, 0}}, nil))
+	if err != nil {
+		t.Fatalf("ParseGameData: %v", err)
+	}
+	if len(data.FreeSpins) != 1 || data.FreeSpins[0].Wins[0].Payout != 100 || data.ScatterWin != 100 {
+		t.Fatalf("free spins not persisted: %+v", data)
+	}
+	if _, ok := data.Paylines[1]; !ok {
+		t.Fatalf("free spin payline shape not persisted: %+v", data.Paylines)
+	}
+
+	svc := newTestService(newFakeStore())
+	replay := svc.buildReplay(&Game{ID: 1, BetAmount: 50, ResultAmount: 200}, data)
+	text := FormatGameReplay(replay)
+	for _, want := range []string{"Фриспин 1 из 1", "Линия 2: 3x 🍒", "Скаттеров: 3"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("replay text %q does not contain %q", text, want)
+		}
+	}
+}
+
+func TestBuildReplay_LegacyGameWithoutFreeSpinGrids(t *testing.T) {
+	// Запись до сохранения фриспинов: только сетка, линии и скаттеры.
+	raw, _ := json.Marshal(map[string]any{
+		"machine":  DefaultMachineName,
+		"grid":     Grid{{"🎰"}, {"🎰"}, {"🎰"}, {"🍒"}, {"🍒"}},
+		"wins":     []WinLine{{LineIndex: 6, Symbol: "🍒", Count: 3, Payout: 100}},
+		"scatters": 3,
+	})
+	data, err := ParseGameData(raw)
+	if err != nil {
+		t.Fatalf("ParseGameData: %v", err)
+	}
+	svc := newTestService(newFakeStore())
+	replay := svc.buildReplay(&Game{ID: 2, BetAmount: 50, ResultAmount: 300}, data)
+	if !replay.FreeSpinsMissing || replay.Result.FreeSpins != 1 || replay.Result.ScatterWin != 100 {
+		t.Fatalf("legacy replay = %+v", replay.Result)
+	}
+	if _, ok := replay.Paylines[6]; !ok {
+		t.Fatalf("legacy payline shape must come from the machine")
+	}
+}
+
+func TestFormatPayline(t *testing.T) {
+	grid := Grid{{"🍒", "🍋"}, {"🍋", "🍒"}, {"🍒", "🍋"}}
+	got := FormatPayline(grid, []int{0, 1, 0}, 2)
+	want := "🍒 ⬛ ⬜\n⬛ 🍒 ⬛\n"
+	if got != want {
+		t.Fatalf("FormatPayline = %q, want %q", got, want)
+	}
+}
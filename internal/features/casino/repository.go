@@ -1,14 +1,27 @@
-// Package casino — repository.go выполняет операции с таблицами casino_games и casino_stats.
+// Package casino — repository.go выполняет операции с таблицами casino_games,
+// casino_stats, casino_daily_limits, casino_jackpot, casino_seeds, casino_duels,
+// casino_exclusions и casino_loss_limits.
 package casino
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// casinoDBTX — общий интерфейс пула и транзакции для запросов казино.
+type casinoDBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // Repository работает с таблицами казино в БД.
 type Repository struct {
 	db *pgxpool.Pool
@@ -21,14 +34,24 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 
 // SaveGame сохраняет результат спина в таблицу casino_games.
 func (r *Repository) SaveGame(ctx context.Context, game *Game) error {
+	return r.saveGame(ctx, r.db, game)
+}
+
+// SaveGameTx сохраняет результат спина внутри транзакции спина.
+func (r *Repository) SaveGameTx(ctx context.Context, tx pgx.Tx, game *Game) error {
+	return r.saveGame(ctx, tx, game)
+}
+
+func (r *Repository) saveGame(ctx context.Context, db casinoDBTX, game *Game) error {
 	query := `
 		INSERT INTO casino_games (user_id, game_type, bet_amount, result_amount, game_data, rtp_percentage)
 		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
 	`
-	_, err := r.db.Exec(ctx, query,
+	err := db.QueryRow(ctx, query,
 		game.UserID, game.GameType, game.BetAmount,
 		game.ResultAmount, game.GameData, game.RTPPercent,
-	)
+	).Scan(&game.ID, &game.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("ошибка сохранения игры: %w", err)
 	}
@@ -39,19 +62,88 @@ func (r *Repository) SaveGame(ctx context.Context, game *Game) error {
 func (r *Repository) GetStats(ctx context.Context, userID int64) (*Stats, error) {
 	query := `
 		SELECT id, user_id, total_spins, total_wagered, total_won, biggest_win,
-		       current_rtp, created_at, updated_at
+		       current_rtp, rtp_tier, created_at, updated_at
 		FROM casino_stats
 		WHERE user_id = $1
 	`
-	var s Stats
-	err := r.db.QueryRow(ctx, query, userID).Scan(
-		&s.ID, &s.UserID, &s.TotalSpins, &s.TotalWagered,
-		&s.TotalWon, &s.BiggestWin, &s.CurrentRTP,
-		&s.CreatedAt, &s.UpdatedAt,
-	)
+	s, err := scanStats(r.db.QueryRow(ctx, query, userID))
 	if err != nil {
 		return nil, fmt.Errorf("статистика не найдена: %w", err)
 	}
+	return s, nil
+}
+
+// ListStatsByTier возвращает статистику игроков для админского обзора RTP:
+// сначала игроки с активной коррекцией, затем по свежести последнего спина.
+func (r *Repository) ListStatsByTier(ctx context.Context, limit int) ([]*Stats, error) {
+	query := `
+		SELECT id, user_id, total_spins, total_wagered, total_won, biggest_win,
+		       current_rtp, rtp_tier, created_at, updated_at
+		FROM casino_stats
+		ORDER BY (rtp_tier = 'normal') ASC, updated_at DESC
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения статистики казино: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*Stats
+	for rows.Next() {
+		s, err := scanStats(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения статистики казино: %w", err)
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// GetRTPTierForUpdateTx лениво загружает ступень коррекции игрока и блокирует
+// его строку статистики до конца спина. Новый игрок получает стандартные веса.
+func (r *Repository) GetRTPTierForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (RTPTier, error) {
+	var raw string
+	err := tx.QueryRow(ctx, `
+		SELECT rtp_tier
+		FROM casino_stats
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return RTPTierNormal, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка загрузки RTP: %w", err)
+	}
+	return parseRTPTier(raw), nil
+}
+
+// SetRTPTierTx сохраняет ступень коррекции, выбранную по итогам спина.
+func (r *Repository) SetRTPTierTx(ctx context.Context, tx pgx.Tx, userID int64, tier RTPTier) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE casino_stats
+		SET rtp_tier = $2
+		WHERE user_id = $1
+	`, userID, string(tier)); err != nil {
+		return fmt.Errorf("ошибка сохранения RTP: %w", err)
+	}
+	return nil
+}
+
+func scanStats(row pgx.Row) (*Stats, error) {
+	var (
+		s    Stats
+		tier string
+	)
+	if err := row.Scan(
+		&s.ID, &s.UserID, &s.TotalSpins, &s.TotalWagered,
+		&s.TotalWon, &s.BiggestWin, &s.CurrentRTP, &tier,
+		&s.CreatedAt, &s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	s.RTPTier = parseRTPTier(tier)
 	return &s, nil
 }
 
@@ -69,10 +161,23 @@ func (r *Repository) CreateStats(ctx context.Context, userID int64, initialRTP f
 // UpdateStats обновляет статистику после спина.
 // Обновляет в одном запросе: спины, ставки, выигрыши, рекорд и RTP.
 func (r *Repository) UpdateStats(ctx context.Context, userID int64, betAmount, wonAmount int64) error {
+	_, err := r.updateStats(ctx, r.db, userID, betAmount, wonAmount)
+	return err
+}
+
+// UpdateStatsTx обновляет статистику внутри транзакции спина
+// и возвращает пересчитанный RTP игрока.
+func (r *Repository) UpdateStatsTx(ctx context.Context, tx pgx.Tx, userID int64, betAmount, wonAmount int64) (float64, error) {
+	return r.updateStats(ctx, tx, userID, betAmount, wonAmount)
+}
+
+func (r *Repository) updateStats(ctx context.Context, db casinoDBTX, userID int64, betAmount, wonAmount int64) (float64, error) {
+	// current_rtp хранится как DECIMAL(5,2): крупный выигрыш на малом обороте
+	// не должен ронять транзакцию спина, поэтому значение ограничено сверху.
 	query := `
 		INSERT INTO casino_stats (user_id, total_spins, total_wagered, total_won, biggest_win, current_rtp)
 		VALUES ($1, 1, $2, $3, $3,
-			CASE WHEN $2 = 0 THEN 96.0 ELSE ($3::DECIMAL / $2::DECIMAL) * 100 END)
+			CASE WHEN $2 = 0 THEN 96.0 ELSE LEAST(($3::DECIMAL / $2::DECIMAL) * 100, 999.99) END)
 		ON CONFLICT (user_id) DO UPDATE SET
 			total_spins = casino_stats.total_spins + 1,
 			total_wagered = casino_stats.total_wagered + $2,
@@ -80,17 +185,585 @@ func (r *Repository) UpdateStats(ctx context.Context, userID int64, betAmount, w
 			biggest_win = GREATEST(casino_stats.biggest_win, $3),
 			current_rtp = CASE
 				WHEN (casino_stats.total_wagered + $2) = 0 THEN 96.0
-				ELSE ((casino_stats.total_won + $3)::DECIMAL / (casino_stats.total_wagered + $2)::DECIMAL) * 100
+				ELSE LEAST(((casino_stats.total_won + $3)::DECIMAL / (casino_stats.total_wagered + $2)::DECIMAL) * 100, 999.99)
 			END,
 			updated_at = NOW()
+		RETURNING current_rtp::FLOAT8
 	`
-	_, err := r.db.Exec(ctx, query, userID, betAmount, wonAmount)
+	var currentRTP float64
+	if err := db.QueryRow(ctx, query, userID, betAmount, wonAmount).Scan(&currentRTP); err != nil {
+		return 0, fmt.Errorf("ошибка обновления статистики: %w", err)
+	}
+	return currentRTP, nil
+}
+
+// GetDailyUsage возвращает учёт игрока за день day; нет записи — нулевой учёт.
+func (r *Repository) GetDailyUsage(ctx context.Context, userID int64, day time.Time) (*DailyUsage, error) {
+	usage := &DailyUsage{UserID: userID, Day: day}
+	err := r.db.QueryRow(ctx, `
+		SELECT spins, wagered, won
+		FROM casino_daily_limits
+		WHERE user_id = $1 AND day = $2
+	`, userID, day).Scan(&usage.Spins, &usage.Wagered, &usage.Won)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return usage, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки дневных лимитов: %w", err)
+	}
+	return usage, nil
+}
+
+// LockDailyUsageTx создаёт строку дневного учёта при необходимости и блокирует
+// её до конца спина, чтобы параллельные спины не обходили лимиты.
+func (r *Repository) LockDailyUsageTx(ctx context.Context, tx pgx.Tx, userID int64, day time.Time) (*DailyUsage, error) {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO casino_daily_limits (user_id, day)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, day) DO NOTHING
+	`, userID, day); err != nil {
+		return nil, fmt.Errorf("ошибка создания дневных лимитов: %w", err)
+	}
+	usage := &DailyUsage{UserID: userID, Day: day}
+	if err := tx.QueryRow(ctx, `
+		SELECT spins, wagered, won
+		FROM casino_daily_limits
+		WHERE user_id = $1 AND day = $2
+		FOR UPDATE
+	`, userID, day).Scan(&usage.Spins, &usage.Wagered, &usage.Won); err != nil {
+		return nil, fmt.Errorf("ошибка блокировки дневных лимитов: %w", err)
+	}
+	return usage, nil
+}
+
+// AddDailyUsageTx добавляет спин к дневному учёту игрока.
+func (r *Repository) AddDailyUsageTx(ctx context.Context, tx pgx.Tx, userID int64, day time.Time, betAmount, wonAmount int64) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO casino_daily_limits (user_id, day, spins, wagered, won)
+		VALUES ($1, $2, 1, $3, $4)
+		ON CONFLICT (user_id, day) DO UPDATE SET
+			spins = casino_daily_limits.spins + 1,
+			wagered = casino_daily_limits.wagered + $3,
+			won = casino_daily_limits.won + $4,
+			updated_at = NOW()
+	`, userID, day, betAmount, wonAmount); err != nil {
+		return fmt.Errorf("ошибка обновления дневных лимитов: %w", err)
+	}
+	return nil
+}
+
+// GetGame возвращает игру по ID.
+func (r *Repository) GetGame(ctx context.Context, gameID int64) (*Game, error) {
+	var g Game
+	err := r.db.QueryRow(ctx, `
+		SELECT id, COALESCE(user_id, 0), COALESCE(game_type, 'slots'), COALESCE(bet_amount, 0), result_amount, game_data,
+		       COALESCE(rtp_percentage, 0)::FLOAT8, created_at
+		FROM casino_games
+		WHERE id = $1
+	`, gameID).Scan(&g.ID, &g.UserID, &g.GameType, &g.BetAmount, &g.ResultAmount,
+		&g.GameData, &g.RTPPercent, &g.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки игры: %w", err)
+	}
+	return &g, nil
+}
+
+const seedColumns = `id, user_id, server_seed, server_seed_hash, client_seed, nonce, created_at, revealed_at`
+
+func scanSeed(row pgx.Row) (*Seed, error) {
+	var s Seed
+	if err := row.Scan(&s.ID, &s.UserID, &s.ServerSeed, &s.ServerSeedHash,
+		&s.ClientSeed, &s.Nonce, &s.CreatedAt, &s.RevealedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSeed возвращает сид по ID.
+func (r *Repository) GetSeed(ctx context.Context, seedID int64) (*Seed, error) {
+	s, err := scanSeed(r.db.QueryRow(ctx, `SELECT `+seedColumns+` FROM casino_seeds WHERE id = $1`, seedID))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки сида: %w", err)
+	}
+	return s, nil
+}
+
+// EnsureActiveSeed возвращает активный сид игрока, создавая candidate, если его нет.
+func (r *Repository) EnsureActiveSeed(ctx context.Context, userID int64, candidate *Seed) (*Seed, error) {
+	if err := r.insertSeed(ctx, r.db, userID, candidate); err != nil {
+		return nil, err
+	}
+	s, err := scanSeed(r.db.QueryRow(ctx, `
+		SELECT `+seedColumns+`
+		FROM casino_seeds
+		WHERE user_id = $1 AND revealed_at IS NULL
+	`, userID))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки сида: %w", err)
+	}
+	return s, nil
+}
+
+// NextNonceTx увеличивает nonce активного сида игрока (создавая candidate,
+// если сида ещё нет) и возвращает сид с номером текущего спина.
+// Строка сида остаётся заблокированной до конца транзакции спина.
+func (r *Repository) NextNonceTx(ctx context.Context, tx pgx.Tx, userID int64, candidate *Seed) (*Seed, error) {
+	if err := r.insertSeed(ctx, tx, userID, candidate); err != nil {
+		return nil, err
+	}
+	s, err := scanSeed(tx.QueryRow(ctx, `
+		UPDATE casino_seeds
+		SET nonce = nonce + 1
+		WHERE user_id = $1 AND revealed_at IS NULL
+		RETURNING `+seedColumns, userID))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления nonce: %w", err)
+	}
+	return s, nil
+}
+
+// RotateSeedTx раскрывает активный сид игрока и заводит next.
+// Возвращает раскрытый сид; nil — активного сида не было.
+func (r *Repository) RotateSeedTx(ctx context.Context, tx pgx.Tx, userID int64, next *Seed) (*Seed, error) {
+	revealed, err := scanSeed(tx.QueryRow(ctx, `
+		UPDATE casino_seeds
+		SET revealed_at = NOW()
+		WHERE user_id = $1 AND revealed_at IS NULL
+		RETURNING `+seedColumns, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		revealed = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("ошибка раскрытия сида: %w", err)
+	}
+	if err := r.insertSeed(ctx, tx, userID, next); err != nil {
+		return nil, err
+	}
+	return revealed, nil
+}
+
+func (r *Repository) insertSeed(ctx context.Context, db casinoDBTX, userID int64, seed *Seed) error {
+	if _, err := db.Exec(ctx, `
+		INSERT INTO casino_seeds (user_id, server_seed, server_seed_hash, client_seed)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) WHERE revealed_at IS NULL DO NOTHING
+	`, userID, seed.ServerSeed, seed.ServerSeedHash, seed.ClientSeed); err != nil {
+		return fmt.Errorf("ошибка создания сида: %w", err)
+	}
+	return nil
+}
+
+// GetJackpot возвращает текущее состояние джекпот-пула.
+func (r *Repository) GetJackpot(ctx context.Context) (*Jackpot, error) {
+	var j Jackpot
+	err := r.db.QueryRow(ctx, `
+		SELECT amount, last_winner_id, last_win_amount, last_won_at, updated_at
+		FROM casino_jackpot
+		WHERE id = 1
+	`).Scan(&j.Amount, &j.LastWinnerID, &j.LastWinAmount, &j.LastWonAt, &j.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Jackpot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки джекпота: %w", err)
+	}
+	return &j, nil
+}
+
+// AddToJackpotTx пополняет пул долей ставки и возвращает новый размер пула.
+// Строка пула остаётся заблокированной до конца транзакции спина, поэтому
+// вызывается последней записью перед коммитом: параллельные спины ждут друг
+// друга только на коммите, а не на весь раунд. Цена — одна строка всё равно
+// упорядочивает все спины со взносом; если этого не хватит, взносы можно
+// писать в отдельную append-only таблицу и суммировать при выплате.
+func (r *Repository) AddToJackpotTx(ctx context.Context, tx pgx.Tx, amount int64) (int64, error) {
+	var pool int64
+	err := tx.QueryRow(ctx, `
+		INSERT INTO casino_jackpot (id, amount)
+		VALUES (1, $1)
+		ON CONFLICT (id) DO UPDATE SET
+			amount = casino_jackpot.amount + $1,
+			updated_at = NOW()
+		RETURNING amount
+	`, amount).Scan(&pool)
 	if err != nil {
-		return fmt.Errorf("ошибка обновления статистики: %w", err)
+		return 0, fmt.Errorf("ошибка пополнения джекпота: %w", err)
+	}
+	return pool, nil
+}
+
+// LockJackpotTx блокирует строку пула и возвращает его размер.
+func (r *Repository) LockJackpotTx(ctx context.Context, tx pgx.Tx) (int64, error) {
+	var pool int64
+	err := tx.QueryRow(ctx, `
+		SELECT amount
+		FROM casino_jackpot
+		WHERE id = 1
+		FOR UPDATE
+	`).Scan(&pool)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка блокировки джекпота: %w", err)
+	}
+	return pool, nil
+}
+
+// PayJackpotTx списывает выплату из пула и запоминает победителя.
+func (r *Repository) PayJackpotTx(ctx context.Context, tx pgx.Tx, userID, amount int64) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE casino_jackpot
+		SET amount = amount - $2,
+			last_winner_id = $1,
+			last_win_amount = $2,
+			last_won_at = NOW(),
+			updated_at = NOW()
+		WHERE id = 1
+	`, userID, amount); err != nil {
+		return fmt.Errorf("ошибка выплаты джекпота: %w", err)
 	}
 	return nil
 }
 
+const duelColumns = `id, token, chat_id, message_id, challenger_id, opponent_id, stake, state,
+	COALESCE(challenger_roll, 0), COALESCE(opponent_roll, 0), winner_id, fee, created_at, expires_at, resolved_at`
+
+func scanDuel(row pgx.Row) (*Duel, error) {
+	var d Duel
+	err := row.Scan(&d.ID, &d.Token, &d.ChatID, &d.MessageID, &d.ChallengerID, &d.OpponentID,
+		&d.Stake, &d.State, &d.ChallengerRoll, &d.OpponentRoll, &d.WinnerID, &d.Fee,
+		&d.CreatedAt, &d.ExpiresAt, &d.ResolvedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrDuelNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки дуэли: %w", err)
+	}
+	return &d, nil
+}
+
+// CreateDuelTx сохраняет новый вызов внутри транзакции депонирования ставки.
+func (r *Repository) CreateDuelTx(ctx context.Context, tx pgx.Tx, duel *Duel) error {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO casino_duels (token, chat_id, message_id, challenger_id, opponent_id, stake, state, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, duel.Token, duel.ChatID, duel.MessageID, duel.ChallengerID, duel.OpponentID,
+		duel.Stake, duel.State, duel.ExpiresAt).Scan(&duel.ID, &duel.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка создания дуэли: %w", err)
+	}
+	return nil
+}
+
+// SetDuelMessage привязывает к дуэли сообщение с кнопками.
+func (r *Repository) SetDuelMessage(ctx context.Context, duelID int64, messageID int) error {
+	if _, err := r.db.Exec(ctx, `
+		UPDATE casino_duels SET message_id = $2, updated_at = NOW() WHERE id = $1
+	`, duelID, messageID); err != nil {
+		return fmt.Errorf("ошибка привязки сообщения дуэли: %w", err)
+	}
+	return nil
+}
+
+// GetDuel возвращает дуэль по токену.
+func (r *Repository) GetDuel(ctx context.Context, token string) (*Duel, error) {
+	return scanDuel(r.db.QueryRow(ctx, `SELECT `+duelColumns+` FROM casino_duels WHERE token = $1`, token))
+}
+
+// LockDuelTx блокирует дуэль до конца транзакции.
+func (r *Repository) LockDuelTx(ctx context.Context, tx pgx.Tx, token string) (*Duel, error) {
+	return scanDuel(tx.QueryRow(ctx, `SELECT `+duelColumns+` FROM casino_duels WHERE token = $1 FOR UPDATE`, token))
+}
+
+// ResolveDuelTx записывает итог дуэли: состояние, броски, победителя и комиссию.
+func (r *Repository) ResolveDuelTx(ctx context.Context, tx pgx.Tx, duel *Duel) error {
+	var challengerRoll, opponentRoll *int
+	if duel.ChallengerRoll > 0 {
+		challengerRoll, opponentRoll = &duel.ChallengerRoll, &duel.OpponentRoll
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE casino_duels
+		SET state = $2, challenger_roll = $3, opponent_roll = $4, winner_id = $5,
+		    fee = $6, resolved_at = $7, updated_at = NOW()
+		WHERE id = $1
+	`, duel.ID, duel.State, challengerRoll, opponentRoll, duel.WinnerID, duel.Fee, duel.ResolvedAt); err != nil {
+		return fmt.Errorf("ошибка записи итога дуэли: %w", err)
+	}
+	return nil
+}
+
+// ListExpiredDuels возвращает токены непринятых вызовов, истёкших к now.
+func (r *Repository) ListExpiredDuels(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT token
+		FROM casino_duels
+		WHERE state = $1 AND expires_at < $2
+		ORDER BY expires_at
+		LIMIT $3
+	`, DuelStatePending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки истёкших дуэлей: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, fmt.Errorf("ошибка чтения дуэли: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetNetLossSince возвращает чистый проигрыш игрока с дня from включительно
+// по дневному учёту casino_daily_limits.
+func (r *Repository) GetNetLossSince(ctx context.Context, userID int64, from time.Time) (int64, error) {
+	var loss int64
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(wagered - won), 0)::BIGINT
+		FROM casino_daily_limits
+		WHERE user_id = $1 AND day >= $2
+	`, userID, from).Scan(&loss)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта проигрыша: %w", err)
+	}
+	return loss, nil
+}
+
+const exclusionColumns = `id, user_id, starts_at, ends_at, lifted_at, lifted_by, created_at`
+
+func scanExclusion(row pgx.Row) (*Exclusion, error) {
+	var e Exclusion
+	if err := row.Scan(&e.ID, &e.UserID, &e.StartsAt, &e.EndsAt, &e.LiftedAt, &e.LiftedBy, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// UpsertExclusion создаёт самоисключение или продлевает действующее до endsAt.
+// Более ранний endsAt действующее исключение не сокращает.
+func (r *Repository) UpsertExclusion(ctx context.Context, userID int64, startsAt, endsAt time.Time) (*Exclusion, error) {
+	e, err := scanExclusion(r.db.QueryRow(ctx, `
+		UPDATE casino_exclusions
+		SET ends_at = GREATEST(ends_at, $3)
+		WHERE id = (
+			SELECT id FROM casino_exclusions
+			WHERE user_id = $1 AND lifted_at IS NULL AND ends_at > $2
+			ORDER BY ends_at DESC
+			LIMIT 1
+		)
+		RETURNING `+exclusionColumns, userID, startsAt, endsAt))
+	if err == nil {
+		return e, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка продления самоисключения: %w", err)
+	}
+	e, err = scanExclusion(r.db.QueryRow(ctx, `
+		INSERT INTO casino_exclusions (user_id, starts_at, ends_at)
+		VALUES ($1, $2, $3)
+		RETURNING `+exclusionColumns, userID, startsAt, endsAt))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания самоисключения: %w", err)
+	}
+	return e, nil
+}
+
+// GetActiveExclusion возвращает действующее на момент now самоисключение или nil.
+func (r *Repository) GetActiveExclusion(ctx context.Context, userID int64, now time.Time) (*Exclusion, error) {
+	e, err := scanExclusion(r.db.QueryRow(ctx, `
+		SELECT `+exclusionColumns+`
+		FROM casino_exclusions
+		WHERE user_id = $1 AND lifted_at IS NULL AND ends_at > $2
+		ORDER BY ends_at DESC
+		LIMIT 1
+	`, userID, now))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки самоисключения: %w", err)
+	}
+	return e, nil
+}
+
+// ListActiveExclusions возвращает действующие самоисключения, ближайшие к окончанию первыми.
+func (r *Repository) ListActiveExclusions(ctx context.Context, now time.Time) ([]*Exclusion, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+exclusionColumns+`
+		FROM casino_exclusions
+		WHERE lifted_at IS NULL AND ends_at > $1
+		ORDER BY ends_at
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки самоисключений: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*Exclusion
+	for rows.Next() {
+		e, err := scanExclusion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения самоисключения: %w", err)
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// LiftExclusion досрочно снимает действующее самоисключение.
+func (r *Repository) LiftExclusion(ctx context.Context, exclusionID, actorID int64, now time.Time) (*Exclusion, error) {
+	e, err := scanExclusion(r.db.QueryRow(ctx, `
+		UPDATE casino_exclusions
+		SET lifted_at = $3, lifted_by = $2
+		WHERE id = $1 AND lifted_at IS NULL AND ends_at > $3
+		RETURNING `+exclusionColumns, exclusionID, actorID, now))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrExclusionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка снятия самоисключения: %w", err)
+	}
+	return e, nil
+}
+
+const lossLimitColumns = `user_id, daily_limit, weekly_limit, pending_daily_limit, pending_weekly_limit, pending_from`
+
+func scanLossLimits(row pgx.Row) (*LossLimits, error) {
+	var l LossLimits
+	if err := row.Scan(&l.UserID, &l.Daily, &l.Weekly, &l.PendingDaily, &l.PendingWeekly, &l.PendingFrom); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetLossLimits возвращает личные лимиты проигрыша или nil, если их нет.
+func (r *Repository) GetLossLimits(ctx context.Context, userID int64) (*LossLimits, error) {
+	l, err := scanLossLimits(r.db.QueryRow(ctx, `SELECT `+lossLimitColumns+` FROM casino_loss_limits WHERE user_id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки лимитов проигрыша: %w", err)
+	}
+	return l, nil
+}
+
+// LockLossLimitsTx создаёт при необходимости строку личных лимитов и блокирует её.
+func (r *Repository) LockLossLimitsTx(ctx context.Context, tx pgx.Tx, userID int64) (*LossLimits, error) {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO casino_loss_limits (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO NOTHING
+	`, userID); err != nil {
+		return nil, fmt.Errorf("ошибка подготовки лимитов проигрыша: %w", err)
+	}
+	l, err := scanLossLimits(tx.QueryRow(ctx, `SELECT `+lossLimitColumns+` FROM casino_loss_limits WHERE user_id = $1 FOR UPDATE`, userID))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка блокировки лимитов проигрыша: %w", err)
+	}
+	return l, nil
+}
+
+// SaveLossLimitsTx сохраняет личные лимиты проигрыша.
+func (r *Repository) SaveLossLimitsTx(ctx context.Context, tx pgx.Tx, limits *LossLimits) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE casino_loss_limits
+		SET daily_limit = $2, weekly_limit = $3, pending_daily_limit = $4,
+		    pending_weekly_limit = $5, pending_from = $6, updated_at = NOW()
+		WHERE user_id = $1
+	`, limits.UserID, limits.Daily, limits.Weekly, limits.PendingDaily, limits.PendingWeekly, limits.PendingFrom); err != nil {
+		return fmt.Errorf("ошибка сохранения лимитов проигрыша: %w", err)
+	}
+	return nil
+}
+
+// statsLeaderExpr — выражения рейтинга по накопительным счётчикам casino_stats.
+var statsLeaderExpr = map[LeaderboardMetric]string{
+	LeaderboardBiggestWin: "biggest_win",
+	LeaderboardNetProfit:  "total_won - total_wagered",
+	LeaderboardSpins:      "total_spins",
+}
+
+// gameLeaderExpr — выражения рейтинга по отдельным играм casino_games.
+var gameLeaderExpr = map[LeaderboardMetric]string{
+	LeaderboardBiggestWin: "MAX(result_amount)",
+	LeaderboardNetProfit:  "SUM(result_amount - bet_amount)",
+	LeaderboardSpins:      "COUNT(*)",
+}
+
+// ListStatsLeaders возвращает рейтинг игроков за всё время.
+func (r *Repository) ListStatsLeaders(ctx context.Context, metric LeaderboardMetric, limit int) ([]LeaderboardEntry, error) {
+	expr, ok := statsLeaderExpr[metric]
+	if !ok {
+		return nil, ErrUnknownLeaderboard
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id, (`+expr+`)::BIGINT AS value
+		FROM casino_stats
+		WHERE total_spins > 0
+		ORDER BY value DESC, user_id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения рейтинга казино: %w", err)
+	}
+	return scanLeaderboard(rows)
+}
+
+// ListGameLeaders возвращает рейтинг игроков по играм из окна [from, to).
+func (r *Repository) ListGameLeaders(ctx context.Context, metric LeaderboardMetric, from, to time.Time, limit int) ([]LeaderboardEntry, error) {
+	expr, ok := gameLeaderExpr[metric]
+	if !ok {
+		return nil, ErrUnknownLeaderboard
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id, (`+expr+`)::BIGINT AS value
+		FROM casino_games
+		WHERE created_at >= $1 AND created_at < $2 AND user_id IS NOT NULL
+		GROUP BY user_id
+		ORDER BY value DESC, user_id
+		LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения рейтинга казино: %w", err)
+	}
+	return scanLeaderboard(rows)
+}
+
+func scanLeaderboard(rows pgx.Rows) ([]LeaderboardEntry, error) {
+	defer rows.Close()
+	var out []LeaderboardEntry
+	for rows.Next() {
+		var e LeaderboardEntry
+		if err := rows.Scan(&e.UserID, &e.Value); err != nil {
+			return nil, fmt.Errorf("ошибка чтения рейтинга казино: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// GetPeriodTotals считает итоги казино по играм из окна [from, to).
+func (r *Repository) GetPeriodTotals(ctx context.Context, from, to time.Time) (*PeriodTotals, error) {
+	var t PeriodTotals
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(DISTINCT user_id),
+		       COALESCE(SUM(bet_amount), 0)::BIGINT, COALESCE(SUM(result_amount), 0)::BIGINT
+		FROM casino_games
+		WHERE created_at >= $1 AND created_at < $2
+	`, from, to).Scan(&t.Games, &t.Players, &t.Wagered, &t.PaidOut)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчёта итогов казино: %w", err)
+	}
+	return &t, nil
+}
+
 // GetStatsOrDefault возвращает статистику или значения по умолчанию.
 func (r *Repository) GetStatsOrDefault(ctx context.Context, userID int64) *Stats {
 	stats, err := r.GetStats(ctx, userID)
@@ -98,18 +771,77 @@ func (r *Repository) GetStatsOrDefault(ctx context.Context, userID int64) *Stats
 		return &Stats{
 			UserID:     userID,
 			CurrentRTP: 96.0,
+			RTPTier:    RTPTierNormal,
 		}
 	}
 	return stats
 }
 
-// SaveGameData сериализует данные игры в JSON для сохранения.
-func SaveGameData(grid Grid, wins []WinLine, scatters int) json.RawMessage {
-	data := map[string]interface{}{
-		"grid":     grid,
-		"wins":     wins,
-		"scatters": scatters,
+// GameData — содержимое casino_games.game_data для слотов.
+type GameData struct {
+	Machine    string         `json:"machine"`
+	Grid       Grid           `json:"grid"`
+	Wins       []WinLine      `json:"wins"`
+	Scatters   int            `json:"scatters"`
+	ScatterWin int64          `json:"scatter_win,omitempty"`
+	JackpotWin int64          `json:"jackpot_win,omitempty"`
+	FreeSpins  []FreeSpinData `json:"free_spins,omitempty"`
+	// Paylines — формы выигравших линий по номеру линии (с 0): по ним
+	// раунд воспроизводится, даже если файл машин с тех пор изменился.
+	Paylines map[int][]int `json:"paylines,omitempty"`
+	Fair     *FairProof    `json:"fair,omitempty"` // Входные данные честного спина
+}
+
+// FreeSpinData — сохранённый фриспин раунда.
+type FreeSpinData struct {
+	Grid       Grid      `json:"grid"`
+	Wins       []WinLine `json:"wins"`
+	Scatters   int       `json:"scatters"`
+	ScatterWin int64     `json:"scatter_win,omitempty"`
+}
+
+// SaveGameData сериализует спин вместе с фриспинами в JSON для сохранения.
+// paylines — линии машины, proof == nil — спин сыгран без доказуемо честного режима.
+func SaveGameData(result *SlotResult, paylines [][]int, proof *FairProof) json.RawMessage {
+	data := GameData{
+		Machine:    result.Machine,
+		Grid:       result.Grid,
+		Wins:       result.WinLines,
+		Scatters:   result.ScatterCount,
+		ScatterWin: result.ScatterWin,
+		JackpotWin: result.JackpotWin,
+		Fair:       proof,
+	}
+	keepShapes := func(wins []WinLine) {
+		for _, win := range wins {
+			if win.LineIndex < 0 || win.LineIndex >= len(paylines) {
+				continue
+			}
+			if data.Paylines == nil {
+				data.Paylines = make(map[int][]int)
+			}
+			data.Paylines[win.LineIndex] = paylines[win.LineIndex]
+		}
+	}
+	keepShapes(result.WinLines)
+	for _, round := range result.FreeSpinRounds {
+		data.FreeSpins = append(data.FreeSpins, FreeSpinData{
+			Grid:       round.Grid,
+			Wins:       round.WinLines,
+			Scatters:   round.ScatterCount,
+			ScatterWin: round.ScatterWin,
+		})
+		keepShapes(round.WinLines)
 	}
 	bytes, _ := json.Marshal(data)
 	return bytes
 }
+
+// ParseGameData разбирает game_data слотов.
+func ParseGameData(raw json.RawMessage) (*GameData, error) {
+	var data GameData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("ошибка разбора данных игры: %w", err)
+	}
+	return &data, nil
+}
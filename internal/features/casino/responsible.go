@@ -0,0 +1,325 @@
+// Package casino — responsible.go реализует ответственную игру: самоисключение
+// (!самоисключение 7д) и лимиты чистого проигрыша за день и неделю — общие
+// из конфигурации и личные (!лимиты). Service проверяет их перед каждой ставкой.
+package casino
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+)
+
+const (
+	// MaxExclusionDays — самый долгий срок самоисключения.
+	MaxExclusionDays = 365
+	// lossLimitRaiseDelay — через сколько вступает в силу ослабление личного лимита.
+	lossLimitRaiseDelay = 24 * time.Hour
+)
+
+const (
+	LossLimitDay  = "day"
+	LossLimitWeek = "week"
+)
+
+var (
+	// ErrExclusionNotFound — активного самоисключения с таким ID нет.
+	ErrExclusionNotFound = errors.New("casino exclusion not found")
+	// ErrInvalidExclusion — срок самоисключения вне 1..MaxExclusionDays дней.
+	ErrInvalidExclusion = errors.New("invalid casino exclusion period")
+	// ErrInvalidLossLimit — неизвестный период или отрицательный лимит.
+	ErrInvalidLossLimit = errors.New("invalid casino loss limit")
+)
+
+// Exclusion — самоисключение игрока из казино (таблица casino_exclusions).
+type Exclusion struct {
+	ID        int64
+	UserID    int64
+	StartsAt  time.Time
+	EndsAt    time.Time
+	LiftedAt  *time.Time
+	LiftedBy  *int64
+	CreatedAt time.Time
+}
+
+// LossLimits — личные лимиты чистого проигрыша (таблица casino_loss_limits).
+// 0 — личного лимита нет, действует только общий.
+type LossLimits struct {
+	UserID        int64
+	Daily         int64
+	Weekly        int64
+	PendingDaily  *int64
+	PendingWeekly *int64
+	PendingFrom   *time.Time
+}
+
+// Effective возвращает лимиты, действующие в момент now: отложенное
+// ослабление применяется, когда наступил PendingFrom.
+func (l *LossLimits) Effective(now time.Time) (daily, weekly int64) {
+	if l == nil {
+		return 0, 0
+	}
+	daily, weekly = l.Daily, l.Weekly
+	if l.PendingFrom != nil && !now.Before(*l.PendingFrom) {
+		if l.PendingDaily != nil {
+			daily = *l.PendingDaily
+		}
+		if l.PendingWeekly != nil {
+			weekly = *l.PendingWeekly
+		}
+	}
+	return daily, weekly
+}
+
+// LossCaps — действующие для игрока лимиты проигрыша и проигрыш за периоды.
+type LossCaps struct {
+	Daily        int64 // 0 — без лимита
+	Weekly       int64 // 0 — без лимита
+	DayLoss      int64
+	WeekLoss     int64
+	Personal     *LossLimits
+	RaisePending bool // Есть отложенное ослабление личного лимита
+}
+
+// SelfExclude закрывает игроку казино на days дней. Повторное исключение
+// может только продлить срок, сократить его может лишь администратор.
+func (s *Service) SelfExclude(ctx context.Context, userID int64, days int) (*Exclusion, error) {
+	if days < 1 || days > MaxExclusionDays {
+		return nil, ErrInvalidExclusion
+	}
+	now := s.now().UTC()
+	return s.repo.UpsertExclusion(ctx, userID, now, now.AddDate(0, 0, days))
+}
+
+// ActiveExclusion возвращает действующее самоисключение игрока или nil.
+func (s *Service) ActiveExclusion(ctx context.Context, userID int64) (*Exclusion, error) {
+	return s.repo.GetActiveExclusion(ctx, userID, s.now().UTC())
+}
+
+// ListActiveExclusions возвращает все действующие самоисключения для админки.
+func (s *Service) ListActiveExclusions(ctx context.Context) ([]*Exclusion, error) {
+	return s.repo.ListActiveExclusions(ctx, s.now().UTC())
+}
+
+// LiftExclusion досрочно снимает самоисключение; каждое снятие уходит в аудит.
+func (s *Service) LiftExclusion(ctx context.Context, exclusionID, actorID int64) (*Exclusion, error) {
+	lifted, err := s.repo.LiftExclusion(ctx, exclusionID, actorID, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		s.audit.LogCasinoExclusionLift(ctx, s.memberLabel(ctx, actorID), s.memberLabel(ctx, lifted.UserID), lifted.EndsAt)
+	}
+	return lifted, nil
+}
+
+// SetLossLimit задаёт личный лимит проигрыша за период LossLimitDay или
+// LossLimitWeek (0 — снять личный лимит). Ужесточение действует сразу,
+// ослабление — через lossLimitRaiseDelay; immediate сообщает, что случилось.
+func (s *Service) SetLossLimit(ctx context.Context, userID int64, period string, amount int64) (limits *LossLimits, immediate bool, err error) {
+	if amount < 0 || (period != LossLimitDay && period != LossLimitWeek) {
+		return nil, false, ErrInvalidLossLimit
+	}
+	now := s.now().UTC()
+	err = s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		current, err := s.repo.LockLossLimitsTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		// Созревшее ослабление переносим в действующие значения.
+		current.Daily, current.Weekly = current.Effective(now)
+		if current.PendingFrom != nil && !now.Before(*current.PendingFrom) {
+			current.PendingDaily, current.PendingWeekly, current.PendingFrom = nil, nil, nil
+		}
+
+		active, pending := &current.Daily, &current.PendingDaily
+		if period == LossLimitWeek {
+			active, pending = &current.Weekly, &current.PendingWeekly
+		}
+		immediate = amount > 0 && (*active == 0 || amount <= *active)
+		if immediate {
+			*active, *pending = amount, nil
+		} else {
+			value, from := amount, now.Add(lossLimitRaiseDelay)
+			*pending, current.PendingFrom = &value, &from
+		}
+		if current.PendingDaily == nil && current.PendingWeekly == nil {
+			current.PendingFrom = nil
+		}
+		limits = current
+		return s.repo.SaveLossLimitsTx(ctx, tx, current)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return limits, immediate, nil
+}
+
+// LossCaps возвращает действующие лимиты проигрыша игрока и проигрыш за
+// текущие день и неделю по APP_TIMEZONE.
+func (s *Service) LossCaps(ctx context.Context, userID int64) (*LossCaps, error) {
+	day := s.today()
+	personal, err := s.repo.GetLossLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.repo.GetDailyUsage(ctx, userID, day)
+	if err != nil {
+		return nil, err
+	}
+	weekLoss, err := s.repo.GetNetLossSince(ctx, userID, weekStart(day))
+	if err != nil {
+		return nil, err
+	}
+	caps := s.lossCaps(personal)
+	caps.DayLoss, caps.WeekLoss = usage.NetLoss(), weekLoss
+	return caps, nil
+}
+
+func (s *Service) lossCaps(personal *LossLimits) *LossCaps {
+	daily, weekly := personal.Effective(s.now().UTC())
+	return &LossCaps{
+		Daily:        tighterCap(s.cfg.CasinoDailyLossCap, daily),
+		Weekly:       tighterCap(s.cfg.CasinoWeeklyLossCap, weekly),
+		Personal:     personal,
+		RaisePending: personal != nil && personal.PendingFrom != nil && s.now().UTC().Before(*personal.PendingFrom),
+	}
+}
+
+// checkNotExcluded отказывает игроку с действующим самоисключением.
+func (s *Service) checkNotExcluded(ctx context.Context, userID int64) error {
+	exclusion, err := s.repo.GetActiveExclusion(ctx, userID, s.now().UTC())
+	if err != nil {
+		return err
+	}
+	if exclusion != nil {
+		return common.ErrCasinoSelfExcluded
+	}
+	return nil
+}
+
+// checkBetAllowedTx проверяет перед ставкой bet самоисключение и все лимиты
+// игрока. Возвращает заблокированный дневной учёт для дальнейшей записи.
+// Лимиты проигрыша считаются по худшему исходу: ставка проиграна целиком.
+func (s *Service) checkBetAllowedTx(ctx context.Context, tx pgx.Tx, userID int64, day time.Time, bet int64) (*DailyUsage, error) {
+	if err := s.checkNotExcluded(ctx, userID); err != nil {
+		return nil, err
+	}
+	usage, err := s.repo.LockDailyUsageTx(ctx, tx, userID, day)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCaps(ctx, userID, day, usage, bet); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// precheckBet — те же проверки ставки без транзакции и блокировок, плюс
+// баланс. Нужна играм, исход которых приходит извне (кубик Telegram):
+// бросок уходит в чат до расчёта, поэтому заведомо отклонённая ставка
+// не должна до него дойти. Окончательно ставку проверяет playRound.
+func (s *Service) precheckBet(ctx context.Context, userID int64, bet int64) error {
+	if err := s.checkNotExcluded(ctx, userID); err != nil {
+		return err
+	}
+	day := s.today()
+	usage, err := s.repo.GetDailyUsage(ctx, userID, day)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCaps(ctx, userID, day, usage, bet); err != nil {
+		return err
+	}
+	balance, err := s.economyService.GetBalance(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if balance < bet {
+		return common.ErrInsufficientBalance
+	}
+	return nil
+}
+
+// checkCaps проверяет ставку против дневного лимита ставок и лимитов проигрыша.
+func (s *Service) checkCaps(ctx context.Context, userID int64, day time.Time, usage *DailyUsage, bet int64) error {
+	if limit := s.cfg.CasinoDailyWagerCap; limit > 0 && usage.Wagered+bet > limit {
+		return common.ErrCasinoDailyWagerCap
+	}
+	personal, err := s.repo.GetLossLimits(ctx, userID)
+	if err != nil {
+		return err
+	}
+	caps := s.lossCaps(personal)
+	if caps.Daily > 0 && usage.NetLoss()+bet > caps.Daily {
+		return common.ErrCasinoDailyLossCap
+	}
+	if caps.Weekly > 0 {
+		weekLoss, err := s.repo.GetNetLossSince(ctx, userID, weekStart(day))
+		if err != nil {
+			return err
+		}
+		if weekLoss+bet > caps.Weekly {
+			return common.ErrCasinoWeeklyLossCap
+		}
+	}
+	return nil
+}
+
+// isBetRefusal сообщает, что ошибка — отказ в ставке, а не сбой.
+func isBetRefusal(err error) bool {
+	return errors.Is(err, common.ErrInsufficientBalance) ||
+		errors.Is(err, common.ErrCasinoSelfExcluded) ||
+		errors.Is(err, common.ErrCasinoDailyWagerCap) ||
+		errors.Is(err, common.ErrCasinoDailyLossCap) ||
+		errors.Is(err, common.ErrCasinoWeeklyLossCap)
+}
+
+// tighterCap возвращает более строгий из двух лимитов (0 — без лимита).
+func tighterCap(a, b int64) int64 {
+	switch {
+	case a == 0:
+		return b
+	case b == 0 || a < b:
+		return a
+	default:
+		return b
+	}
+}
+
+// weekStart возвращает понедельник недели, в которую входит день day.
+func weekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// ParseExclusionPeriod разбирает срок самоисключения: «7д», «2н» (недели),
+// «1м» (30 дней) или число дней.
+func ParseExclusionPeriod(raw string) (int, error) {
+	runes := []rune(normalizeChoice(raw))
+	if len(runes) == 0 {
+		return 0, ErrInvalidExclusion
+	}
+	multiplier := 1
+	switch runes[len(runes)-1] {
+	case 'д':
+		runes = runes[:len(runes)-1]
+	case 'н':
+		multiplier, runes = 7, runes[:len(runes)-1]
+	case 'м':
+		multiplier, runes = 30, runes[:len(runes)-1]
+	}
+	n, err := strconv.Atoi(string(runes))
+	if err != nil {
+		return 0, ErrInvalidExclusion
+	}
+	days := n * multiplier
+	if days < 1 || days > MaxExclusionDays {
+		return 0, ErrInvalidExclusion
+	}
+	return days, nil
+}
@@ -0,0 +1,191 @@
+package casino
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+)
+
+func TestSelfExclude_RefusesEveryGame(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 10_000
+	store.balances[8] = 10_000
+	svc := newTestService(store)
+	ctx := context.Background()
+
+	exclusion, err := svc.SelfExclude(ctx, 7, 7)
+	if err != nil {
+		t.Fatalf("SelfExclude: %v", err)
+	}
+	if want := svc.now().UTC().AddDate(0, 0, 7); !exclusion.EndsAt.Equal(want) {
+		t.Fatalf("ends_at = %v, want %v", exclusion.EndsAt, want)
+	}
+
+	if _, err := svc.PlaySlots(ctx, 7, "", 100); !errors.Is(err, common.ErrCasinoSelfExcluded) {
+		t.Fatalf("slots: err = %v, want ErrCasinoSelfExcluded", err)
+	}
+	if _, err := svc.PlayTableGame(ctx, 7, GameTypeCoinFlip, 100, "орёл", nil); !errors.Is(err, common.ErrCasinoSelfExcluded) {
+		t.Fatalf("coin flip: err = %v, want ErrCasinoSelfExcluded", err)
+	}
+	if _, err := svc.CreateDuel(ctx, 1, 7, 8, 100); !errors.Is(err, common.ErrCasinoSelfExcluded) {
+		t.Fatalf("duel: err = %v, want ErrCasinoSelfExcluded", err)
+	}
+	duel, err := svc.CreateDuel(ctx, 1, 8, 7, 100)
+	if err != nil {
+		t.Fatalf("CreateDuel against excluded: %v", err)
+	}
+	if _, err := svc.AcceptDuel(ctx, duel.Token, 7); !errors.Is(err, common.ErrCasinoSelfExcluded) {
+		t.Fatalf("accept: err = %v, want ErrCasinoSelfExcluded", err)
+	}
+	if store.balances[7] != 10_000 || len(store.games) != 0 {
+		t.Fatalf("refused bets left side effects: balance=%d games=%d", store.balances[7], len(store.games))
+	}
+}
+
+func TestSelfExclude_OnlyExtends(t *testing.T) {
+	store := newFakeStore()
+	svc := newTestService(store)
+	ctx := context.Background()
+
+	long, err := svc.SelfExclude(ctx, 7, 30)
+	if err != nil {
+		t.Fatalf("SelfExclude: %v", err)
+	}
+	short, err := svc.SelfExclude(ctx, 7, 1)
+	if err != nil {
+		t.Fatalf("SelfExclude: %v", err)
+	}
+	if !short.EndsAt.Equal(long.EndsAt) || len(store.exclusions) != 1 {
+		t.Fatalf("shorter exclusion must not cut the active one: %v vs %v", short.EndsAt, long.EndsAt)
+	}
+	if _, err := svc.SelfExclude(ctx, 7, MaxExclusionDays+1); !errors.Is(err, ErrInvalidExclusion) {
+		t.Fatalf("err = %v, want ErrInvalidExclusion", err)
+	}
+}
+
+func TestLiftExclusion_ReopensCasino(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 10_000
+	svc := newTestService(store)
+	ctx := context.Background()
+
+	exclusion, _ := svc.SelfExclude(ctx, 7, 7)
+	lifted, err := svc.LiftExclusion(ctx, exclusion.ID, 1)
+	if err != nil {
+		t.Fatalf("LiftExclusion: %v", err)
+	}
+	if lifted.LiftedBy == nil || *lifted.LiftedBy != 1 {
+		t.Fatalf("lifted_by = %v, want 1", lifted.LiftedBy)
+	}
+	if _, err := svc.PlaySlots(ctx, 7, "", 100); err != nil {
+		t.Fatalf("slots after lift: %v", err)
+	}
+	if _, err := svc.LiftExclusion(ctx, exclusion.ID, 1); !errors.Is(err, ErrExclusionNotFound) {
+		t.Fatalf("second lift: err = %v, want ErrExclusionNotFound", err)
+	}
+}
+
+func TestPlaySlots_LossLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		weeklyCap   int64
+		personal    *LossLimits
+		todayLoss   int64
+		earlierLoss int64
+		bet         int64
+		wantErr     error
+	}{
+		{name: "within weekly cap", weeklyCap: 1000, earlierLoss: 500, todayLoss: 200, bet: 100},
+		{name: "weekly cap", weeklyCap: 1000, earlierLoss: 700, todayLoss: 200, bet: 200, wantErr: common.ErrCasinoWeeklyLossCap},
+		{name: "personal daily tighter", personal: &LossLimits{Daily: 250}, todayLoss: 200, bet: 100, wantErr: common.ErrCasinoDailyLossCap},
+		{name: "personal weekly tighter", weeklyCap: 1000, personal: &LossLimits{Weekly: 300}, earlierLoss: 250, bet: 100, wantErr: common.ErrCasinoWeeklyLossCap},
+		{name: "global cap wins over looser personal", weeklyCap: 500, personal: &LossLimits{Weekly: 5000}, earlierLoss: 450, bet: 100, wantErr: common.ErrCasinoWeeklyLossCap},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.balances[7] = 10_000
+			svc := newTestService(store)
+			svc.cfg.CasinoWeeklyLossCap = tt.weeklyCap
+			store.daily[7] = DailyUsage{UserID: 7, Day: svc.today(), Wagered: tt.todayLoss}
+			store.earlierLoss[7] = tt.earlierLoss
+			if tt.personal != nil {
+				tt.personal.UserID = 7
+				store.limits[7] = *tt.personal
+			}
+
+			_, err := svc.PlaySlots(context.Background(), 7, "", tt.bet)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetLossLimit_LooseningIsDelayed(t *testing.T) {
+	store := newFakeStore()
+	svc := newTestService(store)
+	ctx := context.Background()
+	start := svc.now()
+
+	if _, immediate, err := svc.SetLossLimit(ctx, 7, LossLimitDay, 500); err != nil || !immediate {
+		t.Fatalf("first limit: immediate=%v err=%v", immediate, err)
+	}
+	if _, immediate, err := svc.SetLossLimit(ctx, 7, LossLimitDay, 300); err != nil || !immediate {
+		t.Fatalf("tightening: immediate=%v err=%v", immediate, err)
+	}
+
+	limits, immediate, err := svc.SetLossLimit(ctx, 7, LossLimitDay, 2000)
+	if err != nil || immediate {
+		t.Fatalf("loosening: immediate=%v err=%v", immediate, err)
+	}
+	if limits.Daily != 300 || limits.PendingDaily == nil || *limits.PendingDaily != 2000 {
+		t.Fatalf("limits = %+v, want daily 300 with pending 2000", limits)
+	}
+	caps, _ := svc.LossCaps(ctx, 7)
+	if caps.Daily != 300 || !caps.RaisePending {
+		t.Fatalf("caps before delay = %+v", caps)
+	}
+
+	svc.now = func() time.Time { return start.Add(lossLimitRaiseDelay) }
+	caps, _ = svc.LossCaps(ctx, 7)
+	if caps.Daily != 2000 || caps.RaisePending {
+		t.Fatalf("caps after delay = %+v", caps)
+	}
+
+	// Снятие лимита — тоже ослабление.
+	if _, immediate, err := svc.SetLossLimit(ctx, 7, LossLimitDay, 0); err != nil || immediate {
+		t.Fatalf("removal: immediate=%v err=%v", immediate, err)
+	}
+	if _, _, err := svc.SetLossLimit(ctx, 7, "month", 100); !errors.Is(err, ErrInvalidLossLimit) {
+		t.Fatalf("err = %v, want ErrInvalidLossLimit", err)
+	}
+}
+
+func TestParseExclusionPeriod(t *testing.T) {
+	tests := []struct {
+		raw  string
+		days int
+		ok   bool
+	}{
+		{raw: "7д", days: 7, ok: true},
+		{raw: "7Д", days: 7, ok: true},
+		{raw: "2н", days: 14, ok: true},
+		{raw: "1м", days: 30, ok: true},
+		{raw: "10", days: 10, ok: true},
+		{raw: "0д"},
+		{raw: "366"},
+		{raw: "неделя"},
+		{raw: ""},
+	}
+	for _, tt := range tests {
+		days, err := ParseExclusionPeriod(tt.raw)
+		if (err == nil) != tt.ok || days != tt.days {
+			t.Fatalf("ParseExclusionPeriod(%q) = %d, %v", tt.raw, days, err)
+		}
+	}
+}
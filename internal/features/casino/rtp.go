@@ -6,60 +6,91 @@
 // Целевой диапазон RTP: 94–98%.
 package casino
 
-import (
-	"sync"
+// RTPTier — ступень коррекции весов игрока.
+// Хранится в casino_stats.rtp_tier: веса полностью выводятся из ступени,
+// поэтому перезапуск и несколько инстансов видят одно и то же состояние.
+type RTPTier string
+
+const (
+	RTPTierNormal  RTPTier = "normal"  // Стандартные веса
+	RTPTierTighten RTPTier = "tighten" // RTP выше верхней границы — шансы урезаны
+	RTPTierBoost   RTPTier = "boost"   // RTP ниже нижней границы — шансы повышены
 )
 
-// RTPManager управляет динамическими весами символов для каждого пользователя.
-// Использует мьютекс для потокобезопасности, т.к. несколько спинов
-// могут происходить одновременно.
+// Label возвращает человекочитаемое название ступени.
+func (t RTPTier) Label() string {
+	switch t {
+	case RTPTierTighten:
+		return "🔽 урезан"
+	case RTPTierBoost:
+		return "🔼 повышен"
+	default:
+		return "⚖️ норма"
+	}
+}
+
+// parseRTPTier приводит значение из БД к известной ступени.
+func parseRTPTier(raw string) RTPTier {
+	switch RTPTier(raw) {
+	case RTPTierTighten, RTPTierBoost:
+		return RTPTier(raw)
+	default:
+		return RTPTierNormal
+	}
+}
+
+// RTPManager хранит границы целевого RTP и выбирает ступень коррекции.
+// Сам по себе состояния игроков не держит: ступень живёт в БД.
 type RTPManager struct {
-	mu            sync.RWMutex
-	userWeights   map[int64][]Symbol // Персональные веса символов
-	minRTP        float64            // Минимальный целевой RTP (94%)
-	maxRTP        float64            // Максимальный целевой RTP (98%)
-	initialRTP    float64            // Начальный RTP (96%)
+	minRTP     float64 // Минимальный целевой RTP (94%)
+	maxRTP     float64 // Максимальный целевой RTP (98%)
+	initialRTP float64 // Начальный RTP (96%)
 }
 
 // NewRTPManager создаёт менеджер RTP с заданными границами.
 func NewRTPManager(minRTP, maxRTP, initialRTP float64) *RTPManager {
 	return &RTPManager{
-		userWeights: make(map[int64][]Symbol),
-		minRTP:      minRTP,
-		maxRTP:      maxRTP,
-		initialRTP:  initialRTP,
+		minRTP:     minRTP,
+		maxRTP:     maxRTP,
+		initialRTP: initialRTP,
 	}
 }
 
-// GetAdjustedWeights возвращает скорректированные веса символов для пользователя.
-// Если для пользователя нет персональных весов — возвращает стандартные.
-func (m *RTPManager) GetAdjustedWeights(userID int64) []Symbol {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	if weights, ok := m.userWeights[userID]; ok {
-		return weights
+// TierFor выбирает ступень коррекции по текущему RTP пользователя.
+// Вызывается после каждого спина внутри его транзакции.
+//
+// Алгоритм:
+//   - RTP > 98% → урезаем шансы (tighten)
+//   - RTP < 94% → повышаем шансы (boost)
+//   - 94% ≤ RTP ≤ 98% → веса в норме
+func (m *RTPManager) TierFor(currentRTP float64) RTPTier {
+	switch {
+	case currentRTP > m.maxRTP:
+		return RTPTierTighten
+	case currentRTP < m.minRTP:
+		return RTPTierBoost
+	default:
+		return RTPTierNormal
 	}
-
-	// Копируем стандартные веса
-	return copySymbols(DefaultSymbols)
 }
 
-// AdjustRTP корректирует веса символов на основе текущего RTP пользователя.
-// Вызывается после каждого спина.
-//
-// Алгоритм:
-//   - RTP > 98% → уменьшаем вес дорогих символов (💎, 7️⃣, ⭐)
-//   - RTP < 94% → увеличиваем вес дорогих символов и вайлдов
-//   - 94% ≤ RTP ≤ 98% → веса в норме, не трогаем
-func (m *RTPManager) AdjustRTP(userID int64, currentRTP float64) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// WeightsForTier возвращает веса символов classic для ступени коррекции.
+func WeightsForTier(tier RTPTier) []Symbol {
+	return classic.WeightsForTier(tier)
+}
 
-	// Начинаем со стандартных весов
-	symbols := copySymbols(DefaultSymbols)
+// WeightsForTier возвращает веса символов машины для ступени коррекции.
+// Коррекция ищет символы по имени (Seven, Diamond, Wild, Watermelon, Cherry, Lemon):
+// символы машины с другими именами остаются с исходными весами.
+//   - tighten → уменьшаем вес дорогих символов (💎, 7️⃣, ⭐)
+//   - boost → увеличиваем вес дорогих символов и вайлдов
+//   - normal → стандартные веса
+func (m *Machine) WeightsForTier(tier RTPTier) []Symbol {
+	// Начинаем со стандартных весов машины
+	symbols := copySymbols(m.Symbols)
 
-	if currentRTP > m.maxRTP {
+	switch tier {
+	case RTPTierTighten:
 		// Пользователь выигрывает слишком много — уменьшаем шансы
 		// Уменьшаем вес дорогих символов
 		for i := range symbols {
@@ -67,7 +98,7 @@ func (m *RTPManager) AdjustRTP(userID int64, currentRTP float64) {
 			case "Seven":
 				symbols[i].Weight = max(1, symbols[i].Weight-1) // 3 → 2
 			case "Diamond":
-				symbols[i].Weight = max(3, symbols[i].Weight-2) // 7 → 5
+				symbols[i].Weight = max(min(3, symbols[i].Weight), symbols[i].Weight-2) // 7 → 5
 			case "Wild":
 				symbols[i].Weight = max(1, symbols[i].Weight-1) // 1 → 1 (минимум)
 			case "Cherry":
@@ -76,7 +107,7 @@ func (m *RTPManager) AdjustRTP(userID int64, currentRTP float64) {
 				symbols[i].Weight += 2
 			}
 		}
-	} else if currentRTP < m.minRTP {
+	case RTPTierBoost:
 		// Пользователь проигрывает слишком много — помогаем
 		for i := range symbols {
 			switch symbols[i].Name {
@@ -89,12 +120,12 @@ func (m *RTPManager) AdjustRTP(userID int64, currentRTP float64) {
 			case "Watermelon":
 				symbols[i].Weight += 2 // 10 → 12
 			case "Cherry":
-				symbols[i].Weight = max(15, symbols[i].Weight-5) // 25 → 20
+				symbols[i].Weight = max(min(15, symbols[i].Weight), symbols[i].Weight-5) // 25 → 20
 			}
 		}
 	}
 
-	m.userWeights[userID] = symbols
+	return symbols
 }
 
 // CalculateRTP вычисляет текущий RTP пользователя.
@@ -115,11 +146,3 @@ func copySymbols(src []Symbol) []Symbol {
 	copy(dst, src)
 	return dst
 }
-
-// max возвращает максимум из двух int.
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
@@ -3,128 +3,621 @@ package casino
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/jackc/pgx/v5"
 
+	"serotonyl.ru/telegram-bot/internal/audit"
 	"serotonyl.ru/telegram-bot/internal/common"
 	"serotonyl.ru/telegram-bot/internal/config"
 	"serotonyl.ru/telegram-bot/internal/features/economy"
 )
 
+// rtpOverviewLimit ограничивает список игроков в админском обзоре RTP.
+const rtpOverviewLimit = 30
+
+type casinoRepository interface {
+	GetStats(ctx context.Context, userID int64) (*Stats, error)
+	ListStatsByTier(ctx context.Context, limit int) ([]*Stats, error)
+	GetRTPTierForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (RTPTier, error)
+	SetRTPTierTx(ctx context.Context, tx pgx.Tx, userID int64, tier RTPTier) error
+	UpdateStatsTx(ctx context.Context, tx pgx.Tx, userID int64, betAmount, wonAmount int64) (float64, error)
+	SaveGameTx(ctx context.Context, tx pgx.Tx, game *Game) error
+	GetDailyUsage(ctx context.Context, userID int64, day time.Time) (*DailyUsage, error)
+	LockDailyUsageTx(ctx context.Context, tx pgx.Tx, userID int64, day time.Time) (*DailyUsage, error)
+	AddDailyUsageTx(ctx context.Context, tx pgx.Tx, userID int64, day time.Time, betAmount, wonAmount int64) error
+	GetJackpot(ctx context.Context) (*Jackpot, error)
+	AddToJackpotTx(ctx context.Context, tx pgx.Tx, amount int64) (int64, error)
+	LockJackpotTx(ctx context.Context, tx pgx.Tx) (int64, error)
+	PayJackpotTx(ctx context.Context, tx pgx.Tx, userID, amount int64) error
+	GetGame(ctx context.Context, gameID int64) (*Game, error)
+	GetSeed(ctx context.Context, seedID int64) (*Seed, error)
+	EnsureActiveSeed(ctx context.Context, userID int64, candidate *Seed) (*Seed, error)
+	NextNonceTx(ctx context.Context, tx pgx.Tx, userID int64, candidate *Seed) (*Seed, error)
+	RotateSeedTx(ctx context.Context, tx pgx.Tx, userID int64, next *Seed) (*Seed, error)
+	CreateDuelTx(ctx context.Context, tx pgx.Tx, duel *Duel) error
+	SetDuelMessage(ctx context.Context, duelID int64, messageID int) error
+	GetDuel(ctx context.Context, token string) (*Duel, error)
+	LockDuelTx(ctx context.Context, tx pgx.Tx, token string) (*Duel, error)
+	ResolveDuelTx(ctx context.Context, tx pgx.Tx, duel *Duel) error
+	ListExpiredDuels(ctx context.Context, now time.Time, limit int) ([]string, error)
+	GetNetLossSince(ctx context.Context, userID int64, from time.Time) (int64, error)
+	UpsertExclusion(ctx context.Context, userID int64, startsAt, endsAt time.Time) (*Exclusion, error)
+	GetActiveExclusion(ctx context.Context, userID int64, now time.Time) (*Exclusion, error)
+	ListActiveExclusions(ctx context.Context, now time.Time) ([]*Exclusion, error)
+	LiftExclusion(ctx context.Context, exclusionID, actorID int64, now time.Time) (*Exclusion, error)
+	GetLossLimits(ctx context.Context, userID int64) (*LossLimits, error)
+	LockLossLimitsTx(ctx context.Context, tx pgx.Tx, userID int64) (*LossLimits, error)
+	SaveLossLimitsTx(ctx context.Context, tx pgx.Tx, limits *LossLimits) error
+	ListStatsLeaders(ctx context.Context, metric LeaderboardMetric, limit int) ([]LeaderboardEntry, error)
+	ListGameLeaders(ctx context.Context, metric LeaderboardMetric, from, to time.Time, limit int) ([]LeaderboardEntry, error)
+	GetPeriodTotals(ctx context.Context, from, to time.Time) (*PeriodTotals, error)
+}
+
+type casinoEconomy interface {
+	GetBalance(ctx context.Context, userID int64) (int64, error)
+	WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error
+	AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error
+	DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error
+}
+
+var (
+	// ErrUnknownMachine — запрошенная слот-машина не описана.
+	ErrUnknownMachine = errors.New("unknown slot machine")
+	// ErrGameNotFound — игры с таким ID нет.
+	ErrGameNotFound = errors.New("casino game not found")
+	// ErrGameNotFair — игра сыграна без доказуемо честного режима.
+	ErrGameNotFair = errors.New("casino game has no fairness proof")
+)
+
 // Service управляет казино.
 type Service struct {
-	repo           *Repository
-	economyService *economy.Service
+	repo           casinoRepository
+	economyService casinoEconomy
 	rtpManager     *RTPManager
+	machines       *MachineSet
+	games          map[string]TableGame
 	cfg            *config.Config
+	location       *time.Location
+	now            func() time.Time
+	rng            RandomSource
+	audit          *audit.Logger
+	members        audit.MemberLookup
 }
 
-// NewService создаёт сервис казино.
-func NewService(repo *Repository, economyService *economy.Service, cfg *config.Config) *Service {
+// NewService создаёт сервис казино. machines — проверенный набор машин,
+// загруженный при старте (nil — только встроенная classic).
+func NewService(repo *Repository, economyService *economy.Service, machines *MachineSet, cfg *config.Config) *Service {
+	if machines == nil {
+		machines, _ = NewMachineSet(DefaultMachineName, ClassicMachine())
+	}
+	loc := time.UTC
+	if strings.TrimSpace(cfg.AppTimezone) != "" {
+		if loaded, err := time.LoadLocation(cfg.AppTimezone); err == nil {
+			loc = loaded
+		}
+	}
 	return &Service{
 		repo:           repo,
 		economyService: economyService,
 		rtpManager:     NewRTPManager(cfg.CasinoMinRTP, cfg.CasinoMaxRTP, cfg.CasinoInitRTP),
+		machines:       machines,
+		games:          newTableGames(),
 		cfg:            cfg,
+		location:       loc,
+		now:            time.Now,
+		rng:            secureSource,
 	}
 }
 
-// PlaySlots выполняет полный цикл спина.
-func (s *Service) PlaySlots(ctx context.Context, userID int64) (*SlotResult, error) {
-	bet := s.cfg.CasinoSlotsBet
+// SetAuditLogger подключает аудит досрочного снятия самоисключений; members
+// подписывает игроков в аудите и еженедельной сводке.
+func (s *Service) SetAuditLogger(logger *audit.Logger, members audit.MemberLookup) {
+	s.audit = logger
+	s.members = members
+}
 
-	// Проверяем баланс
-	balance, err := s.economyService.GetBalance(ctx, userID)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка получения баланса: %w", err)
+func (s *Service) memberLabel(ctx context.Context, userID int64) string {
+	if s.members != nil {
+		if member, err := s.members.GetByUserID(ctx, userID); err == nil && member != nil {
+			return audit.MemberLabel(member)
+		}
 	}
-	if balance < bet {
-		return nil, fmt.Errorf("недостаточно %s: нужно %s, у тебя %s", common.FilmFramesEmoji, common.FormatBalance(bet), common.FormatBalance(balance))
+	return fmt.Sprintf("id:%d", userID)
+}
+
+// MachineNames возвращает имена доступных машин, машина по умолчанию первая.
+func (s *Service) MachineNames() []string {
+	return s.machines.Names()
+}
+
+// BetLimits возвращает ставку по умолчанию и границы ставки слотов.
+func (s *Service) BetLimits() (defaultBet, minBet, maxBet int64) {
+	return s.cfg.CasinoSlotsBet, s.cfg.CasinoSlotsMinBet, s.cfg.CasinoSlotsMaxBet
+}
+
+// BetRange — допустимые границы ставки одной игры.
+type BetRange struct {
+	Min, Max int64
+}
+
+// Contains сообщает, что ставка bet в границах.
+func (r BetRange) Contains(bet int64) bool {
+	return bet >= r.Min && bet <= r.Max
+}
+
+// BetRange возвращает границы ставки игры gameType.
+func (s *Service) BetRange(gameType string) BetRange {
+	switch gameType {
+	case GameTypeDice:
+		return BetRange{Min: s.cfg.CasinoDiceMinBet, Max: s.cfg.CasinoDiceMaxBet}
+	case GameTypeRoulette:
+		return BetRange{Min: s.cfg.CasinoRouletteMinBet, Max: s.cfg.CasinoRouletteMaxBet}
+	case GameTypeCoinFlip:
+		return BetRange{Min: s.cfg.CasinoCoinMinBet, Max: s.cfg.CasinoCoinMaxBet}
+	case GameTypeDuel:
+		return BetRange{Min: s.cfg.CasinoDuelMinStake, Max: s.cfg.CasinoDuelMaxStake}
+	default:
+		return BetRange{Min: s.cfg.CasinoSlotsMinBet, Max: s.cfg.CasinoSlotsMaxBet}
 	}
+}
 
-	// Списываем ставку
-	err = s.economyService.DeductBalance(ctx, userID, bet, "casino_bet", "Slots bet")
-	if err != nil {
-		return nil, fmt.Errorf("ошибка списания ставки: %w", err)
+// DefaultBet — ставка без аргумента: CASINO_SLOTS_BET, прижатая к границам игры.
+func (s *Service) DefaultBet(gameType string) int64 {
+	r := s.BetRange(gameType)
+	bet := s.cfg.CasinoSlotsBet
+	if bet < r.Min {
+		return r.Min
+	}
+	if bet > r.Max {
+		return r.Max
+	}
+	return bet
+}
+
+// DailyCaps возвращает дневные лимиты ставок и проигрыша (0 — без лимита).
+func (s *Service) DailyCaps() (wagerCap, lossCap int64) {
+	return s.cfg.CasinoDailyWagerCap, s.cfg.CasinoDailyLossCap
+}
+
+// GetDailyUsage возвращает учёт игрока за текущий день по APP_TIMEZONE.
+func (s *Service) GetDailyUsage(ctx context.Context, userID int64) (*DailyUsage, error) {
+	return s.repo.GetDailyUsage(ctx, userID, s.today())
+}
+
+// PlaySlots выполняет полный цикл спина на машине machineName
+// (пустое имя — машина по умолчанию) со ставкой bet (0 — CASINO_SLOTS_BET).
+// Доля ставки уходит в джекпот-пул, полная линия символа джекпота забирает пул.
+func (s *Service) PlaySlots(ctx context.Context, userID int64, machineName string, bet int64) (*SlotResult, error) {
+	if bet == 0 {
+		bet = s.cfg.CasinoSlotsBet
+	}
+	machine := s.machines.Default()
+	if machineName != "" {
+		var ok bool
+		if machine, ok = s.machines.Get(machineName); !ok {
+			return nil, ErrUnknownMachine
+		}
 	}
 
-	// Генерируем сетку с учётом RTP
-	symbols := s.rtpManager.GetAdjustedWeights(userID)
-	grid, err := GenerateGrid(symbols)
+	var result *SlotResult
+	contribution := s.jackpotContribution(bet)
+	gameID, err := s.playRound(ctx, userID, GameTypeSlots, s.BetRange(GameTypeSlots), bet, contribution, func(ctx context.Context, tx pgx.Tx, tier RTPTier) (*roundSettlement, error) {
+		symbols := machine.WeightsForTier(tier)
+		src := s.rng
+		var proof *FairProof
+		if s.cfg.CasinoProvablyFair {
+			candidate, err := newSeed()
+			if err != nil {
+				return nil, err
+			}
+			seed, err := s.repo.NextNonceTx(ctx, tx, userID, candidate)
+			if err != nil {
+				return nil, err
+			}
+			src = NewFairSource(seed.ServerSeed, seed.ClientSeed, seed.Nonce)
+			proof = newFairProof(seed, tier, symbols)
+		}
+		var err error
+		result, err = machine.Spin(src, symbols, bet)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка генерации: %w", err)
+		}
+		result.Fair = proof
+
+		if err := s.applyJackpot(ctx, tx, userID, machine, result); err != nil {
+			return nil, err
+		}
+		return &roundSettlement{
+			HouseWin:   result.TotalPayout - result.JackpotWin,
+			JackpotWin: result.JackpotWin,
+			GameData:   SaveGameData(result, machine.Paylines, proof),
+		}, nil
+	})
 	if err != nil {
-		_ = s.economyService.AddBalance(ctx, userID, bet, "casino_refund", "Bet refund")
-		return nil, fmt.Errorf("ошибка генерации: %w", err)
+		return nil, err
 	}
+	result.GameID = gameID
+	return result, nil
+}
+
+// roundSettlement — итог раунда любой игры для общего каркаса playRound.
+type roundSettlement struct {
+	HouseWin   int64           // Выплата казино (casino_win)
+	JackpotWin int64           // Выплата из джекпот-пула (jackpot_win)
+	GameData   json.RawMessage // Содержимое casino_games.game_data
+}
 
-	// Проверяем линии и скаттеры
-	winLines := CheckPaylines(grid, bet)
-	scatterCount := CountScatters(grid)
-	scatterBonus, freeSpins := CalculateScatterBonus(scatterCount)
+// roundFunc разыгрывает раунд внутри транзакции, когда ставка уже списана.
+type roundFunc func(ctx context.Context, tx pgx.Tx, tier RTPTier) (*roundSettlement, error)
 
-	var totalPayout int64
-	for _, win := range winLines {
-		totalPayout += win.Payout
+// playRound — общий каркас раунда для всех игр казино: проверка ставки
+// против границ игры limits, самоисключения и лимитов, списание ставки
+// (contribution из неё уходит в джекпот),
+// розыгрыш, выплата, статистика, дневной учёт, ступень RTP, запись игры
+// и пополнение джекпота.
+// Всё фиксируется одной транзакцией: при любой ошибке раунд не оставляет
+// следов ни в балансе, ни в статистике, ни в лимитах. Возвращает ID игры.
+func (s *Service) playRound(ctx context.Context, userID int64, gameType string, limits BetRange, bet, contribution int64, play roundFunc) (int64, error) {
+	if !limits.Contains(bet) {
+		return 0, common.ErrCasinoBetOutOfRange
 	}
-	totalPayout += scatterBonus
 
-	// Фриспины
-	for i := 0; i < freeSpins; i++ {
-		freeGrid, err := GenerateGrid(symbols)
+	day := s.today()
+	var gameID int64
+	err := s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := s.checkBetAllowedTx(ctx, tx, userID, day, bet); err != nil {
+			return err
+		}
+
+		// Ступень коррекции читаем под блокировкой строки статистики,
+		// чтобы параллельные раунды игрока не обгоняли друг друга.
+		tier, err := s.repo.GetRTPTierForUpdateTx(ctx, tx, userID)
 		if err != nil {
-			break
+			return err
 		}
-		freeWins := CheckPaylines(freeGrid, bet)
-		for _, win := range freeWins {
-			totalPayout += win.Payout
+
+		// Ставку списываем до расчёта: раунд без денег на ставку не
+		// состоится. Доля джекпота — отдельной записью, чтобы сумма jackpot_contribution минус jackpot_win сходилась с пулом.
+		if err := s.economyService.DeductBalanceTx(ctx, tx, userID, bet-contribution, economy.TxTypeCasinoBet, gameLabel(gameType)+" bet"); err != nil {
+			return err
 		}
-		fb, _ := CalculateScatterBonus(CountScatters(freeGrid))
-		totalPayout += fb
-	}
+		if contribution > 0 {
+			if err := s.economyService.DeductBalanceTx(ctx, tx, userID, contribution, economy.TxTypeJackpotContribution, gameLabel(gameType)+" jackpot contribution"); err != nil {
+				return err
+			}
+		}
+
+		settlement, err := play(ctx, tx, tier)
+		if err != nil {
+			return err
+		}
+		if settlement.HouseWin > 0 {
+			if err := s.economyService.AddBalanceTx(ctx, tx, userID, settlement.HouseWin, economy.TxTypeCasinoWin, gameLabel(gameType)+" win"); err != nil {
+				return fmt.Errorf("ошибка начисления выигрыша: %w", err)
+			}
+		}
+		if settlement.JackpotWin > 0 {
+			if err := s.economyService.AddBalanceTx(ctx, tx, userID, settlement.JackpotWin, economy.TxTypeJackpotWin, gameLabel(gameType)+" jackpot"); err != nil {
+				return fmt.Errorf("ошибка выплаты джекпота: %w", err)
+			}
+		}
+		payout := settlement.HouseWin + settlement.JackpotWin
 
-	// Начисляем выигрыш
-	if totalPayout > 0 {
-		err = s.economyService.AddBalance(ctx, userID, totalPayout, "casino_win", "Slots win")
+		currentRTP, err := s.repo.UpdateStatsTx(ctx, tx, userID, bet, payout)
 		if err != nil {
-			log.WithError(err).Error("Ошибка начисления выигрыша")
+			return err
 		}
+		// Корректируем RTP для следующего спина
+		if err := s.repo.SetRTPTierTx(ctx, tx, userID, s.rtpManager.TierFor(currentRTP)); err != nil {
+			return err
+		}
+		if err := s.repo.AddDailyUsageTx(ctx, tx, userID, day, bet, payout); err != nil {
+			return err
+		}
+
+		game := &Game{
+			UserID:       userID,
+			GameType:     gameType,
+			BetAmount:    bet,
+			ResultAmount: payout,
+			GameData:     settlement.GameData,
+			RTPPercent:   currentRTP,
+		}
+		if err := s.repo.SaveGameTx(ctx, tx, game); err != nil {
+			return err
+		}
+		gameID = game.ID
+
+		// Пул пополняется последней записью: строка джекпота общая для всех
+		// игроков, и её блокировка держится только до коммита.
+		if contribution > 0 {
+			if _, err := s.repo.AddToJackpotTx(ctx, tx, contribution); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if isBetRefusal(err) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("ошибка игры %s: %w", gameType, err)
 	}
+	return gameID, nil
+}
 
-	// Обновляем статистику
-	if err := s.repo.UpdateStats(ctx, userID, bet, totalPayout); err != nil {
-		log.WithError(err).Error("Ошибка обновления статистики казино")
+// newTableGames регистрирует настольные игры по их game_type.
+func newTableGames() map[string]TableGame {
+	games := make(map[string]TableGame)
+	for _, g := range []TableGame{DiceGame{}, RouletteGame{}, CoinFlipGame{}} {
+		games[g.Type()] = g
 	}
+	return games
+}
 
-	// Корректируем RTP
-	stats := s.repo.GetStatsOrDefault(ctx, userID)
-	s.rtpManager.AdjustRTP(userID, stats.CurrentRTP)
+// TableGame возвращает настольную игру по game_type.
+func (s *Service) TableGame(gameType string) (TableGame, bool) {
+	g, ok := s.games[gameType]
+	return g, ok
+}
 
-	// Сохраняем игру
-	gameData := SaveGameData(grid, winLines, scatterCount)
-	game := &Game{
-		UserID:       userID,
-		GameType:     "slots",
-		BetAmount:    bet,
-		ResultAmount: totalPayout,
-		GameData:     gameData,
-		RTPPercent:   stats.CurrentRTP,
+// HouseEdge возвращает преимущество казино в процентах для игры.
+func (s *Service) HouseEdge(gameType string) float64 {
+	switch gameType {
+	case GameTypeDice:
+		return s.cfg.CasinoDiceHouseEdge
+	case GameTypeRoulette:
+		return s.cfg.CasinoRouletteHouseEdge
+	case GameTypeCoinFlip:
+		return s.cfg.CasinoCoinHouseEdge
+	default:
+		return 0
+	}
+}
+
+// PlayTableGame разыгрывает раунд настольной игры gameType со ставкой bet
+// (0 — DefaultBet) на исход choice. src — источник исхода
+// (nil — криптостойкий; для костей обработчик передаёт бросок Telegram).
+// Исход тянется до транзакции, после проверки ставки и баланса.
+func (s *Service) PlayTableGame(ctx context.Context, userID int64, gameType string, bet int64, choice string, src RandomSource) (*TableResult, error) {
+	game, ok := s.games[gameType]
+	if !ok {
+		return nil, ErrUnknownGame
+	}
+	choice, err := game.ParseChoice(choice)
+	if err != nil {
+		return nil, err
+	}
+	if bet == 0 {
+		bet = s.DefaultBet(gameType)
 	}
-	if err := s.repo.SaveGame(ctx, game); err != nil {
-		log.WithError(err).Error("Ошибка сохранения игры")
+	if src == nil {
+		src = s.rng
 	}
 
-	return &SlotResult{
-		Grid:         grid,
-		WinLines:     winLines,
-		ScatterCount: scatterCount,
-		ScatterWin:   scatterBonus,
-		TotalPayout:  totalPayout,
-		IsWin:        totalPayout > 0,
-		FreeSpins:    freeSpins,
-	}, nil
+	limits := s.BetRange(gameType)
+	if !limits.Contains(bet) {
+		return nil, common.ErrCasinoBetOutOfRange
+	}
+	if err := s.precheckBet(ctx, userID, bet); err != nil {
+		return nil, err
+	}
+
+	// Исход разыгрываем вне транзакции: кубик бросает Telegram, и сетевой
+	// вызов не должен держать блокировки баланса и статистики. Раунд затем
+	// рассчитывается одной транзакцией с уже известным исходом.
+	outcome, err := game.Play(src, choice)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TableResult{GameType: gameType, Title: game.Title(), Choice: choice, Bet: bet, HouseEdge: s.HouseEdge(gameType), Outcome: outcome}
+	gameID, err := s.playRound(ctx, userID, gameType, limits, bet, 0, func(ctx context.Context, tx pgx.Tx, _ RTPTier) (*roundSettlement, error) {
+		result.Payout = tablePayout(bet, outcome, result.HouseEdge)
+		data, err := json.Marshal(tableGameData{Choice: choice, Outcome: outcome, HouseEdge: result.HouseEdge})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сериализации игры: %w", err)
+		}
+		return &roundSettlement{HouseWin: result.Payout, GameData: data}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.GameID = gameID
+	return result, nil
 }
 
 // GetStats возвращает статистику казино пользователя.
 func (s *Service) GetStats(ctx context.Context, userID int64) (*Stats, error) {
 	return s.repo.GetStats(ctx, userID)
 }
+
+// ListRTPTiers возвращает игроков с их текущей ступенью коррекции RTP.
+func (s *Service) ListRTPTiers(ctx context.Context) ([]*Stats, error) {
+	return s.repo.ListStatsByTier(ctx, rtpOverviewLimit)
+}
+
+// CurrentSeed возвращает активный сид игрока, заводя его при первом обращении.
+func (s *Service) CurrentSeed(ctx context.Context, userID int64) (*Seed, error) {
+	candidate, err := newSeed()
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.EnsureActiveSeed(ctx, userID, candidate)
+}
+
+// RotateSeed раскрывает активный серверный сид игрока и заводит новый.
+// clientSeed — клиентский сид для нового сида; пусто — случайный.
+// revealed == nil, если игрок ещё не играл в честном режиме.
+func (s *Service) RotateSeed(ctx context.Context, userID int64, clientSeed string) (revealed, next *Seed, err error) {
+	next, err = newSeed()
+	if err != nil {
+		return nil, nil, err
+	}
+	if clientSeed != "" {
+		if next.ClientSeed, err = normalizeClientSeed(clientSeed); err != nil {
+			return nil, nil, err
+		}
+	}
+	err = s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		revealed, err = s.repo.RotateSeedTx(ctx, tx, userID, next)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return revealed, next, nil
+}
+
+// GameVerification — результат проверки честного спина.
+type GameVerification struct {
+	Game *Game
+	Data *GameData
+	Seed *Seed
+	// Поля ниже заполняются только после раскрытия серверного сида.
+	HashMatches bool
+	Replayed    Grid
+	GridMatches bool
+}
+
+// VerifyGame пересчитывает сетку игры gameID, если её серверный сид уже раскрыт.
+func (s *Service) VerifyGame(ctx context.Context, gameID int64) (*GameVerification, error) {
+	game, err := s.repo.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ParseGameData(game.GameData)
+	if err != nil {
+		return nil, err
+	}
+	if game.GameType != GameTypeSlots || data.Fair == nil {
+		return nil, ErrGameNotFair
+	}
+	seed, err := s.repo.GetSeed(ctx, data.Fair.SeedID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &GameVerification{Game: game, Data: data, Seed: seed}
+	if !seed.Revealed() {
+		return v, nil
+	}
+	v.HashMatches = HashServerSeed(seed.ServerSeed) == data.Fair.ServerSeedHash
+	if len(data.Grid) == 0 {
+		return v, nil
+	}
+	v.Replayed, err = ReplayGrid(seed.ServerSeed, data.Fair, len(data.Grid), len(data.Grid[0]))
+	if err != nil {
+		return nil, err
+	}
+	v.GridMatches = gridsEqual(v.Replayed, data.Grid)
+	return v, nil
+}
+
+// GetJackpot возвращает текущий джекпот-пул.
+func (s *Service) GetJackpot(ctx context.Context) (*Jackpot, error) {
+	return s.repo.GetJackpot(ctx)
+}
+
+// jackpotContribution возвращает долю ставки для пула, округлённую вниз.
+func (s *Service) jackpotContribution(bet int64) int64 {
+	if s.cfg.CasinoJackpotPercent <= 0 {
+		return 0
+	}
+	return int64(float64(bet) * s.cfg.CasinoJackpotPercent / 100)
+}
+
+// applyJackpot заменяет фиксированную выплату линии джекпота всем пулом.
+// Если пул меньше обычной выплаты линии, разницу доплачивает казино,
+// чтобы джекпот никогда не был хуже фиксированного множителя.
+// Фриспины пул не забирают — их линии платят по таблице. Доля ставки
+// выигравшего спина попадает в пул уже после выплаты и открывает новый пул.
+// Блокировка строки пула здесь берётся только при выпавшей линии джекпота.
+func (s *Service) applyJackpot(ctx context.Context, tx pgx.Tx, userID int64, machine *Machine, result *SlotResult) error {
+	idx := machine.JackpotLine(result.WinLines)
+	if idx < 0 {
+		return nil
+	}
+	pool, err := s.repo.LockJackpotTx(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if pool <= 0 {
+		return nil
+	}
+	if err := s.repo.PayJackpotTx(ctx, tx, userID, pool); err != nil {
+		return err
+	}
+
+	line := &result.WinLines[idx]
+	payout := pool
+	if line.Payout > payout {
+		payout = line.Payout
+	}
+	result.TotalPayout += payout - line.Payout
+	line.Payout = payout
+	result.JackpotWin = pool
+	return nil
+}
+
+// today возвращает текущий день по APP_TIMEZONE как дату без часового пояса,
+// в которой хранится ключ casino_daily_limits.day.
+func (s *Service) today() time.Time {
+	loc := s.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := s.now().In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// gameLabel возвращает название игры для описаний транзакций.
+func gameLabel(gameType string) string {
+	switch gameType {
+	case GameTypeSlots:
+		return "Slots"
+	case GameTypeDice:
+		return "Dice"
+	case GameTypeRoulette:
+		return "Roulette"
+	case GameTypeCoinFlip:
+		return "Coin flip"
+	default:
+		return gameType
+	}
+}
+
+// newSeed генерирует серверный и клиентский сиды для нового сида игрока.
+func newSeed() (*Seed, error) {
+	serverSeed, hash, err := newServerSeed()
+	if err != nil {
+		return nil, err
+	}
+	clientSeed, err := newClientSeed()
+	if err != nil {
+		return nil, err
+	}
+	return &Seed{ServerSeed: serverSeed, ServerSeedHash: hash, ClientSeed: clientSeed}, nil
+}
+
+func gridsEqual(a, b Grid) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for reel := range a {
+		if len(a[reel]) != len(b[reel]) {
+			return false
+		}
+		for row := range a[reel] {
+			if a[reel][row] != b[reel][row] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,945 @@
+package casino
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/config"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+)
+
+type ledgerEntry struct {
+	userID int64
+	amount int64
+	txType string
+}
+
+// fakeStore моделирует общую транзакцию: экономика и репозиторий казино
+// откатываются вместе, как в одной транзакции Postgres.
+type fakeStore struct {
+	balances map[int64]int64
+	entries  []ledgerEntry
+	stats    map[int64]Stats
+	games    []Game
+	daily    map[int64]DailyUsage
+	jackpot  Jackpot
+	seeds    []Seed
+	duels    map[string]Duel
+	// exclusions — самоисключения, limits — личные лимиты проигрыша,
+	// earlierLoss — проигрыш за прошлые дни текущей недели.
+	exclusions  []Exclusion
+	limits      map[int64]LossLimits
+	earlierLoss map[int64]int64
+	saveErr     error
+	// inTx — идёт транзакция WithTransaction.
+	inTx bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		balances: make(map[int64]int64),
+		stats:    make(map[int64]Stats),
+		daily:    make(map[int64]DailyUsage),
+		duels:    make(map[string]Duel),
+
+		limits:      make(map[int64]LossLimits),
+		earlierLoss: make(map[int64]int64),
+	}
+}
+
+func (f *fakeStore) WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
+	balances := make(map[int64]int64, len(f.balances))
+	for k, v := range f.balances {
+		balances[k] = v
+	}
+	stats := make(map[int64]Stats, len(f.stats))
+	for k, v := range f.stats {
+		stats[k] = v
+	}
+	daily := make(map[int64]DailyUsage, len(f.daily))
+	for k, v := range f.daily {
+		daily[k] = v
+	}
+	entries, games, jackpot := len(f.entries), len(f.games), f.jackpot
+	seeds := append([]Seed(nil), f.seeds...)
+	duels := make(map[string]Duel, len(f.duels))
+	for k, v := range f.duels {
+		duels[k] = v
+	}
+	limits := make(map[int64]LossLimits, len(f.limits))
+	for k, v := range f.limits {
+		limits[k] = v
+	}
+	f.inTx = true
+	err := fn(ctx, nil)
+	f.inTx = false
+	if err != nil {
+		f.limits = limits
+		f.seeds = seeds
+		f.duels = duels
+		f.balances = balances
+		f.stats = stats
+		f.daily = daily
+		f.jackpot = jackpot
+		f.entries = f.entries[:entries]
+		f.games = f.games[:games]
+		return err
+	}
+	return nil
+}
+
+func (f *fakeStore) GetBalance(ctx context.Context, userID int64) (int64, error) {
+	return f.balances[userID], nil
+}
+
+func (f *fakeStore) AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+	f.balances[userID] += amount
+	f.entries = append(f.entries, ledgerEntry{userID: userID, amount: amount, txType: txType})
+	return nil
+}
+
+func (f *fakeStore) DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+	if f.balances[userID] < amount {
+		return common.ErrInsufficientBalance
+	}
+	f.balances[userID] -= amount
+	f.entries = append(f.entries, ledgerEntry{userID: userID, amount: -amount, txType: txType})
+	return nil
+}
+
+func (f *fakeStore) GetStats(ctx context.Context, userID int64) (*Stats, error) {
+	s, ok := f.stats[userID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &s, nil
+}
+
+func (f *fakeStore) ListStatsByTier(ctx context.Context, limit int) ([]*Stats, error) {
+	var out []*Stats
+	for _, s := range f.stats {
+		cp := s
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetRTPTierForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (RTPTier, error) {
+	s, ok := f.stats[userID]
+	if !ok {
+		return RTPTierNormal, nil
+	}
+	return s.RTPTier, nil
+}
+
+func (f *fakeStore) SetRTPTierTx(ctx context.Context, tx pgx.Tx, userID int64, tier RTPTier) error {
+	s := f.stats[userID]
+	s.RTPTier = tier
+	f.stats[userID] = s
+	return nil
+}
+
+func (f *fakeStore) UpdateStatsTx(ctx context.Context, tx pgx.Tx, userID int64, betAmount, wonAmount int64) (float64, error) {
+	s := f.stats[userID]
+	s.UserID = userID
+	s.TotalSpins++
+	s.TotalWagered += betAmount
+	s.TotalWon += wonAmount
+	if wonAmount > s.BiggestWin {
+		s.BiggestWin = wonAmount
+	}
+	s.CurrentRTP = float64(s.TotalWon) / float64(s.TotalWagered) * 100
+	f.stats[userID] = s
+	return s.CurrentRTP, nil
+}
+
+func (f *fakeStore) SaveGameTx(ctx context.Context, tx pgx.Tx, game *Game) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	game.ID = int64(len(f.games) + 1)
+	f.games = append(f.games, *game)
+	return nil
+}
+
+func (f *fakeStore) GetDailyUsage(ctx context.Context, userID int64, day time.Time) (*DailyUsage, error) {
+	u := f.daily[userID]
+	if !u.Day.Equal(day) {
+		u = DailyUsage{UserID: userID, Day: day}
+	}
+	return &u, nil
+}
+
+func (f *fakeStore) LockDailyUsageTx(ctx context.Context, tx pgx.Tx, userID int64, day time.Time) (*DailyUsage, error) {
+	return f.GetDailyUsage(ctx, userID, day)
+}
+
+func (f *fakeStore) GetNetLossSince(ctx context.Context, userID int64, from time.Time) (int64, error) {
+	loss := f.earlierLoss[userID]
+	if u := f.daily[userID]; !u.Day.Before(from) {
+		loss += u.NetLoss()
+	}
+	return loss, nil
+}
+
+func (f *fakeStore) UpsertExclusion(ctx context.Context, userID int64, startsAt, endsAt time.Time) (*Exclusion, error) {
+	for i := range f.exclusions {
+		e := &f.exclusions[i]
+		if e.UserID == userID && e.LiftedAt == nil && e.EndsAt.After(startsAt) {
+			if endsAt.After(e.EndsAt) {
+				e.EndsAt = endsAt
+			}
+			result := *e
+			return &result, nil
+		}
+	}
+	e := Exclusion{ID: int64(len(f.exclusions) + 1), UserID: userID, StartsAt: startsAt, EndsAt: endsAt, CreatedAt: startsAt}
+	f.exclusions = append(f.exclusions, e)
+	return &e, nil
+}
+
+func (f *fakeStore) GetActiveExclusion(ctx context.Context, userID int64, now time.Time) (*Exclusion, error) {
+	for _, e := range f.exclusions {
+		if e.UserID == userID && e.LiftedAt == nil && e.EndsAt.After(now) {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) ListActiveExclusions(ctx context.Context, now time.Time) ([]*Exclusion, error) {
+	var result []*Exclusion
+	for _, e := range f.exclusions {
+		if e.LiftedAt == nil && e.EndsAt.After(now) {
+			result = append(result, &e)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeStore) LiftExclusion(ctx context.Context, exclusionID, actorID int64, now time.Time) (*Exclusion, error) {
+	for i := range f.exclusions {
+		e := &f.exclusions[i]
+		if e.ID == exclusionID && e.LiftedAt == nil && e.EndsAt.After(now) {
+			e.LiftedAt, e.LiftedBy = &now, &actorID
+			result := *e
+			return &result, nil
+		}
+	}
+	return nil, ErrExclusionNotFound
+}
+
+func (f *fakeStore) GetLossLimits(ctx context.Context, userID int64) (*LossLimits, error) {
+	l, ok := f.limits[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+func (f *fakeStore) LockLossLimitsTx(ctx context.Context, tx pgx.Tx, userID int64) (*LossLimits, error) {
+	l, ok := f.limits[userID]
+	if !ok {
+		l = LossLimits{UserID: userID}
+	}
+	return &l, nil
+}
+
+func (f *fakeStore) SaveLossLimitsTx(ctx context.Context, tx pgx.Tx, limits *LossLimits) error {
+	f.limits[limits.UserID] = *limits
+	return nil
+}
+
+func (f *fakeStore) ListStatsLeaders(ctx context.Context, metric LeaderboardMetric, limit int) ([]LeaderboardEntry, error) {
+	var out []LeaderboardEntry
+	for userID, st := range f.stats {
+		value := map[LeaderboardMetric]int64{
+			LeaderboardBiggestWin: st.BiggestWin,
+			LeaderboardNetProfit:  st.TotalWon - st.TotalWagered,
+			LeaderboardSpins:      int64(st.TotalSpins),
+		}[metric]
+		out = append(out, LeaderboardEntry{UserID: userID, Value: value})
+	}
+	return topEntries(out, limit), nil
+}
+
+func (f *fakeStore) ListGameLeaders(ctx context.Context, metric LeaderboardMetric, from, to time.Time, limit int) ([]LeaderboardEntry, error) {
+	values := make(map[int64]int64)
+	for _, g := range f.games {
+		if g.CreatedAt.Before(from) || !g.CreatedAt.Before(to) {
+			continue
+		}
+		switch metric {
+		case LeaderboardBiggestWin:
+			if g.ResultAmount > values[g.UserID] {
+				values[g.UserID] = g.ResultAmount
+			}
+		case LeaderboardNetProfit:
+			values[g.UserID] += g.ResultAmount - g.BetAmount
+		case LeaderboardSpins:
+			values[g.UserID]++
+		}
+	}
+	var out []LeaderboardEntry
+	for userID, v := range values {
+		out = append(out, LeaderboardEntry{UserID: userID, Value: v})
+	}
+	return topEntries(out, limit), nil
+}
+
+func (f *fakeStore) GetPeriodTotals(ctx context.Context, from, to time.Time) (*PeriodTotals, error) {
+	var t PeriodTotals
+	players := make(map[int64]bool)
+	for _, g := range f.games {
+		if g.CreatedAt.Before(from) || !g.CreatedAt.Before(to) {
+			continue
+		}
+		t.Games++
+		t.Wagered += g.BetAmount
+		t.PaidOut += g.ResultAmount
+		players[g.UserID] = true
+	}
+	t.Players = int64(len(players))
+	return &t, nil
+}
+
+// topEntries сортирует рейтинг как репозиторий: по убыванию, затем по user_id.
+func topEntries(entries []LeaderboardEntry, limit int) []LeaderboardEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+func (f *fakeStore) AddDailyUsageTx(ctx context.Context, tx pgx.Tx, userID int64, day time.Time, betAmount, wonAmount int64) error {
+	u, _ := f.GetDailyUsage(ctx, userID, day)
+	u.Spins++
+	u.Wagered += betAmount
+	u.Won += wonAmount
+	f.daily[userID] = *u
+	return nil
+}
+
+func (f *fakeStore) GetJackpot(ctx context.Context) (*Jackpot, error) {
+	j := f.jackpot
+	return &j, nil
+}
+
+func (f *fakeStore) AddToJackpotTx(ctx context.Context, tx pgx.Tx, amount int64) (int64, error) {
+	f.jackpot.Amount += amount
+	return f.jackpot.Amount, nil
+}
+
+func (f *fakeStore) LockJackpotTx(ctx context.Context, tx pgx.Tx) (int64, error) {
+	return f.jackpot.Amount, nil
+}
+
+func (f *fakeStore) PayJackpotTx(ctx context.Context, tx pgx.Tx, userID, amount int64) error {
+	f.jackpot.Amount -= amount
+	f.jackpot.LastWinnerID = &userID
+	f.jackpot.LastWinAmount = amount
+	return nil
+}
+
+func (f *fakeStore) GetGame(ctx context.Context, gameID int64) (*Game, error) {
+	if gameID <= 0 || gameID > int64(len(f.games)) {
+		return nil, ErrGameNotFound
+	}
+	g := f.games[gameID-1]
+	return &g, nil
+}
+
+func (f *fakeStore) GetSeed(ctx context.Context, seedID int64) (*Seed, error) {
+	if seedID <= 0 || seedID > int64(len(f.seeds)) {
+		return nil, errors.New("not found")
+	}
+	s := f.seeds[seedID-1]
+	return &s, nil
+}
+
+func (f *fakeStore) activeSeed(userID int64, candidate *Seed) *Seed {
+	for i := range f.seeds {
+		if f.seeds[i].UserID == userID && !f.seeds[i].Revealed() {
+			return &f.seeds[i]
+		}
+	}
+	s := *candidate
+	s.ID, s.UserID = int64(len(f.seeds)+1), userID
+	f.seeds = append(f.seeds, s)
+	return &f.seeds[len(f.seeds)-1]
+}
+
+func (f *fakeStore) EnsureActiveSeed(ctx context.Context, userID int64, candidate *Seed) (*Seed, error) {
+	s := *f.activeSeed(userID, candidate)
+	return &s, nil
+}
+
+func (f *fakeStore) NextNonceTx(ctx context.Context, tx pgx.Tx, userID int64, candidate *Seed) (*Seed, error) {
+	active := f.activeSeed(userID, candidate)
+	active.Nonce++
+	s := *active
+	return &s, nil
+}
+
+func (f *fakeStore) RotateSeedTx(ctx context.Context, tx pgx.Tx, userID int64, next *Seed) (*Seed, error) {
+	var revealed *Seed
+	for i := range f.seeds {
+		if f.seeds[i].UserID == userID && !f.seeds[i].Revealed() {
+			now := time.Now()
+			f.seeds[i].RevealedAt = &now
+			s := f.seeds[i]
+			revealed = &s
+		}
+	}
+	f.activeSeed(userID, next)
+	return revealed, nil
+}
+
+func (f *fakeStore) CreateDuelTx(ctx context.Context, tx pgx.Tx, duel *Duel) error {
+	duel.ID = int64(len(f.duels) + 1)
+	f.duels[duel.Token] = *duel
+	return nil
+}
+
+func (f *fakeStore) SetDuelMessage(ctx context.Context, duelID int64, messageID int) error {
+	for token, d := range f.duels {
+		if d.ID == duelID {
+			d.MessageID = messageID
+			f.duels[token] = d
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) GetDuel(ctx context.Context, token string) (*Duel, error) {
+	d, ok := f.duels[token]
+	if !ok {
+		return nil, ErrDuelNotFound
+	}
+	return &d, nil
+}
+
+func (f *fakeStore) LockDuelTx(ctx context.Context, tx pgx.Tx, token string) (*Duel, error) {
+	return f.GetDuel(ctx, token)
+}
+
+func (f *fakeStore) ResolveDuelTx(ctx context.Context, tx pgx.Tx, duel *Duel) error {
+	f.duels[duel.Token] = *duel
+	return nil
+}
+
+func (f *fakeStore) ListExpiredDuels(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var tokens []string
+	for token, d := range f.duels {
+		if d.State == DuelStatePending && d.ExpiresAt.Before(now) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// fixedSource всегда возвращает одно и то же число: сетка из одного символа.
+type fixedSource int64
+
+func (s fixedSource) Int63n(n int64) (int64, error) { return int64(s) % n, nil }
+
+// sevensSource заполняет сетку classic семёрками: сумма весов до 7️⃣ равна 95.
+const sevensSource = fixedSource(95)
+
+func newTestService(store *fakeStore) *Service {
+	cfg := &config.Config{
+		CasinoSlotsBet: 50, CasinoSlotsMinBet: 10, CasinoSlotsMaxBet: 1000,
+		CasinoDiceMinBet: 10, CasinoDiceMaxBet: 1000,
+		CasinoRouletteMinBet: 10, CasinoRouletteMaxBet: 1000,
+		CasinoCoinMinBet: 10, CasinoCoinMaxBet: 1000,
+		CasinoDuelMinStake: 10, CasinoDuelMaxStake: 1000,
+		CasinoInitRTP: 96, CasinoMinRTP: 94, CasinoMaxRTP: 98,
+		CasinoDuelTTL: 10 * time.Minute,
+	}
+	return &Service{
+		repo:           store,
+		economyService: store,
+		rtpManager:     NewRTPManager(cfg.CasinoMinRTP, cfg.CasinoMaxRTP, cfg.CasinoInitRTP),
+		machines:       mustMachineSet(),
+		games:          newTableGames(),
+		cfg:            cfg,
+		location:       time.UTC,
+		now:            func() time.Time { return time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC) },
+		rng:            secureSource,
+	}
+}
+
+func mustMachineSet() *MachineSet {
+	set, err := NewMachineSet(DefaultMachineName, ClassicMachine())
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+func TestPlaySlots_CommitsWholeSpin(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+
+	result, err := svc.PlaySlots(context.Background(), 7, "", 0)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+
+	if got, want := store.balances[7], 1000-50+result.TotalPayout; got != want {
+		t.Fatalf("balance = %d, want %d", got, want)
+	}
+	if store.entries[0].txType != economy.TxTypeCasinoBet || store.entries[0].amount != -50 {
+		t.Fatalf("unexpected bet entry: %+v", store.entries[0])
+	}
+	if result.TotalPayout > 0 && (len(store.entries) != 2 || store.entries[1].txType != economy.TxTypeCasinoWin) {
+		t.Fatalf("win entry missing: %+v", store.entries)
+	}
+	if len(store.games) != 1 || store.games[0].ResultAmount != result.TotalPayout {
+		t.Fatalf("unexpected games: %+v", store.games)
+	}
+	if stats := store.stats[7]; stats.TotalSpins != 1 || stats.TotalWagered != 50 || stats.TotalWon != result.TotalPayout {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if store.games[0].RTPPercent != store.stats[7].CurrentRTP {
+		t.Fatalf("game rtp = %v, want %v", store.games[0].RTPPercent, store.stats[7].CurrentRTP)
+	}
+}
+
+func TestPlaySlots_InsufficientBalanceLeavesNoTrace(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 10
+	svc := newTestService(store)
+
+	_, err := svc.PlaySlots(context.Background(), 7, "", 0)
+	if !errors.Is(err, common.ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+	if store.balances[7] != 10 || len(store.entries) != 0 || len(store.games) != 0 || len(store.stats) != 0 {
+		t.Fatalf("spin left side effects: balance=%d entries=%v games=%v stats=%v", store.balances[7], store.entries, store.games, store.stats)
+	}
+}
+
+func TestPlaySlots_SaveFailureRollsBackBetAndStats(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	store.saveErr = errors.New("db down")
+	svc := newTestService(store)
+
+	if _, err := svc.PlaySlots(context.Background(), 7, "", 0); err == nil {
+		t.Fatal("expected error")
+	}
+	if store.balances[7] != 1000 {
+		t.Fatalf("balance = %d, want 1000", store.balances[7])
+	}
+	if len(store.entries) != 0 || len(store.stats) != 0 || len(store.games) != 0 {
+		t.Fatalf("spin left side effects: entries=%v stats=%v games=%v", store.entries, store.stats, store.games)
+	}
+}
+
+func TestPlaySlots_PersistsRTPTierWithSpin(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	// Игрок давно в минусе: после спина RTP останется ниже нижней границы.
+	store.stats[7] = Stats{UserID: 7, TotalSpins: 1000, TotalWagered: 1_000_000, TotalWon: 100_000, RTPTier: RTPTierNormal}
+	svc := newTestService(store)
+
+	if _, err := svc.PlaySlots(context.Background(), 7, "", 0); err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	if got := store.stats[7].RTPTier; got != RTPTierBoost {
+		t.Fatalf("tier = %q, want %q", got, RTPTierBoost)
+	}
+
+	// Новый сервис (перезапуск) читает ступень из хранилища, а не из памяти.
+	restarted := newTestService(store)
+	tiers, err := restarted.ListRTPTiers(context.Background())
+	if err != nil || len(tiers) != 1 || tiers[0].RTPTier != RTPTierBoost {
+		t.Fatalf("unexpected tiers after restart: %+v, err=%v", tiers, err)
+	}
+}
+
+func TestPlaySlots_FailedSpinKeepsPreviousTier(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	store.stats[7] = Stats{UserID: 7, TotalSpins: 1000, TotalWagered: 1_000_000, TotalWon: 100_000, RTPTier: RTPTierTighten}
+	store.saveErr = errors.New("db down")
+	svc := newTestService(store)
+
+	if _, err := svc.PlaySlots(context.Background(), 7, "", 0); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := store.stats[7].RTPTier; got != RTPTierTighten {
+		t.Fatalf("tier = %q, want rollback to %q", got, RTPTierTighten)
+	}
+}
+
+func TestRTPManager_TierFor(t *testing.T) {
+	m := NewRTPManager(94, 98, 96)
+	cases := map[float64]RTPTier{
+		50:  RTPTierBoost,
+		94:  RTPTierNormal,
+		96:  RTPTierNormal,
+		98:  RTPTierNormal,
+		120: RTPTierTighten,
+	}
+	for rtp, want := range cases {
+		if got := m.TierFor(rtp); got != want {
+			t.Fatalf("TierFor(%v) = %q, want %q", rtp, got, want)
+		}
+	}
+}
+
+func TestWeightsForTier_DoesNotMutateDefaults(t *testing.T) {
+	before := copySymbols(DefaultSymbols)
+	boost := WeightsForTier(RTPTierBoost)
+	tighten := WeightsForTier(RTPTierTighten)
+
+	weight := func(symbols []Symbol, name string) int {
+		for _, s := range symbols {
+			if s.Name == name {
+				return s.Weight
+			}
+		}
+		return -1
+	}
+	if weight(boost, "Seven") <= weight(before, "Seven") || weight(tighten, "Seven") >= weight(before, "Seven") {
+		t.Fatalf("unexpected seven weights: boost=%d tighten=%d default=%d", weight(boost, "Seven"), weight(tighten, "Seven"), weight(before, "Seven"))
+	}
+	for i := range before {
+		if DefaultSymbols[i] != before[i] {
+			t.Fatalf("DefaultSymbols mutated at %d: %+v", i, DefaultSymbols[i])
+		}
+	}
+	if parseRTPTier("garbage") != RTPTierNormal {
+		t.Fatal("unknown tier must fall back to normal")
+	}
+}
+
+func TestPlaySlots_UnknownMachine(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+
+	if _, err := svc.PlaySlots(context.Background(), 7, "нет-такой", 0); !errors.Is(err, ErrUnknownMachine) {
+		t.Fatalf("expected ErrUnknownMachine, got %v", err)
+	}
+	if store.balances[7] != 1000 || len(store.games) != 0 {
+		t.Fatalf("unknown machine must not touch balance or games")
+	}
+}
+
+func TestPlaySlots_RecordsMachineInGameData(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+
+	if _, err := svc.PlaySlots(context.Background(), 7, "CLASSIC", 0); err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	if !strings.Contains(string(store.games[0].GameData), `"machine":"classic"`) {
+		t.Fatalf("game data must name the machine: %s", store.games[0].GameData)
+	}
+}
+
+func TestPlaySlots_CustomBet(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+
+	result, err := svc.PlaySlots(context.Background(), 7, "", 200)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	if result.Bet != 200 || store.entries[0].amount != -200 || store.games[0].BetAmount != 200 {
+		t.Fatalf("bet not applied: result=%d entry=%+v game=%+v", result.Bet, store.entries[0], store.games[0])
+	}
+	if u := store.daily[7]; u.Spins != 1 || u.Wagered != 200 || u.Won != result.TotalPayout {
+		t.Fatalf("unexpected daily usage: %+v", u)
+	}
+	if stats := store.stats[7]; stats.TotalWagered != 200 {
+		t.Fatalf("stats must use actual bet: %+v", stats)
+	}
+}
+
+func TestPlaySlots_BetOutOfRange(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 100_000
+	svc := newTestService(store)
+
+	for _, bet := range []int64{5, 5000} {
+		if _, err := svc.PlaySlots(context.Background(), 7, "", bet); !errors.Is(err, common.ErrCasinoBetOutOfRange) {
+			t.Fatalf("bet %d: expected ErrCasinoBetOutOfRange, got %v", bet, err)
+		}
+	}
+	if len(store.entries) != 0 || len(store.daily) != 0 {
+		t.Fatalf("rejected bet left side effects: entries=%v daily=%v", store.entries, store.daily)
+	}
+}
+
+func TestPlaySlots_DailyCaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		usage   DailyUsage
+		wager   int64
+		loss    int64
+		bet     int64
+		wantErr error
+	}{
+		{name: "within caps", usage: DailyUsage{Wagered: 400, Won: 100}, wager: 1000, loss: 500, bet: 100},
+		{name: "wager cap", usage: DailyUsage{Wagered: 950}, wager: 1000, bet: 100, wantErr: common.ErrCasinoDailyWagerCap},
+		{name: "loss cap", usage: DailyUsage{Wagered: 450}, loss: 500, bet: 100, wantErr: common.ErrCasinoDailyLossCap},
+		{name: "winnings extend loss cap", usage: DailyUsage{Wagered: 450, Won: 300}, loss: 500, bet: 100},
+		{name: "caps disabled", usage: DailyUsage{Wagered: 1_000_000}, bet: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.balances[7] = 10_000
+			svc := newTestService(store)
+			svc.cfg.CasinoDailyWagerCap, svc.cfg.CasinoDailyLossCap = tt.wager, tt.loss
+			tt.usage.UserID, tt.usage.Day = 7, svc.today()
+			store.daily[7] = tt.usage
+
+			_, err := svc.PlaySlots(context.Background(), 7, "", tt.bet)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && (store.balances[7] != 10_000 || store.daily[7] != tt.usage) {
+				t.Fatalf("refused spin left side effects: balance=%d usage=%+v", store.balances[7], store.daily[7])
+			}
+		})
+	}
+}
+
+func TestPlaySlots_DailyUsageResetsNextDay(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 10_000
+	svc := newTestService(store)
+	svc.cfg.CasinoDailyWagerCap = 100
+	store.daily[7] = DailyUsage{UserID: 7, Day: svc.today().AddDate(0, 0, -1), Wagered: 100}
+
+	if _, err := svc.PlaySlots(context.Background(), 7, "", 100); err != nil {
+		t.Fatalf("yesterday's usage must not count: %v", err)
+	}
+}
+
+func TestParseSlotsArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		machine string
+		bet     int64
+		ok      bool
+	}{
+		{args: nil, ok: true},
+		{args: []string{"200"}, bet: 200, ok: true},
+		{args: []string{"хэллоуин", "200"}, machine: "хэллоуин", bet: 200, ok: true},
+		{args: []string{"200", "хэллоуин"}, machine: "хэллоуин", bet: 200, ok: true},
+		{args: []string{"-5"}, ok: false},
+		{args: []string{"100", "200"}, ok: false},
+		{args: []string{"a", "b"}, ok: false},
+	}
+	for _, tt := range tests {
+		machine, bet, ok := parseSlotsArgs(tt.args)
+		if machine != tt.machine || bet != tt.bet || ok != tt.ok {
+			t.Fatalf("parseSlotsArgs(%q) = %q, %d, %v", tt.args, machine, bet, ok)
+		}
+	}
+}
+
+func sumByType(entries []ledgerEntry, txType string) int64 {
+	var total int64
+	for _, e := range entries {
+		if e.txType == txType {
+			total += e.amount
+		}
+	}
+	return total
+}
+
+func TestPlaySlots_BetFundsJackpot(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+	svc.cfg.CasinoJackpotPercent = 2
+	svc.rng = fixedSource(0) // одни вишни, без семёрок
+
+	result, err := svc.PlaySlots(context.Background(), 7, "", 200)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	if store.jackpot.Amount != 4 {
+		t.Fatalf("pool = %d, want 4", store.jackpot.Amount)
+	}
+	if got := sumByType(store.entries, economy.TxTypeCasinoBet); got != -196 {
+		t.Fatalf("casino_bet = %d, want -196", got)
+	}
+	if got := sumByType(store.entries, economy.TxTypeJackpotContribution); got != -4 {
+		t.Fatalf("jackpot_contribution = %d, want -4", got)
+	}
+	if got, want := store.balances[7], 1000-200+result.TotalPayout; got != want {
+		t.Fatalf("balance = %d, want %d", got, want)
+	}
+	if stats := store.stats[7]; stats.TotalWagered != 200 {
+		t.Fatalf("stats must count the whole bet: %+v", stats)
+	}
+}
+
+func TestPlaySlots_JackpotLinePaysWholePool(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	store.jackpot.Amount = 100_000
+	svc := newTestService(store)
+	svc.cfg.CasinoJackpotPercent = 1
+	svc.rng = sevensSource
+
+	result, err := svc.PlaySlots(context.Background(), 7, "", 100)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	// Пул уходит игроку целиком, доля этой ставки открывает новый пул
+	if result.JackpotWin != 100_000 || store.jackpot.Amount != 1 {
+		t.Fatalf("jackpot win = %d, pool left = %d", result.JackpotWin, store.jackpot.Amount)
+	}
+	if got := sumByType(store.entries, economy.TxTypeJackpotWin); got != result.JackpotWin {
+		t.Fatalf("jackpot_win = %d, want %d", got, result.JackpotWin)
+	}
+	if got := sumByType(store.entries, economy.TxTypeCasinoWin); got != result.TotalPayout-result.JackpotWin {
+		t.Fatalf("casino_win = %d, want %d", got, result.TotalPayout-result.JackpotWin)
+	}
+	if got, want := store.balances[7], 1000-100+result.TotalPayout; got != want {
+		t.Fatalf("balance = %d, want %d", got, want)
+	}
+	if store.jackpot.LastWinnerID == nil || *store.jackpot.LastWinnerID != 7 {
+		t.Fatalf("winner not recorded: %+v", store.jackpot)
+	}
+	// Остальные линии из семёрок платят по таблице
+	if result.WinLines[0].Payout != 100_000 || result.WinLines[1].Payout != 100*SpecialPayouts[JackpotEmoji][5] {
+		t.Fatalf("unexpected line payouts: %+v", result.WinLines[:2])
+	}
+}
+
+func TestPlaySlots_SmallPoolKeepsFixedPayout(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	store.jackpot.Amount = 10
+	svc := newTestService(store)
+	svc.rng = sevensSource
+
+	fixed := 100 * SpecialPayouts[JackpotEmoji][5]
+	result, err := svc.PlaySlots(context.Background(), 7, "", 100)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	if result.WinLines[0].Payout != fixed || result.JackpotWin != 10 || store.jackpot.Amount != 0 {
+		t.Fatalf("line = %d, jackpot = %d, pool = %d", result.WinLines[0].Payout, result.JackpotWin, store.jackpot.Amount)
+	}
+	if got, want := store.balances[7], 1000-100+result.TotalPayout; got != want {
+		t.Fatalf("balance = %d, want %d", got, want)
+	}
+}
+
+func TestPlaySlots_FailedSpinKeepsJackpot(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	store.jackpot.Amount = 5000
+	store.saveErr = errors.New("db down")
+	svc := newTestService(store)
+	svc.cfg.CasinoJackpotPercent = 5
+	svc.rng = sevensSource
+
+	if _, err := svc.PlaySlots(context.Background(), 7, "", 100); err == nil {
+		t.Fatal("expected error")
+	}
+	if store.jackpot.Amount != 5000 || store.jackpot.LastWinnerID != nil || store.balances[7] != 1000 {
+		t.Fatalf("failed spin touched jackpot: %+v balance=%d", store.jackpot, store.balances[7])
+	}
+}
+
+func TestPlaySlots_ProvablyFairSpinIsVerifiable(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+	svc.cfg.CasinoProvablyFair = true
+	ctx := context.Background()
+
+	published, err := svc.CurrentSeed(ctx, 7)
+	if err != nil {
+		t.Fatalf("current seed: %v", err)
+	}
+	first, err := svc.PlaySlots(ctx, 7, "", 0)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	second, err := svc.PlaySlots(ctx, 7, "", 0)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	if first.Fair == nil || first.Fair.Nonce != 1 || second.Fair.Nonce != 2 {
+		t.Fatalf("unexpected nonces: %+v %+v", first.Fair, second.Fair)
+	}
+	if first.Fair.ServerSeedHash != published.ServerSeedHash {
+		t.Fatalf("spin used a seed other than the published one")
+	}
+
+	// До смены сида проверка не раскрывает серверный сид
+	v, err := svc.VerifyGame(ctx, second.GameID)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if v.Seed.Revealed() || v.Replayed != nil {
+		t.Fatalf("active seed must stay hidden: %+v", v)
+	}
+
+	revealed, next, err := svc.RotateSeed(ctx, 7, "мой-сид")
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if revealed == nil || revealed.ServerSeedHash != published.ServerSeedHash || next.ClientSeed != "мой-сид" {
+		t.Fatalf("unexpected rotation: revealed=%+v next=%+v", revealed, next)
+	}
+
+	v, err = svc.VerifyGame(ctx, second.GameID)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !v.HashMatches || !v.GridMatches || !gridsEqual(v.Replayed, second.Grid) {
+		t.Fatalf("verification failed: %+v", v)
+	}
+}
+
+func TestVerifyGame_Errors(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	svc := newTestService(store)
+	ctx := context.Background()
+
+	if _, err := svc.VerifyGame(ctx, 42); !errors.Is(err, ErrGameNotFound) {
+		t.Fatalf("expected ErrGameNotFound, got %v", err)
+	}
+	result, err := svc.PlaySlots(ctx, 7, "", 0)
+	if err != nil {
+		t.Fatalf("play slots: %v", err)
+	}
+	if _, err := svc.VerifyGame(ctx, result.GameID); !errors.Is(err, ErrGameNotFair) {
+		t.Fatalf("expected ErrGameNotFair, got %v", err)
+	}
+	if _, _, err := svc.RotateSeed(ctx, 7, "с пробелом"); !errors.Is(err, ErrInvalidClientSeed) {
+		t.Fatalf("expected ErrInvalidClientSeed, got %v", err)
+	}
+}
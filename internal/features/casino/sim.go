@@ -0,0 +1,230 @@
+// Package casino — sim.go прогоняет офлайн-симуляцию спинов для проверки
+// RTP и таблиц выплат до того, как менять веса в проде.
+package casino
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SimOptions задаёт параметры симуляции.
+type SimOptions struct {
+	Machine *Machine // Машина для прогона; nil — встроенная classic
+
+	Spins   int64  // Сколько платных спинов разыграть
+	Bet     int64  // Ставка одного спина
+	Seed    uint64 // Сид детерминированного генератора
+	Players int    // Сколько независимых игроков крутят по очереди
+
+	// RTPLoop включает обратную связь RTPManager: ступень коррекции
+	// каждого игрока пересчитывается после каждого спина, как в боте.
+	RTPLoop    bool
+	MinRTP     float64
+	MaxRTP     float64
+	InitialRTP float64
+}
+
+// SimBucket — корзина распределения выплат в множителях ставки: [Min, Max).
+type SimBucket struct {
+	Label string  `json:"label"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max,omitempty"`
+	Count int64   `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// SimLineStat — статистика одной выигрышной линии.
+type SimLineStat struct {
+	Line      int     `json:"line"` // Номер линии с 1, как в комментариях Paylines
+	Wins      int64   `json:"wins"`
+	Frequency float64 `json:"frequency"` // Выигрышей на один платный спин
+	Payout    int64   `json:"payout"`
+}
+
+// SimReport — итог симуляции.
+type SimReport struct {
+	Machine string `json:"machine"`
+	Spins   int64  `json:"spins"`
+	Bet     int64  `json:"bet"`
+	Seed    uint64 `json:"seed"`
+	Players int    `json:"players"`
+	RTPLoop bool   `json:"rtp_loop"`
+
+	TotalWagered int64   `json:"total_wagered"`
+	TotalWon     int64   `json:"total_won"`
+	RTP          float64 `json:"rtp_percent"`
+	// RTPMargin95 — полуширина 95% доверительного интервала RTP в процентных пунктах.
+	RTPMargin95  float64 `json:"rtp_margin_95"`
+	HitFrequency float64 `json:"hit_frequency"`
+	// Variance и StdDev считаются по выплате спина в множителях ставки.
+	Variance float64 `json:"variance"`
+	StdDev   float64 `json:"std_dev"`
+	MaxWin   int64   `json:"max_win"`
+
+	ScatterWon       int64 `json:"scatter_won"`
+	FreeSpinTriggers int64 `json:"free_spin_triggers"`
+	FreeSpinsPlayed  int64 `json:"free_spins_played"`
+	FreeSpinWon      int64 `json:"free_spin_won"`
+
+	Distribution []SimBucket      `json:"distribution"`
+	Lines        []SimLineStat    `json:"lines"`
+	TierSpins    map[string]int64 `json:"tier_spins,omitempty"`
+}
+
+// simBucketBounds — границы корзин распределения в множителях ставки.
+var simBucketBounds = []struct {
+	label    string
+	min, max float64
+}{
+	{label: "0x", min: 0, max: 0},
+	{label: "<1x", min: 0, max: 1},
+	{label: "1–2x", min: 1, max: 2},
+	{label: "2–5x", min: 2, max: 5},
+	{label: "5–10x", min: 5, max: 10},
+	{label: "10–25x", min: 10, max: 25},
+	{label: "25–50x", min: 25, max: 50},
+	{label: "50–100x", min: 50, max: 100},
+	{label: "100x+", min: 100, max: 0},
+}
+
+// simPlayer — накопленная статистика игрока для петли RTP.
+type simPlayer struct {
+	wagered int64
+	won     int64
+	tier    RTPTier
+}
+
+// Simulate разыгрывает opts.Spins спинов через тот же Machine.Spin, что и бот.
+// Прогрессивный джекпот не моделируется: линия джекпота платит по таблице.
+func Simulate(opts SimOptions) (*SimReport, error) {
+	if opts.Spins <= 0 {
+		return nil, errors.New("количество спинов должно быть положительным")
+	}
+	if opts.Bet <= 0 {
+		return nil, errors.New("ставка должна быть положительной")
+	}
+	if opts.Players <= 0 {
+		opts.Players = 1
+	}
+
+	machine := opts.Machine
+	if machine == nil {
+		machine = classic
+	}
+
+	src := NewSeededSource(opts.Seed)
+	manager := NewRTPManager(opts.MinRTP, opts.MaxRTP, opts.InitialRTP)
+	players := make([]simPlayer, opts.Players)
+	for i := range players {
+		players[i].tier = RTPTierNormal
+	}
+	baseWeights := copySymbols(machine.Symbols)
+	tierWeights := map[RTPTier][]Symbol{
+		RTPTierNormal:  machine.WeightsForTier(RTPTierNormal),
+		RTPTierTighten: machine.WeightsForTier(RTPTierTighten),
+		RTPTierBoost:   machine.WeightsForTier(RTPTierBoost),
+	}
+
+	report := &SimReport{
+		Machine: machine.Name,
+		Spins:   opts.Spins,
+		Bet:     opts.Bet,
+		Seed:    opts.Seed,
+		Players: opts.Players,
+		RTPLoop: opts.RTPLoop,
+		Lines:   make([]SimLineStat, len(machine.Paylines)),
+	}
+	if opts.RTPLoop {
+		report.TierSpins = make(map[string]int64)
+	}
+	buckets := make([]int64, len(simBucketBounds))
+	var hits int64
+	// Онлайн-дисперсия Уэлфорда по множителю выплаты
+	var mean, m2 float64
+
+	for i := int64(0); i < opts.Spins; i++ {
+		symbols := baseWeights
+		player := &players[i%int64(len(players))]
+		if opts.RTPLoop {
+			symbols = tierWeights[player.tier]
+			report.TierSpins[string(player.tier)]++
+		}
+
+		result, err := machine.Spin(src, symbols, opts.Bet)
+		if err != nil {
+			return nil, fmt.Errorf("спин %d: %w", i+1, err)
+		}
+
+		report.TotalWagered += opts.Bet
+		report.TotalWon += result.TotalPayout
+		if result.TotalPayout > 0 {
+			hits++
+		}
+		if result.TotalPayout > report.MaxWin {
+			report.MaxWin = result.TotalPayout
+		}
+		report.ScatterWon += result.ScatterWin
+		countLineWins(report.Lines, result.WinLines)
+		if result.FreeSpins > 0 {
+			report.FreeSpinTriggers++
+		}
+		for _, round := range result.FreeSpinRounds {
+			report.FreeSpinsPlayed++
+			report.FreeSpinWon += round.Payout()
+			report.ScatterWon += round.ScatterWin
+			countLineWins(report.Lines, round.WinLines)
+		}
+
+		multiplier := float64(result.TotalPayout) / float64(opts.Bet)
+		buckets[simBucketIndex(result.TotalPayout, multiplier)]++
+		delta := multiplier - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (multiplier - mean)
+
+		if opts.RTPLoop {
+			player.wagered += opts.Bet
+			player.won += result.TotalPayout
+			player.tier = manager.TierFor(CalculateRTP(player.wagered, player.won))
+		}
+	}
+
+	n := float64(opts.Spins)
+	report.RTP = float64(report.TotalWon) / float64(report.TotalWagered) * 100
+	report.HitFrequency = float64(hits) / n
+	if opts.Spins > 1 {
+		report.Variance = m2 / (n - 1)
+	}
+	report.StdDev = math.Sqrt(report.Variance)
+	report.RTPMargin95 = 1.96 * report.StdDev / math.Sqrt(n) * 100
+
+	report.Distribution = make([]SimBucket, len(simBucketBounds))
+	for i, b := range simBucketBounds {
+		report.Distribution[i] = SimBucket{Label: b.label, Min: b.min, Max: b.max, Count: buckets[i], Share: float64(buckets[i]) / n}
+	}
+	for i := range report.Lines {
+		report.Lines[i].Line = i + 1
+		report.Lines[i].Frequency = float64(report.Lines[i].Wins) / n
+	}
+	return report, nil
+}
+
+func countLineWins(lines []SimLineStat, wins []WinLine) {
+	for _, win := range wins {
+		lines[win.LineIndex].Wins++
+		lines[win.LineIndex].Payout += win.Payout
+	}
+}
+
+func simBucketIndex(payout int64, multiplier float64) int {
+	if payout == 0 {
+		return 0
+	}
+	for i := 1; i < len(simBucketBounds); i++ {
+		b := simBucketBounds[i]
+		if b.max == 0 || multiplier < b.max {
+			return i
+		}
+	}
+	return len(simBucketBounds) - 1
+}
@@ -0,0 +1,87 @@
+package casino
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimulate_IsDeterministicForSeed(t *testing.T) {
+	opts := SimOptions{Spins: 5000, Bet: 50, Seed: 42}
+	first, err := Simulate(opts)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	second, err := Simulate(opts)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("same seed must produce the same report")
+	}
+
+	other, err := Simulate(SimOptions{Spins: 5000, Bet: 50, Seed: 43})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if other.TotalWon == first.TotalWon && other.MaxWin == first.MaxWin {
+		t.Fatal("different seeds should diverge")
+	}
+}
+
+func TestSimulate_ReportIsConsistent(t *testing.T) {
+	report, err := Simulate(SimOptions{Spins: 20000, Bet: 50, Seed: 7})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+
+	if report.TotalWagered != 20000*50 {
+		t.Fatalf("wagered = %d", report.TotalWagered)
+	}
+	var bucketed int64
+	for _, b := range report.Distribution {
+		bucketed += b.Count
+	}
+	if bucketed != report.Spins {
+		t.Fatalf("distribution covers %d spins, want %d", bucketed, report.Spins)
+	}
+	// Весь выигрыш складывается из линий и скаттеров (включая фриспины).
+	won := report.ScatterWon
+	for _, l := range report.Lines {
+		won += l.Payout
+	}
+	if won != report.TotalWon {
+		t.Fatalf("lines+scatters = %d, total won = %d", won, report.TotalWon)
+	}
+	if report.Distribution[0].Share != 1-report.HitFrequency {
+		t.Fatalf("zero bucket share %v must match miss rate %v", report.Distribution[0].Share, 1-report.HitFrequency)
+	}
+	if report.Variance <= 0 || report.RTPMargin95 <= 0 {
+		t.Fatalf("expected positive variance and margin: %+v", report)
+	}
+}
+
+func TestSimulate_RTPLoopTracksTiers(t *testing.T) {
+	report, err := Simulate(SimOptions{Spins: 3000, Bet: 50, Seed: 1, Players: 3, RTPLoop: true, MinRTP: 94, MaxRTP: 98, InitialRTP: 96})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	var total int64
+	for _, n := range report.TierSpins {
+		total += n
+	}
+	if total != report.Spins {
+		t.Fatalf("tier spins = %d, want %d", total, report.Spins)
+	}
+	if report.TierSpins[string(RTPTierNormal)] < 3 {
+		t.Fatalf("each player starts at normal tier: %+v", report.TierSpins)
+	}
+}
+
+func TestSimulate_RejectsInvalidOptions(t *testing.T) {
+	if _, err := Simulate(SimOptions{Spins: 0, Bet: 50}); err == nil {
+		t.Fatal("expected error for zero spins")
+	}
+	if _, err := Simulate(SimOptions{Spins: 10, Bet: 0}); err == nil {
+		t.Fatal("expected error for zero bet")
+	}
+}
@@ -1,21 +1,60 @@
 // Package casino — slots.go реализует движок слот-машины 5x6.
 // Содержит генерацию сетки, проверку линий и подсчёт выигрышей.
-// Использует криптографически безопасный генератор случайных чисел.
+// В боте используется криптографически безопасный генератор случайных чисел,
+// симулятор подставляет детерминированный источник с сидом.
 package casino
 
 import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	mrand "math/rand/v2"
 )
 
-// Paylines — 20 фиксированных выигрышных линий.
+// RandomSource выдаёт случайное число в диапазоне [0, n).
+type RandomSource interface {
+	Int63n(n int64) (int64, error)
+}
+
+// cryptoSource — источник на crypto/rand, используется в реальных спинах.
+type cryptoSource struct{}
+
+func (cryptoSource) Int63n(n int64) (int64, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(n))
+	if err != nil {
+		return 0, err
+	}
+	return v.Int64(), nil
+}
+
+// seededSource — воспроизводимый источник для офлайн-симуляций.
+type seededSource struct {
+	rng *mrand.Rand
+}
+
+// NewSeededSource создаёт детерминированный источник: одинаковый сид даёт
+// одинаковую последовательность сеток. Не годится для реальных ставок.
+func NewSeededSource(seed uint64) RandomSource {
+	return &seededSource{rng: mrand.New(mrand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+func (s *seededSource) Int63n(n int64) (int64, error) {
+	return s.rng.Int64N(n), nil
+}
+
+// secureSource — источник по умолчанию для GenerateGrid.
+var secureSource RandomSource = cryptoSource{}
+
+// classic — встроенная машина, которой пользуются функции пакета без явной машины.
+var classic = ClassicMachine()
+
+// Paylines — 20 выигрышных линий машины classic.
 // Каждая линия — массив из 5 индексов строк (по одному на каждый рил).
 // Индексы строк от 0 до 5 (6 строк).
 //
 // Пример: линия {0,0,0,0,0} — верхняя горизонтальная линия.
 // Линия {0,1,2,1,0} — V-образная линия.
-var Paylines = [20][5]int{
+var Paylines = [][]int{
 	// Горизонтальные линии (6 штук — по одной на строку)
 	{0, 0, 0, 0, 0}, // Линия 1: верхняя горизонтальная
 	{1, 1, 1, 1, 1}, // Линия 2
@@ -45,7 +84,7 @@ var Paylines = [20][5]int{
 	{3, 2, 3, 2, 3}, // Линия 20: волна (зеркальная)
 }
 
-// GenerateGrid создаёт сетку 5x6 символов с использованием взвешенной случайности.
+// GenerateGrid создаёт сетку classic 5x6 с использованием взвешенной случайности.
 // Каждая позиция на сетке выбирается независимо на основе весов символов.
 //
 // Параметры:
@@ -55,14 +94,24 @@ var Paylines = [20][5]int{
 //   - Grid: сетка 5x6 с эмодзи символов
 //   - error: ошибка если криптогенератор не сработал
 func GenerateGrid(symbols []Symbol) (Grid, error) {
-	var grid Grid
+	return classic.GenerateGrid(secureSource, symbols)
+}
+
+// GenerateGridFrom создаёт сетку classic, беря случайность из переданного источника.
+func GenerateGridFrom(src RandomSource, symbols []Symbol) (Grid, error) {
+	return classic.GenerateGrid(src, symbols)
+}
+
+// GenerateGrid создаёт сетку машины, беря случайность из переданного источника.
+func (m *Machine) GenerateGrid(src RandomSource, symbols []Symbol) (Grid, error) {
+	grid := newGrid(m.Reels, m.Rows)
 
 	// Генерируем каждую позицию независимо
-	for reel := 0; reel < 5; reel++ {
-		for row := 0; row < 6; row++ {
-			emoji, err := selectWeightedSymbol(symbols)
+	for reel := 0; reel < m.Reels; reel++ {
+		for row := 0; row < m.Rows; row++ {
+			emoji, err := selectWeightedSymbol(src, symbols)
 			if err != nil {
-				return grid, fmt.Errorf("ошибка генерации символа [%d][%d]: %w", reel, row, err)
+				return nil, fmt.Errorf("ошибка генерации символа [%d][%d]: %w", reel, row, err)
 			}
 			grid[reel][row] = emoji
 		}
@@ -72,28 +121,27 @@ func GenerateGrid(symbols []Symbol) (Grid, error) {
 }
 
 // selectWeightedSymbol выбирает случайный символ на основе весов.
-// Использует crypto/rand для криптографически безопасной случайности.
 //
 // Алгоритм:
 //  1. Считаем сумму всех весов
 //  2. Генерируем случайное число от 0 до суммы-1
 //  3. Проходим по символам, накапливая веса
 //  4. Когда накопленный вес превышает случайное число — возвращаем символ
-func selectWeightedSymbol(symbols []Symbol) (string, error) {
+func selectWeightedSymbol(src RandomSource, symbols []Symbol) (string, error) {
 	// Шаг 1: сумма весов
 	totalWeight := 0
 	for _, s := range symbols {
 		totalWeight += s.Weight
 	}
 
-	// Шаг 2: криптографически безопасное случайное число
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(totalWeight)))
+	// Шаг 2: случайное число из источника
+	n, err := src.Int63n(int64(totalWeight))
 	if err != nil {
 		return "", fmt.Errorf("ошибка генерации случайного числа: %w", err)
 	}
 
 	// Шаг 3-4: выбираем символ
-	randomValue := int(n.Int64())
+	randomValue := int(n)
 	cumulative := 0
 	for _, s := range symbols {
 		cumulative += s.Weight
@@ -106,28 +154,33 @@ func selectWeightedSymbol(symbols []Symbol) (string, error) {
 	return symbols[0].Emoji, nil
 }
 
-// CheckPaylines проверяет все 20 линий на выигрыш.
-// Учитывает Wild (⭐) — он заменяет любой символ кроме Scatter.
+// CheckPaylines проверяет все 20 линий classic на выигрыш.
+func CheckPaylines(grid Grid, bet int64) []WinLine {
+	return classic.CheckPaylines(grid, bet)
+}
+
+// CheckPaylines проверяет все линии машины на выигрыш.
+// Учитывает Wild — он заменяет любой символ кроме Scatter.
 //
 // Алгоритм для каждой линии:
 //  1. Берём первый символ (или Wild)
 //  2. Считаем совпадения слева направо
 //  3. Wild совпадает с любым символом (кроме Scatter)
-//  4. Если 3+ совпадений — это выигрыш
-func CheckPaylines(grid Grid, bet int64) []WinLine {
+//  4. Если за столько совпадений есть выплата — это выигрыш
+func (m *Machine) CheckPaylines(grid Grid, bet int64) []WinLine {
 	var wins []WinLine
 
-	for lineIdx, line := range Paylines {
+	for lineIdx, line := range m.Paylines {
 		// Собираем символы на линии
-		symbols := make([]string, 5)
-		for reel := 0; reel < 5; reel++ {
-			symbols[reel] = grid[reel][line[reel]]
+		symbols := make([]string, len(line))
+		for reel, row := range line {
+			symbols[reel] = grid[reel][row]
 		}
 
 		// Определяем «базовый» символ (первый не-Wild)
 		baseSymbol := ""
 		for _, s := range symbols {
-			if s != WildEmoji && s != ScatterEmoji {
+			if !m.isWild(s) && !m.isScatter(s) {
 				baseSymbol = s
 				break
 			}
@@ -135,60 +188,131 @@ func CheckPaylines(grid Grid, bet int64) []WinLine {
 
 		// Если все Wild — используем Wild как базовый
 		if baseSymbol == "" {
-			baseSymbol = WildEmoji
+			if m.Wild == "" {
+				continue
+			}
+			baseSymbol = m.Wild
 		}
 
 		// Считаем последовательные совпадения слева направо
 		matchCount := 0
 		for _, s := range symbols {
-			if s == baseSymbol || s == WildEmoji {
+			if s == baseSymbol || m.isWild(s) {
 				matchCount++
 			} else {
 				break // Цепочка прервалась
 			}
 		}
 
-		// Минимум 3 для выигрыша
-		if matchCount >= 3 {
-			payout := calculateLinePayout(baseSymbol, matchCount, bet)
-			if payout > 0 {
-				wins = append(wins, WinLine{
-					LineIndex: lineIdx,
-					Symbol:    baseSymbol,
-					Count:     matchCount,
-					Payout:    payout,
-				})
-			}
+		if payout := m.linePayout(baseSymbol, matchCount, bet); payout > 0 {
+			wins = append(wins, WinLine{
+				LineIndex: lineIdx,
+				Symbol:    baseSymbol,
+				Count:     matchCount,
+				Payout:    payout,
+			})
 		}
 	}
 
 	return wins
 }
 
-// calculateLinePayout вычисляет выплату для одной выигрышной линии.
-func calculateLinePayout(symbol string, count int, bet int64) int64 {
-	// Проверяем специальные выплаты (7️⃣ и 💎)
-	if special, ok := SpecialPayouts[symbol]; ok {
+// linePayout вычисляет выплату для одной выигрышной линии.
+func (m *Machine) linePayout(symbol string, count int, bet int64) int64 {
+	// Проверяем специальные выплаты (у classic — 7️⃣ и 💎)
+	if special, ok := m.SpecialPayouts[symbol]; ok {
 		if multiplier, ok := special[count]; ok {
 			return bet * multiplier
 		}
 	}
 
 	// Стандартная выплата
-	if multiplier, ok := PayoutTable[count]; ok {
+	if multiplier, ok := m.Payouts[count]; ok {
 		return bet * multiplier
 	}
 
 	return 0
 }
 
-// CountScatters подсчитывает количество скаттеров (🎰) на всей сетке.
-// Скаттеры считаются в ЛЮБОЙ позиции, не обязательно на линии.
+func (m *Machine) isWild(symbol string) bool {
+	return m.Wild != "" && symbol == m.Wild
+}
+
+func (m *Machine) isScatter(symbol string) bool {
+	return m.Scatter != "" && symbol == m.Scatter
+}
+
+// Spin разыгрывает спин на машине classic.
+func Spin(src RandomSource, symbols []Symbol, bet int64) (*SlotResult, error) {
+	return classic.Spin(src, symbols, bet)
+}
+
+// Spin разыгрывает один платный спин вместе с выпавшими фриспинами
+// и считает итоговую выплату. Побочных эффектов не имеет: баланс,
+// статистику и запись игры ведёт вызывающий код.
+func (m *Machine) Spin(src RandomSource, symbols []Symbol, bet int64) (*SlotResult, error) {
+	grid, err := m.GenerateGrid(src, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем линии и скаттеры
+	winLines := m.CheckPaylines(grid, bet)
+	scatterCount := m.CountScatters(grid)
+	scatterBonus, freeSpins := m.CalculateScatterBonus(scatterCount)
+
+	var totalPayout int64
+	for _, win := range winLines {
+		totalPayout += win.Payout
+	}
+	totalPayout += scatterBonus
+
+	// Фриспины: скаттеры в них дают бонус, но новых фриспинов не добавляют
+	rounds := make([]FreeSpinRound, 0, freeSpins)
+	for i := 0; i < freeSpins; i++ {
+		freeGrid, err := m.GenerateGrid(src, symbols)
+		if err != nil {
+			return nil, err
+		}
+		round := FreeSpinRound{
+			Grid:         freeGrid,
+			WinLines:     m.CheckPaylines(freeGrid, bet),
+			ScatterCount: m.CountScatters(freeGrid),
+		}
+		round.ScatterWin, _ = m.CalculateScatterBonus(round.ScatterCount)
+		totalPayout += round.Payout()
+		rounds = append(rounds, round)
+	}
+
+	return &SlotResult{
+		Machine:        m.Name,
+		Bet:            bet,
+		Grid:           grid,
+		WinLines:       winLines,
+		ScatterCount:   scatterCount,
+		ScatterWin:     scatterBonus,
+		TotalPayout:    totalPayout,
+		IsWin:          totalPayout > 0,
+		FreeSpins:      freeSpins,
+		FreeSpinRounds: rounds,
+	}, nil
+}
+
+// CountScatters подсчитывает количество скаттеров (🎰) на сетке classic.
 func CountScatters(grid Grid) int {
+	return classic.CountScatters(grid)
+}
+
+// CountScatters подсчитывает количество скаттеров на всей сетке.
+// Скаттеры считаются в ЛЮБОЙ позиции, не обязательно на линии.
+func (m *Machine) CountScatters(grid Grid) int {
+	if m.Scatter == "" {
+		return 0
+	}
 	count := 0
-	for reel := 0; reel < 5; reel++ {
-		for row := 0; row < 6; row++ {
-			if grid[reel][row] == ScatterEmoji {
+	for _, reel := range grid {
+		for _, symbol := range reel {
+			if symbol == m.Scatter {
 				count++
 			}
 		}
@@ -196,12 +320,17 @@ func CountScatters(grid Grid) int {
 	return count
 }
 
-// CalculateScatterBonus вычисляет бонус от скаттеров.
+// CalculateScatterBonus вычисляет бонус от скаттеров classic.
 // 3 скаттера: 100 + 1 фриспин
 // 4 скаттера: 200 + 2 фриспина
 // 5 скаттеров: 500 + 3 фриспина
 func CalculateScatterBonus(scatterCount int) (int64, int) {
-	if bonus, ok := ScatterPayouts[scatterCount]; ok {
+	return classic.CalculateScatterBonus(scatterCount)
+}
+
+// CalculateScatterBonus возвращает бонус и число фриспинов за скаттеры.
+func (m *Machine) CalculateScatterBonus(scatterCount int) (int64, int) {
+	if bonus, ok := m.ScatterPayouts[scatterCount]; ok {
 		return bonus.Bonus, bonus.FreeSpins
 	}
 	return 0, 0
@@ -217,9 +346,12 @@ func CalculateScatterBonus(scatterCount int) (int64, int) {
 //	🍉 🍊 🍇 🍋 🍊
 //	🍒 🍇 🍋 🍉 💎
 func FormatGrid(grid Grid) string {
+	if len(grid) == 0 {
+		return ""
+	}
 	result := ""
-	for row := 0; row < 6; row++ {
-		for reel := 0; reel < 5; reel++ {
+	for row := 0; row < len(grid[0]); row++ {
+		for reel := 0; reel < len(grid); reel++ {
 			if reel > 0 {
 				result += " "
 			}
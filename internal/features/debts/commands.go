@@ -0,0 +1,15 @@
+package debts
+
+import (
+	"context"
+
+	"serotonyl.ru/telegram-bot/internal/commands"
+	"serotonyl.ru/telegram-bot/internal/config"
+)
+
+// RegisterCommands регистрирует команды кредитов.
+func RegisterCommands(r *commands.Router, h *Handler, cfg *config.Config) {
+	r.Register("долг", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleDebt(ctx, c.ChatID, c.UserID, c.MessageID, args)
+	})
+}
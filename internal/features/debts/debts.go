@@ -1,5 +0,0 @@
-// Package debts — заглушка для будущей системы кредитов/долгов.
-// Функциональность пока не реализована и отключена.
-// Кнопки "Выдать кредит" и "Аннулировать кредит" в админ-панели
-// показывают сообщение "Функция в разработке".
-package debts
@@ -1,9 +1,15 @@
 package debts
 
-import "serotonyl.ru/telegram-bot/internal/commands"
+import (
+	"serotonyl.ru/telegram-bot/internal/commands"
+	"serotonyl.ru/telegram-bot/internal/config"
+)
 
-type Feature struct{}
+type Feature struct {
+	h   *Handler
+	cfg *config.Config
+}
 
-func NewFeature() *Feature                           { return &Feature{} }
-func (f *Feature) Name() string                      { return "debts" }
-func (f *Feature) RegisterCommands(*commands.Router) {}
+func NewFeature(h *Handler, cfg *config.Config) *Feature { return &Feature{h: h, cfg: cfg} }
+func (f *Feature) Name() string                          { return "debts" }
+func (f *Feature) RegisterCommands(r *commands.Router)   { RegisterCommands(r, f.h, f.cfg) }
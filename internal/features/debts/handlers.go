@@ -0,0 +1,112 @@
+package debts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/telegram"
+)
+
+type handlerService interface {
+	GetActive(ctx context.Context, userID int64) (*Debt, error)
+	Repay(ctx context.Context, userID int64, amount int64) (*RepayResult, error)
+}
+
+type Handler struct {
+	service handlerService
+	tgOps   *telegram.Ops
+	now     func() time.Time
+}
+
+func NewHandler(service *Service, tgOps *telegram.Ops) *Handler {
+	return &Handler{
+		service: service,
+		tgOps:   tgOps,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// HandleDebt обрабатывает `!долг` и `!долг погасить [сумма]`.
+func (h *Handler) HandleDebt(ctx context.Context, chatID, userID int64, replyToMessageID int, args []string) {
+	if len(args) > 0 && strings.EqualFold(args[0], "погасить") {
+		var amount int64
+		if len(args) > 1 {
+			parsed, err := strconv.ParseInt(strings.TrimSpace(args[1]), 10, 64)
+			if err != nil || parsed <= 0 {
+				h.sendMessage(ctx, chatID, "❌ Сумма должна быть положительным целым числом.", replyToMessageID)
+				return
+			}
+			amount = parsed
+		}
+		h.handleRepay(ctx, chatID, userID, replyToMessageID, amount)
+		return
+	}
+
+	debt, err := h.service.GetActive(ctx, userID)
+	if errors.Is(err, ErrDebtNotFound) {
+		h.sendMessage(ctx, chatID, "💳 У вас нет активного кредита.", replyToMessageID)
+		return
+	}
+	if err != nil {
+		log.WithError(err).WithField("user_id", userID).Error("get debt failed")
+		h.sendMessage(ctx, chatID, "❌ Не удалось получить кредит.", replyToMessageID)
+		return
+	}
+	h.sendMessage(ctx, chatID, debtStatusText(debt, h.now()), replyToMessageID)
+}
+
+func (h *Handler) handleRepay(ctx context.Context, chatID, userID int64, replyToMessageID int, amount int64) {
+	result, err := h.service.Repay(ctx, userID, amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDebtNotFound):
+			h.sendMessage(ctx, chatID, "💳 У вас нет активного кредита.", replyToMessageID)
+		case errors.Is(err, common.ErrInsufficientBalance):
+			h.sendMessage(ctx, chatID, "❌ Недостаточно пленок для погашения.", replyToMessageID)
+		default:
+			log.WithError(err).WithField("user_id", userID).Error("repay debt failed")
+			h.sendMessage(ctx, chatID, "❌ Не удалось погасить кредит.", replyToMessageID)
+		}
+		return
+	}
+
+	if result.Debt.Status == StatusRepaid {
+		h.sendMessage(ctx, chatID, fmt.Sprintf("✅ Внесено %s. Кредит полностью погашен!", common.FormatBalance(result.Amount)), replyToMessageID)
+		return
+	}
+	h.sendMessage(ctx, chatID, fmt.Sprintf("✅ Внесено %s. Осталось вернуть %s.", common.FormatBalance(result.Amount), common.FormatBalance(result.Debt.Remaining())), replyToMessageID)
+}
+
+func debtStatusText(debt *Debt, now time.Time) string {
+	lines := []string{
+		fmt.Sprintf("💳 Кредит #%d", debt.ID),
+		fmt.Sprintf("Выдано: %s под %d%%", common.FormatBalance(debt.Principal), debt.InterestPercent),
+		fmt.Sprintf("Вернуть всего: %s", common.FormatBalance(debt.AmountDue)),
+		fmt.Sprintf("Погашено: %s", common.FormatBalance(debt.AmountRepaid)),
+		fmt.Sprintf("Осталось: %s", common.FormatBalance(debt.Remaining())),
+		fmt.Sprintf("Срок: до %s", common.FormatDateTime(debt.DueAt)),
+	}
+	if debt.IsOverdue(now) {
+		lines = append(lines, "⚠️ Кредит просрочен!")
+	}
+	lines = append(lines, "", "Погасить: !долг погасить [сумма]")
+	return strings.Join(lines, "\n")
+}
+
+func (h *Handler) sendMessage(ctx context.Context, chatID int64, text string, replyToMessageID int) {
+	if h.tgOps == nil {
+		return
+	}
+	_, _ = h.tgOps.SendWithOptions(ctx, telegram.SendOptions{
+		ChatID:           chatID,
+		Text:             text,
+		ReplyToMessageID: replyToMessageID,
+	})
+}
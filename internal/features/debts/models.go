@@ -0,0 +1,77 @@
+// Package debts реализует кредиты участникам: выдачу админом, погашение
+// через экономику, напоминания о просрочке и списание долга.
+// models.go описывает структуру кредита и правила расчёта суммы к возврату.
+package debts
+
+import "time"
+
+const (
+	StatusActive     = "active"      // Кредит выдан и ещё не погашен
+	StatusRepaid     = "repaid"      // Кредит полностью погашен
+	StatusWrittenOff = "written_off" // Кредит аннулирован админом
+
+	MaxInterestPercent = 100
+	MaxTermDays        = 90
+
+	// overdueReminderInterval — как часто напоминать о просроченном кредите.
+	overdueReminderInterval = 24 * time.Hour
+)
+
+// Debt — кредит, выданный участнику.
+type Debt struct {
+	ID              int64      `db:"id"`
+	UserID          int64      `db:"user_id"`
+	Principal       int64      `db:"principal"`        // Сколько выдано
+	InterestPercent int        `db:"interest_percent"` // Процент за весь срок
+	AmountDue       int64      `db:"amount_due"`       // Сколько нужно вернуть всего
+	AmountRepaid    int64      `db:"amount_repaid"`    // Сколько уже возвращено
+	Status          string     `db:"status"`
+	DueAt           time.Time  `db:"due_at"`
+	IssuedBy        int64      `db:"issued_by"`
+	ClosedBy        *int64     `db:"closed_by"`
+	LastReminderAt  *time.Time `db:"last_reminder_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	ClosedAt        *time.Time `db:"closed_at"`
+}
+
+// Remaining возвращает остаток долга.
+func (d *Debt) Remaining() int64 {
+	if d == nil || d.AmountRepaid >= d.AmountDue {
+		return 0
+	}
+	return d.AmountDue - d.AmountRepaid
+}
+
+// IsOverdue сообщает, просрочен ли активный кредит на момент now.
+func (d *Debt) IsOverdue(now time.Time) bool {
+	return d != nil && d.Status == StatusActive && now.After(d.DueAt)
+}
+
+// IssueRequest — параметры выдачи кредита.
+type IssueRequest struct {
+	UserID          int64
+	Principal       int64
+	InterestPercent int
+	TermDays        int
+	IssuedBy        int64
+}
+
+// RepayResult — итог погашения.
+type RepayResult struct {
+	Debt   *Debt
+	Amount int64 // Сколько списано в этот раз
+}
+
+// CalculateAmountDue считает сумму к возврату: тело плюс проценты,
+// округлённые вверх до целой пленки.
+func CalculateAmountDue(principal int64, interestPercent int) int64 {
+	if principal <= 0 {
+		return 0
+	}
+	if interestPercent <= 0 {
+		return principal
+	}
+	interest := (principal*int64(interestPercent) + 99) / 100
+	return principal + interest
+}
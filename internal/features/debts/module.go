@@ -1,9 +1,38 @@
 package debts
 
-import "serotonyl.ru/telegram-bot/internal/feature"
+import (
+	"serotonyl.ru/telegram-bot/internal/audit"
+	"serotonyl.ru/telegram-bot/internal/config"
+	"serotonyl.ru/telegram-bot/internal/feature"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/telegram"
+)
 
-type Deps struct{}
+type Deps struct {
+	Cfg           *config.Config
+	Ops           *telegram.Ops
+	Service       *Service
+	MemberService *members.Service
+}
+
+type Module struct {
+	Handler *Handler
+	Feature feature.Feature
+}
+
+func NewModule(deps Deps) (*Module, error) {
+	if deps.Service != nil && deps.Cfg != nil {
+		deps.Service.SetAuditLogger(audit.NewLogger(deps.Ops, deps.Cfg.AdminChatID), deps.MemberService)
+	}
+	h := NewHandler(deps.Service, deps.Ops)
+	f := NewFeature(h, deps.Cfg)
+	return &Module{Handler: h, Feature: f}, nil
+}
 
-func Build(Deps) (feature.Feature, error) {
-	return NewFeature(), nil
+func Build(deps Deps) (feature.Feature, error) {
+	m, err := NewModule(deps)
+	if err != nil {
+		return nil, err
+	}
+	return m.Feature, nil
 }
@@ -0,0 +1,202 @@
+package debts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type debtDBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+const debtColumns = `
+	id, user_id, principal, interest_percent, amount_due, amount_repaid, status,
+	due_at, issued_by, closed_by, last_reminder_at, created_at, updated_at, closed_at
+`
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateTx сохраняет новый активный кредит. Второй активный кредит
+// у того же участника отсекается уникальным индексом.
+func (r *Repository) CreateTx(ctx context.Context, tx pgx.Tx, d *Debt) (*Debt, error) {
+	row := tx.QueryRow(ctx, `
+		INSERT INTO debts (user_id, principal, interest_percent, amount_due, status, due_at, issued_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+debtColumns, d.UserID, d.Principal, d.InterestPercent, d.AmountDue, StatusActive, d.DueAt, d.IssuedBy)
+	created, err := scanDebt(row)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDebtAlreadyActive
+		}
+		return nil, fmt.Errorf("create debt: %w", err)
+	}
+	return created, nil
+}
+
+func (r *Repository) GetActiveByUserID(ctx context.Context, userID int64) (*Debt, error) {
+	return r.getActiveByUserID(ctx, r.db, userID, false)
+}
+
+func (r *Repository) GetActiveByUserIDForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (*Debt, error) {
+	return r.getActiveByUserID(ctx, tx, userID, true)
+}
+
+func (r *Repository) getActiveByUserID(ctx context.Context, db debtDBTX, userID int64, forUpdate bool) (*Debt, error) {
+	query := `SELECT ` + debtColumns + ` FROM debts WHERE user_id = $1 AND status = $2`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+	d, err := scanDebt(db.QueryRow(ctx, query, userID, StatusActive))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDebtNotFound
+		}
+		return nil, fmt.Errorf("get active debt: %w", err)
+	}
+	return d, nil
+}
+
+func (r *Repository) GetByIDForUpdateTx(ctx context.Context, tx pgx.Tx, debtID int64) (*Debt, error) {
+	d, err := scanDebt(tx.QueryRow(ctx, `SELECT `+debtColumns+` FROM debts WHERE id = $1 FOR UPDATE`, debtID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDebtNotFound
+		}
+		return nil, fmt.Errorf("get debt: %w", err)
+	}
+	return d, nil
+}
+
+// ApplyRepaymentTx увеличивает погашенную сумму и закрывает кредит,
+// если долг возвращён полностью.
+func (r *Repository) ApplyRepaymentTx(ctx context.Context, tx pgx.Tx, debtID, amount int64, now time.Time) (*Debt, error) {
+	d, err := scanDebt(tx.QueryRow(ctx, `
+		UPDATE debts
+		SET amount_repaid = amount_repaid + $2,
+		    status = CASE WHEN amount_repaid + $2 >= amount_due THEN $3 ELSE status END,
+		    closed_at = CASE WHEN amount_repaid + $2 >= amount_due THEN $4 ELSE closed_at END,
+		    updated_at = $4
+		WHERE id = $1 AND status = $5
+		RETURNING `+debtColumns, debtID, amount, StatusRepaid, now, StatusActive))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDebtNotFound
+		}
+		return nil, fmt.Errorf("apply debt repayment: %w", err)
+	}
+	return d, nil
+}
+
+func (r *Repository) CloseTx(ctx context.Context, tx pgx.Tx, debtID int64, status string, closedBy int64, now time.Time) (*Debt, error) {
+	d, err := scanDebt(tx.QueryRow(ctx, `
+		UPDATE debts
+		SET status = $2, closed_by = $3, closed_at = $4, updated_at = $4
+		WHERE id = $1 AND status = $5
+		RETURNING `+debtColumns, debtID, status, closedBy, now, StatusActive))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDebtNotFound
+		}
+		return nil, fmt.Errorf("close debt: %w", err)
+	}
+	return d, nil
+}
+
+func (r *Repository) ListActive(ctx context.Context) ([]*Debt, error) {
+	return r.list(ctx, `SELECT `+debtColumns+` FROM debts WHERE status = $1 ORDER BY due_at ASC, id ASC`, StatusActive)
+}
+
+// ListOverdueForReminder возвращает просроченные кредиты, по которым
+// напоминание ещё не отправлялось или отправлялось раньше remindBefore.
+func (r *Repository) ListOverdueForReminder(ctx context.Context, now, remindBefore time.Time) ([]*Debt, error) {
+	return r.list(ctx, `
+		SELECT `+debtColumns+`
+		FROM debts
+		WHERE status = $1
+		  AND due_at < $2
+		  AND (last_reminder_at IS NULL OR last_reminder_at < $3)
+		ORDER BY due_at ASC, id ASC
+	`, StatusActive, now, remindBefore)
+}
+
+// ClaimReminderTx атомарно отмечает отправку напоминания, чтобы
+// несколько инстансов не напомнили об одном кредите дважды.
+func (r *Repository) ClaimReminderTx(ctx context.Context, tx pgx.Tx, debtID int64, now, remindBefore time.Time) (bool, error) {
+	tag, err := tx.Exec(ctx, `
+		UPDATE debts
+		SET last_reminder_at = $2, updated_at = $2
+		WHERE id = $1
+		  AND status = $3
+		  AND (last_reminder_at IS NULL OR last_reminder_at < $4)
+	`, debtID, now, StatusActive, remindBefore)
+	if err != nil {
+		return false, fmt.Errorf("claim debt reminder: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *Repository) ReleaseReminderTx(ctx context.Context, tx pgx.Tx, debtID int64, previous *time.Time) error {
+	if _, err := tx.Exec(ctx, `UPDATE debts SET last_reminder_at = $2 WHERE id = $1`, debtID, previous); err != nil {
+		return fmt.Errorf("release debt reminder: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) list(ctx context.Context, query string, args ...any) ([]*Debt, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list debts: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*Debt
+	for rows.Next() {
+		d, err := scanDebt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan debt: %w", err)
+		}
+		result = append(result, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate debts: %w", err)
+	}
+	return result, nil
+}
+
+func scanDebt(row pgx.Row) (*Debt, error) {
+	var d Debt
+	if err := row.Scan(
+		&d.ID,
+		&d.UserID,
+		&d.Principal,
+		&d.InterestPercent,
+		&d.AmountDue,
+		&d.AmountRepaid,
+		&d.Status,
+		&d.DueAt,
+		&d.IssuedBy,
+		&d.ClosedBy,
+		&d.LastReminderAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+		&d.ClosedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,238 @@
+package debts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/audit"
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+)
+
+var (
+	ErrDebtNotFound      = errors.New("debt not found")
+	ErrDebtAlreadyActive = errors.New("debt already active")
+	ErrInvalidInterest   = errors.New("invalid debt interest")
+	ErrInvalidTerm       = errors.New("invalid debt term")
+)
+
+type debtRepository interface {
+	CreateTx(ctx context.Context, tx pgx.Tx, d *Debt) (*Debt, error)
+	GetActiveByUserID(ctx context.Context, userID int64) (*Debt, error)
+	GetActiveByUserIDForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (*Debt, error)
+	GetByIDForUpdateTx(ctx context.Context, tx pgx.Tx, debtID int64) (*Debt, error)
+	ApplyRepaymentTx(ctx context.Context, tx pgx.Tx, debtID, amount int64, now time.Time) (*Debt, error)
+	CloseTx(ctx context.Context, tx pgx.Tx, debtID int64, status string, closedBy int64, now time.Time) (*Debt, error)
+	ListActive(ctx context.Context) ([]*Debt, error)
+	ListOverdueForReminder(ctx context.Context, now, remindBefore time.Time) ([]*Debt, error)
+	ClaimReminderTx(ctx context.Context, tx pgx.Tx, debtID int64, now, remindBefore time.Time) (bool, error)
+	ReleaseReminderTx(ctx context.Context, tx pgx.Tx, debtID int64, previous *time.Time) error
+}
+
+type debtEconomy interface {
+	WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error
+	AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error
+	DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error
+}
+
+// Service управляет жизненным циклом кредитов.
+// Все движения пленок идут через экономику в одной транзакции с изменением кредита.
+type Service struct {
+	repo    debtRepository
+	economy debtEconomy
+	audit   *audit.Logger
+	members audit.MemberLookup
+	now     func() time.Time
+}
+
+func NewService(repo *Repository, economyService *economy.Service) *Service {
+	return &Service{
+		repo:    repo,
+		economy: economyService,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *Service) SetAuditLogger(logger *audit.Logger, members audit.MemberLookup) {
+	s.audit = logger
+	s.members = members
+}
+
+// Issue выдаёт кредит: начисляет тело на баланс и сохраняет долг.
+func (s *Service) Issue(ctx context.Context, req IssueRequest) (*Debt, error) {
+	if req.Principal <= 0 {
+		return nil, common.ErrInvalidAmount
+	}
+	if req.InterestPercent < 0 || req.InterestPercent > MaxInterestPercent {
+		return nil, ErrInvalidInterest
+	}
+	if req.TermDays <= 0 || req.TermDays > MaxTermDays {
+		return nil, ErrInvalidTerm
+	}
+
+	now := s.now()
+	draft := &Debt{
+		UserID:          req.UserID,
+		Principal:       req.Principal,
+		InterestPercent: req.InterestPercent,
+		AmountDue:       CalculateAmountDue(req.Principal, req.InterestPercent),
+		DueAt:           now.Add(time.Duration(req.TermDays) * 24 * time.Hour),
+		IssuedBy:        req.IssuedBy,
+	}
+
+	var created *Debt
+	err := s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		created, err = s.repo.CreateTx(ctx, tx, draft)
+		if err != nil {
+			return err
+		}
+		return s.economy.AddBalanceTx(ctx, tx, req.UserID, req.Principal, economy.TxTypeCreditIssue, fmt.Sprintf("Кредит #%d", created.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.LogCreditIssue(ctx, s.memberLabel(ctx, req.IssuedBy), s.memberLabel(ctx, req.UserID), created.Principal, created.AmountDue, created.DueAt)
+	}
+	return created, nil
+}
+
+// Repay списывает с баланса участника платёж по активному кредиту.
+// amount <= 0 или больше остатка означает «погасить всё».
+func (s *Service) Repay(ctx context.Context, userID int64, amount int64) (*RepayResult, error) {
+	now := s.now()
+	var result *RepayResult
+	err := s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		debt, err := s.repo.GetActiveByUserIDForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		pay := amount
+		if remaining := debt.Remaining(); pay <= 0 || pay > remaining {
+			pay = remaining
+		}
+		if pay <= 0 {
+			return ErrDebtNotFound
+		}
+		if err := s.economy.DeductBalanceTx(ctx, tx, userID, pay, economy.TxTypeCreditRepay, fmt.Sprintf("Погашение кредита #%d", debt.ID)); err != nil {
+			return err
+		}
+		updated, err := s.repo.ApplyRepaymentTx(ctx, tx, debt.ID, pay, now)
+		if err != nil {
+			return err
+		}
+		result = &RepayResult{Debt: updated, Amount: pay}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.LogCreditRepay(ctx, s.memberLabel(ctx, userID), result.Amount, result.Debt.Remaining())
+	}
+	return result, nil
+}
+
+// WriteOff аннулирует активный кредит без движения пленок.
+func (s *Service) WriteOff(ctx context.Context, debtID int64, actorID int64) (*Debt, error) {
+	now := s.now()
+	var (
+		closed    *Debt
+		remaining int64
+	)
+	err := s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		debt, err := s.repo.GetByIDForUpdateTx(ctx, tx, debtID)
+		if err != nil {
+			return err
+		}
+		if debt.Status != StatusActive {
+			return ErrDebtNotFound
+		}
+		remaining = debt.Remaining()
+		closed, err = s.repo.CloseTx(ctx, tx, debtID, StatusWrittenOff, actorID, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.LogCreditWriteOff(ctx, s.memberLabel(ctx, actorID), s.memberLabel(ctx, closed.UserID), remaining)
+	}
+	return closed, nil
+}
+
+// GetActive возвращает активный кредит участника или ErrDebtNotFound.
+func (s *Service) GetActive(ctx context.Context, userID int64) (*Debt, error) {
+	return s.repo.GetActiveByUserID(ctx, userID)
+}
+
+func (s *Service) ListActive(ctx context.Context) ([]*Debt, error) {
+	return s.repo.ListActive(ctx)
+}
+
+// SendOverdueReminders напоминает должникам о просроченных кредитах не чаще
+// раза в overdueReminderInterval.
+func (s *Service) SendOverdueReminders(ctx context.Context, sendFunc func(context.Context, int64, string) error) error {
+	now := s.now()
+	remindBefore := now.Add(-overdueReminderInterval)
+	overdue, err := s.repo.ListOverdueForReminder(ctx, now, remindBefore)
+	if err != nil {
+		return err
+	}
+
+	for _, debt := range overdue {
+		var claimed bool
+		err := s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			var err error
+			claimed, err = s.repo.ClaimReminderTx(ctx, tx, debt.ID, now, remindBefore)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := sendFunc(ctx, debt.UserID, OverdueReminderText(debt, now)); err != nil {
+			// Как и в напоминаниях огонька: при ошибке доставки снимаем отметку, чтобы повторить позже.
+			releaseErr := s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+				return s.repo.ReleaseReminderTx(ctx, tx, debt.ID, debt.LastReminderAt)
+			})
+			if releaseErr != nil {
+				return fmt.Errorf("send debt reminder debt_id=%d: %w; release claim: %v", debt.ID, err, releaseErr)
+			}
+			return fmt.Errorf("send debt reminder debt_id=%d: %w", debt.ID, err)
+		}
+	}
+	return nil
+}
+
+// OverdueReminderText формирует текст напоминания о просрочке.
+func OverdueReminderText(debt *Debt, now time.Time) string {
+	days := int(now.Sub(debt.DueAt).Hours() / 24)
+	if days < 1 {
+		days = 1
+	}
+	return fmt.Sprintf(
+		"⏰ Кредит просрочен на %d %s. Осталось вернуть %s.\nПогасить: !долг погасить",
+		days,
+		common.PluralizeDays(days),
+		common.FormatBalance(debt.Remaining()),
+	)
+}
+
+func (s *Service) memberLabel(ctx context.Context, userID int64) string {
+	if s.audit == nil {
+		return fmt.Sprintf("id:%d", userID)
+	}
+	return s.audit.ResolveMemberLabel(ctx, s.members, userID)
+}
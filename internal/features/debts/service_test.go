@@ -0,0 +1,357 @@
+package debts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+)
+
+type fakeRepo struct {
+	nextID   int64
+	byID     map[int64]*Debt
+	claimErr error
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{byID: make(map[int64]*Debt)}
+}
+
+func (r *fakeRepo) CreateTx(ctx context.Context, tx pgx.Tx, d *Debt) (*Debt, error) {
+	for _, existing := range r.byID {
+		if existing.UserID == d.UserID && existing.Status == StatusActive {
+			return nil, ErrDebtAlreadyActive
+		}
+	}
+	r.nextID++
+	cp := *d
+	cp.ID = r.nextID
+	cp.Status = StatusActive
+	r.byID[cp.ID] = &cp
+	out := cp
+	return &out, nil
+}
+
+func (r *fakeRepo) GetActiveByUserID(ctx context.Context, userID int64) (*Debt, error) {
+	for _, d := range r.byID {
+		if d.UserID == userID && d.Status == StatusActive {
+			cp := *d
+			return &cp, nil
+		}
+	}
+	return nil, ErrDebtNotFound
+}
+
+func (r *fakeRepo) GetActiveByUserIDForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (*Debt, error) {
+	return r.GetActiveByUserID(ctx, userID)
+}
+
+func (r *fakeRepo) GetByIDForUpdateTx(ctx context.Context, tx pgx.Tx, debtID int64) (*Debt, error) {
+	d, ok := r.byID[debtID]
+	if !ok {
+		return nil, ErrDebtNotFound
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (r *fakeRepo) ApplyRepaymentTx(ctx context.Context, tx pgx.Tx, debtID, amount int64, now time.Time) (*Debt, error) {
+	d, ok := r.byID[debtID]
+	if !ok || d.Status != StatusActive {
+		return nil, ErrDebtNotFound
+	}
+	d.AmountRepaid += amount
+	if d.AmountRepaid >= d.AmountDue {
+		d.Status = StatusRepaid
+		d.ClosedAt = &now
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (r *fakeRepo) CloseTx(ctx context.Context, tx pgx.Tx, debtID int64, status string, closedBy int64, now time.Time) (*Debt, error) {
+	d, ok := r.byID[debtID]
+	if !ok || d.Status != StatusActive {
+		return nil, ErrDebtNotFound
+	}
+	d.Status = status
+	d.ClosedBy = &closedBy
+	d.ClosedAt = &now
+	cp := *d
+	return &cp, nil
+}
+
+func (r *fakeRepo) ListActive(ctx context.Context) ([]*Debt, error) {
+	var out []*Debt
+	for _, d := range r.byID {
+		if d.Status == StatusActive {
+			cp := *d
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) ListOverdueForReminder(ctx context.Context, now, remindBefore time.Time) ([]*Debt, error) {
+	var out []*Debt
+	for _, d := range r.byID {
+		if d.Status == StatusActive && d.DueAt.Before(now) && (d.LastReminderAt == nil || d.LastReminderAt.Before(remindBefore)) {
+			cp := *d
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) ClaimReminderTx(ctx context.Context, tx pgx.Tx, debtID int64, now, remindBefore time.Time) (bool, error) {
+	if r.claimErr != nil {
+		return false, r.claimErr
+	}
+	d := r.byID[debtID]
+	if d.LastReminderAt != nil && !d.LastReminderAt.Before(remindBefore) {
+		return false, nil
+	}
+	d.LastReminderAt = &now
+	return true, nil
+}
+
+func (r *fakeRepo) ReleaseReminderTx(ctx context.Context, tx pgx.Tx, debtID int64, previous *time.Time) error {
+	r.byID[debtID].LastReminderAt = previous
+	return nil
+}
+
+type ledgerEntry struct {
+	userID int64
+	amount int64
+	txType string
+}
+
+type fakeEconomy struct {
+	balances map[int64]int64
+	entries  []ledgerEntry
+}
+
+func (f *fakeEconomy) WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
+	snapshot := make(map[int64]int64, len(f.balances))
+	for k, v := range f.balances {
+		snapshot[k] = v
+	}
+	entries := len(f.entries)
+	if err := fn(ctx, nil); err != nil {
+		f.balances = snapshot
+		f.entries = f.entries[:entries]
+		return err
+	}
+	return nil
+}
+
+func (f *fakeEconomy) AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+	f.balances[userID] += amount
+	f.entries = append(f.entries, ledgerEntry{userID: userID, amount: amount, txType: txType})
+	return nil
+}
+
+func (f *fakeEconomy) DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+	if f.balances[userID] < amount {
+		return common.ErrInsufficientBalance
+	}
+	f.balances[userID] -= amount
+	f.entries = append(f.entries, ledgerEntry{userID: userID, amount: -amount, txType: txType})
+	return nil
+}
+
+func newTestService(now time.Time) (*Service, *fakeRepo, *fakeEconomy) {
+	repo := newFakeRepo()
+	econ := &fakeEconomy{balances: make(map[int64]int64)}
+	svc := &Service{repo: repo, economy: econ, now: func() time.Time { return now }}
+	return svc, repo, econ
+}
+
+func TestCalculateAmountDue_RoundsInterestUp(t *testing.T) {
+	cases := []struct {
+		principal int64
+		percent   int
+		want      int64
+	}{
+		{principal: 100, percent: 0, want: 100},
+		{principal: 100, percent: 10, want: 110},
+		{principal: 15, percent: 10, want: 17},
+		{principal: 1, percent: 1, want: 2},
+	}
+	for _, tc := range cases {
+		if got := CalculateAmountDue(tc.principal, tc.percent); got != tc.want {
+			t.Fatalf("CalculateAmountDue(%d, %d) = %d, want %d", tc.principal, tc.percent, got, tc.want)
+		}
+	}
+}
+
+func TestIssue_CreditsBalanceAndStoresDebt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, repo, econ := newTestService(now)
+
+	debt, err := svc.Issue(context.Background(), IssueRequest{UserID: 10, Principal: 500, InterestPercent: 10, TermDays: 7, IssuedBy: 1})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if debt.AmountDue != 550 {
+		t.Fatalf("amount due = %d, want 550", debt.AmountDue)
+	}
+	if !debt.DueAt.Equal(now.Add(7 * 24 * time.Hour)) {
+		t.Fatalf("unexpected due date %v", debt.DueAt)
+	}
+	if econ.balances[10] != 500 {
+		t.Fatalf("balance = %d, want 500", econ.balances[10])
+	}
+	if len(econ.entries) != 1 || econ.entries[0].txType != economy.TxTypeCreditIssue {
+		t.Fatalf("unexpected ledger entries %+v", econ.entries)
+	}
+	if len(repo.byID) != 1 {
+		t.Fatalf("expected stored debt")
+	}
+}
+
+func TestIssue_RejectsSecondActiveDebtWithoutMovingFunds(t *testing.T) {
+	svc, _, econ := newTestService(time.Now().UTC())
+	if _, err := svc.Issue(context.Background(), IssueRequest{UserID: 10, Principal: 100, TermDays: 3, IssuedBy: 1}); err != nil {
+		t.Fatalf("first issue: %v", err)
+	}
+
+	_, err := svc.Issue(context.Background(), IssueRequest{UserID: 10, Principal: 100, TermDays: 3, IssuedBy: 1})
+	if !errors.Is(err, ErrDebtAlreadyActive) {
+		t.Fatalf("expected ErrDebtAlreadyActive, got %v", err)
+	}
+	if econ.balances[10] != 100 {
+		t.Fatalf("second issue must not credit balance, got %d", econ.balances[10])
+	}
+}
+
+func TestIssue_ValidatesInput(t *testing.T) {
+	svc, _, _ := newTestService(time.Now().UTC())
+	cases := []struct {
+		req  IssueRequest
+		want error
+	}{
+		{req: IssueRequest{UserID: 1, Principal: 0, TermDays: 1}, want: common.ErrInvalidAmount},
+		{req: IssueRequest{UserID: 1, Principal: 10, InterestPercent: MaxInterestPercent + 1, TermDays: 1}, want: ErrInvalidInterest},
+		{req: IssueRequest{UserID: 1, Principal: 10, TermDays: 0}, want: ErrInvalidTerm},
+		{req: IssueRequest{UserID: 1, Principal: 10, TermDays: MaxTermDays + 1}, want: ErrInvalidTerm},
+	}
+	for _, tc := range cases {
+		if _, err := svc.Issue(context.Background(), tc.req); !errors.Is(err, tc.want) {
+			t.Fatalf("Issue(%+v) err = %v, want %v", tc.req, err, tc.want)
+		}
+	}
+}
+
+func TestRepay_PartialThenFull(t *testing.T) {
+	svc, _, econ := newTestService(time.Now().UTC())
+	if _, err := svc.Issue(context.Background(), IssueRequest{UserID: 10, Principal: 100, InterestPercent: 20, TermDays: 3, IssuedBy: 1}); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	econ.balances[10] = 1000
+
+	res, err := svc.Repay(context.Background(), 10, 50)
+	if err != nil {
+		t.Fatalf("partial repay: %v", err)
+	}
+	if res.Amount != 50 || res.Debt.Remaining() != 70 || res.Debt.Status != StatusActive {
+		t.Fatalf("unexpected partial result %+v remaining=%d", res.Debt, res.Debt.Remaining())
+	}
+
+	res, err = svc.Repay(context.Background(), 10, 0)
+	if err != nil {
+		t.Fatalf("full repay: %v", err)
+	}
+	if res.Amount != 70 || res.Debt.Status != StatusRepaid {
+		t.Fatalf("expected full repayment of 70, got %+v", res)
+	}
+	if econ.balances[10] != 880 {
+		t.Fatalf("balance = %d, want 880", econ.balances[10])
+	}
+	if _, err := svc.Repay(context.Background(), 10, 0); !errors.Is(err, ErrDebtNotFound) {
+		t.Fatalf("expected ErrDebtNotFound after repayment, got %v", err)
+	}
+}
+
+func TestRepay_InsufficientBalanceLeavesDebtUntouched(t *testing.T) {
+	svc, repo, econ := newTestService(time.Now().UTC())
+	debt, err := svc.Issue(context.Background(), IssueRequest{UserID: 10, Principal: 100, TermDays: 3, IssuedBy: 1})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	econ.balances[10] = 30
+
+	if _, err := svc.Repay(context.Background(), 10, 0); !errors.Is(err, common.ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance, got %v", err)
+	}
+	if repo.byID[debt.ID].AmountRepaid != 0 {
+		t.Fatalf("debt must not change on failed repayment")
+	}
+}
+
+func TestWriteOff_ClosesActiveDebtOnce(t *testing.T) {
+	svc, _, _ := newTestService(time.Now().UTC())
+	debt, err := svc.Issue(context.Background(), IssueRequest{UserID: 10, Principal: 100, TermDays: 3, IssuedBy: 1})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	closed, err := svc.WriteOff(context.Background(), debt.ID, 1)
+	if err != nil {
+		t.Fatalf("write off: %v", err)
+	}
+	if closed.Status != StatusWrittenOff || closed.ClosedBy == nil || *closed.ClosedBy != 1 {
+		t.Fatalf("unexpected closed debt %+v", closed)
+	}
+	if _, err := svc.WriteOff(context.Background(), debt.ID, 1); !errors.Is(err, ErrDebtNotFound) {
+		t.Fatalf("expected ErrDebtNotFound on repeated write-off, got %v", err)
+	}
+}
+
+func TestSendOverdueReminders_RemindsOncePerInterval(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc, repo, _ := newTestService(now)
+	repo.byID[1] = &Debt{ID: 1, UserID: 10, Principal: 100, AmountDue: 100, Status: StatusActive, DueAt: now.Add(-48 * time.Hour)}
+	repo.byID[2] = &Debt{ID: 2, UserID: 20, Principal: 100, AmountDue: 100, Status: StatusActive, DueAt: now.Add(48 * time.Hour)}
+
+	var sent []int64
+	send := func(ctx context.Context, userID int64, text string) error {
+		if !strings.Contains(text, "просрочен") {
+			t.Fatalf("unexpected reminder text %q", text)
+		}
+		sent = append(sent, userID)
+		return nil
+	}
+
+	if err := svc.SendOverdueReminders(context.Background(), send); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if err := svc.SendOverdueReminders(context.Background(), send); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != 10 {
+		t.Fatalf("expected single reminder to overdue user, got %v", sent)
+	}
+}
+
+func TestSendOverdueReminders_ReleasesClaimOnSendFailure(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc, repo, _ := newTestService(now)
+	repo.byID[1] = &Debt{ID: 1, UserID: 10, Principal: 100, AmountDue: 100, Status: StatusActive, DueAt: now.Add(-time.Hour)}
+
+	err := svc.SendOverdueReminders(context.Background(), func(ctx context.Context, userID int64, text string) error {
+		return errors.New("blocked")
+	})
+	if err == nil {
+		t.Fatalf("expected send error")
+	}
+	if repo.byID[1].LastReminderAt != nil {
+		t.Fatalf("reminder claim must be released after failed delivery")
+	}
+}
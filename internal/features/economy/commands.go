@@ -24,6 +24,12 @@ func RegisterCommands(r *commands.Router, h *Handler, cfg *config.Config) {
 	r.Register("отсыпать", func(ctx context.Context, c commands.Context, args []string) {
 		h.HandleTransferCommand(ctx, c, args)
 	})
+	r.Register("запросить", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleMoneyRequestCommand(ctx, c, args)
+	})
+	r.Register("запросы", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleMoneyRequests(ctx, c.ChatID, c.UserID, c.MessageID)
+	})
 	r.Register("транзакции", func(ctx context.Context, c commands.Context, args []string) {
 		h.HandleTransactions(ctx, c.ChatID, c.UserID)
 	})
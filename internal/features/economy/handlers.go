@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	models "github.com/mymmrac/telego"
 	log "github.com/sirupsen/logrus"
@@ -36,17 +37,20 @@ const (
 	cancelEmojiID            = "5210952531676504517"
 	buttonYesText            = "Да"
 	buttonNoText             = "Нет"
+	transferMemoMaxRunes     = 64
 )
 
 type handlerService interface {
 	GetBalance(ctx context.Context, userID int64) (int64, error)
-	Transfer(ctx context.Context, fromUserID, toUserID, amount int64) error
-	GetTransactionHistory(ctx context.Context, userID int64) (string, error)
+	Transfer(ctx context.Context, fromUserID, toUserID, amount int64, memo string) error
+	GetTransactionHistoryPage(ctx context.Context, userID int64, filter HistoryFilter, page int, now time.Time) (*HistoryPage, error)
 	CreateTransferConfirmation(ctx context.Context, entry *transferConfirmation) error
 	GetTransferConfirmation(ctx context.Context, token string) (*transferConfirmation, error)
 	TransitionTransferConfirmation(ctx context.Context, token string, fromStates []string, toState string) (bool, error)
 	MarkTransferConfirmationExpired(ctx context.Context, token string) error
 	ExecuteTransferConfirmation(ctx context.Context, token string, now time.Time) (*transferConfirmation, error)
+	ListOpenMoneyRequests(ctx context.Context, userID int64) ([]*transferConfirmation, error)
+	TransferFee(amount int64) int64
 }
 
 type memberLookup interface {
@@ -68,6 +72,10 @@ type transferConfirmation struct {
 	State            string
 	ExpiresAt        time.Time
 	ConsumedAt       *time.Time
+	Kind             string
+	Memo             string
+	// Fee — удержанная комиссия; заполняется при исполнении и не хранится.
+	Fee int64
 }
 
 type transferTarget struct {
@@ -77,6 +85,7 @@ type transferTarget struct {
 
 type transferRequest struct {
 	Amount           int64
+	Memo             string
 	ExplicitUsername string
 	Target           transferTarget
 }
@@ -91,6 +100,7 @@ type Handler struct {
 	memberService memberLookup
 	tgOps         *telegram.Ops
 	audit         *audit.Logger
+	requestTTL    time.Duration
 	now           func() time.Time
 }
 
@@ -99,6 +109,7 @@ func NewHandler(service *Service, memberService memberLookup, tgOps *telegram.Op
 		service:       service,
 		memberService: memberService,
 		tgOps:         tgOps,
+		requestTTL:    defaultMoneyRequestTTL,
 		now:           func() time.Time { return time.Now().UTC() },
 	}
 }
@@ -180,11 +191,17 @@ func (h *Handler) HandleEconomyMessage(ctx context.Context, message *models.Mess
 }
 
 func (h *Handler) HandleEconomyCallback(ctx context.Context, q *models.CallbackQuery) bool {
+	if q != nil && strings.HasPrefix(q.Data, requestCallbackPrefix) {
+		return h.handleMoneyRequestCallback(ctx, q)
+	}
+	if q != nil && strings.HasPrefix(q.Data, historyCallbackPrefix) {
+		return h.handleHistoryCallback(ctx, q)
+	}
 	if q == nil || !strings.HasPrefix(q.Data, transferCallbackPrefix) {
 		return false
 	}
 
-	token, action, ok := parseTransferCallbackData(q.Data)
+	token, action, ok := parseCallbackData(q.Data, transferCallbackPrefix)
 	if !ok {
 		h.answerCallback(ctx, q.ID, "")
 		return true
@@ -230,38 +247,35 @@ func (h *Handler) HandleEconomyCallback(ctx context.Context, q *models.CallbackQ
 	}
 }
 
-func (h *Handler) HandleTransactions(ctx context.Context, chatID int64, userID int64) {
-	history, err := h.service.GetTransactionHistory(ctx, userID)
-	if err != nil {
-		log.WithError(err).Error("ошибка получения транзакций")
-		h.sendMessage(ctx, chatID, "❌ Ошибка получения истории транзакций", 0)
-		return
-	}
-
-	h.sendMessage(ctx, chatID, history, 0)
-}
-
 func (h *Handler) parseTransferCommand(ctx context.Context, message *models.Message, args []string) (*transferRequest, error) {
 	if len(args) == 0 {
 		return nil, errTransferAmountMissing
 	}
 
+	// Всё после суммы (кроме @username сразу за ней) — назначение перевода.
 	var (
 		username string
 		amount   int64
 		amountOK bool
+		memo     []string
 	)
 	for _, arg := range args {
 		token := strings.TrimSpace(arg)
 		if token == "" {
 			continue
 		}
-		if isUsernameToken(token) {
+		if isUsernameToken(token) && len(memo) == 0 {
 			username = normalizeUsernameToken(token)
 			continue
 		}
 		if amountOK {
-			return nil, errTransferMalformed
+			if len(memo) == 0 {
+				if _, err := strconv.ParseInt(token, 10, 64); err == nil {
+					return nil, errTransferMalformed
+				}
+			}
+			memo = append(memo, token)
+			continue
 		}
 		parsed, err := parsePositiveInteger(token)
 		if err != nil {
@@ -273,7 +287,14 @@ func (h *Handler) parseTransferCommand(ctx context.Context, message *models.Mess
 	if !amountOK {
 		return nil, errTransferAmountMissing
 	}
-	return h.buildTransferRequest(ctx, message, amount, username)
+	req, err := h.buildTransferRequest(ctx, message, amount, username)
+	if err != nil {
+		return nil, err
+	}
+	if req.Memo, err = parseTransferMemo(memo); err != nil {
+		return nil, err
+	}
+	return req, nil
 }
 
 func (h *Handler) parseTransferPhrase(ctx context.Context, message *models.Message) (*transferRequest, bool, error) {
@@ -306,11 +327,16 @@ func (h *Handler) parseTransferPhrase(ctx context.Context, message *models.Messa
 	if username == "" {
 		return nil, true, errTransferMalformed
 	}
-	if len(parts) > 4 {
-		return nil, true, errTransferMalformed
+	memo, err := parseTransferMemo(parts[4:])
+	if err != nil {
+		return nil, true, err
 	}
 	req, buildErr := h.buildTransferRequest(ctx, message, amount, username)
-	return req, true, buildErr
+	if buildErr != nil {
+		return nil, true, buildErr
+	}
+	req.Memo = memo
+	return req, true, nil
 }
 
 func (h *Handler) buildTransferRequest(ctx context.Context, message *models.Message, amount int64, explicitUsername string) (*transferRequest, error) {
@@ -472,11 +498,13 @@ func (h *Handler) startTransferConfirmation(ctx context.Context, chatID, ownerUs
 		RecipientDisplay: req.Target.Display,
 		State:            transferStateAwaitFirst,
 		ExpiresAt:        h.now().Add(transferTTL),
+		Kind:             confirmationKindTransfer,
+		Memo:             req.Memo,
 	}
 
 	messageID, err := h.tgOps.SendWithOptions(ctx, telegram.SendOptions{
 		ChatID:           chatID,
-		Text:             firstTransferConfirmationText(req.Amount, req.Target.Display),
+		Text:             firstTransferConfirmationText(confirm, h.service.TransferFee(confirm.Amount)),
 		ReplyMarkup:      transferConfirmationMarkup(token),
 		ReplyToMessageID: replyToMessageID,
 	})
@@ -512,7 +540,7 @@ func (h *Handler) handleTransferConfirmYes(ctx context.Context, entry *transferC
 			}
 			entry = reloaded
 		}
-		if err := h.tgOps.Edit(ctx, entry.ChatID, entry.MessageID, secondTransferConfirmationText(entry.Amount, entry.RecipientDisplay), transferConfirmationMarkup(entry.Token)); err != nil && !telegram.IsEditNotModified(err) {
+		if err := h.tgOps.Edit(ctx, entry.ChatID, entry.MessageID, secondTransferConfirmationText(entry, h.service.TransferFee(entry.Amount)), transferConfirmationMarkup(entry.Token)); err != nil && !telegram.IsEditNotModified(err) {
 			log.WithError(err).Warn("transfer first confirm edit failed")
 		}
 		return true
@@ -529,9 +557,9 @@ func (h *Handler) handleTransferConfirmYes(ctx context.Context, entry *transferC
 			h.finishTransferMessage(ctx, entry, finalText, transferStateFailed)
 			return true
 		}
-		h.finishTransferMessage(ctx, executed, successTransferText(executed.Amount, executed.RecipientDisplay), transferStateCompleted)
+		h.finishTransferMessage(ctx, executed, successTransferText(executed), transferStateCompleted)
 		if h.audit != nil && executed != nil {
-			h.audit.LogTransfer(ctx, h.auditMemberLabel(ctx, executed.FromUserID), h.auditMemberLabel(ctx, executed.ToUserID), executed.Amount)
+			h.audit.LogTransfer(ctx, h.auditMemberLabel(ctx, executed.FromUserID), h.auditMemberLabel(ctx, executed.ToUserID), executed.Amount, executed.Fee)
 		}
 		return true
 	default:
@@ -581,8 +609,8 @@ func (h *Handler) finishTransferMessage(ctx context.Context, entry *transferConf
 	}
 }
 
-func parseTransferCallbackData(data string) (token, action string, ok bool) {
-	payload := strings.TrimPrefix(data, transferCallbackPrefix)
+func parseCallbackData(data, prefix string) (token, action string, ok bool) {
+	payload := strings.TrimPrefix(data, prefix)
 	parts := strings.Split(payload, ":")
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 		return "", "", false
@@ -611,16 +639,36 @@ func transferConfirmationMarkup(token string) *models.InlineKeyboardMarkup {
 	}
 }
 
-func firstTransferConfirmationText(amount int64, recipient string) string {
-	return fmt.Sprintf("Вы уверены, что хотите передать %d пользователю %s?", amount, recipient)
+func firstTransferConfirmationText(entry *transferConfirmation, fee int64) string {
+	return fmt.Sprintf("Вы уверены, что хотите передать %d пользователю %s%s?%s", entry.Amount, entry.RecipientDisplay, memoSuffix(entry.Memo), feeNotice(fee))
+}
+
+func secondTransferConfirmationText(entry *transferConfirmation, fee int64) string {
+	return fmt.Sprintf("Вы точно уверены, что хотите передать %d пользователю %s%s?%s", entry.Amount, entry.RecipientDisplay, memoSuffix(entry.Memo), feeNotice(fee))
+}
+
+func successTransferText(entry *transferConfirmation) string {
+	text := fmt.Sprintf("✅ Передано %d пользователю %s%s.", entry.Amount, entry.RecipientDisplay, memoSuffix(entry.Memo))
+	if entry.Fee > 0 {
+		text += fmt.Sprintf(" Комиссия: %d.", entry.Fee)
+	}
+	return text
 }
 
-func secondTransferConfirmationText(amount int64, recipient string) string {
-	return fmt.Sprintf("Вы точно уверены, что хотите передать %d пользователю %s?", amount, recipient)
+// feeNotice предупреждает о комиссии сверх суммы перевода.
+func feeNotice(fee int64) string {
+	if fee <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nКомиссия %d спишется сверх суммы.", fee)
 }
 
-func successTransferText(amount int64, recipient string) string {
-	return fmt.Sprintf("✅ Передано %d пользователю %s.", amount, recipient)
+// memoSuffix — назначение перевода для текстов подтверждений.
+func memoSuffix(memo string) string {
+	if memo == "" {
+		return ""
+	}
+	return " — " + memo
 }
 
 func userFacingTransferError(err error) string {
@@ -630,7 +678,9 @@ func userFacingTransferError(err error) string {
 	case errors.Is(err, errTransferAmountMissing):
 		return "❌ Не указана сумма перевода."
 	case errors.Is(err, errTransferMalformed):
-		return "❌ Некорректная команда перевода. Используйте `!отсыпать <сумма>` в ответе или `передать плёнки <сумма> @username`."
+		return "❌ Некорректная команда перевода. Используйте `!отсыпать <сумма> [за что]` в ответе или `передать плёнки <сумма> @username [за что]`."
+	case errors.Is(err, errTransferMemoTooLong):
+		return fmt.Sprintf("❌ Назначение перевода длиннее %d символов.", transferMemoMaxRunes)
 	case errors.Is(err, common.ErrUserNotFound):
 		return "❌ Не удалось найти пользователя по указанному username."
 	case errors.Is(err, common.ErrSelfTransfer):
@@ -639,6 +689,12 @@ func userFacingTransferError(err error) string {
 		return "❌ Нельзя использовать !отсыпать на ботов."
 	case errors.Is(err, common.ErrInsufficientBalance):
 		return "❌ Недостаточно плёнок для перевода."
+	case errors.Is(err, common.ErrTransferRecipientLeft):
+		return "❌ Получатель вышел из чата — переводы ему закрыты."
+	case errors.Is(err, common.ErrTransferAccountTooNew):
+		return "❌ Переводы станут доступны, когда вы пробудете в чате подольше."
+	case errors.Is(err, common.ErrTransferDailyCap):
+		return "❌ Дневной лимит переводов исчерпан, попробуйте завтра."
 	case errors.Is(err, common.ErrInvalidAmount):
 		return "❌ Сумма должна быть положительным целым числом больше нуля."
 	default:
@@ -652,6 +708,12 @@ func userFacingTransferExecutionError(err error) string {
 		return "❌ Нельзя переводить плёнки самому себе."
 	case errors.Is(err, common.ErrInsufficientBalance):
 		return "❌ Недостаточно плёнок для перевода."
+	case errors.Is(err, common.ErrTransferRecipientLeft):
+		return "❌ Получатель вышел из чата — переводы ему закрыты."
+	case errors.Is(err, common.ErrTransferAccountTooNew):
+		return "❌ Переводы станут доступны, когда вы пробудете в чате подольше."
+	case errors.Is(err, common.ErrTransferDailyCap):
+		return "❌ Дневной лимит переводов исчерпан, попробуйте завтра."
 	case errors.Is(err, common.ErrInvalidAmount):
 		return "❌ Сумма должна быть положительным целым числом больше нуля."
 	default:
@@ -682,6 +744,7 @@ var (
 	errTransferRecipientMissing   = errors.New("transfer recipient missing")
 	errTransferAmountMissing      = errors.New("transfer amount missing")
 	errTransferMalformed          = errors.New("transfer malformed")
+	errTransferMemoTooLong        = errors.New("transfer memo too long")
 	errBalanceTargetMissing       = errors.New("balance target missing")
 	errBalanceCommandMalformed    = errors.New("balance command malformed")
 	errBalanceUsernameNotResolved = errors.New("balance username not resolved")
@@ -705,6 +768,15 @@ func parsePositiveInteger(raw string) (int64, error) {
 	return value, nil
 }
 
+// parseTransferMemo склеивает слова назначения перевода или запроса.
+func parseTransferMemo(words []string) (string, error) {
+	memo := strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+	if utf8.RuneCountInString(memo) > transferMemoMaxRunes {
+		return "", errTransferMemoTooLong
+	}
+	return memo, nil
+}
+
 func normalizeWord(s string) string {
 	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "ё", "е")
 }
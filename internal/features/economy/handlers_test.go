@@ -17,28 +17,32 @@ import (
 )
 
 type fakeEconomyService struct {
-	balance         int64
-	balanceErr      error
-	transferErr     error
-	transferCalls   int
-	lastTransferTo  int64
-	lastTransferAmt int64
-	confirmations   map[string]*transferConfirmation
+	balance           int64
+	balanceErr        error
+	transferErr       error
+	transferCalls     int
+	lastTransferTo    int64
+	lastTransferAmt   int64
+	confirmations     map[string]*transferConfirmation
+	history           []*Transaction
+	lastHistoryFilter HistoryFilter
+	feePercent        float64
 }
 
 func (f *fakeEconomyService) GetBalance(ctx context.Context, userID int64) (int64, error) {
 	return f.balance, f.balanceErr
 }
 
-func (f *fakeEconomyService) Transfer(ctx context.Context, fromUserID, toUserID, amount int64) error {
+func (f *fakeEconomyService) Transfer(ctx context.Context, fromUserID, toUserID, amount int64, memo string) error {
 	f.transferCalls++
 	f.lastTransferTo = toUserID
 	f.lastTransferAmt = amount
 	return f.transferErr
 }
 
-func (f *fakeEconomyService) GetTransactionHistory(ctx context.Context, userID int64) (string, error) {
-	return "", nil
+func (f *fakeEconomyService) GetTransactionHistoryPage(ctx context.Context, userID int64, filter HistoryFilter, page int, now time.Time) (*HistoryPage, error) {
+	f.lastHistoryFilter = filter
+	return paginateHistory(filterHistory(f.history, userID, filter), filter, page), nil
 }
 
 func (f *fakeEconomyService) CreateTransferConfirmation(ctx context.Context, entry *transferConfirmation) error {
@@ -107,10 +111,27 @@ func (f *fakeEconomyService) ExecuteTransferConfirmation(ctx context.Context, to
 		return entry, f.transferErr
 	}
 	entry.State = transferStateCompleted
+	entry.Fee = f.TransferFee(entry.Amount)
 	f.confirmations[token] = entry
 	return entry, nil
 }
 
+func (f *fakeEconomyService) ListOpenMoneyRequests(ctx context.Context, userID int64) ([]*transferConfirmation, error) {
+	var out []*transferConfirmation
+	for _, entry := range f.confirmations {
+		if entry.Kind == confirmationKindRequest && entry.State == transferStateAwaitSecond &&
+			(entry.FromUserID == userID || entry.ToUserID == userID) {
+			cp := *entry
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeEconomyService) TransferFee(amount int64) int64 {
+	return TransferPolicy{FeePercent: f.feePercent}.Fee(amount)
+}
+
 type fakeMemberLookup struct {
 	member         *members.Member
 	userByID       *members.Member
@@ -505,6 +526,56 @@ func TestHandleEconomyCallback_ExecutionErrorEditsMessage(t *testing.T) {
 	}
 }
 
+func TestHandleEconomyCallback_PolicyRejectionEditsMessage(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: common.ErrTransferDailyCap, want: "❌ Дневной лимит переводов исчерпан, попробуйте завтра."},
+		{err: common.ErrTransferAccountTooNew, want: "❌ Переводы станут доступны, когда вы пробудете в чате подольше."},
+		{err: common.ErrTransferRecipientLeft, want: "❌ Получатель вышел из чата — переводы ему закрыты."},
+	}
+
+	for _, tt := range tests {
+		tg := &fakeEconomyTG{}
+		svc := &fakeEconomyService{balance: 50, transferErr: tt.err}
+		h := &Handler{
+			service:       svc,
+			memberService: &fakeMemberLookup{},
+			tgOps:         telegram.NewOps(tg),
+			now:           func() time.Time { return time.Unix(100, 0).UTC() },
+		}
+		svc.confirmations = map[string]*transferConfirmation{"abc": {
+			Token:       "abc",
+			ChatID:      -10,
+			MessageID:   42,
+			OwnerUserID: 1,
+			FromUserID:  1,
+			ToUserID:    2,
+			Amount:      7,
+			State:       transferStateAwaitSecond,
+			ExpiresAt:   time.Unix(200, 0).UTC(),
+		}}
+
+		h.HandleEconomyCallback(context.Background(), callback(-10, 42, 1, transferCallbackPrefix+"abc:"+transferConfirmYes))
+
+		if len(tg.editedText) != 1 || tg.editedText[0].Text != tt.want {
+			t.Fatalf("%v: unexpected final edits: %+v", tt.err, tg.editedText)
+		}
+	}
+}
+
+func TestTransferTexts_ShowFee(t *testing.T) {
+	entry := &transferConfirmation{Amount: 200, RecipientDisplay: "@Dora_2270", Fee: 10}
+
+	if got := firstTransferConfirmationText(entry, 10); got != "Вы уверены, что хотите передать 200 пользователю @Dora_2270?\nКомиссия 10 спишется сверх суммы." {
+		t.Fatalf("unexpected confirmation text: %q", got)
+	}
+	if got := successTransferText(entry); got != "✅ Передано 200 пользователю @Dora_2270. Комиссия: 10." {
+		t.Fatalf("unexpected success text: %q", got)
+	}
+}
+
 func callback(chatID int64, msgID int, userID int64, data string) *models.CallbackQuery {
 	return &models.CallbackQuery{
 		ID:   "cb-id",
@@ -768,3 +839,34 @@ func TestHandleEconomyCallback_SuccessEmitsAuditLog(t *testing.T) {
 		t.Fatalf("expected transfer audit log, sent=%#v", tg.sent)
 	}
 }
+
+func TestHandleTransferCommand_StoresMemo(t *testing.T) {
+	tg := &fakeEconomyTG{}
+	svc := &fakeEconomyService{balance: 10}
+	h := &Handler{
+		service:       svc,
+		memberService: &fakeMemberLookup{member: &members.Member{UserID: 20, Username: "Dora_2270"}},
+		tgOps:         telegram.NewOps(tg),
+		now:           func() time.Time { return time.Unix(100, 0).UTC() },
+	}
+	msg := &models.Message{MessageID: 77, Chat: models.Chat{ID: -100}, From: &models.User{ID: 10, Username: "sender"}}
+
+	h.HandleTransferCommand(context.Background(), commands.Context{ChatID: -100, UserID: 10, MessageID: 77, Message: msg},
+		[]string{"3", "@Dora_2270", "за", "пиццу"})
+
+	if len(tg.sent) != 1 || tg.sent[0].Text != "Вы уверены, что хотите передать 3 пользователю @Dora_2270 — за пиццу?" {
+		t.Fatalf("unexpected confirmation: %#v", tg.sent)
+	}
+	for _, entry := range svc.confirmations {
+		if entry.Memo != "за пиццу" || entry.ToUserID != 20 {
+			t.Fatalf("memo not stored: %+v", entry)
+		}
+	}
+
+	tg.sent = nil
+	h.HandleTransferCommand(context.Background(), commands.Context{ChatID: -100, UserID: 10, MessageID: 78, Message: msg},
+		[]string{"@Dora_2270", "3", "4"})
+	if len(tg.sent) != 1 || !strings.HasPrefix(tg.sent[0].Text, "❌ Некорректная команда перевода") {
+		t.Fatalf("second amount must stay malformed, got %#v", tg.sent)
+	}
+}
@@ -0,0 +1,335 @@
+// Package economy — history.go показывает !транзакции: постраничный экран
+// истории с фильтрами по направлению, виду операции и периоду. Листать и
+// переключать фильтры может только владелец истории.
+package economy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/telegram"
+)
+
+const (
+	historyCallbackPrefix = "economy:history:"
+	historyPageSize       = 10
+)
+
+// HistoryDirection — направление операций в истории.
+type HistoryDirection string
+
+const (
+	HistoryDirectionAll HistoryDirection = "all"
+	HistoryDirectionIn  HistoryDirection = "in"
+	HistoryDirectionOut HistoryDirection = "out"
+)
+
+// HistoryCategory — вид операций в истории; пустой — все виды.
+type HistoryCategory string
+
+const (
+	HistoryCategoryAll      HistoryCategory = "all"
+	HistoryCategoryCasino   HistoryCategory = "casino"
+	HistoryCategoryStreak   HistoryCategory = "streak"
+	HistoryCategoryThanks   HistoryCategory = "thanks"
+	HistoryCategoryAdmin    HistoryCategory = "admin"
+	HistoryCategoryTransfer HistoryCategory = "transfer"
+)
+
+// HistoryPeriod — за какой срок показывать историю.
+type HistoryPeriod string
+
+const (
+	HistoryPeriodWeek  HistoryPeriod = "7d"
+	HistoryPeriodMonth HistoryPeriod = "30d"
+	HistoryPeriodAll   HistoryPeriod = "all"
+)
+
+// HistoryFilter — фильтр экрана истории.
+type HistoryFilter struct {
+	Direction HistoryDirection
+	Category  HistoryCategory
+	Period    HistoryPeriod
+}
+
+// HistoryPage — страница отфильтрованной истории.
+type HistoryPage struct {
+	Transactions []*Transaction
+	Page         int
+	TotalPages   int
+	Total        int
+}
+
+// DefaultHistoryFilter — все операции за 30 дней.
+func DefaultHistoryFilter() HistoryFilter {
+	return HistoryFilter{Direction: HistoryDirectionAll, Category: HistoryCategoryAll, Period: HistoryPeriodMonth}
+}
+
+// Since возвращает начало периода; для «всё время» — нулевое время.
+func (p HistoryPeriod) Since(now time.Time) time.Time {
+	switch p {
+	case HistoryPeriodWeek:
+		return now.Add(-7 * 24 * time.Hour)
+	case HistoryPeriodMonth:
+		return now.Add(-30 * 24 * time.Hour)
+	default:
+		return time.Time{}
+	}
+}
+
+// CategoryOf относит тип транзакции к виду операций истории. Кредиты,
+// покупки и прочие типы видны только без фильтра по виду.
+func CategoryOf(txType string) HistoryCategory {
+	switch {
+	case strings.HasPrefix(txType, "casino_"), strings.HasPrefix(txType, "jackpot_"), strings.HasPrefix(txType, "duel_"):
+		return HistoryCategoryCasino
+	case strings.HasPrefix(txType, "streak_"):
+		return HistoryCategoryStreak
+	case strings.HasPrefix(txType, "thanks_"):
+		return HistoryCategoryThanks
+	case strings.HasPrefix(txType, "admin_"):
+		return HistoryCategoryAdmin
+	case txType == TxTypeTransfer, txType == TxTypeTransferFee:
+		return HistoryCategoryTransfer
+	default:
+		return ""
+	}
+}
+
+func filterHistory(transactions []*Transaction, userID int64, filter HistoryFilter) []*Transaction {
+	out := make([]*Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		outgoing := tx.FromUserID != nil && *tx.FromUserID == userID
+		switch filter.Direction {
+		case HistoryDirectionIn:
+			if outgoing {
+				continue
+			}
+		case HistoryDirectionOut:
+			if !outgoing {
+				continue
+			}
+		}
+		if filter.Category != "" && filter.Category != HistoryCategoryAll && CategoryOf(tx.TransactionType) != filter.Category {
+			continue
+		}
+		out = append(out, tx)
+	}
+	return out
+}
+
+func paginateHistory(transactions []*Transaction, filter HistoryFilter, page int) *HistoryPage {
+	totalPages := (len(transactions) + historyPageSize - 1) / historyPageSize
+	if totalPages < 1 {
+		totalPages = 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	if page >= totalPages {
+		page = totalPages - 1
+	}
+	start := page * historyPageSize
+	end := start + historyPageSize
+	if end > len(transactions) {
+		end = len(transactions)
+	}
+	return &HistoryPage{Transactions: transactions[start:end], Page: page, TotalPages: totalPages, Total: len(transactions)}
+}
+
+// HandleTransactions обрабатывает `!транзакции` — первая страница истории
+// за 30 дней.
+func (h *Handler) HandleTransactions(ctx context.Context, chatID int64, userID int64) {
+	if err := h.renderHistory(ctx, chatID, 0, userID, DefaultHistoryFilter(), 0); err != nil {
+		log.WithError(err).Error("ошибка получения транзакций")
+		h.sendMessage(ctx, chatID, "❌ Ошибка получения истории транзакций", 0)
+	}
+}
+
+func (h *Handler) handleHistoryCallback(ctx context.Context, q *models.CallbackQuery) bool {
+	ownerID, filter, page, ok := parseHistoryCallback(q.Data)
+	msg := callbackMessage(q)
+	if !ok || msg == nil {
+		h.answerCallback(ctx, q.ID, "")
+		return true
+	}
+	if q.From.ID != ownerID {
+		h.answerCallback(ctx, q.ID, "Это история другого участника. Наберите !транзакции.")
+		return true
+	}
+	h.answerCallback(ctx, q.ID, "")
+	if err := h.renderHistory(ctx, msg.Chat.ID, msg.MessageID, ownerID, filter, page); err != nil {
+		log.WithError(err).WithField("data", q.Data).Warn("history render failed")
+	}
+	return true
+}
+
+func (h *Handler) renderHistory(ctx context.Context, chatID int64, messageID int, userID int64, filter HistoryFilter, page int) error {
+	result, err := h.service.GetTransactionHistoryPage(ctx, userID, filter, page, h.now())
+	if err != nil {
+		return err
+	}
+	_, _, err = telegram.RenderScreen(ctx, h.tgOps, telegram.Screen{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        historyText(result, userID, filter),
+		ReplyMarkup: historyKeyboard(userID, filter, result),
+	})
+	return err
+}
+
+func historyText(result *HistoryPage, userID int64, filter HistoryFilter) string {
+	var sb strings.Builder
+	sb.WriteString("📋 История транзакций\n")
+	fmt.Fprintf(&sb, "%s · %s · %s", directionLabel(filter.Direction), categoryLabel(filter.Category), periodLabel(filter.Period))
+	if result.Total == 0 {
+		sb.WriteString("\n\nОпераций не найдено.")
+		return sb.String()
+	}
+	sb.WriteString("\n\n")
+	offset := result.Page * historyPageSize
+	for i, tx := range result.Transactions {
+		sign := "+"
+		if tx.FromUserID != nil && *tx.FromUserID == userID {
+			sign = "-"
+		}
+		fmt.Fprintf(&sb, "%d. %s | %s%d%s | %s\n",
+			offset+i+1,
+			common.FormatDateTime(tx.CreatedAt),
+			sign,
+			tx.Amount,
+			common.PluralizeFilms(tx.Amount),
+			tx.Description,
+		)
+	}
+	fmt.Fprintf(&sb, "\nСтр. %d/%d · всего %d", result.Page+1, result.TotalPages, result.Total)
+	return sb.String()
+}
+
+func historyKeyboard(userID int64, filter HistoryFilter, result *HistoryPage) *models.InlineKeyboardMarkup {
+	button := func(label string, selected bool, next HistoryFilter) models.InlineKeyboardButton {
+		if selected {
+			label = "• " + label
+		}
+		return models.InlineKeyboardButton{Text: label, CallbackData: historyCallbackData(userID, next, 0)}
+	}
+	withDirection := func(d HistoryDirection) HistoryFilter { f := filter; f.Direction = d; return f }
+	withCategory := func(c HistoryCategory) HistoryFilter { f := filter; f.Category = c; return f }
+	withPeriod := func(p HistoryPeriod) HistoryFilter { f := filter; f.Period = p; return f }
+
+	var directions, categories1, categories2, periods []models.InlineKeyboardButton
+	for _, d := range []HistoryDirection{HistoryDirectionAll, HistoryDirectionIn, HistoryDirectionOut} {
+		directions = append(directions, button(directionLabel(d), filter.Direction == d, withDirection(d)))
+	}
+	for i, c := range []HistoryCategory{HistoryCategoryAll, HistoryCategoryCasino, HistoryCategoryStreak, HistoryCategoryThanks, HistoryCategoryAdmin, HistoryCategoryTransfer} {
+		b := button(categoryLabel(c), filter.Category == c, withCategory(c))
+		if i < 3 {
+			categories1 = append(categories1, b)
+		} else {
+			categories2 = append(categories2, b)
+		}
+	}
+	for _, p := range []HistoryPeriod{HistoryPeriodWeek, HistoryPeriodMonth, HistoryPeriodAll} {
+		periods = append(periods, button(periodLabel(p), filter.Period == p, withPeriod(p)))
+	}
+
+	rows := [][]models.InlineKeyboardButton{directions, categories1, categories2, periods}
+	if result.TotalPages > 1 {
+		var nav []models.InlineKeyboardButton
+		if result.Page > 0 {
+			nav = append(nav, models.InlineKeyboardButton{Text: "⬅", CallbackData: historyCallbackData(userID, filter, result.Page-1)})
+		}
+		if result.Page < result.TotalPages-1 {
+			nav = append(nav, models.InlineKeyboardButton{Text: "➡", CallbackData: historyCallbackData(userID, filter, result.Page+1)})
+		}
+		rows = append(rows, nav)
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func directionLabel(d HistoryDirection) string {
+	switch d {
+	case HistoryDirectionIn:
+		return "⬇ Входящие"
+	case HistoryDirectionOut:
+		return "⬆ Исходящие"
+	default:
+		return "Все"
+	}
+}
+
+func categoryLabel(c HistoryCategory) string {
+	switch c {
+	case HistoryCategoryCasino:
+		return "🎰 Казино"
+	case HistoryCategoryStreak:
+		return "🔥 Стрик"
+	case HistoryCategoryThanks:
+		return "🙏 Спасибо"
+	case HistoryCategoryAdmin:
+		return "🛡 Админ"
+	case HistoryCategoryTransfer:
+		return "💸 Переводы"
+	default:
+		return "Все виды"
+	}
+}
+
+func periodLabel(p HistoryPeriod) string {
+	switch p {
+	case HistoryPeriodWeek:
+		return "7 дней"
+	case HistoryPeriodMonth:
+		return "30 дней"
+	default:
+		return "Всё время"
+	}
+}
+
+// historyCallbackData — economy:history:<владелец>:<направление>:<вид>:<период>:<страница>.
+func historyCallbackData(userID int64, filter HistoryFilter, page int) string {
+	return fmt.Sprintf("%s%d:%s:%s:%s:%d", historyCallbackPrefix, userID, filter.Direction, filter.Category, filter.Period, page)
+}
+
+func parseHistoryCallback(data string) (int64, HistoryFilter, int, bool) {
+	parts := strings.Split(strings.TrimPrefix(data, historyCallbackPrefix), ":")
+	if len(parts) != 5 {
+		return 0, HistoryFilter{}, 0, false
+	}
+	ownerID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, HistoryFilter{}, 0, false
+	}
+	page, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return 0, HistoryFilter{}, 0, false
+	}
+	filter := HistoryFilter{
+		Direction: HistoryDirection(parts[1]),
+		Category:  HistoryCategory(parts[2]),
+		Period:    HistoryPeriod(parts[3]),
+	}
+	switch filter.Direction {
+	case HistoryDirectionAll, HistoryDirectionIn, HistoryDirectionOut:
+	default:
+		return 0, HistoryFilter{}, 0, false
+	}
+	switch filter.Category {
+	case HistoryCategoryAll, HistoryCategoryCasino, HistoryCategoryStreak, HistoryCategoryThanks, HistoryCategoryAdmin, HistoryCategoryTransfer:
+	default:
+		return 0, HistoryFilter{}, 0, false
+	}
+	switch filter.Period {
+	case HistoryPeriodWeek, HistoryPeriodMonth, HistoryPeriodAll:
+	default:
+		return 0, HistoryFilter{}, 0, false
+	}
+	return ownerID, filter, page, true
+}
@@ -0,0 +1,124 @@
+package economy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/telegram"
+)
+
+func historyTx(id int64, from, to *int64, txType string) *Transaction {
+	return &Transaction{
+		ID:              id,
+		FromUserID:      from,
+		ToUserID:        to,
+		Amount:          id,
+		TransactionType: txType,
+		Description:     fmt.Sprintf("tx-%d", id),
+		CreatedAt:       time.Unix(1_700_000_000+id, 0).UTC(),
+	}
+}
+
+func TestFilterHistory_DirectionAndCategory(t *testing.T) {
+	me, other := int64(1), int64(2)
+	txs := []*Transaction{
+		historyTx(1, &me, &other, TxTypeTransfer),
+		historyTx(2, &other, &me, TxTypeTransfer),
+		historyTx(3, &me, nil, TxTypeCasinoBet),
+		historyTx(4, nil, &me, TxTypeJackpotWin),
+		historyTx(5, nil, &me, "thanks_reward"),
+		historyTx(6, nil, &me, "admin_adjust"),
+		historyTx(7, nil, &me, TxTypeCreditIssue),
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   []int64
+	}{
+		{name: "all", filter: HistoryFilter{Direction: HistoryDirectionAll, Category: HistoryCategoryAll}, want: []int64{1, 2, 3, 4, 5, 6, 7}},
+		{name: "outgoing", filter: HistoryFilter{Direction: HistoryDirectionOut, Category: HistoryCategoryAll}, want: []int64{1, 3}},
+		{name: "incoming transfers", filter: HistoryFilter{Direction: HistoryDirectionIn, Category: HistoryCategoryTransfer}, want: []int64{2}},
+		{name: "casino both ways", filter: HistoryFilter{Direction: HistoryDirectionAll, Category: HistoryCategoryCasino}, want: []int64{3, 4}},
+		{name: "thanks", filter: HistoryFilter{Direction: HistoryDirectionAll, Category: HistoryCategoryThanks}, want: []int64{5}},
+		{name: "admin", filter: HistoryFilter{Direction: HistoryDirectionAll, Category: HistoryCategoryAdmin}, want: []int64{6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterHistory(txs, me, tt.filter)
+			var ids []int64
+			for _, tx := range got {
+				ids = append(ids, tx.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Fatalf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryPeriodSince(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	if got := HistoryPeriodWeek.Since(now); !got.Equal(now.AddDate(0, 0, -7)) {
+		t.Fatalf("week since = %v", got)
+	}
+	if got := HistoryPeriodAll.Since(now); !got.IsZero() {
+		t.Fatalf("all-time since = %v, want zero", got)
+	}
+}
+
+func TestHandleTransactions_PaginatesAndFiltersForOwnerOnly(t *testing.T) {
+	me, other := int64(1), int64(2)
+	svc := &fakeEconomyService{}
+	for i := int64(1); i <= 12; i++ {
+		svc.history = append(svc.history, historyTx(i, &other, &me, TxTypeTransfer))
+	}
+	svc.history = append(svc.history, historyTx(13, &me, nil, TxTypeCasinoBet))
+	tg := &fakeEconomyTG{}
+	h := &Handler{service: svc, tgOps: telegram.NewOps(tg), now: func() time.Time { return time.Unix(0, 0).UTC() }}
+
+	h.HandleTransactions(context.Background(), -10, me)
+	if len(tg.sent) != 1 {
+		t.Fatalf("expected history message, got %d", len(tg.sent))
+	}
+	first := tg.sent[0]
+	if !strings.Contains(first.Text, "Стр. 1/2 · всего 13") || svc.lastHistoryFilter != DefaultHistoryFilter() {
+		t.Fatalf("unexpected first page: %q filter=%+v", first.Text, svc.lastHistoryFilter)
+	}
+	rows := first.ReplyMarkup.InlineKeyboard
+	next := rows[len(rows)-1][0]
+	if next.Text != "➡" {
+		t.Fatalf("expected next-page button, got %q", next.Text)
+	}
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 1, other, next.CallbackData))
+	if tg.callbackText == "" || len(tg.editedText) != 0 {
+		t.Fatalf("another member must not page the history: alert=%q edits=%d", tg.callbackText, len(tg.editedText))
+	}
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 1, me, next.CallbackData))
+	if len(tg.editedText) != 1 || !strings.Contains(tg.editedText[0].Text, "11. ") || !strings.Contains(tg.editedText[0].Text, "Стр. 2/2") {
+		t.Fatalf("expected second page edit, got %#v", tg.editedText)
+	}
+
+	casino := historyCallbackData(me, HistoryFilter{Direction: HistoryDirectionOut, Category: HistoryCategoryCasino, Period: HistoryPeriodAll}, 0)
+	h.HandleEconomyCallback(context.Background(), callback(-10, 1, me, casino))
+	last := tg.editedText[len(tg.editedText)-1].Text
+	if !strings.Contains(last, "-13") || !strings.Contains(last, "всего 1") || !strings.Contains(last, "⬆ Исходящие · 🎰 Казино · Всё время") {
+		t.Fatalf("unexpected filtered page: %q", last)
+	}
+}
+
+func TestParseHistoryCallback_RejectsUnknownFilters(t *testing.T) {
+	data := historyCallbackData(5, DefaultHistoryFilter(), 2)
+	owner, filter, page, ok := parseHistoryCallback(data)
+	if !ok || owner != 5 || filter != DefaultHistoryFilter() || page != 2 {
+		t.Fatalf("round trip failed: %d %+v %d %v", owner, filter, page, ok)
+	}
+	if _, _, _, ok := parseHistoryCallback(historyCallbackPrefix + "5:all:bogus:30d:0"); ok {
+		t.Fatal("unknown category must be rejected")
+	}
+}
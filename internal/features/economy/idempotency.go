@@ -0,0 +1,150 @@
+package economy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+)
+
+// ErrOperationKeyConflict — ключ операции уже занят проводкой с другим
+// пользователем, суммой или типом. Это ошибка вызывающего кода, а не повтор.
+var ErrOperationKeyConflict = errors.New("operation key reused for a different operation")
+
+// OperationKey собирает ключ идемпотентности балансовой операции:
+// область (riddle, thanks, streak...) и идентификаторы через двоеточие.
+// Для операций из сообщений в ключ входит chat_id: message_id уникален
+// только внутри чата.
+func OperationKey(scope string, parts ...any) string {
+	fields := make([]string, 0, len(parts)+1)
+	fields = append(fields, scope)
+	for _, part := range parts {
+		fields = append(fields, fmt.Sprint(part))
+	}
+	return strings.Join(fields, ":")
+}
+
+// insertTransactionTx записывает проводку. С непустым operationKey повтор
+// ключа ничего не пишет и возвращает applied=false, если исходная проводка
+// совпадает по участникам, сумме и типу.
+func (r *Repository) insertTransactionTx(ctx context.Context, tx pgx.Tx, operationKey string, fromUserID, toUserID *int64, amount int64, txType, description string) (bool, error) {
+	if operationKey == "" {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO transactions (from_user_id, to_user_id, amount, transaction_type, description)
+			VALUES ($1, $2, $3, $4, $5)
+		`, fromUserID, toUserID, amount, txType, description); err != nil {
+			return false, fmt.Errorf("ошибка записи транзакции: %w", err)
+		}
+		return true, nil
+	}
+
+	var id int64
+	err := tx.QueryRow(ctx, `
+		INSERT INTO transactions (from_user_id, to_user_id, amount, transaction_type, description, operation_key)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (operation_key) WHERE operation_key IS NOT NULL DO NOTHING
+		RETURNING id
+	`, fromUserID, toUserID, amount, txType, description, operationKey).Scan(&id)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("ошибка записи транзакции: %w", err)
+	}
+
+	var original Transaction
+	if err := tx.QueryRow(ctx, `
+		SELECT from_user_id, to_user_id, amount, transaction_type
+		FROM transactions
+		WHERE operation_key = $1
+	`, operationKey).Scan(&original.FromUserID, &original.ToUserID, &original.Amount, &original.TransactionType); err != nil {
+		return false, fmt.Errorf("load transaction by operation key: %w", err)
+	}
+	if !sameUserID(original.FromUserID, fromUserID) || !sameUserID(original.ToUserID, toUserID) ||
+		original.Amount != amount || original.TransactionType != txType {
+		return false, fmt.Errorf("%w: %s", ErrOperationKeyConflict, operationKey)
+	}
+	return false, nil
+}
+
+func sameUserID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// AddBalanceOnce начисляет плёнки не больше одного раза на operationKey.
+func (r *Repository) AddBalanceOnce(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	return r.addBalanceWithHook(ctx, operationKey, userID, amount, txType, description, nil)
+}
+
+// AddBalanceOnceWithHook — AddBalanceOnce с hook в той же транзакции;
+// при повторе ключа hook не вызывается.
+func (r *Repository) AddBalanceOnceWithHook(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) (bool, error) {
+	return r.addBalanceWithHook(ctx, operationKey, userID, amount, txType, description, hook)
+}
+
+func (r *Repository) AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	return r.addBalanceTx(ctx, tx, operationKey, userID, amount, txType, description)
+}
+
+// DeductBalanceOnce списывает плёнки не больше одного раза на operationKey.
+func (r *Repository) DeductBalanceOnce(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	return r.deductBalance(ctx, operationKey, userID, amount, txType, description)
+}
+
+func (r *Repository) DeductBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	return r.deductBalanceTx(ctx, tx, operationKey, userID, amount, txType, description)
+}
+
+// AddBalanceOnce начисляет плёнки идемпотентно: повторный вызов с тем же
+// ключом (переотправленный апдейт, ретрай обработчика) — no-op с nil-ошибкой,
+// applied=false. Пустой ключ — обычное начисление.
+func (s *Service) AddBalanceOnce(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	if amount <= 0 {
+		return false, common.ErrInvalidAmount
+	}
+	return s.repo.AddBalanceOnce(ctx, operationKey, userID, amount, txType, description)
+}
+
+func (s *Service) AddBalanceOnceWithHook(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) (bool, error) {
+	if amount <= 0 {
+		return false, common.ErrInvalidAmount
+	}
+	return s.repo.AddBalanceOnceWithHook(ctx, operationKey, userID, amount, txType, description, hook)
+}
+
+func (s *Service) AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	if amount <= 0 {
+		return false, common.ErrInvalidAmount
+	}
+	return s.repo.AddBalanceOnceTx(ctx, tx, operationKey, userID, amount, txType, description)
+}
+
+// DeductBalanceOnce списывает плёнки идемпотентно, см. AddBalanceOnce.
+func (s *Service) DeductBalanceOnce(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	if amount <= 0 {
+		return false, common.ErrInvalidAmount
+	}
+	applied, err := s.repo.DeductBalanceOnce(ctx, operationKey, userID, amount, txType, description)
+	if errors.Is(err, ErrInsufficientFunds) {
+		return false, common.ErrInsufficientBalance
+	}
+	return applied, err
+}
+
+func (s *Service) DeductBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	if amount <= 0 {
+		return false, common.ErrInvalidAmount
+	}
+	applied, err := s.repo.DeductBalanceOnceTx(ctx, tx, operationKey, userID, amount, txType, description)
+	if errors.Is(err, ErrInsufficientFunds) {
+		return false, common.ErrInsufficientBalance
+	}
+	return applied, err
+}
@@ -0,0 +1,32 @@
+package economy
+
+import "testing"
+
+func TestOperationKey(t *testing.T) {
+	if got := OperationKey("thanks", int64(-1001), 42); got != "thanks:-1001:42" {
+		t.Fatalf("unexpected key %q", got)
+	}
+	if got := OperationKey("riddle", int64(7), "answer", int64(3)); got != "riddle:7:answer:3" {
+		t.Fatalf("unexpected key %q", got)
+	}
+}
+
+func TestSameUserID(t *testing.T) {
+	one, other := int64(1), int64(2)
+	sameOne := int64(1)
+	cases := []struct {
+		a, b *int64
+		want bool
+	}{
+		{nil, nil, true},
+		{&one, nil, false},
+		{nil, &one, false},
+		{&one, &sameOne, true},
+		{&one, &other, false},
+	}
+	for _, tc := range cases {
+		if got := sameUserID(tc.a, tc.b); got != tc.want {
+			t.Fatalf("sameUserID(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -37,4 +37,40 @@ const (
 	TxTypeStreakBonus  = "streak_bonus"  // Бонус за стрик
 	TxTypeAdminGive   = "admin_give"   // Выдача админом
 	TxTypeAdminTake   = "admin_take"   // Изъятие админом
+	TxTypeCreditIssue = "credit_issue" // Выдача кредита
+	TxTypeCreditRepay = "credit_repay" // Погашение кредита
+	TxTypeJackpotContribution = "jackpot_contribution" // Доля ставки в джекпот-пул
+	TxTypeJackpotWin          = "jackpot_win"          // Выплата джекпот-пула
+	TxTypeDuelStake           = "duel_stake"           // Ставка дуэли на депонировании
+	TxTypeDuelWin             = "duel_win"             // Банк дуэли победителю
+	TxTypeDuelFee             = "duel_fee"             // Комиссия казино с банка дуэли
+	TxTypeDuelRefund          = "duel_refund"          // Возврат ставки непринятой дуэли
+	TxTypeShopPurchase        = "shop_purchase"        // Покупка в магазине
+	TxTypeShopRefund          = "shop_refund"          // Возврат покупки, которую не удалось выдать
+	TxTypeOpeningBalance      = "opening_balance"      // Входящий остаток, записанный при сверке журнала
+	TxTypeLedgerCorrection    = "ledger_correction"    // Корректировка журнала по итогам сверки
+	TxTypeAdminPayout         = "admin_payout"         // Регулярная выплата по правилу из админки
+	TxTypeTransferFee         = "transfer_fee"         // Комиссия за перевод: в казну или сжигается
+	TxTypeStreakMilestone     = "streak_milestone"     // Бонус за веху огонька (например, 30 или 100 дней)
 )
+
+// LedgerMismatch — расхождение строки balances с журналом transactions.
+// Ledger* посчитаны по журналу: зачисления — to_user_id, списания — from_user_id.
+type LedgerMismatch struct {
+	UserID       int64
+	Balance      int64
+	TotalEarned  int64
+	TotalSpent   int64
+	LedgerEarned int64
+	LedgerSpent  int64
+}
+
+// LedgerBalance — баланс по журналу.
+func (m LedgerMismatch) LedgerBalance() int64 {
+	return m.LedgerEarned - m.LedgerSpent
+}
+
+// Drift — на сколько баланс расходится с журналом (плюс — баланс больше).
+func (m LedgerMismatch) Drift() int64 {
+	return m.Balance - m.LedgerBalance()
+}
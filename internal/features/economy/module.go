@@ -24,6 +24,11 @@ func NewModule(deps Deps) (*Module, error) {
 	h := NewHandler(deps.Service, deps.MemberService, deps.Ops)
 	if deps.Cfg != nil {
 		h.SetAuditLogger(audit.NewLogger(deps.Ops, deps.Cfg.AdminChatID))
+		if deps.Service != nil {
+			deps.Service.SetAuditLogger(audit.NewLogger(deps.Ops, deps.Cfg.AdminChatID), deps.MemberService)
+			deps.Service.SetTransferPolicy(TransferPolicyFromConfig(deps.Cfg))
+		}
+		h.SetRequestTTL(deps.Cfg.EconomyRequestTTL)
 	}
 	f := NewFeature(h, deps.Cfg)
 	return &Module{Handler: h, Feature: f}, nil
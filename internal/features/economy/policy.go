@@ -0,0 +1,158 @@
+package economy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/config"
+)
+
+// TransferPolicy — ограничения на переводы между участниками: комиссия,
+// дневной лимит исходящих переводов, минимальный стаж отправителя и запрет
+// переводов вышедшим из чата. Нулевое значение ничего не ограничивает.
+type TransferPolicy struct {
+	// FeePercent — комиссия в процентах от суммы, списывается с отправителя
+	// сверх суммы перевода.
+	FeePercent float64
+	// TreasuryUserID получает комиссию; 0 — комиссия сжигается.
+	TreasuryUserID int64
+	// DailyCap — сколько плёнок участник может отправить за сутки (без
+	// комиссии); 0 — без лимита.
+	DailyCap int64
+	// MinAccountAge — сколько отправитель должен пробыть в чате
+	// (members.joined_at); 0 — без проверки.
+	MinAccountAge time.Duration
+	// Location задаёт границу суток для дневного лимита.
+	Location *time.Location
+}
+
+// TransferPolicyFromConfig собирает политику переводов из настроек ECONOMY_TRANSFER_*.
+func TransferPolicyFromConfig(cfg *config.Config) TransferPolicy {
+	if cfg == nil {
+		return TransferPolicy{}
+	}
+	loc := time.UTC
+	if loaded, err := time.LoadLocation(cfg.AppTimezone); err == nil {
+		loc = loaded
+	}
+	return TransferPolicy{
+		FeePercent:     cfg.EconomyTransferFeePercent,
+		TreasuryUserID: cfg.EconomyTreasuryUserID,
+		DailyCap:       cfg.EconomyTransferDailyCap,
+		MinAccountAge:  cfg.EconomyTransferMinAccountAge,
+		Location:       loc,
+	}
+}
+
+// Fee возвращает комиссию за перевод amount плёнок, округлённую вниз.
+func (p TransferPolicy) Fee(amount int64) int64 {
+	if p.FeePercent <= 0 || amount <= 0 {
+		return 0
+	}
+	return int64(float64(amount) * p.FeePercent / 100)
+}
+
+// dayStart возвращает начало суток now в часовом поясе политики, в UTC —
+// колонка created_at хранится без пояса.
+func (p TransferPolicy) dayStart(now time.Time) time.Time {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc).UTC()
+}
+
+// transferParties — то, что политика знает об участниках перевода на момент
+// проверки.
+type transferParties struct {
+	SenderJoinedAt  *time.Time
+	RecipientStatus string
+	SentToday       int64
+}
+
+// check проверяет перевод amount плёнок против политики. Участник без
+// joined_at (старые записи) стаж проходит.
+func (p TransferPolicy) check(parties transferParties, amount int64, now time.Time) error {
+	if parties.RecipientStatus == memberStatusLeft {
+		return common.ErrTransferRecipientLeft
+	}
+	if p.MinAccountAge > 0 && parties.SenderJoinedAt != nil && now.Sub(*parties.SenderJoinedAt) < p.MinAccountAge {
+		return common.ErrTransferAccountTooNew
+	}
+	if p.DailyCap > 0 && parties.SentToday+amount > p.DailyCap {
+		return common.ErrTransferDailyCap
+	}
+	return nil
+}
+
+// memberStatusLeft — статус members.status вышедшего из чата участника.
+const memberStatusLeft = "left"
+
+// loadTransferPartiesTx собирает данные для проверки политики. Вызывается
+// после блокировки баланса отправителя, поэтому параллельные переводы того
+// же отправителя не обходят дневной лимит.
+func (r *Repository) loadTransferPartiesTx(ctx context.Context, tx pgx.Tx, fromUserID, toUserID int64, since time.Time) (transferParties, error) {
+	var parties transferParties
+	err := tx.QueryRow(ctx, `
+		SELECT joined_at FROM members WHERE user_id = $1
+	`, fromUserID).Scan(&parties.SenderJoinedAt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return parties, fmt.Errorf("ошибка загрузки отправителя: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		SELECT status FROM members WHERE user_id = $1
+	`, toUserID).Scan(&parties.RecipientStatus)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return parties, fmt.Errorf("ошибка загрузки получателя: %w", err)
+	}
+
+	if err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE from_user_id = $1 AND transaction_type = $2 AND created_at >= $3
+	`, fromUserID, TxTypeTransfer, since).Scan(&parties.SentToday); err != nil {
+		return parties, fmt.Errorf("ошибка подсчёта переводов за сутки: %w", err)
+	}
+	return parties, nil
+}
+
+// chargeTransferFeeTx списывает комиссию с отправителя и зачисляет её в
+// казну либо сжигает. Баланс отправителя уже проверен и заблокирован.
+func (r *Repository) chargeTransferFeeTx(ctx context.Context, tx pgx.Tx, fromUserID, fee int64, description string) error {
+	var treasury *int64
+	if id := r.policy.TreasuryUserID; id != 0 {
+		if err := r.ensureBalanceRowTx(ctx, tx, id); err != nil {
+			return err
+		}
+		treasury = &id
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE balances
+		SET balance = balance - $2, total_spent = total_spent + $2, updated_at = NOW()
+		WHERE user_id = $1
+	`, fromUserID, fee); err != nil {
+		return fmt.Errorf("ошибка списания комиссии: %w", err)
+	}
+	if treasury != nil {
+		if _, err := tx.Exec(ctx, `
+			UPDATE balances
+			SET balance = balance + $2, total_earned = total_earned + $2, updated_at = NOW()
+			WHERE user_id = $1
+		`, *treasury, fee); err != nil {
+			return fmt.Errorf("ошибка зачисления комиссии в казну: %w", err)
+		}
+	}
+
+	if _, err := r.insertTransactionTx(ctx, tx, "", &fromUserID, treasury, fee, TxTypeTransferFee, "Комиссия: "+description); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package economy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+)
+
+func TestTransferPolicy_FeeRoundsDown(t *testing.T) {
+	policy := TransferPolicy{FeePercent: 2.5}
+	if got := policy.Fee(100); got != 2 {
+		t.Fatalf("expected fee 2, got %d", got)
+	}
+	if got := policy.Fee(39); got != 0 {
+		t.Fatalf("expected small transfer to be free, got %d", got)
+	}
+	if got := (TransferPolicy{}).Fee(1000); got != 0 {
+		t.Fatalf("expected no fee without policy, got %d", got)
+	}
+}
+
+func TestTransferPolicy_Check(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	joinedRecently := now.Add(-2 * time.Hour)
+	joinedLongAgo := now.Add(-30 * 24 * time.Hour)
+	policy := TransferPolicy{DailyCap: 100, MinAccountAge: 24 * time.Hour}
+
+	tests := []struct {
+		name    string
+		policy  TransferPolicy
+		parties transferParties
+		amount  int64
+		wantErr error
+	}{
+		{name: "allowed", policy: policy, parties: transferParties{SenderJoinedAt: &joinedLongAgo, RecipientStatus: "active", SentToday: 40}, amount: 60},
+		{name: "cap exceeded", policy: policy, parties: transferParties{SenderJoinedAt: &joinedLongAgo, SentToday: 40}, amount: 61, wantErr: common.ErrTransferDailyCap},
+		{name: "account too new", policy: policy, parties: transferParties{SenderJoinedAt: &joinedRecently}, amount: 1, wantErr: common.ErrTransferAccountTooNew},
+		{name: "unknown join date passes", policy: policy, parties: transferParties{}, amount: 1},
+		{name: "recipient left", policy: TransferPolicy{}, parties: transferParties{RecipientStatus: memberStatusLeft}, amount: 1, wantErr: common.ErrTransferRecipientLeft},
+		{name: "zero policy", policy: TransferPolicy{}, parties: transferParties{SenderJoinedAt: &joinedRecently, SentToday: 1 << 40}, amount: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.check(tt.parties, tt.amount, now)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTransferPolicy_DayStartUsesLocation(t *testing.T) {
+	policy := TransferPolicy{Location: time.FixedZone("MSK", 3*60*60)}
+	now := time.Date(2026, 3, 10, 22, 30, 0, 0, time.UTC)
+
+	got := policy.dayStart(now)
+	want := time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC)
+	if !got.Equal(want) || got.Location() != time.UTC {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -20,7 +20,8 @@ var ErrTransferConfirmationNotFound = errors.New("transfer confirmation not foun
 var ErrTransferConfirmationStateConflict = errors.New("transfer confirmation state conflict")
 
 type Repository struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	policy TransferPolicy
 }
 
 // NewRepository создаёт новый репозиторий экономики.
@@ -28,6 +29,11 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+// SetTransferPolicy задаёт ограничения для переводов между участниками.
+func (r *Repository) SetTransferPolicy(policy TransferPolicy) {
+	r.policy = policy
+}
+
 // CreateBalance создаёт начальный баланс для нового участника.
 // Начальный баланс всегда 0 плёнок.
 func (r *Repository) CreateBalance(ctx context.Context, userID int64) error {
@@ -62,26 +68,33 @@ func (r *Repository) AddBalance(ctx context.Context, userID int64, amount int64,
 	return r.AddBalanceWithHook(ctx, userID, amount, txType, description, nil)
 }
 
-func (r *Repository) AddBalanceWithHook(ctx context.Context, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) (err error) {
+func (r *Repository) AddBalanceWithHook(ctx context.Context, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) error {
+	_, err := r.addBalanceWithHook(ctx, "", userID, amount, txType, description, hook)
+	return err
+}
+
+// addBalanceWithHook начисляет плёнки и вызывает hook в той же транзакции.
+// При повторе operationKey ничего не меняет, hook не вызывается.
+func (r *Repository) addBalanceWithHook(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) (applied bool, err error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("ошибка начала транзакции: %w", err)
+		return false, fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
 	defer rollbackOnFailure(ctx, tx, &err)
 
-	if err = r.addBalanceTx(ctx, tx, userID, amount, txType, description); err != nil {
-		return err
+	if applied, err = r.addBalanceTx(ctx, tx, operationKey, userID, amount, txType, description); err != nil {
+		return false, err
 	}
-	if hook != nil {
+	if applied && hook != nil {
 		if err = hook(ctx, tx); err != nil {
-			return err
+			return false, err
 		}
 	}
 	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("ошибка коммита транзакции: %w", err)
+		return false, fmt.Errorf("ошибка коммита транзакции: %w", err)
 	}
 	err = nil
-	return nil
+	return applied, nil
 }
 
 func (r *Repository) WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) (err error) {
@@ -102,15 +115,46 @@ func (r *Repository) WithTransaction(ctx context.Context, fn func(context.Contex
 }
 
 // DeductBalance списывает плёнки со счёта пользователя.
-func (r *Repository) DeductBalance(ctx context.Context, userID int64, amount int64, txType, description string) (err error) {
+func (r *Repository) DeductBalance(ctx context.Context, userID int64, amount int64, txType, description string) error {
+	_, err := r.deductBalance(ctx, "", userID, amount, txType, description)
+	return err
+}
+
+func (r *Repository) deductBalance(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string) (applied bool, err error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("ошибка начала транзакции: %w", err)
+		return false, fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
 	defer rollbackOnFailure(ctx, tx, &err)
 
-	if err = r.ensureBalanceRowTx(ctx, tx, userID); err != nil {
-		return err
+	if applied, err = r.deductBalanceTx(ctx, tx, operationKey, userID, amount, txType, description); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+	err = nil
+	return applied, nil
+}
+
+// DeductBalanceTx списывает пленки внутри внешней транзакции.
+func (r *Repository) DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+	_, err := r.deductBalanceTx(ctx, tx, "", userID, amount, txType, description)
+	return err
+}
+
+// deductBalanceTx списывает плёнки. Проводка с operationKey записывается
+// до проверки средств: повтор уже проведённой операции — no-op, даже если
+// денег на второе списание не хватило бы.
+func (r *Repository) deductBalanceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	if err := r.ensureBalanceRowTx(ctx, tx, userID); err != nil {
+		return false, err
+	}
+
+	applied, err := r.insertTransactionTx(ctx, tx, operationKey, &userID, nil, amount, txType, description)
+	if err != nil || !applied {
+		return false, err
 	}
 
 	var currentBalance int64
@@ -118,10 +162,10 @@ func (r *Repository) DeductBalance(ctx context.Context, userID int64, amount int
 		SELECT balance FROM balances WHERE user_id = $1 FOR UPDATE
 	`, userID).Scan(&currentBalance)
 	if err != nil {
-		return fmt.Errorf("ошибка получения баланса: %w", err)
+		return false, fmt.Errorf("ошибка получения баланса: %w", err)
 	}
 	if currentBalance < amount {
-		return fmt.Errorf("%w: нужно %d, есть %d", ErrInsufficientFunds, amount, currentBalance)
+		return false, fmt.Errorf("%w: нужно %d, есть %d", ErrInsufficientFunds, amount, currentBalance)
 	}
 
 	_, err = tx.Exec(ctx, `
@@ -130,33 +174,21 @@ func (r *Repository) DeductBalance(ctx context.Context, userID int64, amount int
 		WHERE user_id = $1
 	`, userID, amount)
 	if err != nil {
-		return fmt.Errorf("ошибка списания: %w", err)
-	}
-
-	_, err = tx.Exec(ctx, `
-		INSERT INTO transactions (from_user_id, amount, transaction_type, description)
-		VALUES ($1, $2, $3, $4)
-	`, userID, amount, txType, description)
-	if err != nil {
-		return fmt.Errorf("ошибка записи транзакции: %w", err)
+		return false, fmt.Errorf("ошибка списания: %w", err)
 	}
 
-	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("ошибка коммита транзакции: %w", err)
-	}
-	err = nil
-	return nil
+	return true, nil
 }
 
 // Transfer переводит плёнки от одного пользователя к другому.
-func (r *Repository) Transfer(ctx context.Context, fromUserID, toUserID, amount int64) (err error) {
+func (r *Repository) Transfer(ctx context.Context, fromUserID, toUserID, amount int64, memo string) (err error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
 	defer rollbackOnFailure(ctx, tx, &err)
 
-	if err = r.transferTx(ctx, tx, fromUserID, toUserID, amount); err != nil {
+	if _, err = r.transferTx(ctx, tx, fromUserID, toUserID, amount, transferDescription(&transferConfirmation{Amount: amount, Memo: memo}), time.Now()); err != nil {
 		return err
 	}
 
@@ -171,14 +203,18 @@ func (r *Repository) CreateTransferConfirmation(ctx context.Context, entry *tran
 	if entry == nil {
 		return fmt.Errorf("transfer confirmation is nil")
 	}
+	kind := entry.Kind
+	if kind == "" {
+		kind = confirmationKindTransfer
+	}
 	_, err := r.db.Exec(ctx, `
 		INSERT INTO economy_transfer_confirmations (
 			token, chat_id, message_id, owner_user_id, sender_user_id, target_user_id,
-			amount, recipient_display, state, expires_at, consumed_at, updated_at
+			amount, recipient_display, state, expires_at, consumed_at, kind, memo, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW())
 	`, entry.Token, entry.ChatID, entry.MessageID, entry.OwnerUserID, entry.FromUserID, entry.ToUserID,
-		entry.Amount, entry.RecipientDisplay, entry.State, entry.ExpiresAt, entry.ConsumedAt)
+		entry.Amount, entry.RecipientDisplay, entry.State, entry.ExpiresAt, entry.ConsumedAt, kind, entry.Memo)
 	if err != nil {
 		return fmt.Errorf("create transfer confirmation: %w", err)
 	}
@@ -261,7 +297,7 @@ func (r *Repository) ExecuteTransferConfirmation(ctx context.Context, token stri
 		return nil, fmt.Errorf("mark transfer confirmation executing: %w", err)
 	}
 
-	transferErr := r.transferTx(ctx, tx, entry.FromUserID, entry.ToUserID, entry.Amount)
+	fee, transferErr := r.transferTx(ctx, tx, entry.FromUserID, entry.ToUserID, entry.Amount, transferDescription(entry), now)
 	if transferErr != nil {
 		if _, err = tx.Exec(ctx, `
 			UPDATE economy_transfer_confirmations
@@ -293,6 +329,7 @@ func (r *Repository) ExecuteTransferConfirmation(ctx context.Context, token stri
 	committed = true
 	entry.State = transferStateCompleted
 	entry.ConsumedAt = &consumedAt
+	entry.Fee = fee
 	err = nil
 	return entry, nil
 }
@@ -307,12 +344,14 @@ func rollbackOnFailure(ctx context.Context, tx pgx.Tx, errp *error) {
 	}
 }
 
-func (r *Repository) transferTx(ctx context.Context, tx pgx.Tx, fromUserID, toUserID, amount int64) error {
+// transferTx переводит amount плёнок по политике переводов и возвращает
+// удержанную с отправителя комиссию.
+func (r *Repository) transferTx(ctx context.Context, tx pgx.Tx, fromUserID, toUserID, amount int64, description string, now time.Time) (int64, error) {
 	if err := r.ensureBalanceRowTx(ctx, tx, fromUserID); err != nil {
-		return err
+		return 0, err
 	}
 	if err := r.ensureBalanceRowTx(ctx, tx, toUserID); err != nil {
-		return err
+		return 0, err
 	}
 
 	var senderBalance int64
@@ -320,10 +359,20 @@ func (r *Repository) transferTx(ctx context.Context, tx pgx.Tx, fromUserID, toUs
 		SELECT balance FROM balances WHERE user_id = $1 FOR UPDATE
 	`, fromUserID).Scan(&senderBalance)
 	if err != nil {
-		return fmt.Errorf("отправитель не найден: %w", err)
+		return 0, fmt.Errorf("отправитель не найден: %w", err)
 	}
-	if senderBalance < amount {
-		return fmt.Errorf("%w: нужно %d, есть %d", ErrInsufficientFunds, amount, senderBalance)
+
+	parties, err := r.loadTransferPartiesTx(ctx, tx, fromUserID, toUserID, r.policy.dayStart(now))
+	if err != nil {
+		return 0, err
+	}
+	if err = r.policy.check(parties, amount, now); err != nil {
+		return 0, err
+	}
+
+	fee := r.policy.Fee(amount)
+	if senderBalance < amount+fee {
+		return 0, fmt.Errorf("%w: нужно %d, есть %d", ErrInsufficientFunds, amount+fee, senderBalance)
 	}
 
 	if _, err = tx.Exec(ctx, `
@@ -331,7 +380,7 @@ func (r *Repository) transferTx(ctx context.Context, tx pgx.Tx, fromUserID, toUs
 		SET balance = balance - $2, total_spent = total_spent + $2, updated_at = NOW()
 		WHERE user_id = $1
 	`, fromUserID, amount); err != nil {
-		return fmt.Errorf("ошибка списания у отправителя: %w", err)
+		return 0, fmt.Errorf("ошибка списания у отправителя: %w", err)
 	}
 
 	if _, err = tx.Exec(ctx, `
@@ -339,45 +388,49 @@ func (r *Repository) transferTx(ctx context.Context, tx pgx.Tx, fromUserID, toUs
 		SET balance = balance + $2, total_earned = total_earned + $2, updated_at = NOW()
 		WHERE user_id = $1
 	`, toUserID, amount); err != nil {
-		return fmt.Errorf("ошибка начисления получателю: %w", err)
+		return 0, fmt.Errorf("ошибка начисления получателю: %w", err)
 	}
 
 	if _, err = tx.Exec(ctx, `
 		INSERT INTO transactions (from_user_id, to_user_id, amount, transaction_type, description)
 		VALUES ($1, $2, $3, 'transfer', $4)
-	`, fromUserID, toUserID, amount, fmt.Sprintf("Перевод %d плёнок", amount)); err != nil {
-		return fmt.Errorf("ошибка записи транзакции: %w", err)
+	`, fromUserID, toUserID, amount, description); err != nil {
+		return 0, fmt.Errorf("ошибка записи транзакции: %w", err)
 	}
-	return nil
+
+	if fee > 0 {
+		if err = r.chargeTransferFeeTx(ctx, tx, fromUserID, fee, description); err != nil {
+			return 0, err
+		}
+	}
+	return fee, nil
 }
 
-func (r *Repository) addBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+func (r *Repository) addBalanceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
 	if err := r.ensureBalanceRowTx(ctx, tx, userID); err != nil {
-		return err
+		return false, err
 	}
 
-	_, err := tx.Exec(ctx, `
+	applied, err := r.insertTransactionTx(ctx, tx, operationKey, nil, &userID, amount, txType, description)
+	if err != nil || !applied {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
 		UPDATE balances
 		SET balance = balance + $2, total_earned = total_earned + $2, updated_at = NOW()
 		WHERE user_id = $1
 	`, userID, amount)
 	if err != nil {
-		return fmt.Errorf("ошибка начисления: %w", err)
-	}
-
-	_, err = tx.Exec(ctx, `
-		INSERT INTO transactions (to_user_id, amount, transaction_type, description)
-		VALUES ($1, $2, $3, $4)
-	`, userID, amount, txType, description)
-	if err != nil {
-		return fmt.Errorf("ошибка записи транзакции: %w", err)
+		return false, fmt.Errorf("ошибка начисления: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
 
 func (r *Repository) AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
-	return r.addBalanceTx(ctx, tx, userID, amount, txType, description)
+	_, err := r.addBalanceTx(ctx, tx, "", userID, amount, txType, description)
+	return err
 }
 
 func (r *Repository) ensureBalanceRowTx(ctx context.Context, tx pgx.Tx, userID int64) error {
@@ -392,51 +445,120 @@ func (r *Repository) ensureBalanceRowTx(ctx context.Context, tx pgx.Tx, userID i
 	return nil
 }
 
+const transferConfirmationColumns = `
+	token, chat_id, message_id, owner_user_id, sender_user_id, target_user_id,
+	amount, recipient_display, state, created_at, expires_at, consumed_at, kind, memo
+`
+
 type transferConfirmationQuerier interface {
 	QueryRow(context.Context, string, ...any) pgx.Row
 }
 
 func (r *Repository) getTransferConfirmation(ctx context.Context, q transferConfirmationQuerier, token string) (*transferConfirmation, error) {
-	var entry transferConfirmation
-	err := q.QueryRow(ctx, `
-		SELECT token, chat_id, message_id, owner_user_id, sender_user_id, target_user_id,
-		       amount, recipient_display, state, created_at, expires_at, consumed_at
+	entry, err := scanTransferConfirmation(q.QueryRow(ctx, `
+		SELECT `+transferConfirmationColumns+`
 		FROM economy_transfer_confirmations
 		WHERE token = $1
-	`, token).Scan(
-		&entry.Token, &entry.ChatID, &entry.MessageID, &entry.OwnerUserID, &entry.FromUserID, &entry.ToUserID,
-		&entry.Amount, &entry.RecipientDisplay, &entry.State, &entry.CreatedAt, &entry.ExpiresAt, &entry.ConsumedAt,
-	)
+	`, token))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrTransferConfirmationNotFound
 		}
 		return nil, fmt.Errorf("get transfer confirmation: %w", err)
 	}
-	return &entry, nil
+	return entry, nil
 }
 
 func (r *Repository) getTransferConfirmationForUpdate(ctx context.Context, tx pgx.Tx, token string) (*transferConfirmation, error) {
-	var entry transferConfirmation
-	err := tx.QueryRow(ctx, `
-		SELECT token, chat_id, message_id, owner_user_id, sender_user_id, target_user_id,
-		       amount, recipient_display, state, created_at, expires_at, consumed_at
+	entry, err := scanTransferConfirmation(tx.QueryRow(ctx, `
+		SELECT `+transferConfirmationColumns+`
 		FROM economy_transfer_confirmations
 		WHERE token = $1
 		FOR UPDATE
-	`, token).Scan(
-		&entry.Token, &entry.ChatID, &entry.MessageID, &entry.OwnerUserID, &entry.FromUserID, &entry.ToUserID,
-		&entry.Amount, &entry.RecipientDisplay, &entry.State, &entry.CreatedAt, &entry.ExpiresAt, &entry.ConsumedAt,
-	)
+	`, token))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrTransferConfirmationNotFound
 		}
 		return nil, fmt.Errorf("lock transfer confirmation: %w", err)
 	}
+	return entry, nil
+}
+
+// ListOpenMoneyRequests возвращает неоплаченные и непросроченные запросы,
+// где пользователь плательщик или автор.
+func (r *Repository) ListOpenMoneyRequests(ctx context.Context, userID int64, now time.Time) ([]*transferConfirmation, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+transferConfirmationColumns+`
+		FROM economy_transfer_confirmations
+		WHERE kind = $1 AND state = $2 AND expires_at > $3
+		  AND (sender_user_id = $4 OR target_user_id = $4)
+		ORDER BY created_at
+	`, confirmationKindRequest, transferStateAwaitSecond, now, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list money requests: %w", err)
+	}
+	return collectTransferConfirmations(rows)
+}
+
+// ExpireMoneyRequests помечает просроченными запросы, которые так и не
+// оплатили, и возвращает их для правки сообщений.
+func (r *Repository) ExpireMoneyRequests(ctx context.Context, now time.Time) ([]*transferConfirmation, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE economy_transfer_confirmations
+		SET state = $3, updated_at = NOW()
+		WHERE kind = $1 AND state = $2 AND expires_at <= $4
+		RETURNING `+transferConfirmationColumns,
+		confirmationKindRequest, transferStateAwaitSecond, transferStateExpired, now)
+	if err != nil {
+		return nil, fmt.Errorf("expire money requests: %w", err)
+	}
+	return collectTransferConfirmations(rows)
+}
+
+func collectTransferConfirmations(rows pgx.Rows) ([]*transferConfirmation, error) {
+	defer rows.Close()
+	var out []*transferConfirmation
+	for rows.Next() {
+		entry, err := scanTransferConfirmation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan transfer confirmation: %w", err)
+		}
+		out = append(out, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate transfer confirmations: %w", err)
+	}
+	return out, nil
+}
+
+func scanTransferConfirmation(row pgx.Row) (*transferConfirmation, error) {
+	var entry transferConfirmation
+	if err := row.Scan(
+		&entry.Token, &entry.ChatID, &entry.MessageID, &entry.OwnerUserID, &entry.FromUserID, &entry.ToUserID,
+		&entry.Amount, &entry.RecipientDisplay, &entry.State, &entry.CreatedAt, &entry.ExpiresAt, &entry.ConsumedAt,
+		&entry.Kind, &entry.Memo,
+	); err != nil {
+		return nil, err
+	}
 	return &entry, nil
 }
 
+// transferDescription — описание перевода в истории транзакций; в него
+// попадает назначение перевода или оплаченного запроса.
+func transferDescription(entry *transferConfirmation) string {
+	if entry.Kind == confirmationKindRequest {
+		if entry.Memo != "" {
+			return fmt.Sprintf("Оплата запроса %d плёнок: %s", entry.Amount, entry.Memo)
+		}
+		return fmt.Sprintf("Оплата запроса %d плёнок", entry.Amount)
+	}
+	if entry.Memo != "" {
+		return fmt.Sprintf("Перевод %d плёнок: %s", entry.Amount, entry.Memo)
+	}
+	return fmt.Sprintf("Перевод %d плёнок", entry.Amount)
+}
+
 func normalizeTransferExecutionError(err error) error {
 	switch {
 	case err == nil:
@@ -448,6 +570,132 @@ func normalizeTransferExecutionError(err error) error {
 	}
 }
 
+// ledgerTotalsQuery — зачисления и списания каждого пользователя по журналу.
+const ledgerTotalsQuery = `
+	SELECT user_id, SUM(credit) AS earned, SUM(debit) AS spent
+	FROM (
+		SELECT to_user_id AS user_id, amount AS credit, 0 AS debit
+		FROM transactions WHERE to_user_id IS NOT NULL
+		UNION ALL
+		SELECT from_user_id, 0, amount
+		FROM transactions WHERE from_user_id IS NOT NULL
+	) entries
+	GROUP BY user_id
+`
+
+// FindLedgerMismatches сверяет balances с журналом и возвращает строки,
+// где баланс или итоги total_earned/total_spent не сходятся с проводками.
+func (r *Repository) FindLedgerMismatches(ctx context.Context) ([]LedgerMismatch, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH ledger AS (`+ledgerTotalsQuery+`)
+		SELECT b.user_id, b.balance, b.total_earned, b.total_spent,
+		       COALESCE(l.earned, 0), COALESCE(l.spent, 0)
+		FROM balances b
+		LEFT JOIN ledger l ON l.user_id = b.user_id
+		WHERE b.balance <> COALESCE(l.earned, 0) - COALESCE(l.spent, 0)
+		   OR b.total_earned <> COALESCE(l.earned, 0)
+		   OR b.total_spent <> COALESCE(l.spent, 0)
+		ORDER BY b.user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("find ledger mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	var out []LedgerMismatch
+	for rows.Next() {
+		var m LedgerMismatch
+		if err := rows.Scan(&m.UserID, &m.Balance, &m.TotalEarned, &m.TotalSpent, &m.LedgerEarned, &m.LedgerSpent); err != nil {
+			return nil, fmt.Errorf("scan ledger mismatch: %w", err)
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ledger mismatches: %w", err)
+	}
+	return out, nil
+}
+
+// CorrectLedger дописывает в журнал проводку ledger_correction на разницу
+// баланса и журнала, а итоги total_earned/total_spent пересчитывает по
+// журналу. Сам баланс не меняется. Каждая строка перепроверяется под
+// блокировкой, чтобы не «исправить» перевод, который идёт прямо сейчас.
+func (r *Repository) CorrectLedger(ctx context.Context) (corrected []LedgerMismatch, err error) {
+	mismatches, err := r.FindLedgerMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(mismatches) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer rollbackOnFailure(ctx, tx, &err)
+
+	for _, candidate := range mismatches {
+		m, err := r.lockLedgerRowTx(ctx, tx, candidate.UserID)
+		if err != nil {
+			return nil, err
+		}
+		drift := m.Drift()
+		if drift == 0 && m.TotalEarned == m.LedgerEarned && m.TotalSpent == m.LedgerSpent {
+			continue
+		}
+		earned, spent := m.LedgerEarned, m.LedgerSpent
+		switch {
+		case drift > 0:
+			_, err = tx.Exec(ctx, `
+				INSERT INTO transactions (to_user_id, amount, transaction_type, description)
+				VALUES ($1, $2, $3, $4)
+			`, m.UserID, drift, TxTypeLedgerCorrection, "Корректировка по сверке журнала")
+			earned += drift
+		case drift < 0:
+			_, err = tx.Exec(ctx, `
+				INSERT INTO transactions (from_user_id, amount, transaction_type, description)
+				VALUES ($1, $2, $3, $4)
+			`, m.UserID, -drift, TxTypeLedgerCorrection, "Корректировка по сверке журнала")
+			spent -= drift
+		}
+		if err != nil {
+			return nil, fmt.Errorf("insert ledger correction: %w", err)
+		}
+		if _, err = tx.Exec(ctx, `
+			UPDATE balances
+			SET total_earned = $2, total_spent = $3, updated_at = NOW()
+			WHERE user_id = $1
+		`, m.UserID, earned, spent); err != nil {
+			return nil, fmt.Errorf("update ledger totals: %w", err)
+		}
+		corrected = append(corrected, m)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+	return corrected, nil
+}
+
+func (r *Repository) lockLedgerRowTx(ctx context.Context, tx pgx.Tx, userID int64) (LedgerMismatch, error) {
+	m := LedgerMismatch{UserID: userID}
+	if err := tx.QueryRow(ctx, `
+		SELECT balance, total_earned, total_spent FROM balances WHERE user_id = $1 FOR UPDATE
+	`, userID).Scan(&m.Balance, &m.TotalEarned, &m.TotalSpent); err != nil {
+		return m, fmt.Errorf("lock balance for ledger check: %w", err)
+	}
+	if err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount) FILTER (WHERE to_user_id = $1), 0),
+		       COALESCE(SUM(amount) FILTER (WHERE from_user_id = $1), 0)
+		FROM transactions
+		WHERE to_user_id = $1 OR from_user_id = $1
+	`, userID).Scan(&m.LedgerEarned, &m.LedgerSpent); err != nil {
+		return m, fmt.Errorf("sum ledger for user: %w", err)
+	}
+	return m, nil
+}
+
 // GetTransactions возвращает последние N транзакций пользователя.
 func (r *Repository) GetTransactions(ctx context.Context, userID int64, limit int) ([]*Transaction, error) {
 	query := `
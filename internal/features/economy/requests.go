@@ -0,0 +1,367 @@
+package economy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/commands"
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/telegram"
+)
+
+// Запрос плёнок (!запросить) живёт в той же таблице подтверждений, что и
+// перевод: плательщик — владелец и отправитель, автор запроса — получатель.
+// Запрос создаётся сразу в состоянии await_second, и кнопка «Оплатить»
+// исполняет его через ExecuteTransferConfirmation.
+const (
+	confirmationKindTransfer = "transfer"
+	confirmationKindRequest  = "request"
+
+	requestCallbackPrefix  = "economy:request:"
+	requestActionPay       = "pay"
+	requestActionReject    = "reject"
+	defaultMoneyRequestTTL = 24 * time.Hour
+)
+
+type moneyRequest struct {
+	Amount int64
+	Memo   string
+	Payer  transferTarget
+}
+
+// SetRequestTTL задаёт, сколько запрос плёнок ждёт оплаты.
+func (h *Handler) SetRequestTTL(ttl time.Duration) {
+	if ttl > 0 {
+		h.requestTTL = ttl
+	}
+}
+
+// HandleMoneyRequestCommand обрабатывает `!запросить @user 100 за пиццу`
+// (или ответом на сообщение плательщика) и публикует запрос с кнопками.
+func (h *Handler) HandleMoneyRequestCommand(ctx context.Context, c commands.Context, args []string) {
+	if c.Message == nil || c.Message.From == nil {
+		h.sendMessage(ctx, c.ChatID, "❌ Не удалось прочитать сообщение запроса", c.MessageID)
+		return
+	}
+	req, err := h.parseMoneyRequestCommand(ctx, c.Message, args)
+	if err != nil {
+		h.sendMessage(ctx, c.ChatID, userFacingMoneyRequestError(err), c.MessageID)
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		log.WithError(err).Warn("money request token generation failed")
+		h.sendMessage(ctx, c.ChatID, "❌ Не удалось создать запрос", c.MessageID)
+		return
+	}
+
+	now := h.now()
+	entry := &transferConfirmation{
+		Token:            token,
+		CreatedAt:        now,
+		ChatID:           c.ChatID,
+		OwnerUserID:      req.Payer.UserID,
+		FromUserID:       req.Payer.UserID,
+		ToUserID:         c.Message.From.ID,
+		Amount:           req.Amount,
+		RecipientDisplay: visibleUserName(*c.Message.From),
+		State:            transferStateAwaitSecond,
+		ExpiresAt:        now.Add(h.moneyRequestTTL()),
+		Kind:             confirmationKindRequest,
+		Memo:             req.Memo,
+	}
+
+	messageID, err := h.tgOps.SendWithOptions(ctx, telegram.SendOptions{
+		ChatID:           c.ChatID,
+		Text:             moneyRequestText(entry, req.Payer.Display),
+		ReplyMarkup:      moneyRequestMarkup(token),
+		ReplyToMessageID: c.MessageID,
+	})
+	if err != nil {
+		log.WithError(err).Warn("money request send failed")
+		h.sendMessage(ctx, c.ChatID, "❌ Не удалось отправить запрос", c.MessageID)
+		return
+	}
+
+	entry.MessageID = messageID
+	if err := h.service.CreateTransferConfirmation(ctx, entry); err != nil {
+		log.WithError(err).WithField("token", token).Warn("money request persist failed")
+		if editErr := h.tgOps.Edit(ctx, c.ChatID, messageID, "❌ Не удалось сохранить запрос", nil); editErr != nil && !telegram.IsEditNotModified(editErr) {
+			log.WithError(editErr).Warn("money request persist failure edit failed")
+		}
+	}
+}
+
+// HandleMoneyRequests показывает открытые запросы пользователя: выставленные
+// ему и выставленные им.
+func (h *Handler) HandleMoneyRequests(ctx context.Context, chatID, userID int64, replyToMessageID int) {
+	entries, err := h.service.ListOpenMoneyRequests(ctx, userID)
+	if err != nil {
+		log.WithError(err).Error("ошибка получения запросов плёнок")
+		h.sendMessage(ctx, chatID, "❌ Ошибка получения запросов", replyToMessageID)
+		return
+	}
+	if len(entries) == 0 {
+		h.sendMessage(ctx, chatID, "📭 Открытых запросов нет.", replyToMessageID)
+		return
+	}
+
+	var incoming, outgoing []string
+	for _, entry := range entries {
+		if entry.FromUserID == userID {
+			incoming = append(incoming, moneyRequestLine(entry, "от "+entry.RecipientDisplay))
+			continue
+		}
+		payer := fmt.Sprintf("id:%d", entry.FromUserID)
+		if member, err := h.lookupByUserID(ctx, entry.FromUserID); err == nil {
+			payer = displayMember(member)
+		}
+		outgoing = append(outgoing, moneyRequestLine(entry, "у "+payer))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📨 Открытые запросы плёнок")
+	if len(incoming) > 0 {
+		sb.WriteString("\n\nВам выставили:\n")
+		sb.WriteString(strings.Join(incoming, "\n"))
+	}
+	if len(outgoing) > 0 {
+		sb.WriteString("\n\nВы запросили:\n")
+		sb.WriteString(strings.Join(outgoing, "\n"))
+	}
+	h.sendMessage(ctx, chatID, sb.String(), replyToMessageID)
+}
+
+func (h *Handler) handleMoneyRequestCallback(ctx context.Context, q *models.CallbackQuery) bool {
+	token, action, ok := parseCallbackData(q.Data, requestCallbackPrefix)
+	msg := callbackMessage(q)
+	if !ok || msg == nil {
+		h.answerCallback(ctx, q.ID, "")
+		return true
+	}
+
+	entry, err := h.service.GetTransferConfirmation(ctx, token)
+	if err != nil || entry == nil || entry.Kind != confirmationKindRequest ||
+		entry.ChatID != msg.Chat.ID || entry.MessageID != msg.MessageID {
+		h.answerCallback(ctx, q.ID, "Запрос устарел.")
+		return true
+	}
+
+	actorID := q.From.ID
+	if actorID != entry.OwnerUserID && actorID != entry.ToUserID {
+		h.answerCallback(ctx, q.ID, "Это не ваш запрос.")
+		return true
+	}
+	if action == requestActionPay && actorID != entry.OwnerUserID {
+		h.answerCallback(ctx, q.ID, "Оплатить запрос может только плательщик.")
+		return true
+	}
+	if h.now().After(entry.ExpiresAt) {
+		_ = h.service.MarkTransferConfirmationExpired(ctx, token)
+		h.answerCallback(ctx, q.ID, "Запрос истёк.")
+		h.finishTransferMessage(ctx, entry, expiredMoneyRequestText(entry), transferStateExpired)
+		return true
+	}
+	if entry.State != transferStateAwaitSecond {
+		h.answerCallback(ctx, q.ID, closedMoneyRequestAlert(entry.State))
+		return true
+	}
+
+	switch action {
+	case requestActionReject:
+		h.answerCallback(ctx, q.ID, "")
+		ok, err := h.service.TransitionTransferConfirmation(ctx, token, []string{transferStateAwaitSecond}, transferStateCanceled)
+		if err != nil {
+			log.WithError(err).Warn("money request reject transition failed")
+			return true
+		}
+		if !ok {
+			return true
+		}
+		text := fmt.Sprintf("🚫 %s отклонил(а) запрос на %d🎞️ от %s.", visibleUserName(q.From), entry.Amount, entry.RecipientDisplay)
+		if actorID == entry.ToUserID {
+			text = fmt.Sprintf("🚫 %s отозвал(а) запрос на %d🎞️.", entry.RecipientDisplay, entry.Amount)
+		}
+		h.finishTransferMessage(ctx, entry, text, transferStateCanceled)
+		return true
+	case requestActionPay:
+		return h.payMoneyRequest(ctx, q, entry)
+	default:
+		h.answerCallback(ctx, q.ID, "")
+		return true
+	}
+}
+
+// payMoneyRequest исполняет запрос. Нехватку плёнок проверяем заранее:
+// неудачное исполнение закрывает подтверждение, а запрос должен остаться
+// открытым, пока плательщик не пополнит баланс.
+func (h *Handler) payMoneyRequest(ctx context.Context, q *models.CallbackQuery, entry *transferConfirmation) bool {
+	balance, err := h.getBalance(ctx, entry.FromUserID)
+	if err != nil {
+		log.WithError(err).Warn("money request balance check failed")
+		h.answerCallback(ctx, q.ID, "Не удалось проверить баланс.")
+		return true
+	}
+	if balance < entry.Amount+h.service.TransferFee(entry.Amount) {
+		h.answerCallback(ctx, q.ID, "Недостаточно плёнок для оплаты.")
+		return true
+	}
+	h.answerCallback(ctx, q.ID, "")
+
+	executed, err := h.service.ExecuteTransferConfirmation(ctx, entry.Token, h.now())
+	if err != nil {
+		if errors.Is(err, ErrTransferConfirmationStateConflict) || errors.Is(err, ErrTransferConfirmationNotFound) {
+			return true
+		}
+		if executed != nil {
+			entry = executed
+		}
+		h.finishTransferMessage(ctx, entry, userFacingTransferExecutionError(err), transferStateFailed)
+		return true
+	}
+
+	h.finishTransferMessage(ctx, executed, paidMoneyRequestText(executed, visibleUserName(q.From)), transferStateCompleted)
+	if h.audit != nil {
+		h.audit.LogTransfer(ctx, h.auditMemberLabel(ctx, executed.FromUserID), h.auditMemberLabel(ctx, executed.ToUserID), executed.Amount, executed.Fee)
+	}
+	return true
+}
+
+// parseMoneyRequestCommand разбирает `[@username] <сумма> [назначение]`;
+// без @username плательщик берётся из ответа.
+func (h *Handler) parseMoneyRequestCommand(ctx context.Context, message *models.Message, args []string) (*moneyRequest, error) {
+	var (
+		username string
+		amount   int64
+		rest     []string
+	)
+	for i, arg := range args {
+		token := strings.TrimSpace(arg)
+		if token == "" {
+			continue
+		}
+		if username == "" && isUsernameToken(token) {
+			username = normalizeUsernameToken(token)
+			if username == "" {
+				return nil, errTransferMalformed
+			}
+			continue
+		}
+		parsed, err := parsePositiveInteger(token)
+		if err != nil {
+			return nil, err
+		}
+		amount = parsed
+		rest = args[i+1:]
+		break
+	}
+	if amount <= 0 {
+		return nil, errTransferAmountMissing
+	}
+
+	memo, err := parseTransferMemo(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	payer, err := h.resolveTransferTarget(ctx, message, username)
+	if err != nil {
+		return nil, err
+	}
+	if payer.UserID == message.From.ID {
+		return nil, common.ErrSelfTransfer
+	}
+	return &moneyRequest{Amount: amount, Memo: memo, Payer: payer}, nil
+}
+
+func (h *Handler) moneyRequestTTL() time.Duration {
+	if h.requestTTL > 0 {
+		return h.requestTTL
+	}
+	return defaultMoneyRequestTTL
+}
+
+func moneyRequestMarkup(token string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{
+					Text:              "Оплатить",
+					IconCustomEmojiID: confirmEmojiID,
+					Style:             models.ButtonStyleSuccess,
+					CallbackData:      requestCallbackPrefix + token + ":" + requestActionPay,
+				},
+				{
+					Text:              "Отклонить",
+					IconCustomEmojiID: cancelEmojiID,
+					Style:             models.ButtonStyleDanger,
+					CallbackData:      requestCallbackPrefix + token + ":" + requestActionReject,
+				},
+			},
+		},
+	}
+}
+
+func moneyRequestText(entry *transferConfirmation, payer string) string {
+	return fmt.Sprintf("💸 %s запрашивает %d🎞️ у %s%s.\nОплатить можно до %s.",
+		entry.RecipientDisplay, entry.Amount, payer, memoSuffix(entry.Memo), common.FormatDateTime(entry.ExpiresAt))
+}
+
+func paidMoneyRequestText(entry *transferConfirmation, payer string) string {
+	text := fmt.Sprintf("✅ %s оплатил(а) запрос %s на %d🎞️%s.", payer, entry.RecipientDisplay, entry.Amount, memoSuffix(entry.Memo))
+	if entry.Fee > 0 {
+		text += fmt.Sprintf(" Комиссия: %d.", entry.Fee)
+	}
+	return text
+}
+
+func expiredMoneyRequestText(entry *transferConfirmation) string {
+	return fmt.Sprintf("⌛ Запрос %s на %d🎞️ истёк без оплаты.", entry.RecipientDisplay, entry.Amount)
+}
+
+func moneyRequestLine(entry *transferConfirmation, counterpart string) string {
+	return fmt.Sprintf("• %d🎞️ %s%s (до %s)", entry.Amount, counterpart, memoSuffix(entry.Memo), common.FormatDateTime(entry.ExpiresAt))
+}
+
+func closedMoneyRequestAlert(state string) string {
+	switch state {
+	case transferStateCompleted:
+		return "Запрос уже оплачен."
+	case transferStateCanceled:
+		return "Запрос уже закрыт."
+	case transferStateExpired:
+		return "Запрос истёк."
+	case transferStateExecuting:
+		return "Оплата уже обрабатывается."
+	default:
+		return "Запрос уже закрыт."
+	}
+}
+
+func userFacingMoneyRequestError(err error) string {
+	switch {
+	case errors.Is(err, errTransferRecipientMissing):
+		return "❌ Не указан плательщик. Укажите @username или ответьте на сообщение пользователя."
+	case errors.Is(err, errTransferAmountMissing), errors.Is(err, errTransferMalformed):
+		return "❌ Некорректный запрос. Используйте `!запросить @username <сумма> [за что]` или ответьте `!запросить <сумма> [за что]`."
+	case errors.Is(err, errTransferMemoTooLong):
+		return fmt.Sprintf("❌ Назначение длиннее %d символов.", transferMemoMaxRunes)
+	case errors.Is(err, common.ErrSelfTransfer):
+		return "❌ Нельзя запросить плёнки у самого себя."
+	case errors.Is(err, common.ErrTransferTargetIsBot):
+		return "❌ Нельзя запрашивать плёнки у ботов."
+	case errors.Is(err, common.ErrUserNotFound):
+		return "❌ Не удалось найти пользователя по указанному username."
+	case errors.Is(err, common.ErrInvalidAmount):
+		return "❌ Сумма должна быть положительным целым числом больше нуля."
+	default:
+		return "❌ Не удалось создать запрос."
+	}
+}
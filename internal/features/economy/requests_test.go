@@ -0,0 +1,184 @@
+package economy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/mymmrac/telego"
+
+	"serotonyl.ru/telegram-bot/internal/audit"
+	"serotonyl.ru/telegram-bot/internal/commands"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/telegram"
+)
+
+func newRequestTestHandler(svc *fakeEconomyService, tg *fakeEconomyTG) *Handler {
+	return &Handler{
+		service:       svc,
+		memberService: &fakeMemberLookup{member: &members.Member{UserID: 20, Username: "Dora_2270"}},
+		tgOps:         telegram.NewOps(tg),
+		requestTTL:    time.Hour,
+		now:           func() time.Time { return time.Unix(100, 0).UTC() },
+	}
+}
+
+func openRequest() *transferConfirmation {
+	return &transferConfirmation{
+		Token:            "req",
+		ChatID:           -10,
+		MessageID:        42,
+		OwnerUserID:      20,
+		FromUserID:       20,
+		ToUserID:         10,
+		Amount:           100,
+		RecipientDisplay: "@sender",
+		State:            transferStateAwaitSecond,
+		ExpiresAt:        time.Unix(200, 0).UTC(),
+		Kind:             confirmationKindRequest,
+		Memo:             "за пиццу",
+	}
+}
+
+func TestHandleMoneyRequestCommand_CreatesRequestForPayer(t *testing.T) {
+	tg := &fakeEconomyTG{}
+	svc := &fakeEconomyService{}
+	h := newRequestTestHandler(svc, tg)
+	msg := &models.Message{
+		MessageID: 7,
+		Chat:      models.Chat{ID: -10},
+		From:      &models.User{ID: 10, Username: "sender"},
+	}
+
+	h.HandleMoneyRequestCommand(context.Background(), commands.Context{ChatID: -10, UserID: 10, MessageID: 7, Message: msg},
+		[]string{"@Dora_2270", "100", "за", "пиццу"})
+
+	if len(tg.sent) != 1 || !strings.Contains(tg.sent[0].Text, "@sender запрашивает 100🎞️ у @Dora_2270 — за пиццу") {
+		t.Fatalf("unexpected request message: %#v", tg.sent)
+	}
+	if len(svc.confirmations) != 1 {
+		t.Fatalf("expected one stored request, got %d", len(svc.confirmations))
+	}
+	for _, entry := range svc.confirmations {
+		if entry.Kind != confirmationKindRequest || entry.OwnerUserID != 20 || entry.FromUserID != 20 || entry.ToUserID != 10 {
+			t.Fatalf("request parties stored wrong: %+v", entry)
+		}
+		if entry.Memo != "за пиццу" || entry.State != transferStateAwaitSecond || !entry.ExpiresAt.Equal(time.Unix(100, 0).UTC().Add(time.Hour)) {
+			t.Fatalf("request stored wrong: %+v", entry)
+		}
+	}
+}
+
+func TestHandleMoneyRequestCommand_RejectsSelfRequest(t *testing.T) {
+	tg := &fakeEconomyTG{}
+	svc := &fakeEconomyService{}
+	h := newRequestTestHandler(svc, tg)
+	msg := &models.Message{
+		MessageID: 7,
+		Chat:      models.Chat{ID: -10},
+		From:      &models.User{ID: 20, Username: "Dora_2270"},
+	}
+
+	h.HandleMoneyRequestCommand(context.Background(), commands.Context{ChatID: -10, UserID: 20, MessageID: 7, Message: msg},
+		[]string{"@Dora_2270", "100"})
+
+	if len(svc.confirmations) != 0 || len(tg.sent) != 1 || tg.sent[0].Text != "❌ Нельзя запросить плёнки у самого себя." {
+		t.Fatalf("expected self request refusal, sent=%#v", tg.sent)
+	}
+}
+
+func TestHandleEconomyCallback_MoneyRequestPaidOnlyByPayer(t *testing.T) {
+	tg := &fakeEconomyTG{}
+	svc := &fakeEconomyService{balance: 500, confirmations: map[string]*transferConfirmation{"req": openRequest()}}
+	h := newRequestTestHandler(svc, tg)
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 10, requestCallbackPrefix+"req:"+requestActionPay))
+	if svc.transferCalls != 0 || tg.callbackText != "Оплатить запрос может только плательщик." {
+		t.Fatalf("requester must not pay own request: calls=%d alert=%q", svc.transferCalls, tg.callbackText)
+	}
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 20, requestCallbackPrefix+"req:"+requestActionPay))
+	if svc.transferCalls != 1 || svc.lastTransferTo != 10 || svc.lastTransferAmt != 100 {
+		t.Fatalf("expected payment to requester: calls=%d to=%d amount=%d", svc.transferCalls, svc.lastTransferTo, svc.lastTransferAmt)
+	}
+	if len(tg.editedText) != 1 || !strings.Contains(tg.editedText[0].Text, "оплатил(а) запрос @sender на 100🎞️ — за пиццу") {
+		t.Fatalf("unexpected paid text: %#v", tg.editedText)
+	}
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 20, requestCallbackPrefix+"req:"+requestActionPay))
+	if svc.transferCalls != 1 || tg.callbackText != "Запрос уже оплачен." {
+		t.Fatalf("repeat payment must be refused: calls=%d alert=%q", svc.transferCalls, tg.callbackText)
+	}
+}
+
+func TestHandleEconomyCallback_MoneyRequestAuditIncludesFee(t *testing.T) {
+	tg := &fakeEconomyTG{}
+	svc := &fakeEconomyService{balance: 500, feePercent: 5, confirmations: map[string]*transferConfirmation{"req": openRequest()}}
+	h := newRequestTestHandler(svc, tg)
+	h.SetAuditLogger(audit.NewLogger(telegram.NewOps(tg), 999))
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 20, requestCallbackPrefix+"req:"+requestActionPay))
+
+	found := false
+	for _, sent := range tg.sent {
+		if sent.ChatID == 999 && strings.Contains(sent.Text, "(100, fee 5)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected money request audit with fee, sent=%#v", tg.sent)
+	}
+	if len(tg.editedText) != 1 || !strings.Contains(tg.editedText[0].Text, "Комиссия: 5.") {
+		t.Fatalf("expected paid text to show the fee: %#v", tg.editedText)
+	}
+}
+
+func TestHandleEconomyCallback_MoneyRequestStaysOpenWithoutFunds(t *testing.T) {
+	tg := &fakeEconomyTG{}
+	svc := &fakeEconomyService{balance: 50, confirmations: map[string]*transferConfirmation{"req": openRequest()}}
+	h := newRequestTestHandler(svc, tg)
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 20, requestCallbackPrefix+"req:"+requestActionPay))
+
+	if svc.transferCalls != 0 || svc.confirmations["req"].State != transferStateAwaitSecond {
+		t.Fatalf("request must stay open: calls=%d state=%q", svc.transferCalls, svc.confirmations["req"].State)
+	}
+	if tg.callbackText != "Недостаточно плёнок для оплаты." || len(tg.editedText) != 0 {
+		t.Fatalf("unexpected feedback: alert=%q edits=%d", tg.callbackText, len(tg.editedText))
+	}
+}
+
+func TestHandleEconomyCallback_MoneyRequestRejectAndExpiry(t *testing.T) {
+	tg := &fakeEconomyTG{}
+	svc := &fakeEconomyService{confirmations: map[string]*transferConfirmation{"req": openRequest()}}
+	h := newRequestTestHandler(svc, tg)
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 99, requestCallbackPrefix+"req:"+requestActionReject))
+	if tg.callbackText != "Это не ваш запрос." || svc.confirmations["req"].State != transferStateAwaitSecond {
+		t.Fatalf("outsider must not close request: alert=%q", tg.callbackText)
+	}
+
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 10, requestCallbackPrefix+"req:"+requestActionReject))
+	if svc.confirmations["req"].State != transferStateCanceled || len(tg.editedText) != 1 || !strings.Contains(tg.editedText[0].Text, "отозвал(а)") {
+		t.Fatalf("requester should withdraw request: state=%q edits=%#v", svc.confirmations["req"].State, tg.editedText)
+	}
+
+	expired := openRequest()
+	expired.Token = "old"
+	svc.confirmations["old"] = expired
+	h.now = func() time.Time { return time.Unix(300, 0).UTC() }
+	h.HandleEconomyCallback(context.Background(), callback(-10, 42, 20, requestCallbackPrefix+"old:"+requestActionPay))
+	if svc.transferCalls != 0 || svc.confirmations["old"].State != transferStateExpired || tg.callbackText != "Запрос истёк." {
+		t.Fatalf("expired request must not be paid: calls=%d state=%q", svc.transferCalls, svc.confirmations["old"].State)
+	}
+}
+
+func TestTransferDescription_CarriesRequestMemo(t *testing.T) {
+	if got := transferDescription(&transferConfirmation{Amount: 5}); got != "Перевод 5 плёнок" {
+		t.Fatalf("plain transfer description = %q", got)
+	}
+	if got := transferDescription(openRequest()); got != "Оплата запроса 100 плёнок: за пиццу" {
+		t.Fatalf("request description = %q", got)
+	}
+}
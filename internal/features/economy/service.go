@@ -4,6 +4,7 @@ package economy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,12 +12,15 @@ import (
 	"github.com/jackc/pgx/v5"
 	log "github.com/sirupsen/logrus"
 
+	"serotonyl.ru/telegram-bot/internal/audit"
 	"serotonyl.ru/telegram-bot/internal/common"
 )
 
 // Service управляет экономикой бота (пленки).
 type Service struct {
-	repo *Repository // Репозиторий для работы с БД
+	repo    *Repository // Репозиторий для работы с БД
+	audit   *audit.Logger
+	members audit.MemberLookup
 }
 
 // NewService создаёт новый сервис экономики.
@@ -24,6 +28,62 @@ func NewService(repo *Repository) *Service {
 	return &Service{repo: repo}
 }
 
+// SetAuditLogger подключает аудит сверки журнала; members нужен для подписей
+// пользователей в сообщениях.
+func (s *Service) SetAuditLogger(logger *audit.Logger, members audit.MemberLookup) {
+	s.audit = logger
+	s.members = members
+}
+
+// SetTransferPolicy задаёт комиссию и ограничения для переводов между
+// участниками.
+func (s *Service) SetTransferPolicy(policy TransferPolicy) {
+	s.repo.SetTransferPolicy(policy)
+}
+
+// TransferFee возвращает комиссию, которую спишут сверх перевода amount плёнок.
+func (s *Service) TransferFee(amount int64) int64 {
+	return s.repo.policy.Fee(amount)
+}
+
+// FindLedgerMismatches возвращает пользователей, чей баланс или итоги
+// расходятся с журналом транзакций.
+func (s *Service) FindLedgerMismatches(ctx context.Context) ([]LedgerMismatch, error) {
+	return s.repo.FindLedgerMismatches(ctx)
+}
+
+// CorrectLedger записывает корректирующие проводки по всем расхождениям и
+// возвращает исправленные строки.
+func (s *Service) CorrectLedger(ctx context.Context) ([]LedgerMismatch, error) {
+	return s.repo.CorrectLedger(ctx)
+}
+
+// ReconcileLedger — ежедневная сверка: находит расхождения и отправляет их
+// в аудит. Ничего не исправляет — это решение админа.
+func (s *Service) ReconcileLedger(ctx context.Context) (int, error) {
+	mismatches, err := s.repo.FindLedgerMismatches(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(mismatches) > 0 {
+		s.audit.LogLedgerMismatches(ctx, LedgerDrifts(ctx, s.audit, s.members, mismatches))
+	}
+	return len(mismatches), nil
+}
+
+// LedgerDrifts переводит расхождения в строки аудита с подписями участников.
+func LedgerDrifts(ctx context.Context, logger *audit.Logger, members audit.MemberLookup, mismatches []LedgerMismatch) []audit.LedgerDrift {
+	drifts := make([]audit.LedgerDrift, 0, len(mismatches))
+	for _, m := range mismatches {
+		drifts = append(drifts, audit.LedgerDrift{
+			TargetLabel: logger.ResolveMemberLabel(ctx, members, m.UserID),
+			Balance:     m.Balance,
+			Ledger:      m.LedgerBalance(),
+		})
+	}
+	return drifts
+}
+
 // GetBalance возвращает текущий баланс пользователя.
 func (s *Service) GetBalance(ctx context.Context, userID int64) (int64, error) {
 	return s.repo.GetBalance(ctx, userID)
@@ -65,12 +125,30 @@ func (s *Service) DeductBalance(ctx context.Context, userID int64, amount int64,
 	return s.repo.DeductBalance(ctx, userID, amount, txType, description)
 }
 
+// DeductBalanceTx списывает пленки внутри внешней транзакции.
+// Нехватка средств возвращается как common.ErrInsufficientBalance.
+func (s *Service) DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+	if amount <= 0 {
+		return common.ErrInvalidAmount
+	}
+	if err := s.repo.DeductBalanceTx(ctx, tx, userID, amount, txType, description); err != nil {
+		if errors.Is(err, ErrInsufficientFunds) {
+			return common.ErrInsufficientBalance
+		}
+		return err
+	}
+	return nil
+}
+
 // Transfer переводит пленки от одного пользователя к другому.
 // Выполняет все необходимые проверки:
 //   - Нельзя переводить себе
 //   - Сумма должна быть положительной
-//   - У отправителя должно быть достаточно пленок
-func (s *Service) Transfer(ctx context.Context, fromUserID, toUserID, amount int64) error {
+//   - У отправителя должно быть достаточно пленок (с учётом комиссии)
+//   - Перевод проходит политику переводов (TransferPolicy)
+//
+// memo — необязательное назначение, попадает в описание транзакции.
+func (s *Service) Transfer(ctx context.Context, fromUserID, toUserID, amount int64, memo string) error {
 	// Проверка: нельзя отправить себе
 	if fromUserID == toUserID {
 		return common.ErrSelfTransfer
@@ -82,7 +160,7 @@ func (s *Service) Transfer(ctx context.Context, fromUserID, toUserID, amount int
 	}
 
 	// Выполняем перевод (проверка баланса внутри репозитория)
-	err := s.repo.Transfer(ctx, fromUserID, toUserID, amount)
+	err := s.repo.Transfer(ctx, fromUserID, toUserID, amount, memo)
 	if err != nil {
 		// Если ошибка содержит "недостаточно" — это нехватка пленок
 		if strings.Contains(err.Error(), "недостаточно") {
@@ -120,60 +198,39 @@ func (s *Service) ExecuteTransferConfirmation(ctx context.Context, token string,
 	return s.repo.ExecuteTransferConfirmation(ctx, token, now)
 }
 
-// GetTransactionHistory возвращает форматированную историю транзакций.
-// Последние 10 транзакций. Если больше 5 — оборачивает в спойлер.
-func (s *Service) GetTransactionHistory(ctx context.Context, userID int64) (string, error) {
-	transactions, err := s.repo.GetTransactions(ctx, userID, 10)
-	if err != nil {
-		return "", err
-	}
-
-	if len(transactions) == 0 {
-		return "📋 У вас пока нет транзакций", nil
-	}
-
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "📋 Последние %d транзакций:\n\n", len(transactions))
-
-	// Формируем строки транзакций
-	var lines []string
-	for i, tx := range transactions {
-		// Определяем знак: + если получили, - если отправили
-		sign := "+"
-		if tx.FromUserID != nil && *tx.FromUserID == userID {
-			sign = "-"
-		}
+// ListOpenMoneyRequests возвращает открытые запросы плёнок пользователя —
+// и выставленные ему, и выставленные им.
+func (s *Service) ListOpenMoneyRequests(ctx context.Context, userID int64) ([]*transferConfirmation, error) {
+	return s.repo.ListOpenMoneyRequests(ctx, userID, time.Now().UTC())
+}
 
-		line := fmt.Sprintf("%d. %s | %s%d%s | %s",
-			i+1,
-			common.FormatDateTime(tx.CreatedAt),
-			sign,
-			tx.Amount,
-			common.PluralizeFilms(tx.Amount),
-			tx.Description,
-		)
-		lines = append(lines, line)
+// ExpireMoneyRequests закрывает неоплаченные просроченные запросы и правит
+// их сообщения через editFunc. Возвращает число закрытых запросов.
+func (s *Service) ExpireMoneyRequests(ctx context.Context, editFunc func(ctx context.Context, chatID int64, messageID int, text string) error) (int, error) {
+	expired, err := s.repo.ExpireMoneyRequests(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, err
 	}
-
-	// Если больше 5 — оборачиваем в спойлер (||текст||)
-	if len(lines) > 5 {
-		// Первые 5 показываем открыто
-		for _, line := range lines[:5] {
-			sb.WriteString(line + "\n")
-		}
-		// Остальные в спойлере
-		sb.WriteString("\n||")
-		for _, line := range lines[5:] {
-			sb.WriteString(line + "\n")
+	for _, entry := range expired {
+		if editFunc == nil {
+			break
 		}
-		sb.WriteString("||")
-	} else {
-		for _, line := range lines {
-			sb.WriteString(line + "\n")
+		if err := editFunc(ctx, entry.ChatID, entry.MessageID, expiredMoneyRequestText(entry)); err != nil {
+			log.WithError(err).WithField("token", entry.Token).Warn("money request expiry edit failed")
 		}
 	}
+	return len(expired), nil
+}
 
-	return sb.String(), nil
+// GetTransactionHistoryPage возвращает страницу истории транзакций по
+// фильтру. Период отсекается запросом GetTransactionsByPeriod, направление
+// и вид операции — здесь же.
+func (s *Service) GetTransactionHistoryPage(ctx context.Context, userID int64, filter HistoryFilter, page int, now time.Time) (*HistoryPage, error) {
+	transactions, err := s.repo.GetTransactionsByPeriod(ctx, userID, filter.Period.Since(now))
+	if err != nil {
+		return nil, fmt.Errorf("get transactions by period: %w", err)
+	}
+	return paginateHistory(filterHistory(transactions, userID, filter), filter, page), nil
 }
 
 // CreateBalance создаёт начальный баланс для нового участника (0 пленок).
@@ -65,19 +65,27 @@ func (h *Handler) HandleThanksCommand(ctx context.Context, c commands.Context, a
 		return
 	}
 
-	if err := h.service.GiveThanks(ctx, c.UserID, targetUserID); err != nil {
+	applied, err := h.service.GiveThanks(ctx, c.UserID, targetUserID, ThanksOperationKey(c.ChatID, c.MessageID))
+	if err != nil {
 		h.sendMessage(ctx, c.ChatID, userFacingThanksError(err), c.MessageID)
 		return
 	}
+	if !applied {
+		return
+	}
 
 	h.sendThanksSuccessMessage(ctx, c.ChatID, c.MessageID, c.UserID, cleanUserLabel(visibleUserName(*c.Message.From)), targetUserID, cleanUserLabel(targetDisplay))
 }
 
-func (h *Handler) HandleThankYou(ctx context.Context, chatID int64, fromUserID, toUserID int64) {
-	if err := h.service.GiveThanks(ctx, fromUserID, toUserID); err != nil {
+func (h *Handler) HandleThankYou(ctx context.Context, chatID int64, messageID int, fromUserID, toUserID int64) {
+	applied, err := h.service.GiveThanks(ctx, fromUserID, toUserID, ThanksOperationKey(chatID, messageID))
+	if err != nil {
 		log.WithError(err).Debug("thanks not granted")
 		return
 	}
+	if !applied {
+		return
+	}
 
 	h.sendThanksSuccessMessage(
 		ctx,
@@ -8,6 +8,10 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+type karmaDBTX interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type Repository struct {
 	db *pgxpool.Pool
 }
@@ -27,17 +31,25 @@ func (r *Repository) Create(ctx context.Context, userID int64) error {
 }
 
 func (r *Repository) CountSentSince(ctx context.Context, fromUserID int64, since time.Time) (int, error) {
+	return r.countSentSince(ctx, r.db, fromUserID, since)
+}
+
+func (r *Repository) CountSentSinceTx(ctx context.Context, tx pgx.Tx, fromUserID int64, since time.Time) (int, error) {
+	return r.countSentSince(ctx, tx, fromUserID, since)
+}
+
+func (r *Repository) countSentSince(ctx context.Context, db karmaDBTX, fromUserID int64, since time.Time) (int, error) {
 	const query = `
 		SELECT COUNT(*)
 		FROM karma_logs
 		WHERE from_user_id = $1 AND created_at >= $2
 	`
 	var count int
-	err := r.db.QueryRow(ctx, query, fromUserID, since).Scan(&count)
+	err := db.QueryRow(ctx, query, fromUserID, since).Scan(&count)
 	return count, err
 }
 
-func (r *Repository) HasReciprocalSince(ctx context.Context, fromUserID, toUserID int64, since time.Time) (bool, error) {
+func (r *Repository) HasReciprocalSinceTx(ctx context.Context, tx pgx.Tx, fromUserID, toUserID int64, since time.Time) (bool, error) {
 	const query = `
 		SELECT EXISTS (
 			SELECT 1
@@ -46,7 +58,7 @@ func (r *Repository) HasReciprocalSince(ctx context.Context, fromUserID, toUserI
 		)
 	`
 	var exists bool
-	err := r.db.QueryRow(ctx, query, fromUserID, toUserID, since).Scan(&exists)
+	err := tx.QueryRow(ctx, query, fromUserID, toUserID, since).Scan(&exists)
 	return exists, err
 }
 
@@ -21,13 +21,14 @@ type memberLookup interface {
 type thanksRepository interface {
 	Create(ctx context.Context, userID int64) error
 	CountSentSince(ctx context.Context, fromUserID int64, since time.Time) (int, error)
-	HasReciprocalSince(ctx context.Context, fromUserID, toUserID int64, since time.Time) (bool, error)
+	CountSentSinceTx(ctx context.Context, tx pgx.Tx, fromUserID int64, since time.Time) (int, error)
+	HasReciprocalSinceTx(ctx context.Context, tx pgx.Tx, fromUserID, toUserID int64, since time.Time) (bool, error)
 	LogThanksTx(ctx context.Context, tx pgx.Tx, fromUserID, toUserID, rewardAmount int64) error
 	GetStats(ctx context.Context, userID int64) (*ThanksStats, error)
 }
 
 type balanceRewarder interface {
-	AddBalanceWithHook(ctx context.Context, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) error
+	AddBalanceOnceWithHook(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) (bool, error)
 }
 
 type Service struct {
@@ -56,41 +57,52 @@ func NewService(repo *Repository, economyService *economy.Service, membersServic
 	}
 }
 
-func (s *Service) GiveThanks(ctx context.Context, fromUserID, toUserID int64) error {
+// GiveThanks начисляет награду за «спасибо». operationKey — ключ сообщения
+// (см. ThanksOperationKey): переотправленный апдейт возвращает applied=false
+// без повторного начисления и записи в журнал благодарностей. Лимиты
+// проверяются в транзакции начисления уже после ключа, поэтому повтор
+// последней за день благодарности не упирается в дневной лимит.
+func (s *Service) GiveThanks(ctx context.Context, fromUserID, toUserID int64, operationKey string) (applied bool, err error) {
 	if fromUserID == toUserID {
-		return common.ErrThanksSelfGive
+		return false, common.ErrThanksSelfGive
 	}
 
 	target, err := s.members.GetByUserID(ctx, toUserID)
 	if err != nil || target == nil {
-		return common.ErrUserNotFound
+		return false, common.ErrUserNotFound
 	}
 	if target.IsBot {
-		return common.ErrThanksTargetIsBot
+		return false, common.ErrThanksTargetIsBot
 	}
 
-	sentToday, err := s.repo.CountSentSince(ctx, fromUserID, s.dayStart(s.now()))
-	if err != nil {
-		return err
-	}
-	if sentToday >= s.dailyLimit() {
-		return common.ErrThanksDailyLimit
-	}
+	now := s.now()
+	description := fmt.Sprintf("Спасибо от %d", fromUserID)
+	return s.economy.AddBalanceOnceWithHook(ctx, operationKey, toUserID, ThanksReward, thanksRewardTxType, description, func(ctx context.Context, tx pgx.Tx) error {
+		sentToday, err := s.repo.CountSentSinceTx(ctx, tx, fromUserID, s.dayStart(now))
+		if err != nil {
+			return err
+		}
+		if sentToday >= s.dailyLimit() {
+			return common.ErrThanksDailyLimit
+		}
 
-	reciprocalBlocked, err := s.repo.HasReciprocalSince(ctx, toUserID, fromUserID, s.now().Add(-ThanksReciprocalCooldown))
-	if err != nil {
-		return err
-	}
-	if reciprocalBlocked {
-		return common.ErrThanksReciprocalCooldown
-	}
+		reciprocalBlocked, err := s.repo.HasReciprocalSinceTx(ctx, tx, toUserID, fromUserID, now.Add(-ThanksReciprocalCooldown))
+		if err != nil {
+			return err
+		}
+		if reciprocalBlocked {
+			return common.ErrThanksReciprocalCooldown
+		}
 
-	description := fmt.Sprintf("Спасибо от %d", fromUserID)
-	return s.economy.AddBalanceWithHook(ctx, toUserID, ThanksReward, thanksRewardTxType, description, func(ctx context.Context, tx pgx.Tx) error {
 		return s.repo.LogThanksTx(ctx, tx, fromUserID, toUserID, ThanksReward)
 	})
 }
 
+// ThanksOperationKey — ключ идемпотентности благодарности из сообщения.
+func ThanksOperationKey(chatID int64, messageID int) string {
+	return economy.OperationKey("thanks", chatID, messageID)
+}
+
 func (s *Service) GetThanksStats(ctx context.Context, userID int64) (*ThanksStats, error) {
 	return s.repo.GetStats(ctx, userID)
 }
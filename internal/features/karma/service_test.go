@@ -30,10 +30,14 @@ func (f *fakeThanksRepo) CountSentSince(_ context.Context, _ int64, since time.T
 	f.lastSince = since
 	return f.sentCount, nil
 }
-func (f *fakeThanksRepo) HasReciprocalSince(context.Context, int64, int64, time.Time) (bool, error) {
+func (f *fakeThanksRepo) CountSentSinceTx(ctx context.Context, _ pgx.Tx, fromUserID int64, since time.Time) (int, error) {
+	return f.CountSentSince(ctx, fromUserID, since)
+}
+func (f *fakeThanksRepo) HasReciprocalSinceTx(context.Context, pgx.Tx, int64, int64, time.Time) (bool, error) {
 	return f.reciprocalBlocked, nil
 }
 func (f *fakeThanksRepo) LogThanksTx(_ context.Context, _ pgx.Tx, fromUserID, toUserID, rewardAmount int64) error {
+	f.sentCount++
 	f.logged.from = fromUserID
 	f.logged.to = toUserID
 	f.logged.reward = rewardAmount
@@ -47,24 +51,36 @@ func (f *fakeThanksRepo) GetStats(context.Context, int64) (*ThanksStats, error)
 }
 
 type fakeRewarder struct {
-	called bool
-	userID int64
-	amount int64
-	txType string
-	desc   string
+	called  bool
+	userID  int64
+	amount  int64
+	txType  string
+	desc    string
+	applied map[string]bool
+	hooks   int
 }
 
-func (f *fakeRewarder) AddBalanceWithHook(ctx context.Context, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) error {
+func (f *fakeRewarder) AddBalanceOnceWithHook(ctx context.Context, operationKey string, userID int64, amount int64, txType, description string, hook func(context.Context, pgx.Tx) error) (bool, error) {
 	f.called = true
+	if f.applied[operationKey] {
+		return false, nil
+	}
+	if hook != nil {
+		var tx pgx.Tx
+		if err := hook(ctx, tx); err != nil {
+			return false, err
+		}
+		f.hooks++
+	}
+	if f.applied == nil {
+		f.applied = make(map[string]bool)
+	}
+	f.applied[operationKey] = true
 	f.userID = userID
 	f.amount = amount
 	f.txType = txType
 	f.desc = description
-	if hook != nil {
-		var tx pgx.Tx
-		return hook(ctx, tx)
-	}
-	return nil
+	return true, nil
 }
 
 type fakeMemberLookup struct {
@@ -90,8 +106,8 @@ func TestServiceGiveThanksSuccess(t *testing.T) {
 		now:     func() time.Time { return time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC) },
 	}
 
-	if err := service.GiveThanks(context.Background(), 1, 2); err != nil {
-		t.Fatalf("GiveThanks() error = %v", err)
+	if applied, err := service.GiveThanks(context.Background(), 1, 2, ThanksOperationKey(-100, 10)); err != nil || !applied {
+		t.Fatalf("GiveThanks() applied = %v, error = %v", applied, err)
 	}
 	if !rewarder.called {
 		t.Fatal("expected rewarder to be called")
@@ -113,7 +129,7 @@ func TestServiceGiveThanksDailyLimit(t *testing.T) {
 		now:     func() time.Time { return time.Now().UTC() },
 	}
 
-	err := service.GiveThanks(context.Background(), 1, 2)
+	_, err := service.GiveThanks(context.Background(), 1, 2, ThanksOperationKey(-100, 10))
 	if !errors.Is(err, common.ErrThanksDailyLimit) {
 		t.Fatalf("expected ErrThanksDailyLimit, got %v", err)
 	}
@@ -128,7 +144,7 @@ func TestServiceGiveThanksReciprocalCooldown(t *testing.T) {
 		now:     func() time.Time { return time.Now().UTC() },
 	}
 
-	err := service.GiveThanks(context.Background(), 1, 2)
+	_, err := service.GiveThanks(context.Background(), 1, 2, ThanksOperationKey(-100, 10))
 	if !errors.Is(err, common.ErrThanksReciprocalCooldown) {
 		t.Fatalf("expected ErrThanksReciprocalCooldown, got %v", err)
 	}
@@ -143,7 +159,7 @@ func TestServiceGiveThanksRejectsBot(t *testing.T) {
 		now:     func() time.Time { return time.Now().UTC() },
 	}
 
-	err := service.GiveThanks(context.Background(), 1, 2)
+	_, err := service.GiveThanks(context.Background(), 1, 2, ThanksOperationKey(-100, 10))
 	if !errors.Is(err, common.ErrThanksTargetIsBot) {
 		t.Fatalf("expected ErrThanksTargetIsBot, got %v", err)
 	}
@@ -174,3 +190,61 @@ func TestGetThanksDailyStatusUsesConfiguredTimezone(t *testing.T) {
 		t.Fatalf("expected start of day, got %v", repo.lastSince)
 	}
 }
+
+func TestServiceGiveThanksRedeliveredMessageIsNoop(t *testing.T) {
+	repo := &fakeThanksRepo{}
+	rewarder := &fakeRewarder{}
+	service := &Service{
+		repo:    repo,
+		cfg:     &config.Config{ThanksDailyLimit: 3},
+		economy: rewarder,
+		members: fakeMemberLookup{byID: map[int64]*members.Member{2: {UserID: 2}}},
+		now:     func() time.Time { return time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC) },
+	}
+
+	key := ThanksOperationKey(-100, 10)
+	if applied, err := service.GiveThanks(context.Background(), 1, 2, key); err != nil || !applied {
+		t.Fatalf("first GiveThanks() applied = %v, error = %v", applied, err)
+	}
+	applied, err := service.GiveThanks(context.Background(), 1, 2, key)
+	if err != nil {
+		t.Fatalf("redelivered GiveThanks() error = %v", err)
+	}
+	if applied {
+		t.Fatal("redelivered thanks must not be applied twice")
+	}
+	if rewarder.hooks != 1 {
+		t.Fatalf("expected thanks log written once, got %d", rewarder.hooks)
+	}
+}
+
+func TestServiceGiveThanksRedeliveredLastThanksOfDay(t *testing.T) {
+	repo := &fakeThanksRepo{sentCount: 2}
+	rewarder := &fakeRewarder{}
+	service := &Service{
+		repo:    repo,
+		cfg:     &config.Config{ThanksDailyLimit: 3},
+		economy: rewarder,
+		members: fakeMemberLookup{byID: map[int64]*members.Member{2: {UserID: 2}}},
+		now:     func() time.Time { return time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC) },
+	}
+
+	key := ThanksOperationKey(-100, 10)
+	if applied, err := service.GiveThanks(context.Background(), 1, 2, key); err != nil || !applied {
+		t.Fatalf("last GiveThanks() of the day applied = %v, error = %v", applied, err)
+	}
+	applied, err := service.GiveThanks(context.Background(), 1, 2, key)
+	if err != nil {
+		t.Fatalf("redelivered last GiveThanks() error = %v, want nil", err)
+	}
+	if applied {
+		t.Fatal("redelivered thanks must not be applied twice")
+	}
+	if repo.sentCount != 3 || rewarder.hooks != 1 {
+		t.Fatalf("expected one thanks logged, sent=%d hooks=%d", repo.sentCount, rewarder.hooks)
+	}
+
+	if _, err := service.GiveThanks(context.Background(), 1, 2, ThanksOperationKey(-100, 11)); !errors.Is(err, common.ErrThanksDailyLimit) {
+		t.Fatalf("new thanks after the limit: expected ErrThanksDailyLimit, got %v", err)
+	}
+}
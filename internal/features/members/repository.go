@@ -171,6 +171,14 @@ func (r *Repository) UpdateMemberTag(ctx context.Context, userID int64, tag *str
 	return nil
 }
 
+// UpdateMemberTagTx — UpdateMemberTag внутри внешней транзакции.
+func (r *Repository) UpdateMemberTagTx(ctx context.Context, tx pgx.Tx, userID int64, tag *string, updatedAt time.Time) error {
+	if _, err := tx.Exec(ctx, updateMemberTagQuery(), userID, tag, updatedAt.UTC(), StatusActive); err != nil {
+		return fmt.Errorf("ошибка обновления tag участника: %w", err)
+	}
+	return nil
+}
+
 // PurgeExpiredLeftMembers удаляет пользователей со статусом left, у которых истёк delete_after.
 // Удаление выполняется транзакционно и включает связанные записи из доменных таблиц.
 func (r *Repository) PurgeExpiredLeftMembers(ctx context.Context, now time.Time, limit int) (int, error) {
@@ -363,6 +371,15 @@ func (r *Repository) UpdateRole(ctx context.Context, userID int64, role string)
 	return nil
 }
 
+// UpdateRoleTx — UpdateRole внутри внешней транзакции.
+func (r *Repository) UpdateRoleTx(ctx context.Context, tx pgx.Tx, userID int64, role string) error {
+	query := `UPDATE members SET role = $2, updated_at = NOW() WHERE user_id = $1 AND status = $3`
+	if _, err := tx.Exec(ctx, query, userID, role, StatusActive); err != nil {
+		return fmt.Errorf("ошибка обновления роли: %w", err)
+	}
+	return nil
+}
+
 func (r *Repository) UpdateAdminFlag(ctx context.Context, userID int64, isAdmin bool) error {
 	query := `UPDATE members SET is_admin = $2, updated_at = NOW() WHERE user_id = $1 AND status = $3`
 	if _, err := r.db.Exec(ctx, query, userID, isAdmin, StatusActive); err != nil {
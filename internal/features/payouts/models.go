@@ -0,0 +1,198 @@
+// Package payouts реализует регулярные выплаты: зарплаты ролям, пособия
+// выбранным участникам или всем активным по cron-расписанию.
+// models.go описывает правила выплат, разбор правила из админки и расчёт периодов.
+package payouts
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	TargetRole   = "role"   // Все активные участники с ролью
+	TargetUsers  = "users"  // Явный список участников
+	TargetActive = "active" // Все активные участники
+
+	// MinInterval — правило не может платить чаще раза в час.
+	MinInterval    = time.Hour
+	maxTitleLength = 64
+	maxTargetUsers = 50
+	// maxCatchUpPeriods — сколько пропущенных периодов просматривать при
+	// поиске последнего. Пропущенные периоды не доплачиваются: выплата
+	// одна, за последний наступивший.
+	maxCatchUpPeriods = 10000
+)
+
+var (
+	// ErrRuleNotFound — правила нет.
+	ErrRuleNotFound = errors.New("payout rule not found")
+	// ErrInvalidRule — правило из админки не прошло проверку.
+	ErrInvalidRule = errors.New("invalid payout rule")
+)
+
+// Rule — правило регулярной выплаты (таблица payout_rules).
+type Rule struct {
+	ID            int64
+	Title         string
+	TargetKind    string
+	TargetRole    string
+	TargetUserIDs []int64
+	Amount        int64
+	Schedule      string // Cron-выражение из 5 полей в часовом поясе бота
+	Active        bool
+	CreatedBy     int64
+	LastPeriodAt  *time.Time // Последний выплаченный период (UTC)
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// TargetRefs — @username или user_id из админки до разрешения в TargetUserIDs.
+	TargetRefs []string
+}
+
+// Run — итог выплаты правила за период.
+type Run struct {
+	Rule       *Rule
+	PeriodAt   time.Time
+	Recipients int
+	Total      int64
+}
+
+// ParseRule разбирает правило из строки админки:
+// «получатели | сумма | расписание | название». Получатели — «все»,
+// «роль <название>» или список @username / user_id через пробел.
+func ParseRule(text string) (*Rule, error) {
+	parts := strings.Split(text, "|")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("%w: ожидается 4 поля через |", ErrInvalidRule)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	rule := &Rule{Title: parts[3], Schedule: strings.Join(strings.Fields(parts[2]), " "), Active: true}
+	if err := parseTarget(rule, parts[0]); err != nil {
+		return nil, err
+	}
+	amount, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: сумма должна быть числом", ErrInvalidRule)
+	}
+	rule.Amount = amount
+	if err := ValidateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func parseTarget(rule *Rule, raw string) error {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: не указаны получатели", ErrInvalidRule)
+	}
+	switch strings.ToLower(fields[0]) {
+	case "все":
+		if len(fields) != 1 {
+			return fmt.Errorf("%w: «все» пишется без уточнений", ErrInvalidRule)
+		}
+		rule.TargetKind = TargetActive
+	case "роль":
+		rule.TargetKind = TargetRole
+		rule.TargetRole = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), fields[0]))
+	default:
+		rule.TargetKind = TargetUsers
+		rule.TargetRefs = fields
+	}
+	return nil
+}
+
+// ValidateRule проверяет правило перед сохранением. Список участников
+// проверяется до разрешения ссылок: по TargetRefs или TargetUserIDs.
+func ValidateRule(rule *Rule) error {
+	users := len(rule.TargetUserIDs)
+	if users == 0 {
+		users = len(rule.TargetRefs)
+	}
+	switch {
+	case rule.Title == "" || utf8.RuneCountInString(rule.Title) > maxTitleLength:
+		return fmt.Errorf("%w: название от 1 до %d символов", ErrInvalidRule, maxTitleLength)
+	case rule.Amount <= 0:
+		return fmt.Errorf("%w: сумма должна быть больше нуля", ErrInvalidRule)
+	case rule.TargetKind == TargetRole && rule.TargetRole == "":
+		return fmt.Errorf("%w: укажите название роли", ErrInvalidRule)
+	case rule.TargetKind == TargetUsers && (users == 0 || users > maxTargetUsers):
+		return fmt.Errorf("%w: список получателей от 1 до %d участников", ErrInvalidRule, maxTargetUsers)
+	case rule.TargetKind != TargetRole && rule.TargetKind != TargetUsers && rule.TargetKind != TargetActive:
+		return fmt.Errorf("%w: неизвестные получатели", ErrInvalidRule)
+	}
+	schedule, err := ParseSchedule(rule.Schedule)
+	if err != nil {
+		return err
+	}
+	return checkInterval(schedule, time.Now())
+}
+
+// scheduleCheckSpan — на сколько вперёд проверяются запуски расписания:
+// четыре года с запасом захватывают и 29 февраля.
+const scheduleCheckSpan = 4 * 366 * 24 * time.Hour
+
+// checkInterval проходит запуски расписания за scheduleCheckSpan от from и
+// отказывает, если любые два соседних ближе MinInterval. Двух первых
+// запусков мало: у «0,30 9 * * *» их разница зависит от времени сохранения.
+func checkInterval(schedule cron.Schedule, from time.Time) error {
+	end := from.Add(scheduleCheckSpan)
+	prev := schedule.Next(from)
+	for !prev.IsZero() && prev.Before(end) {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(prev) < MinInterval {
+			return fmt.Errorf("%w: расписание не чаще раза в час", ErrInvalidRule)
+		}
+		prev = next
+	}
+	return nil
+}
+
+// ParseSchedule разбирает cron-выражение из 5 полей.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil || strings.HasPrefix(strings.TrimSpace(spec), "@") || strings.HasPrefix(strings.TrimSpace(spec), "TZ=") {
+		return nil, fmt.Errorf("%w: расписание — cron из 5 полей, например «0 12 * * 5»", ErrInvalidRule)
+	}
+	return schedule, nil
+}
+
+// DuePeriod возвращает последний наступивший период расписания после
+// anchor (не позже now). Время расписания считается в loc.
+func DuePeriod(schedule cron.Schedule, anchor, now time.Time, loc *time.Location) (time.Time, bool) {
+	next := schedule.Next(anchor.In(loc))
+	if next.After(now) {
+		return time.Time{}, false
+	}
+	for i := 0; i < maxCatchUpPeriods; i++ {
+		after := schedule.Next(next)
+		if after.After(now) {
+			break
+		}
+		next = after
+	}
+	return next, true
+}
+
+// TargetLabel — получатели правила для админки и аудита.
+func TargetLabel(rule *Rule) string {
+	switch rule.TargetKind {
+	case TargetRole:
+		return fmt.Sprintf("роль «%s»", rule.TargetRole)
+	case TargetUsers:
+		return fmt.Sprintf("участники (%d)", len(rule.TargetUserIDs))
+	default:
+		return "все активные"
+	}
+}
@@ -0,0 +1,128 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const ruleColumns = `
+	id, title, target_kind, target_role, target_user_ids, amount, schedule,
+	active, created_by, last_period_at, created_at, updated_at
+`
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// ListRules возвращает правила выплат; выключенные — только если includeInactive.
+func (r *Repository) ListRules(ctx context.Context, includeInactive bool) ([]*Rule, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+ruleColumns+`
+		FROM payout_rules
+		WHERE active OR $1
+		ORDER BY active DESC, id
+	`, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("list payout rules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan payout rule: %w", err)
+		}
+		out = append(out, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate payout rules: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repository) GetRule(ctx context.Context, ruleID int64) (*Rule, error) {
+	rule, err := scanRule(r.db.QueryRow(ctx, `SELECT `+ruleColumns+` FROM payout_rules WHERE id = $1`, ruleID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get payout rule %d: %w", ruleID, err)
+	}
+	return rule, nil
+}
+
+func (r *Repository) CreateRule(ctx context.Context, rule *Rule) (*Rule, error) {
+	userIDs := rule.TargetUserIDs
+	if userIDs == nil {
+		userIDs = []int64{}
+	}
+	created, err := scanRule(r.db.QueryRow(ctx, `
+		INSERT INTO payout_rules (title, target_kind, target_role, target_user_ids, amount, schedule, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+ruleColumns,
+		rule.Title, rule.TargetKind, rule.TargetRole, userIDs, rule.Amount, rule.Schedule, rule.Active, rule.CreatedBy))
+	if err != nil {
+		return nil, fmt.Errorf("create payout rule: %w", err)
+	}
+	return created, nil
+}
+
+// SetRuleActive включает или выключает правило.
+func (r *Repository) SetRuleActive(ctx context.Context, ruleID int64, active bool) (*Rule, error) {
+	rule, err := scanRule(r.db.QueryRow(ctx, `
+		UPDATE payout_rules
+		SET active = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+ruleColumns, ruleID, active))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update payout rule %d: %w", ruleID, err)
+	}
+	return rule, nil
+}
+
+// CreateRunTx записывает выплату за период. false — период уже выплачен.
+func (r *Repository) CreateRunTx(ctx context.Context, tx pgx.Tx, ruleID int64, periodAt time.Time, recipients int, total int64) (bool, error) {
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO payout_runs (rule_id, period_at, recipients, total)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (rule_id, period_at) DO NOTHING
+	`, ruleID, periodAt.UTC(), recipients, total)
+	if err != nil {
+		return false, fmt.Errorf("create payout run: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// MarkPeriodTx сдвигает последний выплаченный период правила.
+func (r *Repository) MarkPeriodTx(ctx context.Context, tx pgx.Tx, ruleID int64, periodAt time.Time) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE payout_rules
+		SET last_period_at = $2, updated_at = NOW()
+		WHERE id = $1 AND (last_period_at IS NULL OR last_period_at < $2)
+	`, ruleID, periodAt.UTC()); err != nil {
+		return fmt.Errorf("mark payout period: %w", err)
+	}
+	return nil
+}
+
+func scanRule(row pgx.Row) (*Rule, error) {
+	var rule Rule
+	if err := row.Scan(&rule.ID, &rule.Title, &rule.TargetKind, &rule.TargetRole, &rule.TargetUserIDs, &rule.Amount,
+		&rule.Schedule, &rule.Active, &rule.CreatedBy, &rule.LastPeriodAt, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
@@ -0,0 +1,237 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/audit"
+	"serotonyl.ru/telegram-bot/internal/config"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+)
+
+type payoutRepository interface {
+	ListRules(ctx context.Context, includeInactive bool) ([]*Rule, error)
+	GetRule(ctx context.Context, ruleID int64) (*Rule, error)
+	CreateRule(ctx context.Context, rule *Rule) (*Rule, error)
+	SetRuleActive(ctx context.Context, ruleID int64, active bool) (*Rule, error)
+	CreateRunTx(ctx context.Context, tx pgx.Tx, ruleID int64, periodAt time.Time, recipients int, total int64) (bool, error)
+	MarkPeriodTx(ctx context.Context, tx pgx.Tx, ruleID int64, periodAt time.Time) error
+}
+
+type payoutEconomy interface {
+	WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error
+	AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error)
+}
+
+type memberDirectory interface {
+	ListActiveMembers(ctx context.Context) ([]*members.Member, error)
+	GetByUserID(ctx context.Context, userID int64) (*members.Member, error)
+	GetByUsername(ctx context.Context, username string) (*members.Member, error)
+}
+
+// Service ведёт правила выплат и платит по ним. Выплата периода —
+// одна транзакция экономики: запись payout_runs, начисления и сдвиг
+// last_period_at; повтор того же периода упирается в payout_runs.
+type Service struct {
+	repo     payoutRepository
+	economy  payoutEconomy
+	members  memberDirectory
+	audit    *audit.Logger
+	location *time.Location
+	now      func() time.Time
+}
+
+func NewService(repo *Repository, economyService *economy.Service, memberRepo *members.Repository, cfg *config.Config) *Service {
+	loc := time.UTC
+	if cfg != nil && strings.TrimSpace(cfg.AppTimezone) != "" {
+		if loaded, err := time.LoadLocation(cfg.AppTimezone); err == nil {
+			loc = loaded
+		}
+	}
+	return &Service{
+		repo:     repo,
+		economy:  economyService,
+		members:  memberRepo,
+		location: loc,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SetAuditLogger подключает сводки выплат в админ-чат.
+func (s *Service) SetAuditLogger(logger *audit.Logger) {
+	s.audit = logger
+}
+
+func (s *Service) ListRules(ctx context.Context) ([]*Rule, error) {
+	return s.repo.ListRules(ctx, true)
+}
+
+func (s *Service) GetRule(ctx context.Context, ruleID int64) (*Rule, error) {
+	return s.repo.GetRule(ctx, ruleID)
+}
+
+// CreateRule сохраняет правило из админки; @username и user_id из списка
+// получателей должны быть известными участниками.
+func (s *Service) CreateRule(ctx context.Context, rule *Rule, actorID int64) (*Rule, error) {
+	if rule.TargetKind == TargetUsers && len(rule.TargetRefs) > 0 {
+		ids, err := s.resolveRefs(ctx, rule.TargetRefs)
+		if err != nil {
+			return nil, err
+		}
+		rule.TargetUserIDs = ids
+	}
+	if err := ValidateRule(rule); err != nil {
+		return nil, err
+	}
+	rule.CreatedBy = actorID
+	return s.repo.CreateRule(ctx, rule)
+}
+
+func (s *Service) SetActive(ctx context.Context, ruleID int64, active bool) (*Rule, error) {
+	return s.repo.SetRuleActive(ctx, ruleID, active)
+}
+
+// NextRun — ближайшая выплата правила после now.
+func (s *Service) NextRun(rule *Rule, now time.Time) (time.Time, bool) {
+	schedule, err := ParseSchedule(rule.Schedule)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return schedule.Next(now.In(s.location)), true
+}
+
+// RunDuePayouts платит по всем включённым правилам, у которых наступил
+// период. Пропущенные периоды (бот был выключен) схлопываются в последний.
+func (s *Service) RunDuePayouts(ctx context.Context) (int, error) {
+	rules, err := s.repo.ListRules(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	now := s.now()
+	paid := 0
+	var errs []error
+	for _, rule := range rules {
+		schedule, err := ParseSchedule(rule.Schedule)
+		if err != nil {
+			log.WithError(err).WithField("rule_id", rule.ID).Warn("payout rule has invalid schedule")
+			continue
+		}
+		anchor := rule.CreatedAt
+		if rule.LastPeriodAt != nil {
+			anchor = *rule.LastPeriodAt
+		}
+		period, due := DuePeriod(schedule, anchor, now, s.location)
+		if !due {
+			continue
+		}
+		run, err := s.payPeriod(ctx, rule, period)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("payout rule %d: %w", rule.ID, err))
+			continue
+		}
+		if run == nil {
+			continue
+		}
+		paid++
+		s.audit.LogPayoutRun(ctx, rule.Title, TargetLabel(rule), run.PeriodAt, run.Recipients, rule.Amount, run.Total)
+	}
+	return paid, errors.Join(errs...)
+}
+
+// payPeriod выплачивает период в одной транзакции. nil без ошибки — период
+// уже выплачен другим запуском.
+func (s *Service) payPeriod(ctx context.Context, rule *Rule, period time.Time) (*Run, error) {
+	recipients, err := s.recipients(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	period = period.UTC()
+	run := &Run{Rule: rule, PeriodAt: period, Recipients: len(recipients), Total: rule.Amount * int64(len(recipients))}
+	description := fmt.Sprintf("Выплата «%s»", rule.Title)
+
+	var created bool
+	err = s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		created, err = s.repo.CreateRunTx(ctx, tx, rule.ID, period, run.Recipients, run.Total)
+		if err != nil || !created {
+			return err
+		}
+		for _, userID := range recipients {
+			if _, err := s.economy.AddBalanceOnceTx(ctx, tx, OperationKey(rule.ID, period, userID), userID, rule.Amount, economy.TxTypeAdminPayout, description); err != nil {
+				return err
+			}
+		}
+		return s.repo.MarkPeriodTx(ctx, tx, rule.ID, period)
+	})
+	if err != nil || !created {
+		return nil, err
+	}
+	return run, nil
+}
+
+// recipients — активные участники (не боты и не забаненные), подходящие под правило.
+func (s *Service) recipients(ctx context.Context, rule *Rule) ([]int64, error) {
+	active, err := s.members.ListActiveMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[int64]bool, len(rule.TargetUserIDs))
+	for _, id := range rule.TargetUserIDs {
+		wanted[id] = true
+	}
+	var out []int64
+	for _, m := range active {
+		if m == nil || m.IsBot || m.IsBanned {
+			continue
+		}
+		switch rule.TargetKind {
+		case TargetRole:
+			if m.Role == nil || !strings.EqualFold(strings.TrimSpace(*m.Role), rule.TargetRole) {
+				continue
+			}
+		case TargetUsers:
+			if !wanted[m.UserID] {
+				continue
+			}
+		}
+		out = append(out, m.UserID)
+	}
+	return out, nil
+}
+
+func (s *Service) resolveRefs(ctx context.Context, refs []string) ([]int64, error) {
+	seen := make(map[int64]bool, len(refs))
+	out := make([]int64, 0, len(refs))
+	for _, ref := range refs {
+		var (
+			member *members.Member
+			err    error
+		)
+		if id, parseErr := strconv.ParseInt(ref, 10, 64); parseErr == nil {
+			member, err = s.members.GetByUserID(ctx, id)
+		} else {
+			member, err = s.members.GetByUsername(ctx, strings.TrimPrefix(ref, "@"))
+		}
+		if err != nil || member == nil {
+			return nil, fmt.Errorf("%w: участник %s не найден", ErrInvalidRule, ref)
+		}
+		if !seen[member.UserID] {
+			seen[member.UserID] = true
+			out = append(out, member.UserID)
+		}
+	}
+	return out, nil
+}
+
+// OperationKey — ключ идемпотентности начисления по правилу за период.
+func OperationKey(ruleID int64, period time.Time, userID int64) string {
+	return economy.OperationKey("payout", ruleID, period.UTC().Format(time.RFC3339), userID)
+}
@@ -0,0 +1,254 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/features/members"
+)
+
+// fakeStore реализует репозиторий, экономику и участников разом.
+type fakeStore struct {
+	rules    map[int64]*Rule
+	runs     map[string]bool
+	balances map[int64]int64
+	keys     map[string]bool
+	members  []*members.Member
+}
+
+func newFakeStore() *fakeStore {
+	role := "Модератор"
+	return &fakeStore{
+		rules:    make(map[int64]*Rule),
+		runs:     make(map[string]bool),
+		balances: make(map[int64]int64),
+		keys:     make(map[string]bool),
+		members: []*members.Member{
+			{UserID: 1, Username: "mod", Role: &role},
+			{UserID: 2, Username: "member"},
+			{UserID: 3, Username: "bot", Role: &role, IsBot: true},
+		},
+	}
+}
+
+func newTestService(store *fakeStore, now *time.Time) *Service {
+	return &Service{
+		repo:     store,
+		economy:  store,
+		members:  store,
+		location: time.UTC,
+		now:      func() time.Time { return *now },
+	}
+}
+
+func (f *fakeStore) ListRules(ctx context.Context, includeInactive bool) ([]*Rule, error) {
+	var out []*Rule
+	for _, rule := range f.rules {
+		if rule.Active || includeInactive {
+			cp := *rule
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetRule(ctx context.Context, ruleID int64) (*Rule, error) {
+	rule, ok := f.rules[ruleID]
+	if !ok {
+		return nil, ErrRuleNotFound
+	}
+	cp := *rule
+	return &cp, nil
+}
+
+func (f *fakeStore) CreateRule(ctx context.Context, rule *Rule) (*Rule, error) {
+	cp := *rule
+	cp.ID = int64(len(f.rules) + 1)
+	f.rules[cp.ID] = &cp
+	return &cp, nil
+}
+
+func (f *fakeStore) SetRuleActive(ctx context.Context, ruleID int64, active bool) (*Rule, error) {
+	f.rules[ruleID].Active = active
+	return f.GetRule(ctx, ruleID)
+}
+
+func (f *fakeStore) CreateRunTx(ctx context.Context, tx pgx.Tx, ruleID int64, periodAt time.Time, recipients int, total int64) (bool, error) {
+	key := OperationKey(ruleID, periodAt, 0)
+	if f.runs[key] {
+		return false, nil
+	}
+	f.runs[key] = true
+	return true, nil
+}
+
+func (f *fakeStore) MarkPeriodTx(ctx context.Context, tx pgx.Tx, ruleID int64, periodAt time.Time) error {
+	f.rules[ruleID].LastPeriodAt = &periodAt
+	return nil
+}
+
+func (f *fakeStore) WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
+	return fn(ctx, nil)
+}
+
+func (f *fakeStore) AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	if f.keys[operationKey] {
+		return false, nil
+	}
+	f.keys[operationKey] = true
+	f.balances[userID] += amount
+	return true, nil
+}
+
+func (f *fakeStore) ListActiveMembers(ctx context.Context) ([]*members.Member, error) {
+	return f.members, nil
+}
+
+func (f *fakeStore) GetByUserID(ctx context.Context, userID int64) (*members.Member, error) {
+	for _, m := range f.members {
+		if m.UserID == userID {
+			return m, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeStore) GetByUsername(ctx context.Context, username string) (*members.Member, error) {
+	for _, m := range f.members {
+		if m.Username == username {
+			return m, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func TestRunDuePayouts_PaysOncePerPeriod(t *testing.T) {
+	store := newFakeStore()
+	created := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC) // понедельник
+	store.rules[1] = &Rule{ID: 1, Title: "Зарплата", TargetKind: TargetRole, TargetRole: "модератор", Amount: 100, Schedule: "0 12 * * 5", Active: true, CreatedAt: created}
+	now := created.Add(time.Hour)
+	svc := newTestService(store, &now)
+
+	if paid, err := svc.RunDuePayouts(context.Background()); err != nil || paid != 0 {
+		t.Fatalf("nothing is due before friday, paid=%d err=%v", paid, err)
+	}
+
+	now = time.Date(2026, 3, 6, 12, 0, 30, 0, time.UTC)
+	if paid, err := svc.RunDuePayouts(context.Background()); err != nil || paid != 1 {
+		t.Fatalf("expected friday payout, paid=%d err=%v", paid, err)
+	}
+	if store.balances[1] != 100 || store.balances[2] != 0 || store.balances[3] != 0 {
+		t.Fatalf("only the human moderator must be paid, got %v", store.balances)
+	}
+
+	now = now.Add(time.Minute)
+	if paid, err := svc.RunDuePayouts(context.Background()); err != nil || paid != 0 {
+		t.Fatalf("same period must not be paid twice, paid=%d err=%v", paid, err)
+	}
+
+	// Повтор периода в обход last_period_at упирается в payout_runs.
+	store.rules[1].LastPeriodAt = nil
+	if paid, err := svc.RunDuePayouts(context.Background()); err != nil || paid != 0 {
+		t.Fatalf("replayed period must be a no-op, paid=%d err=%v", paid, err)
+	}
+	if store.balances[1] != 100 {
+		t.Fatalf("replay must not credit again, got %d", store.balances[1])
+	}
+}
+
+func TestRunDuePayouts_MissedPeriodsCollapse(t *testing.T) {
+	store := newFakeStore()
+	last := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	store.rules[1] = &Rule{ID: 1, Title: "Пособие", TargetKind: TargetActive, Amount: 10, Schedule: "0 12 * * 5", Active: true, LastPeriodAt: &last}
+	now := time.Date(2026, 3, 28, 8, 0, 0, 0, time.UTC) // пропущены три пятницы
+	svc := newTestService(store, &now)
+
+	if paid, err := svc.RunDuePayouts(context.Background()); err != nil || paid != 1 {
+		t.Fatalf("expected a single catch-up payout, paid=%d err=%v", paid, err)
+	}
+	if store.balances[1] != 10 || store.balances[2] != 10 {
+		t.Fatalf("expected one allowance per active member, got %v", store.balances)
+	}
+	want := time.Date(2026, 3, 27, 12, 0, 0, 0, time.UTC)
+	if got := store.rules[1].LastPeriodAt; got == nil || !got.Equal(want) {
+		t.Fatalf("expected last period %v, got %v", want, got)
+	}
+}
+
+func TestCreateRule_ResolvesUserRefs(t *testing.T) {
+	store := newFakeStore()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(store, &now)
+
+	draft, err := ParseRule("@member 1 @member | 50 | 0 10 * * 1 | Стипендия")
+	if err != nil {
+		t.Fatalf("ParseRule() error = %v", err)
+	}
+	rule, err := svc.CreateRule(context.Background(), draft, 77)
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if rule.TargetKind != TargetUsers || len(rule.TargetUserIDs) != 2 || rule.CreatedBy != 77 {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+
+	draft, _ = ParseRule("@ghost | 50 | 0 10 * * 1 | Стипендия")
+	if _, err := svc.CreateRule(context.Background(), draft, 77); !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("expected unknown member to be rejected, got %v", err)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("роль Старший модератор | 100 | 0 12 * * 5 | Зарплата")
+	if err != nil {
+		t.Fatalf("ParseRule() error = %v", err)
+	}
+	if rule.TargetKind != TargetRole || rule.TargetRole != "Старший модератор" || rule.Amount != 100 || rule.Schedule != "0 12 * * 5" {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+
+	for _, input := range []string{
+		"все | 100 | 0 12 * * 5",
+		"все | -5 | 0 12 * * 5 | Пособие",
+		"роль | 100 | 0 12 * * 5 | Пособие",
+		"все | 100 | */5 * * * * | Пособие",
+		"все | 100 | @every 2h | Пособие",
+		"все | 100 | когда-нибудь | Пособие",
+	} {
+		if _, err := ParseRule(input); !errors.Is(err, ErrInvalidRule) {
+			t.Fatalf("ParseRule(%q) expected ErrInvalidRule, got %v", input, err)
+		}
+	}
+}
+
+func TestCheckInterval_IndependentOfSaveTime(t *testing.T) {
+	twiceAtNine, err := ParseSchedule("0,30 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Сохранение между запусками (09:15) раньше видело только 09:30 → 09:00
+	// следующего дня и пропускало правило.
+	for _, saved := range []time.Time{
+		time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+	} {
+		if err := checkInterval(twiceAtNine, saved); !errors.Is(err, ErrInvalidRule) {
+			t.Fatalf("saved at %s: expected ErrInvalidRule, got %v", saved.Format("15:04"), err)
+		}
+	}
+
+	// Редкие запуски с короткой парой тоже ловятся: раз в месяц, но дважды за полчаса.
+	monthly, _ := ParseSchedule("0,30 9 1 * *")
+	if err := checkInterval(monthly, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("monthly pair: expected ErrInvalidRule, got %v", err)
+	}
+
+	hourly, _ := ParseSchedule("0 * * * *")
+	if err := checkInterval(hourly, time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("hourly schedule must pass, got %v", err)
+	}
+}
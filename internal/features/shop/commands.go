@@ -0,0 +1,18 @@
+package shop
+
+import (
+	"context"
+
+	"serotonyl.ru/telegram-bot/internal/commands"
+	"serotonyl.ru/telegram-bot/internal/config"
+)
+
+// RegisterCommands регистрирует команды магазина.
+func RegisterCommands(r *commands.Router, h *Handler, cfg *config.Config) {
+	if !cfg.FeatureShopEnabled {
+		return
+	}
+	r.Register("магазин", func(ctx context.Context, c commands.Context, args []string) {
+		h.HandleShop(ctx, c.ChatID, c.UserID)
+	})
+}
@@ -0,0 +1,341 @@
+// Package shop — handlers.go показывает !магазин: постраничный экран
+// каталога с карточкой товара и покупкой по кнопке. Листать и покупать
+// может только тот, кто открыл магазин.
+package shop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	models "github.com/mymmrac/telego"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/telegram"
+)
+
+const (
+	shopCallbackPrefix = "shop:"
+	shopActionPage     = "page"
+	shopActionItem     = "item"
+	shopActionBuy      = "buy"
+
+	shopPageSize = 5
+)
+
+type handlerService interface {
+	ListItems(ctx context.Context) ([]*Item, error)
+	GetItem(ctx context.Context, itemID int64) (*Item, error)
+	Buy(ctx context.Context, userID, itemID int64) (*Purchase, error)
+}
+
+type balanceProvider interface {
+	GetBalance(ctx context.Context, userID int64) (int64, error)
+}
+
+// Handler обрабатывает команду !магазин и кнопки магазина.
+type Handler struct {
+	service handlerService
+	economy balanceProvider
+	tgOps   *telegram.Ops
+}
+
+// NewHandler создаёт обработчик магазина.
+func NewHandler(service *Service, economy balanceProvider, tgOps *telegram.Ops) *Handler {
+	return &Handler{service: service, economy: economy, tgOps: tgOps}
+}
+
+// HandleShop обрабатывает `!магазин` — первая страница каталога.
+func (h *Handler) HandleShop(ctx context.Context, chatID, userID int64) {
+	if err := h.renderPage(ctx, chatID, 0, userID, 0); err != nil {
+		log.WithError(err).WithField("user_id", userID).Warn("shop render failed")
+	}
+}
+
+// HandleShopCallback обрабатывает кнопки магазина; возвращает true,
+// если callback принадлежит магазину.
+func (h *Handler) HandleShopCallback(ctx context.Context, q *models.CallbackQuery) bool {
+	if q == nil || !strings.HasPrefix(q.Data, shopCallbackPrefix) {
+		return false
+	}
+	msg := callbackMessage(q)
+	action, ownerID, itemID, page, ok := parseShopCallback(q.Data)
+	if msg == nil || !ok {
+		h.answerCallback(ctx, q.ID, "")
+		return true
+	}
+	if q.From.ID != ownerID {
+		h.answerCallback(ctx, q.ID, "Этот магазин открыл другой участник. Наберите !магазин.")
+		return true
+	}
+
+	var err error
+	switch action {
+	case shopActionPage:
+		err = h.renderPage(ctx, msg.Chat.ID, msg.MessageID, ownerID, page)
+	case shopActionItem:
+		err = h.renderItem(ctx, msg.Chat.ID, msg.MessageID, ownerID, itemID, page)
+	case shopActionBuy:
+		h.handleBuy(ctx, q, msg, ownerID, itemID, page)
+		return true
+	}
+	if err != nil {
+		if errors.Is(err, ErrItemNotFound) {
+			h.answerCallback(ctx, q.ID, "Товар больше не продаётся.")
+			return true
+		}
+		log.WithError(err).WithField("data", q.Data).Warn("shop callback render failed")
+	}
+	h.answerCallback(ctx, q.ID, "")
+	return true
+}
+
+func (h *Handler) handleBuy(ctx context.Context, q *models.CallbackQuery, msg *models.Message, userID, itemID int64, page int) {
+	purchase, err := h.service.Buy(ctx, userID, itemID)
+	if err != nil {
+		h.answerCallback(ctx, q.ID, buyRefusal(err))
+		if !isBuyRefusal(err) {
+			log.WithError(err).WithFields(log.Fields{"user_id": userID, "item_id": itemID}).Error("shop purchase failed")
+		}
+		return
+	}
+	h.answerCallback(ctx, q.ID, "")
+
+	text := purchaseText(purchase)
+	if h.economy != nil {
+		if balance, err := h.economy.GetBalance(ctx, userID); err == nil {
+			text += fmt.Sprintf("\n\n💰 Баланс: %s", common.FormatBalance(balance))
+		}
+	}
+	if err := h.render(ctx, msg.Chat.ID, msg.MessageID, text, backKeyboard(userID, page)); err != nil {
+		log.WithError(err).Warn("shop purchase render failed")
+	}
+}
+
+func (h *Handler) renderPage(ctx context.Context, chatID int64, messageID int, ownerID int64, page int) error {
+	items, err := h.service.ListItems(ctx)
+	if err != nil {
+		return err
+	}
+	totalPages := pageCount(len(items))
+	page = clampPage(page, totalPages)
+	start := page * shopPageSize
+	end := start + shopPageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	pageItems := items[start:end]
+
+	var sb strings.Builder
+	sb.WriteString("🛒 МАГАЗИН")
+	if h.economy != nil {
+		if balance, err := h.economy.GetBalance(ctx, ownerID); err == nil {
+			fmt.Fprintf(&sb, "\n💰 Ваш баланс: %s", common.FormatBalance(balance))
+		}
+	}
+	if len(items) == 0 {
+		sb.WriteString("\n\nПока ничего не продаётся.")
+	}
+	rows := make([][]models.InlineKeyboardButton, 0, len(pageItems)+1)
+	for i, item := range pageItems {
+		fmt.Fprintf(&sb, "\n\n%d. %s", start+i+1, itemLine(item))
+		if item.Description != "" {
+			fmt.Fprintf(&sb, "\n%s", item.Description)
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("%s %s — %s", KindIcon(item.Kind), item.Title, common.FormatBalance(item.Price)),
+			CallbackData: shopCallbackData(shopActionItem, ownerID, item.ID, page),
+		}})
+	}
+	if totalPages > 1 {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: "⬅", CallbackData: shopCallbackData(shopActionPage, ownerID, 0, clampPage(page-1, totalPages))},
+			{Text: fmt.Sprintf("Стр %d/%d", page+1, totalPages), CallbackData: shopCallbackData(shopActionPage, ownerID, 0, page)},
+			{Text: "➡", CallbackData: shopCallbackData(shopActionPage, ownerID, 0, clampPage(page+1, totalPages))},
+		})
+	}
+	return h.render(ctx, chatID, messageID, sb.String(), &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (h *Handler) renderItem(ctx context.Context, chatID int64, messageID int, ownerID, itemID int64, page int) error {
+	item, err := h.service.GetItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	if !item.Active {
+		return ErrItemNotFound
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n\n", KindIcon(item.Kind), item.Title)
+	if item.Description != "" {
+		fmt.Fprintf(&sb, "%s\n\n", item.Description)
+	}
+	fmt.Fprintf(&sb, "Вы получите: %s\n", itemEffect(item))
+	fmt.Fprintf(&sb, "Цена: %s\n", common.FormatBalance(item.Price))
+	sb.WriteString(stockLabel(item))
+
+	rows := [][]models.InlineKeyboardButton{}
+	if item.InStock() {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("✅ Купить за %s", common.FormatBalance(item.Price)),
+			CallbackData: shopCallbackData(shopActionBuy, ownerID, item.ID, page),
+		}})
+	}
+	rows = append(rows, backKeyboard(ownerID, page).InlineKeyboard...)
+	return h.render(ctx, chatID, messageID, sb.String(), &models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
+
+func (h *Handler) render(ctx context.Context, chatID int64, messageID int, text string, keyboard *models.InlineKeyboardMarkup) error {
+	if h.tgOps == nil {
+		return nil
+	}
+	_, _, err := telegram.RenderScreen(ctx, h.tgOps, telegram.Screen{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ReplyMarkup: keyboard,
+	})
+	return err
+}
+
+func backKeyboard(ownerID int64, page int) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+		{Text: "⬅ В магазин", CallbackData: shopCallbackData(shopActionPage, ownerID, 0, page)},
+	}}}
+}
+
+// itemLine описывает товар одной строкой списка.
+func itemLine(item *Item) string {
+	line := fmt.Sprintf("%s %s — %s", KindIcon(item.Kind), item.Title, common.FormatBalance(item.Price))
+	if item.Stock != nil {
+		line += fmt.Sprintf(" (осталось %d)", *item.Stock)
+	}
+	return line
+}
+
+// itemEffect описывает, что выдаёт товар.
+func itemEffect(item *Item) string {
+	switch item.Kind {
+	case KindRole:
+		return fmt.Sprintf("роль «%s»", item.Payload)
+	case KindTag:
+		return fmt.Sprintf("тег «%s» в чате", item.Payload)
+	case KindFreeze:
+		return fmt.Sprintf("заморозок стрика: %d", item.FreezeCount())
+	default:
+		return item.Payload
+	}
+}
+
+func stockLabel(item *Item) string {
+	switch {
+	case item.Stock == nil:
+		return "В наличии"
+	case *item.Stock == 0:
+		return "❌ Закончился"
+	default:
+		return fmt.Sprintf("Осталось: %d", *item.Stock)
+	}
+}
+
+func purchaseText(p *Purchase) string {
+	item := &Item{Kind: p.Kind, Payload: p.Payload}
+	text := fmt.Sprintf("✅ Покупка оформлена: %s за %s.", itemEffect(item), common.FormatBalance(p.Price))
+	if p.Kind == KindFreeze {
+		text += "\nЗаморозки сохранены за вашим стриком."
+	}
+	return text
+}
+
+// buyRefusal возвращает текст отказа в покупке для всплывающего ответа.
+func buyRefusal(err error) string {
+	switch {
+	case errors.Is(err, common.ErrInsufficientBalance):
+		return "Недостаточно плёнок."
+	case errors.Is(err, ErrOutOfStock):
+		return "Товар закончился."
+	case errors.Is(err, ErrItemNotFound):
+		return "Товар больше не продаётся."
+	case errors.Is(err, ErrAlreadyOwned):
+		return "Это у вас уже есть."
+	case errors.Is(err, ErrNotMember):
+		return "Покупать могут только участники чата."
+	case errors.Is(err, ErrTagRejected):
+		return "Telegram не принял тег, плёнки возвращены."
+	default:
+		return "Не удалось оформить покупку."
+	}
+}
+
+func isBuyRefusal(err error) bool {
+	return errors.Is(err, common.ErrInsufficientBalance) ||
+		errors.Is(err, ErrOutOfStock) ||
+		errors.Is(err, ErrItemNotFound) ||
+		errors.Is(err, ErrAlreadyOwned) ||
+		errors.Is(err, ErrNotMember)
+}
+
+func shopCallbackData(action string, ownerID, itemID int64, page int) string {
+	return fmt.Sprintf("%s%s:%d:%d:%d", shopCallbackPrefix, action, ownerID, itemID, page)
+}
+
+func parseShopCallback(data string) (action string, ownerID, itemID int64, page int, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(data, shopCallbackPrefix), ":")
+	if len(parts) != 4 {
+		return "", 0, 0, 0, false
+	}
+	switch parts[0] {
+	case shopActionPage, shopActionItem, shopActionBuy:
+	default:
+		return "", 0, 0, 0, false
+	}
+	ownerID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, 0, 0, false
+	}
+	itemID, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, 0, 0, false
+	}
+	page, err = strconv.Atoi(parts[3])
+	if err != nil {
+		return "", 0, 0, 0, false
+	}
+	return parts[0], ownerID, itemID, page, true
+}
+
+func pageCount(total int) int {
+	if total <= 0 {
+		return 1
+	}
+	return (total + shopPageSize - 1) / shopPageSize
+}
+
+func clampPage(page, totalPages int) int {
+	if page >= totalPages {
+		page = totalPages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	return page
+}
+
+func callbackMessage(q *models.CallbackQuery) *models.Message {
+	if q == nil || q.Message == nil {
+		return nil
+	}
+	return q.Message.Message()
+}
+
+func (h *Handler) answerCallback(ctx context.Context, callbackID, text string) {
+	if h.tgOps == nil || callbackID == "" {
+		return
+	}
+	if err := h.tgOps.AnswerCallback(ctx, callbackID, text, false); err != nil {
+		log.WithError(err).Debug("shop callback answer failed")
+	}
+}
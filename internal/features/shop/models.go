@@ -0,0 +1,199 @@
+// Package shop реализует магазин за плёнки: участники покупают роли, теги
+// и заморозки стрика из каталога, который ведут администраторы.
+// models.go описывает товары, покупки и разбор товара из админки.
+package shop
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	KindRole   = "role"   // Роль участника (members.role)
+	KindTag    = "tag"    // Тег участника в чате (setChatMemberTag)
+	KindFreeze = "freeze" // Заморозки стрика
+
+	// MaxTagLength — ограничение Telegram на длину тега.
+	MaxTagLength = 16
+	// MaxFreezesPerItem — сколько заморозок может выдать один товар.
+	MaxFreezesPerItem = 10
+	maxTitleLength    = 64
+	maxRoleLength     = 64
+)
+
+var (
+	// ErrItemNotFound — товара нет или он скрыт из магазина.
+	ErrItemNotFound = errors.New("shop item not found")
+	// ErrOutOfStock — товар закончился.
+	ErrOutOfStock = errors.New("shop item out of stock")
+	// ErrAlreadyOwned — у покупателя уже есть эта роль или тег.
+	ErrAlreadyOwned = errors.New("shop item already owned")
+	// ErrNotMember — покупатель не активный участник чата.
+	ErrNotMember = errors.New("shop buyer is not an active member")
+	// ErrTagRejected — Telegram не принял тег, покупка отменена или возвращена.
+	ErrTagRejected = errors.New("shop tag rejected by telegram")
+	// ErrInvalidItem — описание товара из админки не прошло проверку.
+	ErrInvalidItem = errors.New("invalid shop item")
+)
+
+// Item — товар каталога (таблица shop_items).
+type Item struct {
+	ID          int64
+	Title       string
+	Description string
+	Kind        string
+	Price       int64
+	Stock       *int // nil — без ограничения
+	Payload     string
+	Active      bool
+	CreatedBy   int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// InStock сообщает, что товар ещё можно купить.
+func (i *Item) InStock() bool {
+	return i.Stock == nil || *i.Stock > 0
+}
+
+// FreezeCount возвращает число заморозок, которое выдаёт товар KindFreeze.
+func (i *Item) FreezeCount() int {
+	n, err := strconv.Atoi(i.Payload)
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// Purchase — покупка (таблица shop_purchases). Цена и выдача копируются
+// из товара, чтобы правка каталога не меняла историю.
+type Purchase struct {
+	ID        int64
+	ItemID    int64
+	UserID    int64
+	Price     int64
+	Kind      string
+	Payload   string
+	CreatedAt time.Time
+}
+
+// KindLabel возвращает название вида товара для интерфейса.
+func KindLabel(kind string) string {
+	switch kind {
+	case KindRole:
+		return "роль"
+	case KindTag:
+		return "тег"
+	case KindFreeze:
+		return "заморозка"
+	default:
+		return kind
+	}
+}
+
+// KindIcon возвращает значок вида товара.
+func KindIcon(kind string) string {
+	switch kind {
+	case KindRole:
+		return "🎭"
+	case KindTag:
+		return "🏷"
+	case KindFreeze:
+		return "🧊"
+	default:
+		return "📦"
+	}
+}
+
+func parseKind(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "роль", KindRole:
+		return KindRole, true
+	case "тег", KindTag:
+		return KindTag, true
+	case "заморозка", KindFreeze:
+		return KindFreeze, true
+	default:
+		return "", false
+	}
+}
+
+// ParseItem разбирает товар из строки админки:
+// «вид | название | цена | остаток | выдача | описание».
+// Остаток «-» — без ограничения, описание можно опустить.
+func ParseItem(text string) (*Item, error) {
+	parts := strings.Split(text, "|")
+	if len(parts) < 5 || len(parts) > 6 {
+		return nil, fmt.Errorf("%w: ожидается 5 или 6 полей через |", ErrInvalidItem)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	kind, ok := parseKind(parts[0])
+	if !ok {
+		return nil, fmt.Errorf("%w: вид — роль, тег или заморозка", ErrInvalidItem)
+	}
+	price, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: цена должна быть числом", ErrInvalidItem)
+	}
+	stock, err := ParseStock(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	item := &Item{Kind: kind, Title: parts[1], Price: price, Stock: stock, Payload: parts[4], Active: true}
+	if len(parts) == 6 {
+		item.Description = parts[5]
+	}
+	if err := ValidateItem(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ParseStock разбирает остаток товара: число или «-» / «∞» без ограничения.
+func ParseStock(raw string) (*int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "-" || raw == "∞" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%w: остаток — неотрицательное число или «-»", ErrInvalidItem)
+	}
+	return &n, nil
+}
+
+// ValidateItem проверяет товар перед сохранением.
+func ValidateItem(item *Item) error {
+	switch {
+	case item.Title == "" || utf8.RuneCountInString(item.Title) > maxTitleLength:
+		return fmt.Errorf("%w: название от 1 до %d символов", ErrInvalidItem, maxTitleLength)
+	case item.Price <= 0:
+		return fmt.Errorf("%w: цена должна быть больше нуля", ErrInvalidItem)
+	case item.Stock != nil && *item.Stock < 0:
+		return fmt.Errorf("%w: остаток не может быть отрицательным", ErrInvalidItem)
+	}
+	switch item.Kind {
+	case KindRole:
+		if item.Payload == "" || utf8.RuneCountInString(item.Payload) > maxRoleLength {
+			return fmt.Errorf("%w: роль от 1 до %d символов", ErrInvalidItem, maxRoleLength)
+		}
+	case KindTag:
+		if item.Payload == "" || utf8.RuneCountInString(item.Payload) > MaxTagLength {
+			return fmt.Errorf("%w: тег от 1 до %d символов", ErrInvalidItem, MaxTagLength)
+		}
+	case KindFreeze:
+		n, err := strconv.Atoi(item.Payload)
+		if err != nil || n < 1 || n > MaxFreezesPerItem {
+			return fmt.Errorf("%w: заморозок от 1 до %d", ErrInvalidItem, MaxFreezesPerItem)
+		}
+	default:
+		return fmt.Errorf("%w: неизвестный вид %q", ErrInvalidItem, item.Kind)
+	}
+	return nil
+}
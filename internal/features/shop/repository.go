@@ -0,0 +1,190 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type shopDBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+const itemColumns = `
+	id, title, description, kind, price, stock, payload, active, created_by, created_at, updated_at
+`
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// ListItems возвращает каталог по возрастанию цены; скрытые товары —
+// только если includeHidden.
+func (r *Repository) ListItems(ctx context.Context, includeHidden bool) ([]*Item, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+itemColumns+`
+		FROM shop_items
+		WHERE active OR $1
+		ORDER BY active DESC, price, id
+	`, includeHidden)
+	if err != nil {
+		return nil, fmt.Errorf("list shop items: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*Item
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan shop item: %w", err)
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate shop items: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repository) GetItem(ctx context.Context, itemID int64) (*Item, error) {
+	return r.getItem(ctx, r.db, itemID, false)
+}
+
+// LockItemTx читает товар с блокировкой строки до конца покупки.
+func (r *Repository) LockItemTx(ctx context.Context, tx pgx.Tx, itemID int64) (*Item, error) {
+	return r.getItem(ctx, tx, itemID, true)
+}
+
+func (r *Repository) getItem(ctx context.Context, db shopDBTX, itemID int64, forUpdate bool) (*Item, error) {
+	query := `SELECT ` + itemColumns + ` FROM shop_items WHERE id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+	item, err := scanItem(db.QueryRow(ctx, query, itemID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get shop item %d: %w", itemID, err)
+	}
+	return item, nil
+}
+
+func (r *Repository) CreateItem(ctx context.Context, item *Item) (*Item, error) {
+	created, err := scanItem(r.db.QueryRow(ctx, `
+		INSERT INTO shop_items (title, description, kind, price, stock, payload, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+itemColumns,
+		item.Title, item.Description, item.Kind, item.Price, item.Stock, item.Payload, item.Active, item.CreatedBy))
+	if err != nil {
+		return nil, fmt.Errorf("create shop item: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateItemPrice меняет цену товара.
+func (r *Repository) UpdateItemPrice(ctx context.Context, itemID, price int64) (*Item, error) {
+	return r.updateItem(ctx, `price = $2`, itemID, price)
+}
+
+// UpdateItemStock меняет остаток товара; nil — без ограничения.
+func (r *Repository) UpdateItemStock(ctx context.Context, itemID int64, stock *int) (*Item, error) {
+	return r.updateItem(ctx, `stock = $2`, itemID, stock)
+}
+
+// SetItemActive показывает или скрывает товар в магазине.
+func (r *Repository) SetItemActive(ctx context.Context, itemID int64, active bool) (*Item, error) {
+	return r.updateItem(ctx, `active = $2`, itemID, active)
+}
+
+func (r *Repository) updateItem(ctx context.Context, set string, itemID int64, value any) (*Item, error) {
+	item, err := scanItem(r.db.QueryRow(ctx, `
+		UPDATE shop_items
+		SET `+set+`, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+itemColumns, itemID, value))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update shop item %d: %w", itemID, err)
+	}
+	return item, nil
+}
+
+// DecrementStockTx уменьшает остаток ограниченного товара на единицу.
+func (r *Repository) DecrementStockTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE shop_items
+		SET stock = stock - 1, updated_at = NOW()
+		WHERE id = $1 AND stock IS NOT NULL
+	`, itemID)
+	if err != nil {
+		return fmt.Errorf("decrement shop stock: %w", err)
+	}
+	return nil
+}
+
+// IncrementStockTx возвращает на остаток единицу ограниченного товара.
+func (r *Repository) IncrementStockTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE shop_items
+		SET stock = stock + 1, updated_at = NOW()
+		WHERE id = $1 AND stock IS NOT NULL
+	`, itemID)
+	if err != nil {
+		return fmt.Errorf("increment shop stock: %w", err)
+	}
+	return nil
+}
+
+// MarkPurchaseRefundedTx помечает покупку возвращённой.
+func (r *Repository) MarkPurchaseRefundedTx(ctx context.Context, tx pgx.Tx, purchaseID int64) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE shop_purchases
+		SET refunded_at = NOW()
+		WHERE id = $1
+	`, purchaseID)
+	if err != nil {
+		return fmt.Errorf("mark shop purchase refunded: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) CreatePurchaseTx(ctx context.Context, tx pgx.Tx, p *Purchase) error {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO shop_purchases (item_id, user_id, price, kind, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, p.ItemID, p.UserID, p.Price, p.Kind, p.Payload).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create shop purchase: %w", err)
+	}
+	return nil
+}
+
+func scanItem(row pgx.Row) (*Item, error) {
+	var (
+		item  Item
+		stock *int32
+	)
+	if err := row.Scan(&item.ID, &item.Title, &item.Description, &item.Kind, &item.Price, &stock,
+		&item.Payload, &item.Active, &item.CreatedBy, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if stock != nil {
+		n := int(*stock)
+		item.Stock = &n
+	}
+	return &item, nil
+}
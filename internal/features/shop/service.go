@@ -0,0 +1,245 @@
+package shop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	log "github.com/sirupsen/logrus"
+
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+	"serotonyl.ru/telegram-bot/internal/features/streak"
+)
+
+type shopRepository interface {
+	ListItems(ctx context.Context, includeHidden bool) ([]*Item, error)
+	GetItem(ctx context.Context, itemID int64) (*Item, error)
+	LockItemTx(ctx context.Context, tx pgx.Tx, itemID int64) (*Item, error)
+	CreateItem(ctx context.Context, item *Item) (*Item, error)
+	UpdateItemPrice(ctx context.Context, itemID, price int64) (*Item, error)
+	UpdateItemStock(ctx context.Context, itemID int64, stock *int) (*Item, error)
+	SetItemActive(ctx context.Context, itemID int64, active bool) (*Item, error)
+	DecrementStockTx(ctx context.Context, tx pgx.Tx, itemID int64) error
+	CreatePurchaseTx(ctx context.Context, tx pgx.Tx, p *Purchase) error
+	IncrementStockTx(ctx context.Context, tx pgx.Tx, itemID int64) error
+	MarkPurchaseRefundedTx(ctx context.Context, tx pgx.Tx, purchaseID int64) error
+}
+
+type shopEconomy interface {
+	WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error
+	DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error
+	AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error)
+}
+
+type memberStore interface {
+	GetByUserID(ctx context.Context, userID int64) (*members.Member, error)
+	UpdateRoleTx(ctx context.Context, tx pgx.Tx, userID int64, role string) error
+	UpdateMemberTagTx(ctx context.Context, tx pgx.Tx, userID int64, tag *string, updatedAt time.Time) error
+}
+
+type freezeStore interface {
+	AddFreezeTokensTx(ctx context.Context, tx pgx.Tx, userID int64, count int) error
+}
+
+type tagSetter interface {
+	SetChatMemberTag(ctx context.Context, chatID int64, userID int64, tag string) error
+}
+
+// Service продаёт товары каталога. Списание, уменьшение остатка, выдача
+// и запись покупки идут в одной транзакции экономики; тег в Telegram
+// ставится после коммита.
+type Service struct {
+	repo      shopRepository
+	economy   shopEconomy
+	members   memberStore
+	freezes   freezeStore
+	tags      tagSetter
+	tagChatID int64
+	now       func() time.Time
+}
+
+func NewService(repo *Repository, economyService *economy.Service, memberRepo *members.Repository, streakRepo *streak.Repository) *Service {
+	return &Service{
+		repo:    repo,
+		economy: economyService,
+		members: memberRepo,
+		freezes: streakRepo,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SetTagSetter задаёт, через что и в каком чате ставятся купленные теги.
+// Без него товары-теги не продаются.
+func (s *Service) SetTagSetter(tags tagSetter, chatID int64) {
+	s.tags = tags
+	s.tagChatID = chatID
+}
+
+// ListItems возвращает товары, которые можно купить.
+func (s *Service) ListItems(ctx context.Context) ([]*Item, error) {
+	return s.repo.ListItems(ctx, false)
+}
+
+// ListAllItems возвращает весь каталог вместе со скрытыми товарами.
+func (s *Service) ListAllItems(ctx context.Context) ([]*Item, error) {
+	return s.repo.ListItems(ctx, true)
+}
+
+// GetItem возвращает товар по ID, в том числе скрытый.
+func (s *Service) GetItem(ctx context.Context, itemID int64) (*Item, error) {
+	return s.repo.GetItem(ctx, itemID)
+}
+
+// CreateItem добавляет товар в каталог.
+func (s *Service) CreateItem(ctx context.Context, item *Item, actorID int64) (*Item, error) {
+	if err := ValidateItem(item); err != nil {
+		return nil, err
+	}
+	item.CreatedBy = actorID
+	return s.repo.CreateItem(ctx, item)
+}
+
+// SetPrice меняет цену товара.
+func (s *Service) SetPrice(ctx context.Context, itemID, price int64) (*Item, error) {
+	if price <= 0 {
+		return nil, fmt.Errorf("%w: цена должна быть больше нуля", ErrInvalidItem)
+	}
+	return s.repo.UpdateItemPrice(ctx, itemID, price)
+}
+
+// SetStock меняет остаток товара; nil — без ограничения.
+func (s *Service) SetStock(ctx context.Context, itemID int64, stock *int) (*Item, error) {
+	if stock != nil && *stock < 0 {
+		return nil, fmt.Errorf("%w: остаток не может быть отрицательным", ErrInvalidItem)
+	}
+	return s.repo.UpdateItemStock(ctx, itemID, stock)
+}
+
+// SetActive показывает или скрывает товар в магазине.
+func (s *Service) SetActive(ctx context.Context, itemID int64, active bool) (*Item, error) {
+	return s.repo.SetItemActive(ctx, itemID, active)
+}
+
+// Buy покупает товар: в одной транзакции блокирует товар, уменьшает
+// остаток, списывает цену (shop_purchase), выдаёт товар и записывает
+// покупку. Тег ставится в Telegram уже после коммита, чтобы сетевой вызов
+// не держал блокировки; если Telegram его не принял, покупка возвращается
+// (см. refund).
+func (s *Service) Buy(ctx context.Context, userID, itemID int64) (*Purchase, error) {
+	member, err := s.members.GetByUserID(ctx, userID)
+	if err != nil || member == nil || member.Status != members.StatusActive {
+		return nil, ErrNotMember
+	}
+
+	var (
+		purchase *Purchase
+		title    string
+	)
+	err = s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		item, err := s.repo.LockItemTx(ctx, tx, itemID)
+		if err != nil {
+			return err
+		}
+		if !item.Active {
+			return ErrItemNotFound
+		}
+		if !item.InStock() {
+			return ErrOutOfStock
+		}
+		if owned(member, item) {
+			return ErrAlreadyOwned
+		}
+		if item.Kind == KindTag && s.tags == nil {
+			return fmt.Errorf("%w: теги не настроены", ErrTagRejected)
+		}
+
+		if err := s.repo.DecrementStockTx(ctx, tx, item.ID); err != nil {
+			return err
+		}
+		if err := s.economy.DeductBalanceTx(ctx, tx, userID, item.Price,
+			economy.TxTypeShopPurchase, fmt.Sprintf("Покупка в магазине: %s", item.Title)); err != nil {
+			return err
+		}
+		purchase = &Purchase{ItemID: item.ID, UserID: userID, Price: item.Price, Kind: item.Kind, Payload: item.Payload}
+		if err := s.repo.CreatePurchaseTx(ctx, tx, purchase); err != nil {
+			return err
+		}
+		title = item.Title
+		return s.applyTx(ctx, tx, userID, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if purchase.Kind == KindTag {
+		if err := s.tags.SetChatMemberTag(ctx, s.tagChatID, userID, purchase.Payload); err != nil {
+			if refundErr := s.refund(ctx, purchase, title, member.Tag); refundErr != nil {
+				log.WithError(refundErr).WithFields(log.Fields{
+					"purchase_id": purchase.ID,
+					"user_id":     userID,
+				}).Error("shop tag refund failed")
+				return nil, fmt.Errorf("refund shop purchase %d: %w", purchase.ID, refundErr)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrTagRejected, err)
+		}
+	}
+	return purchase, nil
+}
+
+// applyTx выдаёт купленный товар. Для тега пишется только members.tag —
+// в Telegram его ставит Buy после коммита.
+func (s *Service) applyTx(ctx context.Context, tx pgx.Tx, userID int64, item *Item) error {
+	switch item.Kind {
+	case KindRole:
+		return s.members.UpdateRoleTx(ctx, tx, userID, item.Payload)
+	case KindFreeze:
+		return s.freezes.AddFreezeTokensTx(ctx, tx, userID, item.FreezeCount())
+	case KindTag:
+		tag := item.Payload
+		return s.members.UpdateMemberTagTx(ctx, tx, userID, &tag, s.now())
+	default:
+		return fmt.Errorf("%w: неизвестный вид %q", ErrInvalidItem, item.Kind)
+	}
+}
+
+// refund отменяет покупку, которую не удалось выдать: возвращает цену
+// (shop_refund, не больше одного раза на покупку), остаток и прежний тег
+// и помечает покупку возвращённой.
+func (s *Service) refund(ctx context.Context, purchase *Purchase, title string, previousTag *string) error {
+	return s.economy.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := s.repo.MarkPurchaseRefundedTx(ctx, tx, purchase.ID); err != nil {
+			return err
+		}
+		if err := s.repo.IncrementStockTx(ctx, tx, purchase.ItemID); err != nil {
+			return err
+		}
+		if err := s.members.UpdateMemberTagTx(ctx, tx, purchase.UserID, previousTag, s.now()); err != nil {
+			return err
+		}
+		_, err := s.economy.AddBalanceOnceTx(ctx, tx, RefundOperationKey(purchase.ID), purchase.UserID, purchase.Price,
+			economy.TxTypeShopRefund, fmt.Sprintf("Возврат покупки: %s", title))
+		return err
+	})
+}
+
+// RefundOperationKey — ключ идемпотентности возврата покупки.
+func RefundOperationKey(purchaseID int64) string {
+	return economy.OperationKey("shop_refund", purchaseID)
+}
+
+// owned сообщает, что роль или тег товара уже стоят у участника.
+func owned(member *members.Member, item *Item) bool {
+	var current *string
+	switch item.Kind {
+	case KindRole:
+		current = member.Role
+	case KindTag:
+		current = member.Tag
+	default:
+		return false
+	}
+	return current != nil && strings.EqualFold(strings.TrimSpace(*current), item.Payload)
+}
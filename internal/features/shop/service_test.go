@@ -0,0 +1,388 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/common"
+	"serotonyl.ru/telegram-bot/internal/features/economy"
+	"serotonyl.ru/telegram-bot/internal/features/members"
+)
+
+// fakeStore реализует репозиторий, экономику, участников, заморозки и
+// теги разом; WithTransaction откатывает всё состояние при ошибке.
+type fakeStore struct {
+	items     map[int64]Item
+	balances  map[int64]int64
+	txTypes   []string
+	purchases []Purchase
+	members   map[int64]members.Member
+	freezes   map[int64]int
+	telegram  map[int64]string
+	tagErr    error
+	refunded  map[int64]bool
+	inTx      bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		items:    make(map[int64]Item),
+		balances: make(map[int64]int64),
+		members:  map[int64]members.Member{7: {UserID: 7, Status: members.StatusActive}},
+		freezes:  make(map[int64]int),
+		telegram: make(map[int64]string),
+		refunded: make(map[int64]bool),
+	}
+}
+
+func newTestService(store *fakeStore) *Service {
+	return &Service{
+		repo:      store,
+		economy:   store,
+		members:   store,
+		freezes:   store,
+		tags:      store,
+		tagChatID: -100,
+		now:       func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) },
+	}
+}
+
+func (f *fakeStore) WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
+	items, balances, mems, freezes, tags, refunded := cloneMap(f.items), cloneMap(f.balances), cloneMap(f.members), cloneMap(f.freezes), cloneMap(f.telegram), cloneMap(f.refunded)
+	txTypes, purchases := len(f.txTypes), len(f.purchases)
+	f.inTx = true
+	err := fn(ctx, nil)
+	f.inTx = false
+	if err != nil {
+		f.items, f.balances, f.members, f.freezes, f.telegram, f.refunded = items, balances, mems, freezes, tags, refunded
+		f.txTypes, f.purchases = f.txTypes[:txTypes], f.purchases[:purchases]
+		return err
+	}
+	return nil
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func (f *fakeStore) DeductBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+	if f.balances[userID] < amount {
+		return common.ErrInsufficientBalance
+	}
+	f.balances[userID] -= amount
+	f.txTypes = append(f.txTypes, txType)
+	return nil
+}
+
+func (f *fakeStore) AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
+	f.balances[userID] += amount
+	f.txTypes = append(f.txTypes, txType)
+	return true, nil
+}
+
+func (f *fakeStore) ListItems(ctx context.Context, includeHidden bool) ([]*Item, error) {
+	var out []*Item
+	for _, item := range f.items {
+		if item.Active || includeHidden {
+			cp := item
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetItem(ctx context.Context, itemID int64) (*Item, error) {
+	item, ok := f.items[itemID]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	return &item, nil
+}
+
+func (f *fakeStore) LockItemTx(ctx context.Context, tx pgx.Tx, itemID int64) (*Item, error) {
+	return f.GetItem(ctx, itemID)
+}
+
+func (f *fakeStore) CreateItem(ctx context.Context, item *Item) (*Item, error) {
+	item.ID = int64(len(f.items) + 1)
+	f.items[item.ID] = *item
+	return item, nil
+}
+
+func (f *fakeStore) UpdateItemPrice(ctx context.Context, itemID, price int64) (*Item, error) {
+	return f.update(itemID, func(item *Item) { item.Price = price })
+}
+
+func (f *fakeStore) UpdateItemStock(ctx context.Context, itemID int64, stock *int) (*Item, error) {
+	return f.update(itemID, func(item *Item) { item.Stock = stock })
+}
+
+func (f *fakeStore) SetItemActive(ctx context.Context, itemID int64, active bool) (*Item, error) {
+	return f.update(itemID, func(item *Item) { item.Active = active })
+}
+
+func (f *fakeStore) update(itemID int64, fn func(*Item)) (*Item, error) {
+	item, ok := f.items[itemID]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	fn(&item)
+	f.items[itemID] = item
+	return &item, nil
+}
+
+func (f *fakeStore) DecrementStockTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
+	item := f.items[itemID]
+	if item.Stock != nil {
+		n := *item.Stock - 1
+		item.Stock = &n
+		f.items[itemID] = item
+	}
+	return nil
+}
+
+func (f *fakeStore) IncrementStockTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
+	item := f.items[itemID]
+	if item.Stock != nil {
+		n := *item.Stock + 1
+		item.Stock = &n
+		f.items[itemID] = item
+	}
+	return nil
+}
+
+func (f *fakeStore) MarkPurchaseRefundedTx(ctx context.Context, tx pgx.Tx, purchaseID int64) error {
+	f.refunded[purchaseID] = true
+	return nil
+}
+
+func (f *fakeStore) CreatePurchaseTx(ctx context.Context, tx pgx.Tx, p *Purchase) error {
+	p.ID = int64(len(f.purchases) + 1)
+	f.purchases = append(f.purchases, *p)
+	return nil
+}
+
+func (f *fakeStore) GetByUserID(ctx context.Context, userID int64) (*members.Member, error) {
+	m, ok := f.members[userID]
+	if !ok {
+		return nil, errors.New("member not found")
+	}
+	return &m, nil
+}
+
+func (f *fakeStore) UpdateRoleTx(ctx context.Context, tx pgx.Tx, userID int64, role string) error {
+	m := f.members[userID]
+	m.Role = &role
+	f.members[userID] = m
+	return nil
+}
+
+func (f *fakeStore) UpdateMemberTagTx(ctx context.Context, tx pgx.Tx, userID int64, tag *string, updatedAt time.Time) error {
+	m := f.members[userID]
+	m.Tag = tag
+	f.members[userID] = m
+	return nil
+}
+
+func (f *fakeStore) AddFreezeTokensTx(ctx context.Context, tx pgx.Tx, userID int64, count int) error {
+	f.freezes[userID] += count
+	return nil
+}
+
+func (f *fakeStore) SetChatMemberTag(ctx context.Context, chatID int64, userID int64, tag string) error {
+	if f.tagErr != nil {
+		return f.tagErr
+	}
+	f.telegram[userID] = tag
+	return nil
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestBuy_DebitsAndAppliesItem(t *testing.T) {
+	tests := []struct {
+		name  string
+		item  Item
+		check func(t *testing.T, store *fakeStore)
+	}{
+		{
+			name: "role",
+			item: Item{Kind: KindRole, Payload: "Меценат"},
+			check: func(t *testing.T, store *fakeStore) {
+				if role := store.members[7].Role; role == nil || *role != "Меценат" {
+					t.Fatalf("role = %v, want Меценат", role)
+				}
+			},
+		},
+		{
+			name: "tag",
+			item: Item{Kind: KindTag, Payload: "кит"},
+			check: func(t *testing.T, store *fakeStore) {
+				if tag := store.members[7].Tag; tag == nil || *tag != "кит" || store.telegram[7] != "кит" {
+					t.Fatalf("tag = %v, telegram = %q, want кит in both", tag, store.telegram[7])
+				}
+			},
+		},
+		{
+			name: "freeze",
+			item: Item{Kind: KindFreeze, Payload: "2"},
+			check: func(t *testing.T, store *fakeStore) {
+				if store.freezes[7] != 2 {
+					t.Fatalf("freezes = %d, want 2", store.freezes[7])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.balances[7] = 1000
+			tt.item.ID, tt.item.Title, tt.item.Price, tt.item.Stock, tt.item.Active = 1, "Товар", 300, intPtr(2), true
+			store.items[1] = tt.item
+			svc := newTestService(store)
+
+			purchase, err := svc.Buy(context.Background(), 7, 1)
+			if err != nil {
+				t.Fatalf("Buy: %v", err)
+			}
+			if purchase.Price != 300 || len(store.purchases) != 1 {
+				t.Fatalf("purchase = %+v, recorded = %d", purchase, len(store.purchases))
+			}
+			if store.balances[7] != 700 || len(store.txTypes) != 1 || store.txTypes[0] != economy.TxTypeShopPurchase {
+				t.Fatalf("balance = %d, tx types = %v", store.balances[7], store.txTypes)
+			}
+			if stock := store.items[1].Stock; *stock != 1 {
+				t.Fatalf("stock = %d, want 1", *stock)
+			}
+			tt.check(t, store)
+		})
+	}
+}
+
+func TestBuy_RefusalsLeaveNoSideEffects(t *testing.T) {
+	role := "Меценат"
+	tests := []struct {
+		name    string
+		balance int64
+		item    Item
+		member  *members.Member
+		wantErr error
+	}{
+		{name: "insufficient balance", balance: 100, item: Item{Kind: KindRole, Payload: "Меценат", Active: true}, wantErr: common.ErrInsufficientBalance},
+		{name: "out of stock", balance: 1000, item: Item{Kind: KindRole, Payload: "Меценат", Active: true, Stock: intPtr(0)}, wantErr: ErrOutOfStock},
+		{name: "hidden", balance: 1000, item: Item{Kind: KindRole, Payload: "Меценат"}, wantErr: ErrItemNotFound},
+		{name: "already owned", balance: 1000, item: Item{Kind: KindRole, Payload: "Меценат", Active: true},
+			member: &members.Member{UserID: 7, Status: members.StatusActive, Role: &role}, wantErr: ErrAlreadyOwned},
+		{name: "left member", balance: 1000, item: Item{Kind: KindFreeze, Payload: "1", Active: true},
+			member: &members.Member{UserID: 7, Status: members.StatusLeft}, wantErr: ErrNotMember},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.balances[7] = tt.balance
+			if tt.member != nil {
+				store.members[7] = *tt.member
+			}
+			tt.item.ID, tt.item.Title, tt.item.Price = 1, "Товар", 300
+			if tt.item.Stock == nil {
+				tt.item.Stock = intPtr(5)
+			}
+			store.items[1] = tt.item
+			svc := newTestService(store)
+
+			if _, err := svc.Buy(context.Background(), 7, 1); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if store.balances[7] != tt.balance || len(store.purchases) != 0 || len(store.txTypes) != 0 {
+				t.Fatalf("refused purchase left side effects: balance=%d purchases=%d", store.balances[7], len(store.purchases))
+			}
+			if *store.items[1].Stock != *tt.item.Stock || store.members[7].Tag != nil {
+				t.Fatalf("refused purchase changed stock or tag: %+v", store.items[1])
+			}
+		})
+	}
+}
+
+func TestBuy_RejectedTagIsRefunded(t *testing.T) {
+	store := newFakeStore()
+	store.balances[7] = 1000
+	previous := "рыба"
+	store.members[7] = members.Member{UserID: 7, Status: members.StatusActive, Tag: &previous}
+	store.items[1] = Item{ID: 1, Title: "Товар", Kind: KindTag, Payload: "кит", Price: 300, Stock: intPtr(5), Active: true}
+	store.tagErr = errors.New("not enough rights")
+	svc := newTestService(store)
+
+	var inTx bool
+	svc.tags = tagSetterFunc(func(ctx context.Context, chatID, userID int64, tag string) error {
+		inTx = store.inTx
+		return store.SetChatMemberTag(ctx, chatID, userID, tag)
+	})
+
+	if _, err := svc.Buy(context.Background(), 7, 1); !errors.Is(err, ErrTagRejected) {
+		t.Fatalf("err = %v, want ErrTagRejected", err)
+	}
+	if inTx {
+		t.Fatal("tag must be set in Telegram after the purchase transaction commits")
+	}
+	if store.balances[7] != 1000 || *store.items[1].Stock != 5 {
+		t.Fatalf("refund left balance=%d stock=%d, want 1000 and 5", store.balances[7], *store.items[1].Stock)
+	}
+	if len(store.txTypes) != 2 || store.txTypes[0] != economy.TxTypeShopPurchase || store.txTypes[1] != economy.TxTypeShopRefund {
+		t.Fatalf("tx types = %v, want purchase then refund", store.txTypes)
+	}
+	if len(store.purchases) != 1 || !store.refunded[store.purchases[0].ID] {
+		t.Fatalf("purchase must stay in history marked refunded: %+v, refunded=%v", store.purchases, store.refunded)
+	}
+	if tag := store.members[7].Tag; tag == nil || *tag != previous {
+		t.Fatalf("tag = %v, want previous %q restored", tag, previous)
+	}
+}
+
+type tagSetterFunc func(ctx context.Context, chatID, userID int64, tag string) error
+
+func (f tagSetterFunc) SetChatMemberTag(ctx context.Context, chatID, userID int64, tag string) error {
+	return f(ctx, chatID, userID, tag)
+}
+
+func TestParseItem(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    *Item
+		wantErr bool
+	}{
+		{text: "роль | Меценат | 500 | 10 | Меценат | Для щедрых", want: &Item{Kind: KindRole, Title: "Меценат", Price: 500, Stock: intPtr(10), Payload: "Меценат", Description: "Для щедрых"}},
+		{text: "Заморозка | Лёд | 150 | - | 1", want: &Item{Kind: KindFreeze, Title: "Лёд", Price: 150, Payload: "1"}},
+		{text: "тег | Кит | 1000 | ∞ | кит", want: &Item{Kind: KindTag, Title: "Кит", Price: 1000, Payload: "кит"}},
+		{text: "тег | Длинный | 100 | - | слишком длинный тег", wantErr: true},
+		{text: "заморозка | Лёд | 150 | - | 0", wantErr: true},
+		{text: "значок | X | 100 | - | x", wantErr: true},
+		{text: "роль | X | 0 | - | x", wantErr: true},
+		{text: "роль | X | 100 | -1 | x", wantErr: true},
+		{text: "роль | X | 100", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseItem(tt.text)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidItem) {
+				t.Fatalf("ParseItem(%q) err = %v, want ErrInvalidItem", tt.text, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParseItem(%q): %v", tt.text, err)
+		}
+		if got.Kind != tt.want.Kind || got.Title != tt.want.Title || got.Price != tt.want.Price ||
+			got.Payload != tt.want.Payload || got.Description != tt.want.Description || !got.Active ||
+			(got.Stock == nil) != (tt.want.Stock == nil) || (got.Stock != nil && *got.Stock != *tt.want.Stock) {
+			t.Fatalf("ParseItem(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
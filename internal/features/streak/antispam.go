@@ -0,0 +1,217 @@
+package streak
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+
+	"serotonyl.ru/telegram-bot/internal/config"
+)
+
+// SpamRules — правила антиспама огонька. Нулевой лимит отключает правило.
+type SpamRules struct {
+	// MaxPerMinute — сколько сообщений засчитывается за минуту.
+	MaxPerMinute int
+	// DuplicateWindow — сколько помнить засчитанные тексты для поиска повторов.
+	DuplicateWindow time.Duration
+	// Similarity — доля общих шинглов (0..1], начиная с которой текст считается
+	// повтором; 1 — только точные повторы.
+	Similarity float64
+	// FloodLimit — сколько стикеров и сообщений из одних эмодзи за FloodWindow
+	// ставят засчитывание на паузу до конца окна.
+	FloodLimit  int
+	FloodWindow time.Duration
+}
+
+// DefaultSpamRules — 2 сообщения в минуту и точные повторы за 3 секунды;
+// почти-повторы и пауза после стикеров включаются настройками.
+func DefaultSpamRules() SpamRules {
+	return SpamRules{
+		MaxPerMinute:    2,
+		DuplicateWindow: 3 * time.Second,
+		Similarity:      1,
+		FloodWindow:     2 * time.Minute,
+	}
+}
+
+// SpamRulesFromConfig собирает правила из настроек STREAK_SPAM_*.
+func SpamRulesFromConfig(cfg *config.Config) SpamRules {
+	if cfg == nil {
+		return DefaultSpamRules()
+	}
+	return SpamRules{
+		MaxPerMinute:    cfg.StreakSpamMaxPerMinute,
+		DuplicateWindow: cfg.StreakSpamDuplicateWindow,
+		Similarity:      cfg.StreakSpamSimilarity,
+		FloodLimit:      cfg.StreakSpamFloodLimit,
+		FloodWindow:     cfg.StreakSpamFloodWindow,
+	}
+}
+
+// lookback — за сколько назад правилам нужна история участника.
+func (r SpamRules) lookback() time.Duration {
+	return max(time.Minute, r.DuplicateWindow, r.FloodWindow)
+}
+
+// allow решает, засчитать ли сообщение normalized с учётом недавних событий.
+func (r SpamRules) allow(events []SpamEvent, normalized string, now time.Time) bool {
+	shingles := shingleSet(normalized)
+	counted, noise := 0, 0
+	for _, e := range events {
+		age := now.Sub(e.At)
+		if e.Noise {
+			if age < r.FloodWindow {
+				noise++
+			}
+			continue
+		}
+		if age < time.Minute {
+			counted++
+		}
+		if age <= r.DuplicateWindow && r.isRepeat(normalized, shingles, e.Text) {
+			return false
+		}
+	}
+	if r.MaxPerMinute > 0 && counted >= r.MaxPerMinute {
+		return false
+	}
+	if r.FloodLimit > 0 && noise >= r.FloodLimit {
+		return false
+	}
+	return true
+}
+
+// isRepeat сравнивает текст с засчитанным ранее: при пороге 1 — только
+// точное совпадение, иначе по похожести шинглов.
+func (r SpamRules) isRepeat(normalized string, shingles map[string]struct{}, previous string) bool {
+	if r.Similarity >= 1 {
+		return normalized == previous
+	}
+	return similarity(shingles, shingleSet(previous)) >= r.Similarity
+}
+
+// SpamEvent — сообщение участника, которое помнит антиспам: засчитанный
+// текст (нормализованный) или шум — стикер либо сообщение из одних эмодзи.
+type SpamEvent struct {
+	UserID int64
+	At     time.Time
+	Noise  bool
+	Text   string
+}
+
+// antiSpamStore хранит недавние события антиспама. Память подходит для
+// одного процесса; Repository хранит их в Postgres, чтобы правила
+// переживали рестарт и работали на нескольких инстансах.
+//
+// Проверка и запись засчитанного сообщения идут в транзакции огонька под
+// блокировкой строки streaks, поэтому параллельные сообщения участника
+// не проходят проверку вместе. Postgres откатывает событие вместе с
+// транзакцией, память — через ReleaseSpamEvent.
+type antiSpamStore interface {
+	RecentSpamEventsTx(ctx context.Context, tx pgx.Tx, userID int64, since time.Time) ([]SpamEvent, error)
+	RecordSpamEventTx(ctx context.Context, tx pgx.Tx, e SpamEvent) error
+	ReleaseSpamEvent(ctx context.Context, e SpamEvent) error
+	RecordSpamEvent(ctx context.Context, e SpamEvent) error
+}
+
+// MemorySpamStore — антиспам в памяти процесса; события старше since
+// последнего запроса выбрасываются. Транзакцию он не видит: событие
+// записывается сразу, а из упавшей транзакции снимается ReleaseSpamEvent.
+type MemorySpamStore struct {
+	mu     sync.Mutex
+	events map[int64][]SpamEvent
+}
+
+func NewMemorySpamStore() *MemorySpamStore {
+	return &MemorySpamStore{events: make(map[int64][]SpamEvent)}
+}
+
+func (m *MemorySpamStore) RecentSpamEventsTx(ctx context.Context, _ pgx.Tx, userID int64, since time.Time) ([]SpamEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.events[userID][:0]
+	for _, e := range m.events[userID] {
+		if !e.At.Before(since) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		delete(m.events, userID)
+		return nil, nil
+	}
+	m.events[userID] = kept
+	return append([]SpamEvent(nil), kept...), nil
+}
+
+func (m *MemorySpamStore) RecordSpamEventTx(ctx context.Context, _ pgx.Tx, e SpamEvent) error {
+	return m.RecordSpamEvent(ctx, e)
+}
+
+func (m *MemorySpamStore) RecordSpamEvent(ctx context.Context, e SpamEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[e.UserID] = append(m.events[e.UserID], e)
+	return nil
+}
+
+// ReleaseSpamEvent убирает событие, записанное в упавшей транзакции.
+func (m *MemorySpamStore) ReleaseSpamEvent(ctx context.Context, e SpamEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := m.events[e.UserID]
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i] == e {
+			m.events[e.UserID] = append(events[:i], events[i+1:]...)
+			break
+		}
+	}
+	if len(m.events[e.UserID]) == 0 {
+		delete(m.events, e.UserID)
+	}
+	return nil
+}
+
+// isSpamNoise — стикер (передаётся как его эмодзи) или сообщение из одних эмодзи.
+func isSpamNoise(text string) bool {
+	return IsEmojiOnly(text)
+}
+
+// shingleSize — длина шингла в словах.
+const shingleSize = 2
+
+// shingleSet разбивает текст на шинглы — пары соседних слов без
+// пунктуации. Текст короче шингла даёт один шингл из всех слов.
+func shingleSet(normalized string) map[string]struct{} {
+	words := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]struct{})
+	if len(words) < shingleSize {
+		set[strings.Join(words, " ")] = struct{}{}
+		return set
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		set[strings.Join(words[i:i+shingleSize], " ")] = struct{}{}
+	}
+	return set
+}
+
+// similarity — коэффициент Жаккара двух множеств шинглов.
+func similarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,186 @@
+package streak
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestCountMessage_NearDuplicateExcluded(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, _, current := newTestService(now)
+	svc.spamRules.DuplicateWindow = 10 * time.Minute
+	svc.spamRules.Similarity = 0.8
+
+	if err := svc.CountMessage(context.Background(), 2, 1, "сегодня идём гулять в парк вечером"); err != nil {
+		t.Fatal(err)
+	}
+	*current = now.Add(5 * time.Minute)
+	if err := svc.CountMessage(context.Background(), 2, 2, "Сегодня, идём гулять в парк вечером!!"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.byUser[2].MessagesToday; got != 1 {
+		t.Fatalf("expected near-duplicate to be skipped, got %d counted", got)
+	}
+
+	*current = now.Add(11 * time.Minute)
+	if err := svc.CountMessage(context.Background(), 2, 3, "сегодня идём гулять в парк вечером"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.byUser[2].MessagesToday; got != 2 {
+		t.Fatalf("expected repeat outside the window to count, got %d", got)
+	}
+}
+
+func TestCountMessage_DefaultRulesSkipOnlyExactRepeats(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, _, current := newTestService(now)
+
+	text := "сегодня идём гулять в парк вечером"
+	if err := svc.CountMessage(context.Background(), 3, 1, text); err != nil {
+		t.Fatal(err)
+	}
+	*current = now.Add(2 * time.Second)
+	if err := svc.CountMessage(context.Background(), 3, 2, text); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.byUser[3].MessagesToday; got != 1 {
+		t.Fatalf("expected exact repeat within 3s to be skipped, got %d counted", got)
+	}
+
+	if err := svc.CountMessage(context.Background(), 3, 3, "Сегодня, идём гулять в парк вечером!! ага"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.byUser[3].MessagesToday; got != 2 {
+		t.Fatalf("expected near-duplicate to count by default, got %d", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := svc.CountMessage(context.Background(), 6, int64(i+1), "😂"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.CountMessage(context.Background(), 6, 10, "а теперь вполне нормальное сообщение"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.byUser[6].MessagesToday; got != 1 {
+		t.Fatalf("expected stickers not to pause counting by default, got %d", got)
+	}
+}
+
+func TestCountMessage_StickerFloodPausesCounting(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, _, current := newTestService(now)
+	svc.spamRules.FloodLimit = 3
+
+	for i := 0; i < 3; i++ {
+		if err := svc.CountMessage(context.Background(), 4, int64(i+1), "😂"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.CountMessage(context.Background(), 4, 10, "а теперь вполне нормальное сообщение"); err != nil {
+		t.Fatal(err)
+	}
+	if st := repo.byUser[4]; st != nil && st.MessagesToday != 0 {
+		t.Fatalf("expected flood to pause counting, got %+v", st)
+	}
+
+	*current = now.Add(3 * time.Minute)
+	if err := svc.CountMessage(context.Background(), 4, 11, "а теперь вполне нормальное сообщение"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.byUser[4].MessagesToday; got != 1 {
+		t.Fatalf("expected counting to resume after the flood window, got %d", got)
+	}
+}
+
+func TestSpamRules_ZeroLimitsDisableRules(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	rules := SpamRules{Similarity: 1}
+	events := []SpamEvent{
+		{At: now.Add(-time.Second), Text: "раз два три четыре пять"},
+		{At: now.Add(-time.Second), Text: "раз два три четыре шесть"},
+		{At: now.Add(-time.Second), Noise: true},
+	}
+	if !rules.allow(events, "раз два три четыре семь", now) {
+		t.Fatal("expected zero limits to allow the message")
+	}
+	if rules.allow(events, "раз два три четыре пять", now.Add(-time.Second)) {
+		t.Fatal("expected exact repeat inside a zero window to be rejected")
+	}
+}
+
+func TestSimilarity_Shingles(t *testing.T) {
+	a := shingleSet(normalizeMessageText("раз два три четыре пять"))
+	b := shingleSet(normalizeMessageText("раз два три четыре шесть"))
+	if got := similarity(a, b); got < 0.59 || got > 0.61 {
+		t.Fatalf("expected 3/5 shared shingles, got %v", got)
+	}
+	if got := similarity(a, a); got != 1 {
+		t.Fatalf("expected identical texts to be fully similar, got %v", got)
+	}
+}
+
+func TestMemorySpamStore_DropsOldEvents(t *testing.T) {
+	store := NewMemorySpamStore()
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	_ = store.RecordSpamEvent(ctx, SpamEvent{UserID: 1, At: now.Add(-time.Hour), Text: "старое"})
+	_ = store.RecordSpamEvent(ctx, SpamEvent{UserID: 1, At: now, Text: "новое"})
+
+	events, err := store.RecentSpamEventsTx(ctx, nil, 1, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Text != "новое" {
+		t.Fatalf("expected only the recent event, got %+v", events)
+	}
+	if len(store.events[1]) != 1 {
+		t.Fatalf("expected old events pruned, got %+v", store.events[1])
+	}
+}
+
+// txCheckingSpamStore запоминает вызовы антиспама вне транзакции огонька.
+type txCheckingSpamStore struct {
+	*MemorySpamStore
+	econ      *fakeEconomy
+	outsideTx []string
+}
+
+func (s *txCheckingSpamStore) RecentSpamEventsTx(ctx context.Context, tx pgx.Tx, userID int64, since time.Time) ([]SpamEvent, error) {
+	if !s.econ.inTx {
+		s.outsideTx = append(s.outsideTx, "recent")
+	}
+	return s.MemorySpamStore.RecentSpamEventsTx(ctx, tx, userID, since)
+}
+
+func (s *txCheckingSpamStore) RecordSpamEventTx(ctx context.Context, tx pgx.Tx, e SpamEvent) error {
+	if !s.econ.inTx {
+		s.outsideTx = append(s.outsideTx, "record")
+	}
+	return s.MemorySpamStore.RecordSpamEventTx(ctx, tx, e)
+}
+
+func TestCountMessage_AntiSpamCheckedAndRecordedInTransaction(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, econ, current := newTestService(now)
+	store := &txCheckingSpamStore{MemorySpamStore: NewMemorySpamStore(), econ: econ}
+	svc.SetAntiSpamStore(store)
+
+	text := "сегодня идём гулять в парк вечером"
+	if err := svc.CountMessage(context.Background(), 5, 1, text); err != nil {
+		t.Fatal(err)
+	}
+	*current = now.Add(time.Second)
+	if err := svc.CountMessage(context.Background(), 5, 2, text); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.outsideTx) != 0 {
+		t.Fatalf("anti-spam must check and record inside the streak transaction, got calls outside: %v", store.outsideTx)
+	}
+	if got := repo.byUser[5].MessagesToday; got != 1 {
+		t.Fatalf("expected the repeat to be rejected, got %d counted", got)
+	}
+}
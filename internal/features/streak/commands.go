@@ -19,10 +19,22 @@ func RegisterCommands(r *commands.Router, h *Handler, cfg *config.Config) {
 		h.HandleOgonek(ctx, c.ChatID, c.UserID, c.MessageID)
 	})
 
+	r.Register("календарь", func(ctx context.Context, c commands.Context, args []string) {
+		if cfg == nil || c.ChatID != cfg.MemberSourceChatID {
+			return
+		}
+		h.HandleCalendar(ctx, c.ChatID, c.UserID, c.MessageID)
+	})
+
 	r.Register("топогонек", func(ctx context.Context, c commands.Context, args []string) {
 		if cfg == nil || c.ChatID != cfg.MemberSourceChatID {
 			return
 		}
-		h.HandleTopOgonek(ctx, c.ChatID, c.MessageID)
+		board, ok := ParseBoard(args)
+		if !ok {
+			h.sendMessage(ctx, c.ChatID, "Использование: !топогонек [рекорд|всего]", c.MessageID)
+			return
+		}
+		h.HandleTopOgonek(ctx, c.ChatID, board, c.MessageID)
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -36,25 +37,61 @@ func (h *Handler) HandleOgonek(ctx context.Context, chatID int64, userID int64,
 		return
 	}
 
-	nextReward := CalculateReward(st.CurrentStreak)
-	if st.QuotaCompletedToday {
-		nextReward = CalculateReward(st.CurrentStreak)
-	}
+	policy := h.service.Policy()
+	nextDay := st.CurrentStreak + 1
+	nextReward := policy.Reward(nextDay) + policy.MilestoneBonus(nextDay)
 
 	text := fmt.Sprintf(
-		"🔥 Огонёк: %d %s\nСегодня: %d/%d\nСтатус: %s\nСледующая награда: %s",
+		"🔥 Огонёк: %d %s\nСегодня: %d/%d\nСтатус: %s\nСледующая награда: %s\n❄️ Заморозки: %d",
 		st.CurrentStreak,
 		common.PluralizeDays(st.CurrentStreak),
 		st.MessagesToday,
-		dailyMessageTarget,
+		policy.MessagesNeed,
 		streakStatusText(st),
 		common.FormatBalance(nextReward),
+		st.FreezeTokens,
 	)
+	if line := nextMilestoneText(policy, st.CurrentStreak); line != "" {
+		text += "\n" + line
+	}
 	h.sendMessage(ctx, chatID, text, replyToMessageID)
 }
 
-func (h *Handler) HandleTopOgonek(ctx context.Context, chatID int64, replyToMessageID int) {
-	top, err := h.service.GetTop(ctx, 10)
+// HandleCalendar показывает огонёк участника за текущий месяц сеткой эмодзи.
+func (h *Handler) HandleCalendar(ctx context.Context, chatID int64, userID int64, replyToMessageID int) {
+	cal, err := h.service.GetCalendar(ctx, userID)
+	if err != nil {
+		log.WithError(err).Error("get streak calendar failed")
+		h.sendMessage(ctx, chatID, "❌ Не удалось получить календарь огонька.", replyToMessageID)
+		return
+	}
+	h.sendMessage(ctx, chatID, renderCalendar(cal), replyToMessageID)
+}
+
+// topBoards — заголовки досок топа огонька.
+var topBoards = map[Board]string{
+	BoardCurrent: "🔥 Топ огонька",
+	BoardLongest: "🏆 Рекорды огонька",
+	BoardTotal:   "📆 Топ по закрытым дням",
+}
+
+// ParseBoard разбирает аргумент !топогонек: без аргумента — текущие серии,
+// "рекорд" — самые длинные серии, "всего" — всего закрытых дней.
+func ParseBoard(args []string) (Board, bool) {
+	if len(args) == 0 {
+		return BoardCurrent, true
+	}
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "рекорд", "рекорды":
+		return BoardLongest, true
+	case "всего", "дни":
+		return BoardTotal, true
+	}
+	return BoardCurrent, false
+}
+
+func (h *Handler) HandleTopOgonek(ctx context.Context, chatID int64, board Board, replyToMessageID int) {
+	top, err := h.service.GetTop(ctx, board, 10)
 	if err != nil {
 		log.WithError(err).Error("get top streaks failed")
 		h.sendMessage(ctx, chatID, "❌ Не удалось получить топ огонька.", replyToMessageID)
@@ -66,13 +103,98 @@ func (h *Handler) HandleTopOgonek(ctx context.Context, chatID int64, replyToMess
 	}
 
 	var lines []string
-	lines = append(lines, "🔥 Топ огонька")
+	lines = append(lines, topBoards[board])
 	for i, entry := range top {
-		lines = append(lines, fmt.Sprintf("%d. %s — %d %s", i+1, h.displayName(ctx, entry.UserID), entry.CurrentStreak, common.PluralizeDays(entry.CurrentStreak)))
+		lines = append(lines, fmt.Sprintf("%d. %s — %d %s", i+1, h.displayName(ctx, entry.UserID), entry.Value, common.PluralizeDays(entry.Value)))
 	}
 	h.sendMessage(ctx, chatID, strings.Join(lines, "\n"), replyToMessageID)
 }
 
+// AnnounceCelebration поздравляет участника с вехой огонька в чате участников.
+func (h *Handler) AnnounceCelebration(ctx context.Context, c Celebration) error {
+	if h.cfg == nil || h.cfg.MemberSourceChatID == 0 {
+		return nil
+	}
+	text := fmt.Sprintf("🎉 %s держит огонёк уже %d %s подряд! 🔥", h.displayName(ctx, c.UserID), c.Milestone, common.PluralizeDays(c.Milestone))
+	if bonus := h.service.Policy().MilestoneBonus(c.Milestone); bonus > 0 {
+		text += "\nБонус за веху: " + common.FormatBalance(bonus) + "."
+	}
+	_, err := h.tgOps.SendWithOptions(ctx, telegram.SendOptions{ChatID: h.cfg.MemberSourceChatID, Text: text})
+	return err
+}
+
+var monthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+// renderCalendar рисует месяц неделями с понедельника: ✅ день закрыт,
+// 🟡 начат, ❄️ спасён заморозкой, ▪️ пропущен, ⬜ ещё впереди.
+func renderCalendar(cal *Calendar) string {
+	lines := []string{fmt.Sprintf("📅 %s %d", monthNames[cal.Month.Month()-1], cal.Month.Year())}
+
+	daysInMonth := cal.Month.AddDate(0, 1, -1).Day()
+	offset := (int(cal.Month.Weekday()) + 6) % 7
+	week := make([]string, 0, 7)
+	for i := 0; i < offset; i++ {
+		week = append(week, "➖")
+	}
+	completed, elapsed := 0, 0
+	for day := 1; day <= daysInMonth; day++ {
+		current := time.Date(cal.Month.Year(), cal.Month.Month(), day, 0, 0, 0, 0, cal.Month.Location())
+		d, ok := cal.Days[day]
+		if d.Completed {
+			completed++
+		}
+		if !current.After(cal.Today) {
+			elapsed++
+		}
+		week = append(week, calendarCell(d, ok, current.After(cal.Today) || sameDay(current, cal.Today)))
+		if len(week) == 7 {
+			lines = append(lines, strings.Join(week, ""))
+			week = week[:0]
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < 7 {
+			week = append(week, "➖")
+		}
+		lines = append(lines, strings.Join(week, ""))
+	}
+
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Закрыто дней: %d из %d", completed, elapsed),
+		"✅ закрыт · 🟡 начат · ❄️ заморозка · ▪️ пропуск",
+	)
+	return strings.Join(lines, "\n")
+}
+
+func calendarCell(d Day, ok, pending bool) string {
+	switch {
+	case ok && d.Completed:
+		return "✅"
+	case ok && d.FreezeUsed:
+		return "❄️"
+	case ok && d.Messages > 0:
+		return "🟡"
+	case pending:
+		return "⬜"
+	default:
+		return "▪️"
+	}
+}
+
+// nextMilestoneText — ближайшая веха после текущего дня серии.
+func nextMilestoneText(policy Policy, currentStreak int) string {
+	for _, day := range policy.MilestoneDays() {
+		if day > currentStreak {
+			return fmt.Sprintf("🏁 Веха: %d %s (+%s)", day, common.PluralizeDays(day), common.FormatBalance(policy.MilestoneBonus(day)))
+		}
+	}
+	return ""
+}
+
 func streakStatusText(st *Streak) string {
 	if st.QuotaCompletedToday {
 		return "сегодня закрыт"
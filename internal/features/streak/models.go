@@ -2,13 +2,6 @@ package streak
 
 import "time"
 
-const (
-	dailyMessageTarget = 4
-	maxRewardDay       = 7
-	maxValidPerMinute  = 2
-	duplicateWindow    = 3 * time.Second
-)
-
 type Streak struct {
 	ID                   int64      `db:"id"`
 	UserID               int64      `db:"user_id"`
@@ -22,19 +15,80 @@ type Streak struct {
 	LastMessageAt        *time.Time `db:"last_message_at"`
 	TotalQuotasCompleted int        `db:"total_quotas_completed"`
 	ReminderSentToday    bool       `db:"reminder_sent_today"`
+	FreezeTokens         int        `db:"freeze_tokens"`
+	LastFrozenDay        *time.Time `db:"last_frozen_day"`
 	CreatedAt            time.Time  `db:"created_at"`
 	UpdatedAt            time.Time  `db:"updated_at"`
+
+	// frozenDays — дни, закрытые заморозками при нормализации; пишутся в
+	// streak_days при сохранении состояния.
+	frozenDays []time.Time
 }
 
+// Board — по какому показателю строится топ огонька.
+type Board int
+
+const (
+	BoardCurrent Board = iota // текущая серия
+	BoardLongest              // рекорд серии
+	BoardTotal                // всего закрытых дней
+)
+
+// column — колонка streaks, по которой сортируется топ.
+func (b Board) column() string {
+	switch b {
+	case BoardLongest:
+		return "longest_streak"
+	case BoardTotal:
+		return "total_quotas_completed"
+	default:
+		return "current_streak"
+	}
+}
+
+// TopEntry — строка топа: участник и значение показателя доски.
 type TopEntry struct {
-	UserID        int64
-	CurrentStreak int
+	UserID int64
+	Value  int
+}
+
+// Celebration — веха огонька, о которой бот объявляет в чате участников.
+// Одна серия достигает вехи один раз, поэтому пара (веха, день) уникальна.
+type Celebration struct {
+	UserID    int64
+	Milestone int
+	ReachedOn time.Time
+}
+
+// Day — итог одного дня огонька участника (строка streak_days).
+type Day struct {
+	UserID     int64
+	Day        time.Time
+	Messages   int
+	Completed  bool
+	Reward     int64
+	FreezeUsed bool
 }
 
-func GetReward(currentStreak int) int64 {
-	day := currentStreak + 1
-	if day > maxRewardDay {
-		day = maxRewardDay
+// DayStats — сводка закрытия квоты за один день по всем участникам.
+type DayStats struct {
+	Day       time.Time
+	Active    int // писали хотя бы одно засчитанное сообщение
+	Completed int
+	Frozen    int
+}
+
+// CompletionRate — доля активных участников, закрывших день, в процентах.
+func (d DayStats) CompletionRate() float64 {
+	if d.Active == 0 {
+		return 0
 	}
-	return int64(day * 10)
+	return float64(d.Completed) * 100 / float64(d.Active)
+}
+
+// Calendar — дни огонька участника за месяц для !календарь.
+type Calendar struct {
+	Month time.Time   // первое число месяца
+	Today time.Time   // начало сегодняшнего дня
+	Days  map[int]Day // по числу месяца; нет записи — день без активности
 }
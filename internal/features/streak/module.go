@@ -21,6 +21,9 @@ type Module struct {
 
 func NewModule(deps Deps) (*Module, error) {
 	h := NewHandler(deps.Service, deps.Members, deps.Ops, deps.Cfg)
+	if deps.Service != nil && deps.Ops != nil {
+		deps.Service.SetCelebrationAnnouncer(h.AnnounceCelebration)
+	}
 	f := NewFeature(h, deps.Cfg)
 	return &Module{Handler: h, Feature: f}, nil
 }
@@ -0,0 +1,235 @@
+package streak
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"serotonyl.ru/telegram-bot/internal/config"
+)
+
+// maxPolicyDays ограничивает длину таблицы наград и дни вех: огонёк длиннее
+// трёх лет — скорее опечатка в конфиге, чем план.
+const maxPolicyDays = 1000
+
+// Policy — правила огонька: сколько сообщений закрывают день, награда за
+// каждый день серии, бонусы за круглые даты и дни, о которых бот объявляет
+// в чате.
+type Policy struct {
+	// MessagesNeed — сколько засчитанных сообщений закрывают день.
+	MessagesNeed int
+	// Rewards[i] — награда за (i+1)-й день серии. Последний элемент — день
+	// потолка: дальше награда не растёт.
+	Rewards []int64
+	// Milestones — разовый бонус в день, когда серия достигает ключа.
+	Milestones map[int]int64
+	// Celebrations — дни серии, о достижении которых бот поздравляет в чате
+	// участников, по возрастанию.
+	Celebrations []int
+	// FreezeEarnEvery — за каждые столько закрытых дней начисляется
+	// заморозка; 0 — заморозки только из магазина.
+	FreezeEarnEvery int
+	// FreezeMax — сколько заморозок можно накопить начислением; 0 — без
+	// ограничения.
+	FreezeMax int
+}
+
+// DefaultPolicy — 4 сообщения в день, награда 10 за день с потолком 70 на
+// седьмом дне, без вех; заморозка за каждые 10 закрытых дней, не больше 3.
+func DefaultPolicy() Policy {
+	return Policy{
+		MessagesNeed:    4,
+		Rewards:         []int64{10, 20, 30, 40, 50, 60, 70},
+		FreezeEarnEvery: 10,
+		FreezeMax:       3,
+	}
+}
+
+// NewPolicy собирает и проверяет политику из STREAK_MESSAGES_NEED,
+// STREAK_REWARDS, STREAK_MILESTONES, STREAK_CELEBRATIONS и STREAK_FREEZE_*.
+func NewPolicy(cfg *config.Config) (Policy, error) {
+	if cfg == nil {
+		return DefaultPolicy(), nil
+	}
+	rewards, err := ParseRewards(cfg.StreakRewards)
+	if err != nil {
+		return Policy{}, fmt.Errorf("STREAK_REWARDS: %w", err)
+	}
+	milestones, err := ParseMilestones(cfg.StreakMilestones)
+	if err != nil {
+		return Policy{}, fmt.Errorf("STREAK_MILESTONES: %w", err)
+	}
+	celebrations, err := ParseCelebrations(cfg.StreakCelebrations)
+	if err != nil {
+		return Policy{}, fmt.Errorf("STREAK_CELEBRATIONS: %w", err)
+	}
+	p := Policy{
+		MessagesNeed:    cfg.StreakMessagesNeed,
+		Rewards:         rewards,
+		Milestones:      milestones,
+		Celebrations:    celebrations,
+		FreezeEarnEvery: cfg.StreakFreezeEarnEvery,
+		FreezeMax:       cfg.StreakFreezeMax,
+	}
+	if err := p.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// ParseRewards разбирает таблицу наград "10,20,30": i-е число — награда за
+// i-й день серии.
+func ParseRewards(raw string) ([]int64, error) {
+	var out []int64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("награда %q не число", part)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// ParseMilestones разбирает вехи "30:300,100:1000" (день:бонус).
+func ParseMilestones(raw string) (map[int]int64, error) {
+	out := make(map[int]int64)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		dayRaw, bonusRaw, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("веха %q: нужен формат день:бонус", part)
+		}
+		day, err := strconv.Atoi(strings.TrimSpace(dayRaw))
+		if err != nil {
+			return nil, fmt.Errorf("веха %q: день не число", part)
+		}
+		bonus, err := strconv.ParseInt(strings.TrimSpace(bonusRaw), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("веха %q: бонус не число", part)
+		}
+		if _, dup := out[day]; dup {
+			return nil, fmt.Errorf("веха на день %d указана дважды", day)
+		}
+		out[day] = bonus
+	}
+	return out, nil
+}
+
+// ParseCelebrations разбирает дни поздравлений "7,30,100"; повторы
+// схлопываются, результат отсортирован.
+func ParseCelebrations(raw string) ([]int, error) {
+	seen := make(map[int]bool)
+	var out []int
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("день %q не число", part)
+		}
+		if seen[day] {
+			continue
+		}
+		seen[day] = true
+		out = append(out, day)
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// Validate проверяет политику: квота и таблица наград непустые, суммы
+// неотрицательные, дни вех в пределах, настройки заморозок не меньше нуля.
+func (p Policy) Validate() error {
+	if p.MessagesNeed <= 0 {
+		return fmt.Errorf("STREAK_MESSAGES_NEED must be > 0")
+	}
+	if len(p.Rewards) == 0 {
+		return fmt.Errorf("STREAK_REWARDS must list at least one reward")
+	}
+	if len(p.Rewards) > maxPolicyDays {
+		return fmt.Errorf("STREAK_REWARDS must list at most %d rewards", maxPolicyDays)
+	}
+	for i, r := range p.Rewards {
+		if r < 0 {
+			return fmt.Errorf("STREAK_REWARDS: reward for day %d must be >= 0", i+1)
+		}
+	}
+	for day, bonus := range p.Milestones {
+		if day <= 0 || day > maxPolicyDays {
+			return fmt.Errorf("STREAK_MILESTONES: day %d must be in range [1..%d]", day, maxPolicyDays)
+		}
+		if bonus <= 0 {
+			return fmt.Errorf("STREAK_MILESTONES: bonus for day %d must be > 0", day)
+		}
+	}
+	for _, day := range p.Celebrations {
+		if day <= 0 || day > maxPolicyDays {
+			return fmt.Errorf("STREAK_CELEBRATIONS: day %d must be in range [1..%d]", day, maxPolicyDays)
+		}
+	}
+	if p.FreezeEarnEvery < 0 || p.FreezeMax < 0 {
+		return fmt.Errorf("STREAK_FREEZE_EARN_EVERY and STREAK_FREEZE_MAX must be >= 0")
+	}
+	return nil
+}
+
+// EarnsFreeze сообщает, начисляется ли заморозка за totalCompleted-й
+// закрытый день участнику, у которого на руках tokens заморозок.
+func (p Policy) EarnsFreeze(totalCompleted, tokens int) bool {
+	if p.FreezeEarnEvery <= 0 || totalCompleted%p.FreezeEarnEvery != 0 {
+		return false
+	}
+	return p.FreezeMax == 0 || tokens < p.FreezeMax
+}
+
+// CapDay — день серии, после которого награда не растёт.
+func (p Policy) CapDay() int {
+	return len(p.Rewards)
+}
+
+// Reward возвращает награду за day-й день серии (с 1).
+func (p Policy) Reward(day int) int64 {
+	if len(p.Rewards) == 0 || day <= 0 {
+		return 0
+	}
+	if day > len(p.Rewards) {
+		day = len(p.Rewards)
+	}
+	return p.Rewards[day-1]
+}
+
+// MilestoneBonus возвращает бонус за достижение day-го дня серии; 0 — не веха.
+func (p Policy) MilestoneBonus(day int) int64 {
+	return p.Milestones[day]
+}
+
+// MilestoneDays возвращает дни вех по возрастанию.
+func (p Policy) MilestoneDays() []int {
+	days := make([]int, 0, len(p.Milestones))
+	for day := range p.Milestones {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+	return days
+}
+
+// Celebrates сообщает, поздравляет ли бот с day-м днём серии.
+func (p Policy) Celebrates(day int) bool {
+	for _, d := range p.Celebrations {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package streak
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"serotonyl.ru/telegram-bot/internal/config"
+)
+
+func TestNewPolicy_DefaultsMatchDefaultPolicy(t *testing.T) {
+	got, err := NewPolicy(&config.Config{StreakMessagesNeed: 4, StreakRewards: "10,20,30,40,50,60,70", StreakFreezeEarnEvery: 10, StreakFreezeMax: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultPolicy()
+	if got.MessagesNeed != want.MessagesNeed || !reflect.DeepEqual(got.Rewards, want.Rewards) || len(got.Milestones) != 0 {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestNewPolicy_RejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{name: "zero quota", cfg: config.Config{StreakMessagesNeed: 0, StreakRewards: "10"}, want: "STREAK_MESSAGES_NEED"},
+		{name: "empty rewards", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: " "}, want: "STREAK_REWARDS"},
+		{name: "reward not a number", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10,x"}, want: "STREAK_REWARDS"},
+		{name: "negative reward", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10,-5"}, want: "STREAK_REWARDS"},
+		{name: "milestone without bonus", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10", StreakMilestones: "30"}, want: "STREAK_MILESTONES"},
+		{name: "duplicate milestone", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10", StreakMilestones: "30:100,30:200"}, want: "STREAK_MILESTONES"},
+		{name: "zero milestone day", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10", StreakMilestones: "0:100"}, want: "STREAK_MILESTONES"},
+		{name: "celebration not a number", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10", StreakCelebrations: "7,x"}, want: "STREAK_CELEBRATIONS"},
+		{name: "zero celebration day", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10", StreakCelebrations: "0"}, want: "STREAK_CELEBRATIONS"},
+		{name: "negative freeze cap", cfg: config.Config{StreakMessagesNeed: 4, StreakRewards: "10", StreakFreezeMax: -1}, want: "STREAK_FREEZE_MAX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPolicy(&tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %s error, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestPolicy_RewardTableAndCap(t *testing.T) {
+	p, err := NewPolicy(&config.Config{StreakMessagesNeed: 3, StreakRewards: "5, 5, 25, 15", StreakMilestones: "100:1000, 30:300"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CapDay() != 4 {
+		t.Fatalf("expected cap day 4, got %d", p.CapDay())
+	}
+	for day, want := range map[int]int64{1: 5, 3: 25, 4: 15, 40: 15} {
+		if got := p.Reward(day); got != want {
+			t.Fatalf("day %d: expected %d, got %d", day, want, got)
+		}
+	}
+	if p.MilestoneBonus(30) != 300 || p.MilestoneBonus(31) != 0 {
+		t.Fatalf("unexpected milestones: %v", p.Milestones)
+	}
+	if days := p.MilestoneDays(); !reflect.DeepEqual(days, []int{30, 100}) {
+		t.Fatalf("expected sorted milestone days, got %v", days)
+	}
+}
+
+func TestPolicy_CelebrationsSortedAndDeduplicated(t *testing.T) {
+	p, err := NewPolicy(&config.Config{StreakMessagesNeed: 4, StreakRewards: "10", StreakCelebrations: "100, 7,30,7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Celebrations, []int{7, 30, 100}) {
+		t.Fatalf("expected sorted celebration days, got %v", p.Celebrations)
+	}
+	if !p.Celebrates(30) || p.Celebrates(31) {
+		t.Fatalf("unexpected Celebrates result for %v", p.Celebrations)
+	}
+}
+
+func TestCountMessage_UsesPolicyQuotaAndPaysMilestone(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, msk)
+	svc, repo, econ, current := newTestService(now)
+	svc.policy = Policy{MessagesNeed: 2, Rewards: []int64{15}, Milestones: map[int]int64{30: 300}}
+	lastCompleted := time.Date(2026, 3, 7, 0, 0, 0, 0, msk)
+	repo.byUser[3] = &Streak{UserID: 3, CurrentStreak: 29, LongestStreak: 29, LastQuotaCompletion: &lastCompleted}
+
+	for i, text := range []string{"раз два три четыре пять", "раз два три четыре шесть"} {
+		*current = now.Add(time.Duration(i) * time.Minute)
+		if err := svc.CountMessage(context.Background(), 3, int64(i+1), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := repo.byUser[3]
+	if !st.QuotaCompletedToday || st.CurrentStreak != 30 || st.MessagesToday != 2 {
+		t.Fatalf("expected quota of two messages to close day 30, got %+v", st)
+	}
+	if !reflect.DeepEqual(econ.awards, []int64{15, 300}) {
+		t.Fatalf("expected capped reward and milestone bonus, got %v", econ.awards)
+	}
+	if econ.keys[1] != "streak_milestone:3:2026-03-08" {
+		t.Fatalf("unexpected milestone key: %v", econ.keys)
+	}
+}
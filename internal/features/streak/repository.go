@@ -68,7 +68,8 @@ func (r *Repository) getByUserID(ctx context.Context, db streakDBTX, userID int6
 		SELECT id, user_id, current_streak, longest_streak, messages_today,
 		       quota_completed_today, last_quota_completion, progress_date,
 		       last_rewarded_day, last_message_at, total_quotas_completed,
-		       reminder_sent_today, created_at, updated_at
+		       reminder_sent_today, freeze_tokens, last_frozen_day,
+		       created_at, updated_at
 		FROM streaks
 		WHERE user_id = $1
 	`
@@ -81,7 +82,8 @@ func (r *Repository) getByUserID(ctx context.Context, db streakDBTX, userID int6
 		&s.ID, &s.UserID, &s.CurrentStreak, &s.LongestStreak,
 		&s.MessagesToday, &s.QuotaCompletedToday, &s.LastQuotaCompletion,
 		&s.ProgressDate, &s.LastRewardedDay, &s.LastMessageAt,
-		&s.TotalQuotasCompleted, &s.ReminderSentToday, &s.CreatedAt, &s.UpdatedAt,
+		&s.TotalQuotasCompleted, &s.ReminderSentToday, &s.FreezeTokens, &s.LastFrozenDay,
+		&s.CreatedAt, &s.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("get streak user_id=%d: %w", userID, err)
@@ -102,17 +104,35 @@ func (r *Repository) UpdateTx(ctx context.Context, tx pgx.Tx, s *Streak) error {
 		    last_message_at = $9,
 		    total_quotas_completed = $10,
 		    reminder_sent_today = $11,
+		    freeze_tokens = $12,
+		    last_frozen_day = $13,
 		    updated_at = NOW()
 		WHERE user_id = $1
 	`, s.UserID, s.CurrentStreak, s.LongestStreak, s.MessagesToday, s.QuotaCompletedToday,
 		s.LastQuotaCompletion, s.ProgressDate, s.LastRewardedDay, s.LastMessageAt,
-		s.TotalQuotasCompleted, s.ReminderSentToday)
+		s.TotalQuotasCompleted, s.ReminderSentToday, s.FreezeTokens, s.LastFrozenDay)
 	if err != nil {
 		return fmt.Errorf("update streak: %w", err)
 	}
 	return nil
 }
 
+// AddFreezeTokensTx начисляет заморозки стрика; строку стрика создаёт,
+// если участник ещё не писал.
+func (r *Repository) AddFreezeTokensTx(ctx context.Context, tx pgx.Tx, userID int64, count int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO streaks (user_id, freeze_tokens)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET freeze_tokens = streaks.freeze_tokens + EXCLUDED.freeze_tokens,
+		    updated_at = NOW()
+	`, userID, count)
+	if err != nil {
+		return fmt.Errorf("add freeze tokens: %w", err)
+	}
+	return nil
+}
+
 func (r *Repository) MarkProcessedMessageTx(ctx context.Context, tx pgx.Tx, userID, messageID int64, streakDay time.Time) error {
 	_, err := tx.Exec(ctx, `
 		INSERT INTO streak_processed_messages (user_id, message_id, streak_day)
@@ -158,12 +178,13 @@ func (r *Repository) ClearReminderSentTx(ctx context.Context, tx pgx.Tx, userID
 	return nil
 }
 
-func (r *Repository) GetTop(ctx context.Context, limit int) ([]TopEntry, error) {
+func (r *Repository) GetTop(ctx context.Context, board Board, limit int) ([]TopEntry, error) {
+	col := board.column()
 	rows, err := r.db.Query(ctx, `
-		SELECT user_id, current_streak
+		SELECT user_id, `+col+`
 		FROM streaks
-		WHERE current_streak > 0
-		ORDER BY current_streak DESC, user_id ASC
+		WHERE `+col+` > 0
+		ORDER BY `+col+` DESC, user_id ASC
 		LIMIT $1
 	`, limit)
 	if err != nil {
@@ -174,7 +195,7 @@ func (r *Repository) GetTop(ctx context.Context, limit int) ([]TopEntry, error)
 	out := make([]TopEntry, 0, limit)
 	for rows.Next() {
 		var entry TopEntry
-		if err := rows.Scan(&entry.UserID, &entry.CurrentStreak); err != nil {
+		if err := rows.Scan(&entry.UserID, &entry.Value); err != nil {
 			return nil, fmt.Errorf("scan top streak: %w", err)
 		}
 		out = append(out, entry)
@@ -190,7 +211,8 @@ func (r *Repository) GetByMinStreak(ctx context.Context, minStreak int) ([]*Stre
 		SELECT id, user_id, current_streak, longest_streak, messages_today,
 		       quota_completed_today, last_quota_completion, progress_date,
 		       last_rewarded_day, last_message_at, total_quotas_completed,
-		       reminder_sent_today, created_at, updated_at
+		       reminder_sent_today, freeze_tokens, last_frozen_day,
+		       created_at, updated_at
 		FROM streaks
 		WHERE current_streak >= $1
 	`, minStreak)
@@ -206,7 +228,8 @@ func (r *Repository) GetByMinStreak(ctx context.Context, minStreak int) ([]*Stre
 			&s.ID, &s.UserID, &s.CurrentStreak, &s.LongestStreak,
 			&s.MessagesToday, &s.QuotaCompletedToday, &s.LastQuotaCompletion,
 			&s.ProgressDate, &s.LastRewardedDay, &s.LastMessageAt,
-			&s.TotalQuotasCompleted, &s.ReminderSentToday, &s.CreatedAt, &s.UpdatedAt,
+			&s.TotalQuotasCompleted, &s.ReminderSentToday, &s.FreezeTokens, &s.LastFrozenDay,
+			&s.CreatedAt, &s.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scan streak: %w", err)
 		}
@@ -218,7 +241,100 @@ func (r *Repository) GetByMinStreak(ctx context.Context, minStreak int) ([]*Stre
 	return out, nil
 }
 
+// UpsertDayTx записывает итог дня в streak_days. Повторная запись дня
+// ничего не откатывает: счётчик и награда только растут, отметки закрытия и
+// заморозки не снимаются.
+func (r *Repository) UpsertDayTx(ctx context.Context, tx pgx.Tx, d Day) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO streak_days (user_id, day, messages, completed, reward, freeze_used)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET messages = GREATEST(streak_days.messages, EXCLUDED.messages),
+		    completed = streak_days.completed OR EXCLUDED.completed,
+		    reward = GREATEST(streak_days.reward, EXCLUDED.reward),
+		    freeze_used = streak_days.freeze_used OR EXCLUDED.freeze_used,
+		    updated_at = NOW()
+	`, d.UserID, d.Day, d.Messages, d.Completed, d.Reward, d.FreezeUsed)
+	if err != nil {
+		return fmt.Errorf("upsert streak day: %w", err)
+	}
+	return nil
+}
+
+// ListDays возвращает дни участника в полуинтервале [from, to).
+func (r *Repository) ListDays(ctx context.Context, userID int64, from, to time.Time) ([]Day, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id, day, messages, completed, reward, freeze_used
+		FROM streak_days
+		WHERE user_id = $1 AND day >= $2 AND day < $3
+		ORDER BY day
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list streak days: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Day
+	for rows.Next() {
+		var d Day
+		if err := rows.Scan(&d.UserID, &d.Day, &d.Messages, &d.Completed, &d.Reward, &d.FreezeUsed); err != nil {
+			return nil, fmt.Errorf("scan streak day: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate streak days: %w", err)
+	}
+	return out, nil
+}
+
+// CompletionStats сводит streak_days по дням в полуинтервале [from, to).
+func (r *Repository) CompletionStats(ctx context.Context, from, to time.Time) ([]DayStats, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT day,
+		       COUNT(*) FILTER (WHERE messages > 0),
+		       COUNT(*) FILTER (WHERE completed),
+		       COUNT(*) FILTER (WHERE freeze_used)
+		FROM streak_days
+		WHERE day >= $1 AND day < $2
+		GROUP BY day
+		ORDER BY day
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("streak completion stats: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DayStats
+	for rows.Next() {
+		var d DayStats
+		if err := rows.Scan(&d.Day, &d.Active, &d.Completed, &d.Frozen); err != nil {
+			return nil, fmt.Errorf("scan streak completion stats: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate streak completion stats: %w", err)
+	}
+	return out, nil
+}
+
 func (r *Repository) ResetDaily(ctx context.Context, day time.Time) error {
+	// Перед обнулением дневных счётчиков сохраняем итог прошедших дней:
+	// streak_days уже пишется при подсчёте, снимок страхует от пропусков.
+	if _, err := r.db.Exec(ctx, `
+		INSERT INTO streak_days (user_id, day, messages, completed)
+		SELECT user_id, progress_date, messages_today, quota_completed_today
+		FROM streaks
+		WHERE progress_date IS NOT NULL AND progress_date <> $1 AND messages_today > 0
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET messages = GREATEST(streak_days.messages, EXCLUDED.messages),
+		    completed = streak_days.completed OR EXCLUDED.completed,
+		    updated_at = NOW()
+	`, day); err != nil {
+		return fmt.Errorf("snapshot streak days: %w", err)
+	}
+
 	_, err := r.db.Exec(ctx, `
 		UPDATE streaks
 		SET messages_today = CASE WHEN progress_date = $1 THEN messages_today ELSE 0 END,
@@ -237,5 +353,133 @@ func (r *Repository) ResetDaily(ctx context.Context, day time.Time) error {
 	`, retentionCutoff); err != nil {
 		return fmt.Errorf("cleanup processed streak messages: %w", err)
 	}
+
+	// Правилам антиспама нужны минуты истории; сутки — с запасом на любые окна.
+	if _, err := r.db.Exec(ctx, `
+		DELETE FROM streak_spam_events
+		WHERE at < $1
+	`, day.UTC().AddDate(0, 0, -1)); err != nil {
+		return fmt.Errorf("cleanup streak spam events: %w", err)
+	}
 	return nil
 }
+
+// RecentSpamEventsTx возвращает события антиспама участника начиная с since.
+func (r *Repository) RecentSpamEventsTx(ctx context.Context, tx pgx.Tx, userID int64, since time.Time) ([]SpamEvent, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT user_id, at, noise, text
+		FROM streak_spam_events
+		WHERE user_id = $1 AND at >= $2
+		ORDER BY at
+	`, userID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list streak spam events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SpamEvent
+	for rows.Next() {
+		var e SpamEvent
+		if err := rows.Scan(&e.UserID, &e.At, &e.Noise, &e.Text); err != nil {
+			return nil, fmt.Errorf("scan streak spam event: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate streak spam events: %w", err)
+	}
+	return out, nil
+}
+
+// RecordSpamEvent сохраняет событие антиспама; время хранится в UTC.
+func (r *Repository) RecordSpamEvent(ctx context.Context, e SpamEvent) error {
+	return r.recordSpamEvent(ctx, r.db, e)
+}
+
+func (r *Repository) RecordSpamEventTx(ctx context.Context, tx pgx.Tx, e SpamEvent) error {
+	return r.recordSpamEvent(ctx, tx, e)
+}
+
+// ReleaseSpamEvent ничего не делает: событие из упавшей транзакции
+// откатилось вместе с ней.
+func (r *Repository) ReleaseSpamEvent(context.Context, SpamEvent) error {
+	return nil
+}
+
+func (r *Repository) recordSpamEvent(ctx context.Context, db streakDBTX, e SpamEvent) error {
+	if _, err := db.Exec(ctx, `
+		INSERT INTO streak_spam_events (user_id, at, noise, text)
+		VALUES ($1, $2, $3, $4)
+	`, e.UserID, e.At.UTC(), e.Noise, e.Text); err != nil {
+		return fmt.Errorf("record streak spam event: %w", err)
+	}
+	return nil
+}
+
+// RecordCelebrationTx запоминает достигнутую веху; повтор той же вехи в тот
+// же день ничего не меняет.
+func (r *Repository) RecordCelebrationTx(ctx context.Context, tx pgx.Tx, c Celebration) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO streak_celebrations (user_id, milestone, reached_on)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, milestone, reached_on) DO NOTHING
+	`, c.UserID, c.Milestone, c.ReachedOn); err != nil {
+		return fmt.Errorf("record streak celebration: %w", err)
+	}
+	return nil
+}
+
+// ClaimCelebration помечает веху объявленной. false — её уже объявил
+// другой обработчик.
+func (r *Repository) ClaimCelebration(ctx context.Context, c Celebration) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE streak_celebrations
+		SET announced_at = NOW()
+		WHERE user_id = $1 AND milestone = $2 AND reached_on = $3
+		  AND announced_at IS NULL
+	`, c.UserID, c.Milestone, c.ReachedOn)
+	if err != nil {
+		return false, fmt.Errorf("claim streak celebration: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseCelebration снимает отметку, если поздравление не отправилось.
+func (r *Repository) ReleaseCelebration(ctx context.Context, c Celebration) error {
+	if _, err := r.db.Exec(ctx, `
+		UPDATE streak_celebrations
+		SET announced_at = NULL
+		WHERE user_id = $1 AND milestone = $2 AND reached_on = $3
+	`, c.UserID, c.Milestone, c.ReachedOn); err != nil {
+		return fmt.Errorf("release streak celebration: %w", err)
+	}
+	return nil
+}
+
+// PendingCelebrations возвращает вехи, о которых ещё не объявили, от старых к новым.
+func (r *Repository) PendingCelebrations(ctx context.Context, limit int) ([]Celebration, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id, milestone, reached_on
+		FROM streak_celebrations
+		WHERE announced_at IS NULL
+		ORDER BY created_at, user_id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list pending streak celebrations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Celebration
+	for rows.Next() {
+		var c Celebration
+		if err := rows.Scan(&c.UserID, &c.Milestone, &c.ReachedOn); err != nil {
+			return nil, fmt.Errorf("scan streak celebration: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate streak celebrations: %w", err)
+	}
+	return out, nil
+}
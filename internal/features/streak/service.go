@@ -5,28 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	log "github.com/sirupsen/logrus"
 
 	"serotonyl.ru/telegram-bot/internal/common"
 	"serotonyl.ru/telegram-bot/internal/config"
 	"serotonyl.ru/telegram-bot/internal/features/economy"
 )
 
-const streakRewardTxType = "streak_bonus"
-
-type antiSpamState struct {
-	LastNormalized string
-	LastAt         time.Time
-	CountedAt      []time.Time
-}
-
-type antiSpamDecision struct {
-	normalized string
-	now        time.Time
-}
+const (
+	streakRewardTxType    = "streak_bonus"
+	streakMilestoneTxType = "streak_milestone"
+)
 
 type streakRepository interface {
 	Create(ctx context.Context, userID int64) error
@@ -37,28 +29,42 @@ type streakRepository interface {
 	MarkProcessedMessageTx(ctx context.Context, tx pgx.Tx, userID, messageID int64, streakDay time.Time) error
 	MarkReminderSentIfNotSentTodayTx(ctx context.Context, tx pgx.Tx, userID int64, progressDay time.Time) (bool, error)
 	ClearReminderSentTx(ctx context.Context, tx pgx.Tx, userID int64, progressDay time.Time) error
-	GetTop(ctx context.Context, limit int) ([]TopEntry, error)
+	GetTop(ctx context.Context, board Board, limit int) ([]TopEntry, error)
 	GetByMinStreak(ctx context.Context, minStreak int) ([]*Streak, error)
 	ResetDaily(ctx context.Context, day time.Time) error
+	UpsertDayTx(ctx context.Context, tx pgx.Tx, d Day) error
+	ListDays(ctx context.Context, userID int64, from, to time.Time) ([]Day, error)
+	CompletionStats(ctx context.Context, from, to time.Time) ([]DayStats, error)
+	RecordCelebrationTx(ctx context.Context, tx pgx.Tx, c Celebration) error
+	ClaimCelebration(ctx context.Context, c Celebration) (bool, error)
+	ReleaseCelebration(ctx context.Context, c Celebration) error
+	PendingCelebrations(ctx context.Context, limit int) ([]Celebration, error)
 }
 
+// errSpamRejected откатывает транзакцию сообщения, которое антиспам не засчитал.
+var errSpamRejected = errors.New("streak message rejected by anti-spam")
+
+// celebrationBatch — сколько недошедших поздравлений планировщик досылает за проход.
+const celebrationBatch = 20
+
 type rewardEconomy interface {
 	WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error
-	AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error
+	AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error)
 }
 
 type Service struct {
 	repo           streakRepository
 	economyService rewardEconomy
+	policy         Policy
 	cfg            *config.Config
 	location       *time.Location
 	now            func() time.Time
-
-	mu       sync.Mutex
-	antiSpam map[int64]*antiSpamState
+	announce       func(ctx context.Context, c Celebration) error
+	spamRules      SpamRules
+	spamStore      antiSpamStore
 }
 
-func NewService(repo *Repository, economyService *economy.Service, cfg *config.Config) *Service {
+func NewService(repo *Repository, economyService *economy.Service, policy Policy, cfg *config.Config) *Service {
 	loc := time.UTC
 	if cfg != nil && strings.TrimSpace(cfg.AppTimezone) != "" {
 		if loaded, err := time.LoadLocation(cfg.AppTimezone); err == nil {
@@ -68,24 +74,35 @@ func NewService(repo *Repository, economyService *economy.Service, cfg *config.C
 	return &Service{
 		repo:           repo,
 		economyService: economyService,
+		policy:         policy,
 		cfg:            cfg,
 		location:       loc,
 		now:            time.Now,
-		antiSpam:       make(map[int64]*antiSpamState),
+		spamRules:      SpamRulesFromConfig(cfg),
+		spamStore:      NewMemorySpamStore(),
 	}
 }
 
-func (s *Service) CountMessage(ctx context.Context, userID, messageID int64, text string) error {
-	if !IsValidForStreak(text) {
-		return nil
-	}
+// SetAntiSpamStore подключает хранилище антиспама вместо памяти процесса.
+func (s *Service) SetAntiSpamStore(store antiSpamStore) {
+	s.spamStore = store
+}
 
+func (s *Service) CountMessage(ctx context.Context, userID, messageID int64, text string) error {
 	now := s.now().In(s.location)
-	decision, allowed := s.checkAntiSpam(userID, text, now)
-	if !allowed {
+	if isSpamNoise(text) {
+		if s.spamRules.FloodLimit == 0 {
+			return nil
+		}
+		return s.spamStore.RecordSpamEvent(ctx, SpamEvent{UserID: userID, At: now.UTC(), Noise: true})
+	}
+	if !IsValidForStreak(text) {
 		return nil
 	}
 
+	event := SpamEvent{UserID: userID, At: now.UTC(), Text: normalizeMessageText(text)}
+	recorded := false
+	var celebration *Celebration
 	err := s.economyService.WithTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
 		if err := s.repo.CreateTx(ctx, tx, userID); err != nil {
 			return err
@@ -103,27 +120,40 @@ func (s *Service) CountMessage(ctx context.Context, userID, messageID int64, tex
 		if err != nil {
 			return err
 		}
+		// Строка огонька уже заблокирована: параллельное сообщение участника
+		// ждёт коммита и увидит записанное здесь событие.
+		allowed, err := s.checkAntiSpamTx(ctx, tx, event)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errSpamRejected
+		}
+		if err := s.spamStore.RecordSpamEventTx(ctx, tx, event); err != nil {
+			return err
+		}
+		recorded = true
 		s.normalizeStateForDay(st, now)
 
 		lastMessageAt := now.UTC()
 		st.LastMessageAt = &lastMessageAt
 		if st.QuotaCompletedToday {
-			return s.repo.UpdateTx(ctx, tx, st)
+			return s.saveTx(ctx, tx, st)
 		}
 
-		if st.MessagesToday < dailyMessageTarget {
+		today := s.dayStart(now)
+		if st.MessagesToday < s.policy.MessagesNeed {
 			st.MessagesToday++
 		}
-		if st.MessagesToday < dailyMessageTarget {
-			return s.repo.UpdateTx(ctx, tx, st)
-		}
-
-		if st.QuotaCompletedToday || sameDayPtr(st.LastRewardedDay, s.dayStart(now)) {
-			return s.repo.UpdateTx(ctx, tx, st)
+		if st.MessagesToday < s.policy.MessagesNeed || sameDayPtr(st.LastRewardedDay, today) {
+			if err := s.saveTx(ctx, tx, st); err != nil {
+				return err
+			}
+			return s.repo.UpsertDayTx(ctx, tx, Day{UserID: userID, Day: today, Messages: st.MessagesToday})
 		}
 
 		st.QuotaCompletedToday = true
-		completedDay := s.dayStart(now)
+		completedDay := today
 		st.LastQuotaCompletion = &completedDay
 		st.LastRewardedDay = &completedDay
 		st.CurrentStreak++
@@ -131,20 +161,96 @@ func (s *Service) CountMessage(ctx context.Context, userID, messageID int64, tex
 			st.LongestStreak = st.CurrentStreak
 		}
 		st.TotalQuotasCompleted++
+		s.earnFreeze(st)
 
-		if err := s.repo.UpdateTx(ctx, tx, st); err != nil {
+		if err := s.saveTx(ctx, tx, st); err != nil {
 			return err
 		}
 
-		reward := CalculateReward(st.CurrentStreak - 1)
+		reward := s.policy.Reward(st.CurrentStreak)
 		description := FormatRewardDescription(st.CurrentStreak)
-		return s.economyService.AddBalanceTx(ctx, tx, userID, reward, streakRewardTxType, description)
+		if _, err = s.economyService.AddBalanceOnceTx(ctx, tx, RewardOperationKey(userID, completedDay), userID, reward, streakRewardTxType, description); err != nil {
+			return err
+		}
+		if bonus := s.policy.MilestoneBonus(st.CurrentStreak); bonus > 0 {
+			if _, err = s.economyService.AddBalanceOnceTx(ctx, tx, MilestoneOperationKey(userID, completedDay), userID, bonus, streakMilestoneTxType, FormatMilestoneDescription(st.CurrentStreak)); err != nil {
+				return err
+			}
+			reward += bonus
+		}
+		if s.policy.Celebrates(st.CurrentStreak) {
+			celebration = &Celebration{UserID: userID, Milestone: st.CurrentStreak, ReachedOn: completedDay}
+			if err := s.repo.RecordCelebrationTx(ctx, tx, *celebration); err != nil {
+				return err
+			}
+		}
+		return s.repo.UpsertDayTx(ctx, tx, Day{UserID: userID, Day: completedDay, Messages: st.MessagesToday, Completed: true, Reward: reward})
 	})
+	if errors.Is(err, errSpamRejected) {
+		return nil
+	}
 	if err != nil {
+		// Сообщение из упавшей транзакции не должно занимать лимит
+		// и не должно считаться повтором.
+		if recorded {
+			if releaseErr := s.spamStore.ReleaseSpamEvent(ctx, event); releaseErr != nil {
+				log.WithError(releaseErr).WithField("user_id", userID).Warn("release streak anti-spam event failed")
+			}
+		}
 		return err
 	}
 
-	s.commitAntiSpam(userID, decision)
+	if celebration != nil {
+		// Сообщение уже засчитано; недошедшее поздравление дошлёт планировщик.
+		if err := s.celebrate(ctx, *celebration); err != nil {
+			log.WithError(err).WithField("user_id", userID).Warn("streak celebration failed")
+		}
+	}
+	return nil
+}
+
+// SetCelebrationAnnouncer подключает отправку поздравлений с вехами в чат.
+// Без него вехи копятся в streak_celebrations и не объявляются.
+func (s *Service) SetCelebrationAnnouncer(announce func(ctx context.Context, c Celebration) error) {
+	s.announce = announce
+}
+
+// AnnounceCelebrations досылает поздравления, которые не ушли сразу после
+// закрытия дня. Возвращает, сколько поздравлений отправлено.
+func (s *Service) AnnounceCelebrations(ctx context.Context) (int, error) {
+	if s.announce == nil {
+		return 0, nil
+	}
+	pending, err := s.repo.PendingCelebrations(ctx, celebrationBatch)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, c := range pending {
+		if err := s.celebrate(ctx, c); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// celebrate объявляет веху ровно один раз: сначала занимает её в БД, затем
+// отправляет и снимает отметку, если Telegram не принял сообщение.
+func (s *Service) celebrate(ctx context.Context, c Celebration) error {
+	if s.announce == nil {
+		return nil
+	}
+	claimed, err := s.repo.ClaimCelebration(ctx, c)
+	if err != nil || !claimed {
+		return err
+	}
+	if err := s.announce(ctx, c); err != nil {
+		if releaseErr := s.repo.ReleaseCelebration(ctx, c); releaseErr != nil {
+			return fmt.Errorf("announce celebration user_id=%d day=%d: %w; release claim: %v", c.UserID, c.Milestone, err, releaseErr)
+		}
+		return fmt.Errorf("announce celebration user_id=%d day=%d: %w", c.UserID, c.Milestone, err)
+	}
 	return nil
 }
 
@@ -160,7 +266,7 @@ func (s *Service) GetStreak(ctx context.Context, userID int64) (*Streak, error)
 			return err
 		}
 		if s.normalizeStateForDay(st, s.now().In(s.location)) {
-			if err := s.repo.UpdateTx(ctx, tx, st); err != nil {
+			if err := s.saveTx(ctx, tx, st); err != nil {
 				return err
 			}
 		}
@@ -173,8 +279,36 @@ func (s *Service) GetStreak(ctx context.Context, userID int64) (*Streak, error)
 	return out, nil
 }
 
-func (s *Service) GetTop(ctx context.Context, limit int) ([]TopEntry, error) {
-	return s.repo.GetTop(ctx, limit)
+// GetCalendar возвращает дни огонька участника за текущий месяц.
+func (s *Service) GetCalendar(ctx context.Context, userID int64) (*Calendar, error) {
+	today := s.dayStart(s.now().In(s.location))
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, s.location)
+	days, err := s.repo.ListDays(ctx, userID, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	cal := &Calendar{Month: month, Today: today, Days: make(map[int]Day, len(days))}
+	for _, d := range days {
+		cal.Days[d.Day.Day()] = d
+	}
+	return cal, nil
+}
+
+// CompletionStats возвращает сводку закрытия квоты за последние days дней,
+// включая сегодняшний.
+func (s *Service) CompletionStats(ctx context.Context, days int) ([]DayStats, error) {
+	today := s.dayStart(s.now().In(s.location))
+	return s.repo.CompletionStats(ctx, today.AddDate(0, 0, -(days-1)), today.AddDate(0, 0, 1))
+}
+
+// Policy возвращает правила огонька: квоту, награды и вехи.
+func (s *Service) Policy() Policy {
+	return s.policy
+}
+
+// GetTop возвращает первых limit участников по показателю board.
+func (s *Service) GetTop(ctx context.Context, board Board, limit int) ([]TopEntry, error) {
+	return s.repo.GetTop(ctx, board, limit)
 }
 
 func (s *Service) CreateStreak(ctx context.Context, userID int64) error {
@@ -202,7 +336,7 @@ func (s *Service) SendReminders(ctx context.Context, sendFunc func(context.Conte
 			}
 
 			if s.normalizeStateForDay(st, now) {
-				if err := s.repo.UpdateTx(ctx, tx, st); err != nil {
+				if err := s.saveTx(ctx, tx, st); err != nil {
 					return err
 				}
 			}
@@ -232,7 +366,8 @@ func (s *Service) SendReminders(ctx context.Context, sendFunc func(context.Conte
 			continue
 		}
 
-		text := fmt.Sprintf("🔥 У тебя огонек %d %s! Не забудь добить 4/4.", reminder.CurrentStreak, common.PluralizeDays(reminder.CurrentStreak))
+		text := fmt.Sprintf("🔥 У тебя огонек %d %s! Не забудь добить %d/%d.", reminder.CurrentStreak, common.PluralizeDays(reminder.CurrentStreak), s.policy.MessagesNeed, s.policy.MessagesNeed) +
+			freezeReminderSuffix(reminder.FreezeTokens)
 		if err := sendFunc(ctx, reminder.UserID, text); err != nil {
 			// Keep the claim-first behavior for cross-instance duplicate suppression,
 			// but release the claim again when Telegram delivery fails so the reminder is retried instead of lost.
@@ -261,6 +396,9 @@ func (s *Service) normalizeStateForDay(st *Streak, now time.Time) bool {
 		changed = true
 	}
 	if s.isContinuityBroken(st, today) {
+		if s.useFreezes(st, today) {
+			return true
+		}
 		if st.CurrentStreak != 0 {
 			st.CurrentStreak = 0
 			changed = true
@@ -286,11 +424,12 @@ func (s *Service) isContinuityBroken(st *Streak, today time.Time) bool {
 	if st.CurrentStreak == 0 {
 		return false
 	}
-	if st.LastQuotaCompletion == nil {
+	anchor := continuityAnchor(st)
+	if anchor == nil {
 		return true
 	}
 
-	last := s.dayStart(st.LastQuotaCompletion.In(s.location))
+	last := s.dayStart(anchor.In(s.location))
 	if sameDay(last, today) {
 		return false
 	}
@@ -298,55 +437,81 @@ func (s *Service) isContinuityBroken(st *Streak, today time.Time) bool {
 	return !sameDay(last, yesterday)
 }
 
-func (s *Service) checkAntiSpam(userID int64, text string, now time.Time) (antiSpamDecision, bool) {
-	normalized := normalizeMessageText(text)
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	state := s.antiSpam[userID]
-	if state == nil {
-		state = &antiSpamState{}
-		s.antiSpam[userID] = state
+// useFreezes закрывает заморозками все дни, пропущенные с последнего
+// закрытого (или замороженного) дня до сегодняшнего. Если заморозок не
+// хватает на все пропуски, не тратит ни одной — огонёк всё равно сгорит.
+func (s *Service) useFreezes(st *Streak, today time.Time) bool {
+	anchor := continuityAnchor(st)
+	if anchor == nil {
+		return false
 	}
-
-	if state.LastNormalized == normalized && !state.LastAt.IsZero() && now.Sub(state.LastAt) <= duplicateWindow {
-		return antiSpamDecision{}, false
+	missed := daysBetween(s.dayStart(anchor.In(s.location)), today) - 1
+	if missed <= 0 || st.FreezeTokens < missed {
+		return false
+	}
+	st.FreezeTokens -= missed
+	for i := missed; i >= 1; i-- {
+		st.frozenDays = append(st.frozenDays, today.AddDate(0, 0, -i))
 	}
+	frozen := today.AddDate(0, 0, -1)
+	st.LastFrozenDay = &frozen
+	return true
+}
 
-	kept := make([]time.Time, 0, len(state.CountedAt))
-	for _, ts := range state.CountedAt {
-		if now.Sub(ts) < time.Minute {
-			kept = append(kept, ts)
+// saveTx сохраняет состояние огонька и дописывает в streak_days дни,
+// закрытые заморозками при нормализации.
+func (s *Service) saveTx(ctx context.Context, tx pgx.Tx, st *Streak) error {
+	frozen := st.frozenDays
+	st.frozenDays = nil
+	if err := s.repo.UpdateTx(ctx, tx, st); err != nil {
+		return err
+	}
+	for _, day := range frozen {
+		if err := s.repo.UpsertDayTx(ctx, tx, Day{UserID: st.UserID, Day: day, FreezeUsed: true}); err != nil {
+			return err
 		}
 	}
-	if len(kept) >= maxValidPerMinute {
-		return antiSpamDecision{}, false
+	return nil
+}
+
+// earnFreeze начисляет заморозку по правилам Policy.EarnsFreeze.
+func (s *Service) earnFreeze(st *Streak) {
+	if s.policy.EarnsFreeze(st.TotalQuotasCompleted, st.FreezeTokens) {
+		st.FreezeTokens++
 	}
+}
 
-	return antiSpamDecision{normalized: normalized, now: now}, true
+// continuityAnchor — последний день, который держит огонёк: закрытый или
+// замороженный, что позже.
+func continuityAnchor(st *Streak) *time.Time {
+	if st.LastFrozenDay != nil && (st.LastQuotaCompletion == nil || st.LastFrozenDay.After(*st.LastQuotaCompletion)) {
+		return st.LastFrozenDay
+	}
+	return st.LastQuotaCompletion
 }
 
-func (s *Service) commitAntiSpam(userID int64, decision antiSpamDecision) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// daysBetween считает календарные дни от a до b без учёта перехода на летнее время.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
 
-	state := s.antiSpam[userID]
-	if state == nil {
-		state = &antiSpamState{}
-		s.antiSpam[userID] = state
+// freezeReminderSuffix предупреждает в напоминании, что пропуск спишет заморозку.
+func freezeReminderSuffix(tokens int) string {
+	if tokens <= 0 {
+		return ""
 	}
+	return fmt.Sprintf(" Если не успеешь, огонёк спасёт заморозка ❄️ (осталось %d).", tokens)
+}
 
-	kept := state.CountedAt[:0]
-	for _, ts := range state.CountedAt {
-		if decision.now.Sub(ts) < time.Minute {
-			kept = append(kept, ts)
-		}
+// checkAntiSpamTx проверяет засчитываемое сообщение по правилам антиспама.
+func (s *Service) checkAntiSpamTx(ctx context.Context, tx pgx.Tx, event SpamEvent) (bool, error) {
+	recent, err := s.spamStore.RecentSpamEventsTx(ctx, tx, event.UserID, event.At.Add(-s.spamRules.lookback()))
+	if err != nil {
+		return false, err
 	}
-	state.CountedAt = kept
-	state.LastNormalized = decision.normalized
-	state.LastAt = decision.now
-	state.CountedAt = append(state.CountedAt, decision.now)
+	return s.spamRules.allow(recent, event.Text, event.At), nil
 }
 
 func (s *Service) dayStart(t time.Time) time.Time {
@@ -362,10 +527,21 @@ func sameDayPtr(a *time.Time, b time.Time) bool {
 	return a != nil && sameDay(*a, b)
 }
 
-func CalculateReward(currentStreak int) int64 {
-	return GetReward(currentStreak)
+// RewardOperationKey — ключ идемпотентности награды за день: огонёк
+// платит не больше одного раза в сутки, даже если dedup сообщений пропустил повтор.
+func RewardOperationKey(userID int64, day time.Time) string {
+	return economy.OperationKey("streak", userID, day.Format("2006-01-02"))
+}
+
+// MilestoneOperationKey — ключ бонуса за веху, выплаченного в день day.
+func MilestoneOperationKey(userID int64, day time.Time) string {
+	return economy.OperationKey("streak_milestone", userID, day.Format("2006-01-02"))
 }
 
 func FormatRewardDescription(day int) string {
 	return fmt.Sprintf("Ogonek reward - day %d", day)
 }
+
+func FormatMilestoneDescription(day int) string {
+	return fmt.Sprintf("Ogonek milestone - day %d", day)
+}
@@ -20,6 +20,8 @@ type fakeRepo struct {
 	updateCalls             map[int64]int
 	reminderClaimCalls      map[int64]int
 	reminderClaimShouldFail map[int64]bool
+	days                    map[string]Day
+	celebrations            map[string]bool // ключ celebrationKey → объявлено
 }
 
 func newFakeRepo() *fakeRepo {
@@ -29,6 +31,8 @@ func newFakeRepo() *fakeRepo {
 		updateCalls:             make(map[int64]int),
 		reminderClaimCalls:      make(map[int64]int),
 		reminderClaimShouldFail: make(map[int64]bool),
+		days:                    make(map[string]Day),
+		celebrations:            make(map[string]bool),
 	}
 }
 
@@ -100,18 +104,25 @@ func (r *fakeRepo) ClearReminderSentTx(ctx context.Context, tx pgx.Tx, userID in
 	return nil
 }
 
-func (r *fakeRepo) GetTop(ctx context.Context, limit int) ([]TopEntry, error) {
+func (r *fakeRepo) GetTop(ctx context.Context, board Board, limit int) ([]TopEntry, error) {
 	top := make([]TopEntry, 0, len(r.byUser))
 	for _, st := range r.byUser {
-		if st.CurrentStreak > 0 {
-			top = append(top, TopEntry{UserID: st.UserID, CurrentStreak: st.CurrentStreak})
+		value := st.CurrentStreak
+		switch board {
+		case BoardLongest:
+			value = st.LongestStreak
+		case BoardTotal:
+			value = st.TotalQuotasCompleted
+		}
+		if value > 0 {
+			top = append(top, TopEntry{UserID: st.UserID, Value: value})
 		}
 	}
 	sort.Slice(top, func(i, j int) bool {
-		if top[i].CurrentStreak == top[j].CurrentStreak {
+		if top[i].Value == top[j].Value {
 			return top[i].UserID < top[j].UserID
 		}
-		return top[i].CurrentStreak > top[j].CurrentStreak
+		return top[i].Value > top[j].Value
 	})
 	if len(top) > limit {
 		top = top[:limit]
@@ -132,17 +143,121 @@ func (r *fakeRepo) GetByMinStreak(ctx context.Context, minStreak int) ([]*Streak
 
 func (r *fakeRepo) ResetDaily(ctx context.Context, day time.Time) error { return nil }
 
+func dayKey(userID int64, day time.Time) string {
+	return fmt.Sprintf("%d:%s", userID, day.Format("2006-01-02"))
+}
+
+func (r *fakeRepo) UpsertDayTx(ctx context.Context, tx pgx.Tx, d Day) error {
+	key := dayKey(d.UserID, d.Day)
+	if prev, ok := r.days[key]; ok {
+		d.Messages = max(d.Messages, prev.Messages)
+		d.Completed = d.Completed || prev.Completed
+		d.Reward = max(d.Reward, prev.Reward)
+		d.FreezeUsed = d.FreezeUsed || prev.FreezeUsed
+	}
+	r.days[key] = d
+	return nil
+}
+
+func (r *fakeRepo) ListDays(ctx context.Context, userID int64, from, to time.Time) ([]Day, error) {
+	var out []Day
+	for _, d := range r.days {
+		if d.UserID == userID && !d.Day.Before(from) && d.Day.Before(to) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func (r *fakeRepo) CompletionStats(ctx context.Context, from, to time.Time) ([]DayStats, error) {
+	byDay := make(map[string]*DayStats)
+	for _, d := range r.days {
+		if d.Day.Before(from) || !d.Day.Before(to) {
+			continue
+		}
+		key := d.Day.Format("2006-01-02")
+		stats := byDay[key]
+		if stats == nil {
+			stats = &DayStats{Day: d.Day}
+			byDay[key] = stats
+		}
+		if d.Messages > 0 {
+			stats.Active++
+		}
+		if d.Completed {
+			stats.Completed++
+		}
+		if d.FreezeUsed {
+			stats.Frozen++
+		}
+	}
+	out := make([]DayStats, 0, len(byDay))
+	for _, stats := range byDay {
+		out = append(out, *stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func celebrationKey(c Celebration) string {
+	return fmt.Sprintf("%d:%d:%s", c.UserID, c.Milestone, c.ReachedOn.Format("2006-01-02"))
+}
+
+func (r *fakeRepo) RecordCelebrationTx(ctx context.Context, tx pgx.Tx, c Celebration) error {
+	if _, ok := r.celebrations[celebrationKey(c)]; !ok {
+		r.celebrations[celebrationKey(c)] = false
+	}
+	return nil
+}
+
+func (r *fakeRepo) ClaimCelebration(ctx context.Context, c Celebration) (bool, error) {
+	announced, ok := r.celebrations[celebrationKey(c)]
+	if !ok || announced {
+		return false, nil
+	}
+	r.celebrations[celebrationKey(c)] = true
+	return true, nil
+}
+
+func (r *fakeRepo) ReleaseCelebration(ctx context.Context, c Celebration) error {
+	r.celebrations[celebrationKey(c)] = false
+	return nil
+}
+
+func (r *fakeRepo) PendingCelebrations(ctx context.Context, limit int) ([]Celebration, error) {
+	var out []Celebration
+	for key, announced := range r.celebrations {
+		if announced {
+			continue
+		}
+		var c Celebration
+		var day string
+		if _, err := fmt.Sscanf(strings.ReplaceAll(key, ":", " "), "%d %d %s", &c.UserID, &c.Milestone, &day); err != nil {
+			return nil, err
+		}
+		c.ReachedOn, _ = time.Parse("2006-01-02", day)
+		out = append(out, c)
+	}
+	return out, nil
+}
+
 type fakeEconomy struct {
 	awards          []int64
 	failAfterTx     bool
 	failAddBalance  bool
 	withTxCalls     int
 	addBalanceCalls int
+	keys            []string
+	inTx            bool
 }
 
 func (e *fakeEconomy) WithTransaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
 	e.withTxCalls++
-	if err := fn(ctx, nil); err != nil {
+	e.inTx = true
+	err := fn(ctx, nil)
+	e.inTx = false
+	if err != nil {
 		return err
 	}
 	if e.failAfterTx {
@@ -151,13 +266,14 @@ func (e *fakeEconomy) WithTransaction(ctx context.Context, fn func(context.Conte
 	return nil
 }
 
-func (e *fakeEconomy) AddBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, amount int64, txType, description string) error {
+func (e *fakeEconomy) AddBalanceOnceTx(ctx context.Context, tx pgx.Tx, operationKey string, userID int64, amount int64, txType, description string) (bool, error) {
 	e.addBalanceCalls++
 	if e.failAddBalance {
-		return errors.New("add balance failed")
+		return false, errors.New("add balance failed")
 	}
+	e.keys = append(e.keys, operationKey)
 	e.awards = append(e.awards, amount)
-	return nil
+	return true, nil
 }
 
 func newTestService(now time.Time) (*Service, *fakeRepo, *fakeEconomy, *time.Time) {
@@ -167,10 +283,12 @@ func newTestService(now time.Time) (*Service, *fakeRepo, *fakeEconomy, *time.Tim
 	svc := &Service{
 		repo:           repo,
 		economyService: econ,
+		policy:         DefaultPolicy(),
 		cfg:            &config.Config{AppTimezone: "Europe/Moscow", StreakReminderThreshold: 7, StreakInactiveHours: 10},
 		location:       time.FixedZone("MSK", 3*60*60),
 		now:            func() time.Time { return current },
-		antiSpam:       make(map[int64]*antiSpamState),
+		spamRules:      DefaultSpamRules(),
+		spamStore:      NewMemorySpamStore(),
 	}
 	return svc, repo, econ, &current
 }
@@ -211,6 +329,9 @@ func TestCountMessage_CompletesDayAndRewardsOnce(t *testing.T) {
 	if len(econ.awards) != 1 || econ.awards[0] != 10 {
 		t.Fatalf("unexpected awards: %v", econ.awards)
 	}
+	if len(econ.keys) != 1 || econ.keys[0] != "streak:1:2026-03-08" {
+		t.Fatalf("expected reward keyed by user and day, got %v", econ.keys)
+	}
 
 	if err := svc.CountMessage(context.Background(), 1, 4, texts[3]); err != nil {
 		t.Fatalf("duplicate message id: %v", err)
@@ -303,6 +424,82 @@ func TestGetStreak_ResetsBrokenContinuityAndPersists(t *testing.T) {
 	}
 }
 
+func TestGetStreak_FreezeCoversMissedDays(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, msk)
+	svc, repo, _, _ := newTestService(now)
+	lastCompleted := time.Date(2026, 3, 5, 0, 0, 0, 0, msk)
+	repo.byUser[5] = &Streak{
+		UserID:              5,
+		CurrentStreak:       4,
+		LongestStreak:       4,
+		FreezeTokens:        3,
+		LastQuotaCompletion: &lastCompleted,
+		ProgressDate:        &lastCompleted,
+	}
+
+	st, err := svc.GetStreak(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.CurrentStreak != 4 || st.FreezeTokens != 1 {
+		t.Fatalf("expected two freezes spent on 6th and 7th, got %+v", st)
+	}
+	if st.LastFrozenDay == nil || !sameDay(*st.LastFrozenDay, time.Date(2026, 3, 7, 0, 0, 0, 0, msk)) {
+		t.Fatalf("expected yesterday frozen, got %v", st.LastFrozenDay)
+	}
+	if svc.isContinuityBroken(repo.byUser[5], svc.dayStart(now)) {
+		t.Fatal("frozen yesterday must keep continuity")
+	}
+}
+
+func TestGetStreak_NotEnoughFreezesKeepsTokens(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, msk)
+	svc, repo, _, _ := newTestService(now)
+	lastCompleted := time.Date(2026, 3, 5, 0, 0, 0, 0, msk)
+	repo.byUser[5] = &Streak{
+		UserID:              5,
+		CurrentStreak:       4,
+		FreezeTokens:        1,
+		LastQuotaCompletion: &lastCompleted,
+		ProgressDate:        &lastCompleted,
+	}
+
+	st, err := svc.GetStreak(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.CurrentStreak != 0 || st.FreezeTokens != 1 {
+		t.Fatalf("expected reset without spending freezes, got %+v", st)
+	}
+}
+
+func TestCountMessage_EarnsFreezeEveryNQuotas(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, _, current := newTestService(now)
+	svc.policy.FreezeEarnEvery = 5
+	svc.policy.FreezeMax = 2
+	repo.byUser[1] = &Streak{UserID: 1, TotalQuotasCompleted: 4, FreezeTokens: 1}
+
+	texts := []string{"раз два три четыре пять", "раз два три четыре шесть", "раз два три четыре семь", "раз два три четыре восемь"}
+	for i, text := range texts {
+		*current = now.Add(time.Duration(i) * time.Minute)
+		if err := svc.CountMessage(context.Background(), 1, int64(i+1), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := repo.byUser[1]; st.TotalQuotasCompleted != 5 || st.FreezeTokens != 2 {
+		t.Fatalf("expected freeze earned on 5th quota, got %+v", st)
+	}
+
+	st := &Streak{TotalQuotasCompleted: 10, FreezeTokens: 2}
+	svc.earnFreeze(st)
+	if st.FreezeTokens != 2 {
+		t.Fatalf("expected cap to stop earning, got %d", st.FreezeTokens)
+	}
+}
+
 func TestContinuityAllowsYesterdayButNotTwoDaysAgo(t *testing.T) {
 	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
 	svc, _, _, _ := newTestService(now)
@@ -448,6 +645,34 @@ func TestSendReminders_UsesReadableReminderText(t *testing.T) {
 	}
 }
 
+func TestSendReminders_MentionsFreeze(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, _, _ := newTestService(now)
+	progressDate := time.Date(2026, 3, 8, 0, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	lastCompleted := time.Date(2026, 3, 7, 0, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	lastMessageAt := now.Add(-12 * time.Hour).UTC()
+	repo.byUser[12] = &Streak{
+		UserID:              12,
+		CurrentStreak:       8,
+		FreezeTokens:        2,
+		ProgressDate:        &progressDate,
+		LastQuotaCompletion: &lastCompleted,
+		LastMessageAt:       &lastMessageAt,
+	}
+
+	var got string
+	if err := svc.SendReminders(context.Background(), func(ctx context.Context, userID int64, text string) error {
+		got = text
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(got, "заморозка ❄️ (осталось 2)") {
+		t.Fatalf("expected freeze notice, got %q", got)
+	}
+}
+
 func TestCountMessage_AntiSpamNotConsumedOnFailedTransaction(t *testing.T) {
 	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
 	svc, repo, econ, current := newTestService(now)
@@ -461,8 +686,8 @@ func TestCountMessage_AntiSpamNotConsumedOnFailedTransaction(t *testing.T) {
 	if repo.byUser[9].MessagesToday != 1 {
 		t.Fatalf("expected state change from failed fake transaction, got %+v", repo.byUser[9])
 	}
-	if state := svc.antiSpam[9]; state != nil && (state.LastNormalized != "" || len(state.CountedAt) != 0 || !state.LastAt.IsZero()) {
-		t.Fatalf("expected anti-spam state to stay uncommitted, got %+v", state)
+	if events, _ := svc.spamStore.RecentSpamEventsTx(context.Background(), nil, 9, now.Add(-time.Hour)); len(events) != 0 {
+		t.Fatalf("expected anti-spam state to stay uncommitted, got %+v", events)
 	}
 
 	repo.byUser[9] = &Streak{UserID: 9}
@@ -483,7 +708,7 @@ func TestGetTop_SortsByCurrentStreakThenUserID(t *testing.T) {
 	repo.byUser[5] = &Streak{UserID: 5, CurrentStreak: 5}
 	repo.byUser[7] = &Streak{UserID: 7, CurrentStreak: 5}
 
-	top, err := svc.GetTop(context.Background(), 10)
+	top, err := svc.GetTop(context.Background(), BoardCurrent, 10)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -494,3 +719,189 @@ func TestGetTop_SortsByCurrentStreakThenUserID(t *testing.T) {
 		t.Fatalf("unexpected order: %+v", top)
 	}
 }
+
+func TestGetTop_BoardsRankByLongestAndTotal(t *testing.T) {
+	svc, repo, _, _ := newTestService(time.Now())
+	repo.byUser[1] = &Streak{UserID: 1, CurrentStreak: 0, LongestStreak: 40, TotalQuotasCompleted: 45}
+	repo.byUser[2] = &Streak{UserID: 2, CurrentStreak: 12, LongestStreak: 12, TotalQuotasCompleted: 90}
+
+	longest, err := svc.GetTop(context.Background(), BoardLongest, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(longest) != 2 || longest[0].UserID != 1 || longest[0].Value != 40 {
+		t.Fatalf("unexpected longest board: %+v", longest)
+	}
+
+	total, err := svc.GetTop(context.Background(), BoardTotal, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(total) != 2 || total[0].UserID != 2 || total[0].Value != 90 {
+		t.Fatalf("unexpected total board: %+v", total)
+	}
+}
+
+func TestParseBoard(t *testing.T) {
+	cases := []struct {
+		args []string
+		want Board
+		ok   bool
+	}{
+		{nil, BoardCurrent, true},
+		{[]string{"Рекорд"}, BoardLongest, true},
+		{[]string{"всего"}, BoardTotal, true},
+		{[]string{"вчера"}, BoardCurrent, false},
+	}
+	for _, tc := range cases {
+		got, ok := ParseBoard(tc.args)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("ParseBoard(%v) = %v, %v; want %v, %v", tc.args, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCountMessage_CelebratesMilestoneOnce(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, _, current := newTestService(now)
+	svc.policy.Celebrations = []int{7}
+	yesterday := svc.dayStart(now).AddDate(0, 0, -1)
+	repo.byUser[1] = &Streak{UserID: 1, CurrentStreak: 6, LongestStreak: 6, LastQuotaCompletion: &yesterday}
+
+	var announced []Celebration
+	svc.SetCelebrationAnnouncer(func(ctx context.Context, c Celebration) error {
+		announced = append(announced, c)
+		return nil
+	})
+
+	texts := []string{"раз два три четыре пять", "раз два три четыре шесть", "раз два три четыре семь", "раз два три четыре восемь"}
+	for i, text := range texts {
+		*current = now.Add(time.Duration(i) * time.Minute)
+		if err := svc.CountMessage(context.Background(), 1, int64(i+1), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(announced) != 1 || announced[0].Milestone != 7 || !sameDay(announced[0].ReachedOn, now) {
+		t.Fatalf("expected one day-7 celebration, got %+v", announced)
+	}
+
+	// Повторная доставка апдейта и проход планировщика не поздравляют снова.
+	if err := svc.CountMessage(context.Background(), 1, 4, texts[3]); err != nil {
+		t.Fatal(err)
+	}
+	if sent, err := svc.AnnounceCelebrations(context.Background()); err != nil || sent != 0 {
+		t.Fatalf("expected nothing pending, got sent=%d err=%v", sent, err)
+	}
+	if len(announced) != 1 {
+		t.Fatalf("celebration must be posted once, got %+v", announced)
+	}
+}
+
+func TestAnnounceCelebrations_RetriesFailedPost(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	svc, repo, _, _ := newTestService(now)
+	c := Celebration{UserID: 1, Milestone: 30, ReachedOn: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)}
+	if err := repo.RecordCelebrationTx(context.Background(), nil, c); err != nil {
+		t.Fatal(err)
+	}
+
+	fail := true
+	posts := 0
+	svc.SetCelebrationAnnouncer(func(ctx context.Context, c Celebration) error {
+		posts++
+		if fail {
+			return errors.New("telegram down")
+		}
+		return nil
+	})
+
+	if _, err := svc.AnnounceCelebrations(context.Background()); err == nil {
+		t.Fatal("expected send error")
+	}
+	if repo.celebrations[celebrationKey(c)] {
+		t.Fatal("failed post must release the claim")
+	}
+
+	fail = false
+	if sent, err := svc.AnnounceCelebrations(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("expected retry to post, got sent=%d err=%v", sent, err)
+	}
+	if sent, _ := svc.AnnounceCelebrations(context.Background()); sent != 0 || posts != 2 {
+		t.Fatalf("expected no third post, got sent=%d posts=%d", sent, posts)
+	}
+}
+
+func TestCountMessage_RecordsDayHistory(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, msk)
+	svc, repo, _, current := newTestService(now)
+
+	texts := []string{"раз два три четыре пять", "раз два три четыре шесть", "раз два три четыре семь", "раз два три четыре восемь"}
+	for i, text := range texts[:2] {
+		*current = now.Add(time.Duration(i) * time.Minute)
+		if err := svc.CountMessage(context.Background(), 1, int64(i+1), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := repo.days["1:2026-03-08"]; d.Messages != 2 || d.Completed {
+		t.Fatalf("expected partial day recorded, got %+v", d)
+	}
+
+	for i, text := range texts[2:] {
+		*current = now.Add(time.Duration(i+5) * time.Minute)
+		if err := svc.CountMessage(context.Background(), 1, int64(i+3), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := repo.days["1:2026-03-08"]; d.Messages != 4 || !d.Completed || d.Reward != 10 {
+		t.Fatalf("expected completed day with reward, got %+v", d)
+	}
+}
+
+func TestGetStreak_RecordsFrozenDays(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, msk)
+	svc, repo, _, _ := newTestService(now)
+	lastCompleted := time.Date(2026, 3, 5, 0, 0, 0, 0, msk)
+	repo.byUser[5] = &Streak{UserID: 5, CurrentStreak: 4, FreezeTokens: 2, LastQuotaCompletion: &lastCompleted, ProgressDate: &lastCompleted}
+
+	if _, err := svc.GetStreak(context.Background(), 5); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"5:2026-03-06", "5:2026-03-07"} {
+		if !repo.days[key].FreezeUsed {
+			t.Fatalf("expected %s marked as frozen, got %+v", key, repo.days)
+		}
+	}
+	if len(repo.days) != 2 {
+		t.Fatalf("expected only missed days recorded, got %+v", repo.days)
+	}
+}
+
+func TestRenderCalendar_MonthGrid(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, msk)
+	svc, repo, _, _ := newTestService(now)
+	repo.days["9:2026-03-01"] = Day{UserID: 9, Day: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Messages: 4, Completed: true}
+	repo.days["9:2026-03-02"] = Day{UserID: 9, Day: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), FreezeUsed: true}
+	repo.days["9:2026-03-04"] = Day{UserID: 9, Day: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), Messages: 1}
+
+	cal, err := svc.GetCalendar(context.Background(), 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(renderCalendar(cal), "\n")
+	if lines[0] != "📅 Март 2026" {
+		t.Fatalf("unexpected title: %q", lines[0])
+	}
+	// 1 марта 2026 — воскресенье: первая неделя из шести пустых клеток и ✅.
+	if lines[1] != "➖➖➖➖➖➖✅" {
+		t.Fatalf("unexpected first week: %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "❄️▪️🟡⬜") {
+		t.Fatalf("unexpected second week: %q", lines[2])
+	}
+	if !strings.Contains(renderCalendar(cal), "Закрыто дней: 1 из 4") {
+		t.Fatalf("unexpected summary: %q", renderCalendar(cal))
+	}
+}
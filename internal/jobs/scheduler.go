@@ -21,6 +21,14 @@ const (
 	cronErrorDailyReset  = "[CRON] Daily reset failed"
 	cronDebugReminders   = "[CRON] Checking reminders"
 	cronErrorReminders   = "[CRON] Reminder run failed"
+	cronErrorCelebrate   = "[CRON] Streak celebration run failed"
+	cronDebugDebtRemind  = "[CRON] Checking overdue debts"
+	cronErrorDebtRemind  = "[CRON] Debt reminder run failed"
+	cronErrorDuelRefund  = "[CRON] Duel refund run failed"
+	cronErrorRequestExp  = "[CRON] Money request expiry run failed"
+	cronErrorDigest      = "[CRON] Casino digest failed"
+	cronErrorLedger      = "[CRON] Ledger reconciliation failed"
+	cronErrorPayouts     = "[CRON] Payout run failed"
 	cronInfoStarted      = "Scheduler started"
 	cronInfoStopped      = "Scheduler stopped"
 
@@ -39,6 +47,30 @@ type adminCleaner interface {
 	CleanupStaleAuthState(ctx context.Context, now time.Time) error
 }
 
+type debtReminder interface {
+	SendOverdueReminders(ctx context.Context, sendFunc func(context.Context, int64, string) error) error
+}
+
+type duelRefunder interface {
+	RefundExpiredDuels(ctx context.Context, editFunc func(ctx context.Context, chatID int64, messageID int, text string) error) (int, error)
+}
+
+type moneyRequestExpirer interface {
+	ExpireMoneyRequests(ctx context.Context, editFunc func(ctx context.Context, chatID int64, messageID int, text string) error) (int, error)
+}
+
+type ledgerReconciler interface {
+	ReconcileLedger(ctx context.Context) (int, error)
+}
+
+type payoutRunner interface {
+	RunDuePayouts(ctx context.Context) (int, error)
+}
+
+type casinoDigester interface {
+	SendWeeklyDigest(ctx context.Context, sendFunc func(ctx context.Context, text string) error) error
+}
+
 type PurgeMetrics struct {
 	TotalDeleted   int64
 	LastRunAt      time.Time
@@ -87,6 +119,12 @@ type Scheduler struct {
 	streakService      *streak.Service
 	memberService      memberPurger
 	adminService       adminCleaner
+	debtService        debtReminder
+	duelService        duelRefunder
+	requestService     moneyRequestExpirer
+	casinoDigest       casinoDigester
+	ledger             ledgerReconciler
+	payouts            payoutRunner
 	sendFunc           func(ctx context.Context, userID int64, text string) error
 	tgOps              *telegram.Ops
 	memberSourceChatID int64
@@ -126,11 +164,48 @@ func NewScheduler(cfg *config.Config, streakService *streak.Service, memberServi
 	}
 }
 
+// SetDebtService подключает напоминания о просроченных кредитах.
+func (s *Scheduler) SetDebtService(debtService debtReminder) {
+	s.debtService = debtService
+}
+
+// SetDuelService подключает возврат ставок непринятых дуэлей казино.
+func (s *Scheduler) SetDuelService(duelService duelRefunder) {
+	s.duelService = duelService
+}
+
+// SetMoneyRequestService подключает закрытие просроченных запросов плёнок.
+func (s *Scheduler) SetMoneyRequestService(requestService moneyRequestExpirer) {
+	s.requestService = requestService
+}
+
+// SetCasinoDigest подключает еженедельную сводку казино в чат участников.
+func (s *Scheduler) SetCasinoDigest(digest casinoDigester) {
+	s.casinoDigest = digest
+}
+
+// SetLedgerReconciler подключает ежедневную сверку балансов с журналом.
+func (s *Scheduler) SetLedgerReconciler(ledger ledgerReconciler) {
+	s.ledger = ledger
+}
+
+// SetPayoutService подключает регулярные выплаты по правилам из админки.
+func (s *Scheduler) SetPayoutService(payouts payoutRunner) {
+	s.payouts = payouts
+}
+
 // Start launches background tasks.
 func (s *Scheduler) Start(ctx context.Context) {
 	const (
-		dailyResetSpec = "0 0 * * *"
-		remindersSpec  = "0 * * * *"
+		dailyResetSpec    = "0 0 * * *"
+		remindersSpec     = "0 * * * *"
+		celebrationsSpec  = "* * * * *"
+		debtRemindersSpec = "30 * * * *"
+		duelRefundSpec    = "* * * * *"
+		requestExpirySpec = "*/5 * * * *"
+		casinoDigestSpec  = "0 10 * * 1"
+		ledgerSpec        = "15 4 * * *"
+		payoutsSpec       = "* * * * *"
 	)
 
 	if _, err := s.cron.AddFunc(dailyResetSpec, func() {
@@ -151,6 +226,63 @@ func (s *Scheduler) Start(ctx context.Context) {
 		log.WithError(err).WithFields(log.Fields{"spec": remindersSpec, "job": "reminders"}).Error("[CRON] failed to register job")
 	}
 
+	if _, err := s.cron.AddFunc(celebrationsSpec, func() {
+		s.announceCelebrations(ctx)
+	}); err != nil {
+		log.WithError(err).WithFields(log.Fields{"spec": celebrationsSpec, "job": "streak_celebrations"}).Error("[CRON] failed to register job")
+	}
+
+	if s.debtService != nil {
+		if _, err := s.cron.AddFunc(debtRemindersSpec, func() {
+			log.Debug(cronDebugDebtRemind)
+			if err := s.debtService.SendOverdueReminders(ctx, s.sendFunc); err != nil {
+				log.WithError(err).Error(cronErrorDebtRemind)
+			}
+		}); err != nil {
+			log.WithError(err).WithFields(log.Fields{"spec": debtRemindersSpec, "job": "debt_reminders"}).Error("[CRON] failed to register job")
+		}
+	}
+
+	if s.duelService != nil {
+		if _, err := s.cron.AddFunc(duelRefundSpec, func() {
+			s.refundExpiredDuels(ctx)
+		}); err != nil {
+			log.WithError(err).WithFields(log.Fields{"spec": duelRefundSpec, "job": "duel_refunds"}).Error("[CRON] failed to register job")
+		}
+	}
+
+	if s.requestService != nil {
+		if _, err := s.cron.AddFunc(requestExpirySpec, func() {
+			s.expireMoneyRequests(ctx)
+		}); err != nil {
+			log.WithError(err).WithFields(log.Fields{"spec": requestExpirySpec, "job": "money_request_expiry"}).Error("[CRON] failed to register job")
+		}
+	}
+
+	if s.casinoDigest != nil && s.tgOps != nil && s.memberSourceChatID != 0 {
+		if _, err := s.cron.AddFunc(casinoDigestSpec, func() {
+			s.sendCasinoDigest(ctx)
+		}); err != nil {
+			log.WithError(err).WithFields(log.Fields{"spec": casinoDigestSpec, "job": "casino_digest"}).Error("[CRON] failed to register job")
+		}
+	}
+
+	if s.ledger != nil {
+		if _, err := s.cron.AddFunc(ledgerSpec, func() {
+			s.reconcileLedger(ctx)
+		}); err != nil {
+			log.WithError(err).WithFields(log.Fields{"spec": ledgerSpec, "job": "ledger_reconciliation"}).Error("[CRON] failed to register job")
+		}
+	}
+
+	if s.payouts != nil {
+		if _, err := s.cron.AddFunc(payoutsSpec, func() {
+			s.runPayouts(ctx)
+		}); err != nil {
+			log.WithError(err).WithFields(log.Fields{"spec": payoutsSpec, "job": "payouts"}).Error("[CRON] failed to register job")
+		}
+	}
+
 	s.cron.Start()
 	log.WithField("timezone", s.cron.Location().String()).Info(cronInfoStarted)
 
@@ -169,6 +301,77 @@ func (s *Scheduler) Start(ctx context.Context) {
 	}()
 }
 
+func (s *Scheduler) announceCelebrations(ctx context.Context) {
+	sent, err := s.streakService.AnnounceCelebrations(ctx)
+	if err != nil {
+		log.WithError(err).Error(cronErrorCelebrate)
+	}
+	if sent > 0 {
+		log.WithField("sent", sent).Info("[CRON] Streak celebrations announced")
+	}
+}
+
+func (s *Scheduler) refundExpiredDuels(ctx context.Context) {
+	refunded, err := s.duelService.RefundExpiredDuels(ctx, func(ctx context.Context, chatID int64, messageID int, text string) error {
+		if s.tgOps == nil {
+			return nil
+		}
+		return s.tgOps.Edit(ctx, chatID, messageID, text, nil)
+	})
+	if err != nil {
+		log.WithError(err).Error(cronErrorDuelRefund)
+	}
+	if refunded > 0 {
+		log.WithField("refunded", refunded).Info("[CRON] Expired duels refunded")
+	}
+}
+
+func (s *Scheduler) expireMoneyRequests(ctx context.Context) {
+	expired, err := s.requestService.ExpireMoneyRequests(ctx, func(ctx context.Context, chatID int64, messageID int, text string) error {
+		if s.tgOps == nil {
+			return nil
+		}
+		return s.tgOps.Edit(ctx, chatID, messageID, text, nil)
+	})
+	if err != nil {
+		log.WithError(err).Error(cronErrorRequestExp)
+	}
+	if expired > 0 {
+		log.WithField("expired", expired).Info("[CRON] Expired money requests closed")
+	}
+}
+
+func (s *Scheduler) reconcileLedger(ctx context.Context) {
+	mismatches, err := s.ledger.ReconcileLedger(ctx)
+	if err != nil {
+		log.WithError(err).Error(cronErrorLedger)
+		return
+	}
+	if mismatches > 0 {
+		log.WithField("mismatches", mismatches).Warn("[CRON] Ledger mismatches found")
+	}
+}
+
+func (s *Scheduler) runPayouts(ctx context.Context) {
+	paid, err := s.payouts.RunDuePayouts(ctx)
+	if err != nil {
+		log.WithError(err).Error(cronErrorPayouts)
+	}
+	if paid > 0 {
+		log.WithField("rules", paid).Info("[CRON] Payouts made")
+	}
+}
+
+func (s *Scheduler) sendCasinoDigest(ctx context.Context) {
+	err := s.casinoDigest.SendWeeklyDigest(ctx, func(ctx context.Context, text string) error {
+		_, err := s.tgOps.Send(ctx, s.memberSourceChatID, text, nil)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error(cronErrorDigest)
+	}
+}
+
 func (s *Scheduler) runPurgeWorker(ctx context.Context) {
 	ticker := time.NewTicker(purgeTickInterval)
 	defer ticker.Stop()
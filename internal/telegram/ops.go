@@ -28,6 +28,14 @@ type optionSender interface {
 	SendMessageWithOptions(opts SendOptions) (messageID int, err error)
 }
 
+type diceSender interface {
+	SendDice(chatID int64, emoji string) (messageID int, value int, err error)
+}
+
+type memberTagSetter interface {
+	SetChatMemberTag(chatID int64, userID int64, tag string) error
+}
+
 type parseModeEditor interface {
 	EditMessageWithParseMode(chatID int64, messageID int, text string, markup *botapi.InlineKeyboardMarkup, parseMode *string) error
 }
@@ -114,6 +122,18 @@ func (a *botClient) SendMessageWithOptions(opts SendOptions) (int, error) {
 	return msg.MessageID, nil
 }
 
+// SendDice отправляет анимированный кубик; значение выбирает Telegram.
+func (a *botClient) SendDice(chatID int64, emoji string) (int, int, error) {
+	msg, err := a.bot.SendDice(context.Background(), &botapi.SendDiceParams{ChatID: botapi.ChatID{ID: chatID}, Emoji: emoji})
+	if err != nil {
+		return 0, 0, err
+	}
+	if msg == nil || msg.Dice == nil {
+		return 0, 0, fmt.Errorf("sendDice: empty dice in response")
+	}
+	return msg.MessageID, msg.Dice.Value, nil
+}
+
 func (a *botClient) EditMessage(chatID int64, messageID int, text string, markup *botapi.InlineKeyboardMarkup) error {
 	_, err := a.bot.EditMessageText(context.Background(), buildEditMessageTextParams(EditOptions{ChatID: chatID, MessageID: messageID, Text: text, ReplyMarkup: markup}))
 	return err
@@ -165,6 +185,10 @@ func (a *botClient) GetChatMember(chatID int64, userID int64) (botapi.ChatMember
 	return cm, nil
 }
 
+func (a *botClient) SetChatMemberTag(chatID int64, userID int64, tag string) error {
+	return a.bot.SetChatMemberTag(context.Background(), &botapi.SetChatMemberTagParams{ChatID: botapi.ChatID{ID: chatID}, UserID: userID, Tag: tag})
+}
+
 func (a *botClient) RegisterUpdateHandler(match func(*botapi.Update) bool, handler func(context.Context, *botapi.Update)) {
 	a.handlers = append(a.handlers, updateHandler{match: match, handler: handler})
 }
@@ -319,6 +343,22 @@ func (o *Ops) SendWithOptions(ctx context.Context, opts SendOptions) (int, error
 func (o *Ops) SendText(ctx context.Context, chatID int64, text string, markup *botapi.InlineKeyboardMarkup) (int, error) {
 	return o.Send(ctx, chatID, text, markup)
 }
+
+// SendDice отправляет анимацию emoji (🎲, 🎯, 🏀 …) и возвращает ID сообщения
+// и выпавшее значение, которое Telegram выбирает на своей стороне.
+func (o *Ops) SendDice(ctx context.Context, chatID int64, emoji string) (int, int, error) {
+	c, ok := any(o.c).(diceSender)
+	if !ok {
+		return 0, 0, fmt.Errorf("client does not support sendDice")
+	}
+	msgID, value, err := c.SendDice(chatID, emoji)
+	if err != nil {
+		o.log.WithContext(ctx).WithError(err).WithField("chat_id", chatID).Warn("telegram send dice failed")
+		return 0, 0, err
+	}
+	return msgID, value, nil
+}
+
 func (o *Ops) Edit(ctx context.Context, chatID int64, messageID int, text string, markup *botapi.InlineKeyboardMarkup) error {
 	return o.EditWithParseMode(ctx, chatID, messageID, text, markup, nil)
 }
@@ -386,6 +426,20 @@ func (o *Ops) GetChatMember(ctx context.Context, chatID int64, userID int64) (bo
 	return member, nil
 }
 
+// SetChatMemberTag ставит тег обычному участнику группы; у бота должно
+// быть право can_manage_tags.
+func (o *Ops) SetChatMemberTag(ctx context.Context, chatID int64, userID int64, tag string) error {
+	c, ok := any(o.c).(memberTagSetter)
+	if !ok {
+		return fmt.Errorf("client does not support setChatMemberTag")
+	}
+	if err := c.SetChatMemberTag(chatID, userID, tag); err != nil {
+		o.log.WithContext(ctx).WithError(err).WithFields(logrus.Fields{"chat_id": chatID, "user_id": userID}).Warn("telegram set member tag failed")
+		return err
+	}
+	return nil
+}
+
 func (o *Ops) ExtractMemberTag(member botapi.ChatMember) *string {
 	switch m := member.(type) {
 	case *botapi.ChatMemberMember:
@@ -222,3 +222,29 @@ func TestLongPollingUpdatesParams_ContainsRequiredAllowedUpdates(t *testing.T) {
 		}
 	}
 }
+
+type diceClient struct {
+	fakeClient
+	emoji string
+	value int
+}
+
+func (c *diceClient) SendDice(chatID int64, emoji string) (int, int, error) {
+	c.emoji = emoji
+	return 77, c.value, nil
+}
+
+func TestOpsSendDice(t *testing.T) {
+	client := &diceClient{value: 4}
+	msgID, value, err := NewOps(client).SendDice(context.Background(), 1, "🎲")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if msgID != 77 || value != 4 || client.emoji != "🎲" {
+		t.Fatalf("SendDice = %d, %d (emoji %q)", msgID, value, client.emoji)
+	}
+
+	if _, _, err := NewOps(&fakeClient{}).SendDice(context.Background(), 1, "🎲"); err == nil {
+		t.Fatal("expected error for client without sendDice")
+	}
+}
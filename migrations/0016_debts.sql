@@ -0,0 +1,25 @@
+-- Миграция 16: кредиты участникам. Одна активная запись на участника;
+-- индекс по (status, due_at) нужен напоминаниям о просрочке.
+CREATE TABLE IF NOT EXISTS debts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES members(user_id),
+    principal BIGINT NOT NULL CHECK (principal > 0),
+    interest_percent INTEGER NOT NULL DEFAULT 0 CHECK (interest_percent >= 0),
+    amount_due BIGINT NOT NULL CHECK (amount_due > 0),
+    amount_repaid BIGINT NOT NULL DEFAULT 0 CHECK (amount_repaid >= 0),
+    status TEXT NOT NULL DEFAULT 'active',
+    due_at TIMESTAMP NOT NULL,
+    issued_by BIGINT NOT NULL,
+    closed_by BIGINT,
+    last_reminder_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_debts_single_active_per_user
+    ON debts (user_id)
+    WHERE status = 'active';
+
+CREATE INDEX IF NOT EXISTS idx_debts_status_due_at
+    ON debts (status, due_at);
@@ -0,0 +1,4 @@
+-- Миграция 17: ступень коррекции RTP хранится рядом со статистикой казино,
+-- чтобы перезапуск или второй инстанс не сбрасывали веса игроков.
+ALTER TABLE casino_stats
+    ADD COLUMN IF NOT EXISTS rtp_tier VARCHAR(16) NOT NULL DEFAULT 'normal';
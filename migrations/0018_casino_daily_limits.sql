@@ -0,0 +1,11 @@
+-- Миграция 18: дневной учёт ставок и выигрышей для лимитов казино.
+-- День считается по APP_TIMEZONE; строка блокируется в транзакции спина.
+CREATE TABLE IF NOT EXISTS casino_daily_limits (
+    user_id BIGINT NOT NULL REFERENCES members(user_id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    spins INTEGER NOT NULL DEFAULT 0,
+    wagered BIGINT NOT NULL DEFAULT 0,
+    won BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, day)
+);
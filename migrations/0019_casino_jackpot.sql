@@ -0,0 +1,14 @@
+-- Миграция 19: прогрессивный джекпот слотов.
+-- Единственная строка id = 1 хранит текущий пул; движения пула
+-- дублируются в transactions типами jackpot_contribution и jackpot_win.
+CREATE TABLE IF NOT EXISTS casino_jackpot (
+    id SMALLINT PRIMARY KEY CHECK (id = 1),
+    amount BIGINT NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    last_winner_id BIGINT REFERENCES members(user_id) ON DELETE SET NULL,
+    last_win_amount BIGINT NOT NULL DEFAULT 0,
+    last_won_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO casino_jackpot (id, amount) VALUES (1, 0)
+ON CONFLICT (id) DO NOTHING;
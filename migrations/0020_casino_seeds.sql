@@ -0,0 +1,16 @@
+-- Миграция 20: сиды доказуемо честных спинов.
+-- У игрока один активный сид (revealed_at IS NULL); серверный сид
+-- показывается только после смены, хэш публикуется сразу.
+CREATE TABLE IF NOT EXISTS casino_seeds (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES members(user_id) ON DELETE CASCADE,
+    server_seed VARCHAR(128) NOT NULL,
+    server_seed_hash VARCHAR(64) NOT NULL,
+    client_seed VARCHAR(64) NOT NULL,
+    nonce BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revealed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_casino_seeds_active_user
+    ON casino_seeds (user_id) WHERE revealed_at IS NULL;
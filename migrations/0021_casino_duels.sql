@@ -0,0 +1,25 @@
+-- Миграция 21: дуэли игроков со ставкой на условном депонировании.
+-- Ставка вызывающего списывается при вызове (duel_stake), ставка соперника —
+-- при принятии; банк уходит победителю (duel_win, duel_fee), непринятый
+-- вызов возвращается (duel_refund). Кнопки ссылаются на строку по token.
+CREATE TABLE IF NOT EXISTS casino_duels (
+    id BIGSERIAL PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    chat_id BIGINT NOT NULL,
+    message_id INTEGER NOT NULL DEFAULT 0,
+    challenger_id BIGINT NOT NULL REFERENCES members(user_id),
+    opponent_id BIGINT NOT NULL REFERENCES members(user_id),
+    stake BIGINT NOT NULL CHECK (stake > 0),
+    state TEXT NOT NULL,
+    challenger_roll INTEGER,
+    opponent_roll INTEGER,
+    winner_id BIGINT REFERENCES members(user_id),
+    fee BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_casino_duels_pending_expires_at
+    ON casino_duels (expires_at) WHERE state = 'pending';
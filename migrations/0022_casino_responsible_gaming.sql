@@ -0,0 +1,27 @@
+-- Миграция 22: ответственная игра в казино.
+-- casino_exclusions — история самоисключений; активное — lifted_at IS NULL
+-- и ends_at в будущем. Досрочно снять исключение может только администратор.
+CREATE TABLE IF NOT EXISTS casino_exclusions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES members(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    lifted_at TIMESTAMP,
+    lifted_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_casino_exclusions_active_user
+    ON casino_exclusions (user_id, ends_at) WHERE lifted_at IS NULL;
+
+-- casino_loss_limits — личные лимиты чистого проигрыша (0 — только общий лимит).
+-- Ужесточение действует сразу, ослабление ждёт в pending_* до pending_from.
+CREATE TABLE IF NOT EXISTS casino_loss_limits (
+    user_id BIGINT PRIMARY KEY REFERENCES members(user_id) ON DELETE CASCADE,
+    daily_limit BIGINT NOT NULL DEFAULT 0 CHECK (daily_limit >= 0),
+    weekly_limit BIGINT NOT NULL DEFAULT 0 CHECK (weekly_limit >= 0),
+    pending_daily_limit BIGINT CHECK (pending_daily_limit >= 0),
+    pending_weekly_limit BIGINT CHECK (pending_weekly_limit >= 0),
+    pending_from TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,35 @@
+-- Миграция 23: магазин за плёнки.
+-- shop_items — каталог, который ведут администраторы. stock NULL — без
+-- ограничения; скрытые товары (active = FALSE) не показываются в !магазин.
+-- payload — что выдаёт товар: текст роли, текст тега или число заморозок.
+CREATE TABLE IF NOT EXISTS shop_items (
+    id BIGSERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL CHECK (kind IN ('role', 'tag', 'freeze')),
+    price BIGINT NOT NULL CHECK (price > 0),
+    stock INTEGER CHECK (stock >= 0),
+    payload TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- shop_purchases — история покупок с ценой и выдачей на момент покупки.
+CREATE TABLE IF NOT EXISTS shop_purchases (
+    id BIGSERIAL PRIMARY KEY,
+    item_id BIGINT NOT NULL REFERENCES shop_items(id),
+    user_id BIGINT NOT NULL REFERENCES members(user_id) ON DELETE CASCADE,
+    price BIGINT NOT NULL,
+    kind TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shop_purchases_user
+    ON shop_purchases (user_id, created_at DESC);
+
+-- Заморозки стрика: покупаются в магазине, расходуются при пропуске дня.
+ALTER TABLE streaks
+    ADD COLUMN IF NOT EXISTS freeze_tokens INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- Миграция 24: запросы плёнок (!запросить).
+-- Запрос хранится в economy_transfer_confirmations рядом с подтверждениями
+-- переводов: kind = 'request', плательщик — owner/sender, автор запроса —
+-- target. memo — назначение платежа, попадает в описание транзакции.
+ALTER TABLE economy_transfer_confirmations
+    ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'transfer',
+    ADD COLUMN IF NOT EXISTS memo TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_economy_transfer_confirmations_open_requests
+    ON economy_transfer_confirmations (expires_at)
+    WHERE kind = 'request' AND state = 'await_second';
@@ -0,0 +1,47 @@
+-- Миграция 25: входящие остатки для сверки журнала.
+-- Балансы, набранные до ведения журнала или изменённые мимо него, не
+-- сходятся с суммой transactions. Записываем разницу одной проводкой
+-- opening_balance на пользователя (датой создания баланса), а итоги
+-- total_earned/total_spent приводим к журналу — дальше сверка стартует с нуля.
+WITH ledger AS (
+    SELECT user_id, SUM(credit) AS earned, SUM(debit) AS spent
+    FROM (
+        SELECT to_user_id AS user_id, amount AS credit, 0 AS debit
+        FROM transactions WHERE to_user_id IS NOT NULL
+        UNION ALL
+        SELECT from_user_id, 0, amount
+        FROM transactions WHERE from_user_id IS NOT NULL
+    ) entries
+    GROUP BY user_id
+), drift AS (
+    SELECT b.user_id,
+           b.created_at,
+           COALESCE(b.balance, 0) - (COALESCE(l.earned, 0) - COALESCE(l.spent, 0)) AS diff
+    FROM balances b
+    LEFT JOIN ledger l ON l.user_id = b.user_id
+)
+INSERT INTO transactions (from_user_id, to_user_id, amount, transaction_type, description, created_at)
+SELECT CASE WHEN diff < 0 THEN user_id END,
+       CASE WHEN diff > 0 THEN user_id END,
+       ABS(diff),
+       'opening_balance',
+       'Входящий остаток',
+       COALESCE(created_at, NOW())
+FROM drift
+WHERE diff <> 0;
+
+UPDATE balances b
+SET total_earned = l.earned, total_spent = l.spent, updated_at = NOW()
+FROM (
+    SELECT user_id, SUM(credit) AS earned, SUM(debit) AS spent
+    FROM (
+        SELECT to_user_id AS user_id, amount AS credit, 0 AS debit
+        FROM transactions WHERE to_user_id IS NOT NULL
+        UNION ALL
+        SELECT from_user_id, 0, amount
+        FROM transactions WHERE from_user_id IS NOT NULL
+    ) entries
+    GROUP BY user_id
+) l
+WHERE l.user_id = b.user_id
+  AND (b.total_earned IS DISTINCT FROM l.earned OR b.total_spent IS DISTINCT FROM l.spent);
@@ -0,0 +1,10 @@
+-- Миграция 26: ключи идемпотентности балансовых операций.
+-- Переотправленный апдейт Telegram или ретрай обработчика приходит с тем же
+-- operation_key (например, riddle:<id>:answer:<id> или thanks:<chat>:<msg>);
+-- уникальный индекс не даёт записать проводку дважды, и повтор становится no-op.
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS operation_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_operation_key
+    ON transactions (operation_key)
+    WHERE operation_key IS NOT NULL;
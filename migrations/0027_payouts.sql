@@ -0,0 +1,30 @@
+-- Миграция 27: регулярные выплаты (зарплаты ролям, пособия).
+-- payout_rules — правила из админ-панели: кому (роль, список участников или
+-- все активные), сколько и по какому cron-расписанию. last_period_at —
+-- последний выплаченный период, от него считается следующий.
+CREATE TABLE IF NOT EXISTS payout_rules (
+    id BIGSERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    target_kind TEXT NOT NULL CHECK (target_kind IN ('role', 'users', 'active')),
+    target_role TEXT NOT NULL DEFAULT '',
+    target_user_ids BIGINT[] NOT NULL DEFAULT '{}',
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    schedule TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT NOT NULL,
+    last_period_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- payout_runs — по строке на выплаченный период. Уникальность
+-- (rule_id, period_at) делает повторный запуск того же периода no-op.
+CREATE TABLE IF NOT EXISTS payout_runs (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id BIGINT NOT NULL REFERENCES payout_rules(id),
+    period_at TIMESTAMP NOT NULL,
+    recipients INTEGER NOT NULL,
+    total BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (rule_id, period_at)
+);
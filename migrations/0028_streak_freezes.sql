@@ -0,0 +1,6 @@
+-- Миграция 28: заморозки стрика защищают огонёк при пропуске дня.
+-- freeze_tokens уже есть (магазин, миграция 23); last_frozen_day — последний
+-- день, закрытый заморозкой: непрерывность огонька считается от него, если он
+-- позже last_quota_completion.
+ALTER TABLE streaks
+    ADD COLUMN IF NOT EXISTS last_frozen_day TIMESTAMP;
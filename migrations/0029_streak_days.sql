@@ -0,0 +1,19 @@
+-- Миграция 29: история огонька по дням.
+-- streak_days — итог дня участника: сколько сообщений засчитано, закрыт ли
+-- день, сколько плёнок выплачено и спасла ли день заморозка. Пишется при
+-- подсчёте сообщений и в ночном сбросе; в отличие от
+-- streak_processed_messages не чистится — по ней строятся !календарь и отчёт
+-- в админке.
+CREATE TABLE IF NOT EXISTS streak_days (
+    user_id BIGINT NOT NULL REFERENCES members(user_id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    messages INTEGER NOT NULL DEFAULT 0,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    reward BIGINT NOT NULL DEFAULT 0,
+    freeze_used BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_streak_days_day
+    ON streak_days (day);
@@ -0,0 +1,18 @@
+-- Миграция 30: объявления о вехах огонька.
+-- streak_celebrations — вехи, которых участник достиг. Строка пишется в той же
+-- транзакции, что и закрытие дня; announced_at ставится перед отправкой
+-- поздравления в чат и снимается, если Telegram не принял сообщение. Так
+-- рестарт и повторная доставка апдейта не поздравляют дважды, а недошедшее
+-- поздравление дошлёт планировщик.
+CREATE TABLE IF NOT EXISTS streak_celebrations (
+    user_id BIGINT NOT NULL REFERENCES members(user_id) ON DELETE CASCADE,
+    milestone INTEGER NOT NULL,
+    reached_on DATE NOT NULL,
+    announced_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, milestone, reached_on)
+);
+
+CREATE INDEX IF NOT EXISTS idx_streak_celebrations_pending
+    ON streak_celebrations (created_at)
+    WHERE announced_at IS NULL;
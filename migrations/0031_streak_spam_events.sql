@@ -0,0 +1,16 @@
+-- Миграция 31: история антиспама огонька.
+-- streak_spam_events — недавние сообщения участника, которые учитывает
+-- антиспам: засчитанные тексты (нормализованные, для поиска повторов) и шум —
+-- стикеры и сообщения из одних эмодзи. Хранится в БД, чтобы лимиты
+-- переживали рестарт и были общими для нескольких инстансов; старые события
+-- удаляет ночной сброс огонька.
+CREATE TABLE IF NOT EXISTS streak_spam_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    at TIMESTAMP NOT NULL,
+    noise BOOLEAN NOT NULL DEFAULT FALSE,
+    text TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_streak_spam_events_user_at
+    ON streak_spam_events (user_id, at);
@@ -0,0 +1,6 @@
+-- Миграция 32: возвраты покупок магазина.
+-- refunded_at — когда покупку вернули: тег ставится в Telegram после
+-- коммита покупки, и если Telegram его не принял, плёнки и остаток
+-- возвращаются отдельной транзакцией.
+ALTER TABLE shop_purchases
+    ADD COLUMN IF NOT EXISTS refunded_at TIMESTAMP;